@@ -0,0 +1,89 @@
+package astiarchive
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/asticode/go-astitools/zip"
+	"github.com/pkg/errors"
+)
+
+// DefaultFileMode represents the default file mode
+var DefaultFileMode os.FileMode = 0775
+
+// Formats
+const (
+	FormatTarGz = "tar.gz"
+	FormatZip   = "zip"
+)
+
+// Magic bytes
+var (
+	magicBytesGzip = []byte{0x1f, 0x8b}
+	magicBytesZip  = []byte("PK\x03\x04")
+)
+
+// ErrUnknownFormat is returned when the format of an archive can't be detected
+var ErrUnknownFormat = errors.New("astiarchive: unknown format")
+
+// DetectFormat detects the format of an archive based on its first bytes
+func DetectFormat(src string) (format string, err error) {
+	// Open file
+	var f *os.File
+	if f, err = os.Open(src); err != nil {
+		err = errors.Wrapf(err, "astiarchive: opening %s failed", src)
+		return
+	}
+	defer f.Close()
+
+	// Read first bytes
+	var b = make([]byte, 4)
+	var n int
+	if n, err = io.ReadFull(f, b); err != nil && err != io.ErrUnexpectedEOF {
+		err = errors.Wrapf(err, "astiarchive: reading first bytes of %s failed", src)
+		return
+	}
+	b = b[:n]
+	err = nil
+
+	// Detect format
+	switch {
+	case bytes.HasPrefix(b, magicBytesZip):
+		format = FormatZip
+	case bytes.HasPrefix(b, magicBytesGzip):
+		format = FormatTarGz
+	default:
+		err = ErrUnknownFormat
+	}
+	return
+}
+
+// Extract extracts a src into a dst after detecting its format
+func Extract(ctx context.Context, src, dst string) (err error) {
+	// Detect format
+	var format string
+	if format, err = DetectFormat(src); err != nil {
+		return
+	}
+
+	// Extract
+	switch format {
+	case FormatTarGz:
+		return ExtractTarGz(ctx, src, dst)
+	default:
+		return astizip.Unzip(ctx, src, dst)
+	}
+}
+
+// safePath joins an archive entry name to a dst and makes sure the result doesn't escape dst
+func safePath(dst, name string) (p string, err error) {
+	p = filepath.Join(dst, name)
+	if p != filepath.Clean(dst) && !strings.HasPrefix(p, filepath.Clean(dst)+string(os.PathSeparator)) {
+		err = errors.Errorf("astiarchive: entry %s escapes destination %s", name, dst)
+	}
+	return
+}
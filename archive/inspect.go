@@ -0,0 +1,221 @@
+package astiarchive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"hash/crc32"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+
+	"github.com/asticode/go-astitools/io"
+	"github.com/pkg/errors"
+)
+
+// ErrEntryNotFound is returned when an entry can't be found in an archive
+var ErrEntryNotFound = errors.New("astiarchive: entry not found")
+
+// EntryInfo represents information about an archive entry
+type EntryInfo struct {
+	// CRC-32 (IEEE) checksum of the content of regular files
+	CRC32 uint32
+	// Target of the symlink if Mode has os.ModeSymlink set
+	Linkname string
+	Mode     os.FileMode
+	ModTime  time.Time
+	// Slash separated name of the entry, as stored in the archive
+	Name string
+	// Uncompressed size in bytes
+	Size int64
+}
+
+// List lists the entries of an archive without extracting it, after detecting its format
+// For tar.gz archives, content has to be decompressed to compute checksums.
+func List(ctx context.Context, src string) (es []EntryInfo, err error) {
+	// Detect format
+	var format string
+	if format, err = DetectFormat(src); err != nil {
+		return
+	}
+
+	// List
+	switch format {
+	case FormatTarGz:
+		err = walkTarGz(ctx, src, func(h *tar.Header, r io.Reader) (bool, error) {
+			var e = EntryInfo{
+				Linkname: h.Linkname,
+				Mode:     h.FileInfo().Mode(),
+				ModTime:  h.ModTime,
+				Name:     h.Name,
+				Size:     h.Size,
+			}
+			if h.Typeflag == tar.TypeReg {
+				var c = crc32.NewIEEE()
+				if _, err := astiio.Copy(ctx, r, c); err != nil {
+					return false, errors.Wrapf(err, "astiarchive: computing checksum of %s failed", h.Name)
+				}
+				e.CRC32 = c.Sum32()
+			}
+			es = append(es, e)
+			return true, nil
+		})
+	default:
+		err = walkZip(ctx, src, func(f *zip.File) (bool, error) {
+			var e = EntryInfo{
+				CRC32:   f.CRC32,
+				Mode:    f.Mode(),
+				ModTime: f.Modified,
+				Name:    f.Name,
+				Size:    int64(f.UncompressedSize64),
+			}
+			if e.Mode&os.ModeSymlink != 0 {
+				l, err := readZipFile(f)
+				if err != nil {
+					return false, err
+				}
+				e.Linkname = string(l)
+			}
+			es = append(es, e)
+			return true, nil
+		})
+	}
+	return
+}
+
+// ExtractSingle writes the content of a single regular file of an archive, after detecting its format
+// name is matched against entries names once cleaned, so that "./dir/file" and "/dir/file" match "dir/file".
+// ErrEntryNotFound is returned if no entry matches.
+func ExtractSingle(ctx context.Context, src, name string, dst io.Writer) (err error) {
+	// Detect format
+	var format string
+	if format, err = DetectFormat(src); err != nil {
+		return
+	}
+
+	// Extract
+	var found bool
+	name = cleanEntryName(name)
+	switch format {
+	case FormatTarGz:
+		err = walkTarGz(ctx, src, func(h *tar.Header, r io.Reader) (bool, error) {
+			if cleanEntryName(h.Name) != name || h.Typeflag != tar.TypeReg {
+				return true, nil
+			}
+			found = true
+			if _, err := astiio.Copy(ctx, r, dst); err != nil {
+				return false, errors.Wrapf(err, "astiarchive: copying %s failed", h.Name)
+			}
+			return false, nil
+		})
+	default:
+		err = walkZip(ctx, src, func(f *zip.File) (bool, error) {
+			if cleanEntryName(f.Name) != name || !f.Mode().IsRegular() {
+				return true, nil
+			}
+			found = true
+			r, err := f.Open()
+			if err != nil {
+				return false, errors.Wrapf(err, "astiarchive: opening %s failed", f.Name)
+			}
+			defer r.Close()
+			if _, err = astiio.Copy(ctx, r, dst); err != nil {
+				return false, errors.Wrapf(err, "astiarchive: copying %s failed", f.Name)
+			}
+			return false, nil
+		})
+	}
+	if err == nil && !found {
+		err = ErrEntryNotFound
+	}
+	return
+}
+
+// cleanEntryName returns the shortest relative form of an entry name
+func cleanEntryName(name string) string {
+	return path.Clean("/" + name)[1:]
+}
+
+// walkTarGz executes fn on every entry of a .tar.gz src until it returns false or an error
+func walkTarGz(ctx context.Context, src string, fn func(h *tar.Header, r io.Reader) (bool, error)) (err error) {
+	// Open source file
+	var f *os.File
+	if f, err = os.Open(src); err != nil {
+		return errors.Wrapf(err, "astiarchive: opening %s failed", src)
+	}
+	defer f.Close()
+
+	// Create gzip reader
+	var gr *gzip.Reader
+	if gr, err = gzip.NewReader(f); err != nil {
+		return errors.Wrapf(err, "astiarchive: creating gzip reader on %s failed", src)
+	}
+	defer gr.Close()
+
+	// Loop through entries
+	var tr = tar.NewReader(gr)
+	for {
+		// Check context
+		if err = ctx.Err(); err != nil {
+			return
+		}
+
+		// Next entry
+		var h *tar.Header
+		if h, err = tr.Next(); err == io.EOF {
+			err = nil
+			return
+		} else if err != nil {
+			return errors.Wrapf(err, "astiarchive: reading next tar entry of %s failed", src)
+		}
+
+		// Execute
+		var ok bool
+		if ok, err = fn(h, tr); err != nil || !ok {
+			return
+		}
+	}
+}
+
+// walkZip executes fn on every entry of a .zip src until it returns false or an error
+func walkZip(ctx context.Context, src string, fn func(f *zip.File) (bool, error)) (err error) {
+	// Open archive
+	var zr *zip.ReadCloser
+	if zr, err = zip.OpenReader(src); err != nil {
+		return errors.Wrapf(err, "astiarchive: opening %s failed", src)
+	}
+	defer zr.Close()
+
+	// Loop through entries
+	for _, f := range zr.File {
+		// Check context
+		if err = ctx.Err(); err != nil {
+			return
+		}
+
+		// Execute
+		var ok bool
+		if ok, err = fn(f); err != nil || !ok {
+			return
+		}
+	}
+	return
+}
+
+// readZipFile reads the content of a zip entry
+func readZipFile(f *zip.File) (b []byte, err error) {
+	var r io.ReadCloser
+	if r, err = f.Open(); err != nil {
+		err = errors.Wrapf(err, "astiarchive: opening %s failed", f.Name)
+		return
+	}
+	defer r.Close()
+	if b, err = ioutil.ReadAll(r); err != nil {
+		err = errors.Wrapf(err, "astiarchive: reading %s failed", f.Name)
+		return
+	}
+	return
+}
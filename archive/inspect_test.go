@@ -0,0 +1,70 @@
+package astiarchive_test
+
+import (
+	"bytes"
+	"context"
+	"hash/crc32"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/asticode/go-astitools/archive"
+	"github.com/asticode/go-astitools/zip"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListAndExtractSingle(t *testing.T) {
+	// Init
+	dir, err := ioutil.TempDir("", "astiarchive")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "src")
+	os.MkdirAll(filepath.Join(src, "d"), 0755)
+	ioutil.WriteFile(filepath.Join(src, "d", "f"), []byte("content"), 0600)
+	os.Symlink("d/f", filepath.Join(src, "l"))
+	tgz := filepath.Join(dir, "archive.tar.gz")
+	assert.NoError(t, astiarchive.CreateTarGz(context.Background(), src, tgz, "root"))
+	z := filepath.Join(dir, "archive.zip")
+	assert.NoError(t, astizip.Zip(context.Background(), filepath.Join(src, "d"), z, ""))
+
+	// List tar.gz
+	es, err := astiarchive.List(context.Background(), tgz)
+	assert.NoError(t, err)
+	m := make(map[string]astiarchive.EntryInfo)
+	for _, e := range es {
+		m[e.Name] = e
+	}
+	e, ok := m["root/d/f"]
+	assert.True(t, ok)
+	assert.Equal(t, int64(7), e.Size)
+	assert.Equal(t, os.FileMode(0600), e.Mode.Perm())
+	assert.Equal(t, crc32.ChecksumIEEE([]byte("content")), e.CRC32)
+	e, ok = m["root/l"]
+	assert.True(t, ok)
+	assert.Equal(t, "d/f", e.Linkname)
+	assert.True(t, e.Mode&os.ModeSymlink != 0)
+
+	// List zip
+	es, err = astiarchive.List(context.Background(), z)
+	assert.NoError(t, err)
+	var found bool
+	for _, e := range es {
+		if e.Name == "/f" {
+			found = true
+			assert.Equal(t, int64(7), e.Size)
+			assert.Equal(t, crc32.ChecksumIEEE([]byte("content")), e.CRC32)
+		}
+	}
+	assert.True(t, found)
+
+	// Extract single
+	buf := &bytes.Buffer{}
+	assert.NoError(t, astiarchive.ExtractSingle(context.Background(), tgz, "./root/d/f", buf))
+	assert.Equal(t, "content", buf.String())
+	buf.Reset()
+	assert.NoError(t, astiarchive.ExtractSingle(context.Background(), z, "f", buf))
+	assert.Equal(t, "content", buf.String())
+	assert.Equal(t, astiarchive.ErrEntryNotFound, astiarchive.ExtractSingle(context.Background(), tgz, "root/l", buf))
+	assert.Equal(t, astiarchive.ErrEntryNotFound, astiarchive.ExtractSingle(context.Background(), z, "missing", buf))
+}
@@ -0,0 +1,177 @@
+package astiarchive
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/asticode/go-astitools/io"
+	"github.com/asticode/go-astitools/os"
+	"github.com/pkg/errors"
+)
+
+// CreateTarGz creates a .tar.gz archive of a src into a dst, preserving file modes and symlinks
+// dstRoot can be used to create root directories so that the content is archived in /path/to/archive.tar.gz/root/path
+func CreateTarGz(ctx context.Context, src, dst, dstRoot string) (err error) {
+	// Create destination file
+	var f *os.File
+	if f, err = os.Create(dst); err != nil {
+		return errors.Wrapf(err, "astiarchive: creating %s failed", dst)
+	}
+	defer f.Close()
+
+	// Write
+	if err = WriteTarGz(ctx, f, WalkDir(ctx, src, dstRoot)); err != nil {
+		return errors.Wrapf(err, "astiarchive: writing tar.gz archive of %s failed", src)
+	}
+	return
+}
+
+// ExtractTarGz extracts a .tar.gz src into a dst, preserving file modes, symlinks and hard links
+// Entries of other types, such as devices or fifos, make the extraction fail.
+func ExtractTarGz(ctx context.Context, src, dst string) (err error) {
+	// Open source file
+	var f *os.File
+	if f, err = os.Open(src); err != nil {
+		return errors.Wrapf(err, "astiarchive: opening %s failed", src)
+	}
+	defer f.Close()
+
+	// Create gzip reader
+	var gr *gzip.Reader
+	if gr, err = gzip.NewReader(f); err != nil {
+		return errors.Wrapf(err, "astiarchive: creating gzip reader on %s failed", src)
+	}
+	defer gr.Close()
+
+	// Loop through entries
+	var dirs = make(map[string]os.FileMode)
+	var symlinks []tarSymlink
+	var tr = tar.NewReader(gr)
+	for {
+		// Check context
+		if err = ctx.Err(); err != nil {
+			return
+		}
+
+		// Next entry
+		var h *tar.Header
+		if h, err = tr.Next(); err == io.EOF {
+			err = nil
+			break
+		} else if err != nil {
+			return errors.Wrapf(err, "astiarchive: reading next tar entry of %s failed", src)
+		}
+
+		// Get path
+		var p string
+		if p, err = safePath(dst, h.Name); err != nil {
+			return
+		}
+		if err = astios.CheckParents(dst, p); err != nil {
+			return
+		}
+
+		// Switch on type
+		switch h.Typeflag {
+		case tar.TypeDir:
+			// Modes are applied once the content has been extracted so that read-only dirs can be populated
+			if err = os.MkdirAll(p, DefaultFileMode); err != nil {
+				return errors.Wrapf(err, "astiarchive: mkdirall %s failed", p)
+			}
+			dirs[p] = os.FileMode(h.Mode).Perm()
+		case tar.TypeSymlink:
+			// Symlinks are created last so that no entry can be written through them
+			if err = astios.CheckSymlink(dst, p, h.Linkname); err != nil {
+				return
+			}
+			symlinks = append(symlinks, tarSymlink{linkname: h.Linkname, p: p})
+		case tar.TypeLink:
+			// Hard links targets are relative to the root of the archive
+			var t = filepath.Join(dst, h.Linkname)
+			if err = astios.CheckSymlink(dst, p, t); err != nil {
+				return
+			}
+			if err = astios.CheckParents(dst, t); err != nil {
+				return
+			}
+			if err = os.MkdirAll(filepath.Dir(p), DefaultFileMode); err != nil {
+				return errors.Wrapf(err, "astiarchive: mkdirall %s failed", filepath.Dir(p))
+			}
+			if err = os.Link(t, p); err != nil {
+				return errors.Wrapf(err, "astiarchive: creating hard link from %s to %s failed", t, p)
+			}
+		case tar.TypeReg:
+			if err = extractTarFile(ctx, tr, h, p); err != nil {
+				return
+			}
+		case tar.TypeXGlobalHeader:
+			// PAX global headers only hold metadata
+		default:
+			return errors.Errorf("astiarchive: entry %s has unsupported type %q", h.Name, h.Typeflag)
+		}
+	}
+
+	// Create symlinks in archive order, refusing those whose parent is a symlink created before
+	for _, s := range symlinks {
+		if err = astios.CheckParents(dst, s.p); err != nil {
+			return
+		}
+		if err = os.MkdirAll(filepath.Dir(s.p), DefaultFileMode); err != nil {
+			return errors.Wrapf(err, "astiarchive: mkdirall %s failed", filepath.Dir(s.p))
+		}
+		if err = os.Symlink(s.linkname, s.p); err != nil {
+			return errors.Wrapf(err, "astiarchive: creating symlink from %s to %s failed", s.linkname, s.p)
+		}
+	}
+
+	// Apply dirs modes, deepest dirs first
+	var ps []string
+	for p := range dirs {
+		ps = append(ps, p)
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(ps)))
+	for _, p := range ps {
+		if err = os.Chmod(p, dirs[p]); err != nil {
+			return errors.Wrapf(err, "astiarchive: chmoding %s failed", p)
+		}
+	}
+	return
+}
+
+// tarSymlink represents a symlink entry whose creation is delayed
+type tarSymlink struct {
+	linkname string
+	p        string
+}
+
+// extractTarFile extracts a regular file tar entry into a path
+func extractTarFile(ctx context.Context, r io.Reader, h *tar.Header, p string) (err error) {
+	// Since dirs don't always come up we make sure the directory of the entry exists with the default file mode
+	if err = os.MkdirAll(filepath.Dir(p), DefaultFileMode); err != nil {
+		return errors.Wrapf(err, "astiarchive: mkdirall %s failed", filepath.Dir(p))
+	}
+
+	// Open the file
+	var m = os.FileMode(h.Mode).Perm()
+	var f *os.File
+	if f, err = os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, m); err != nil {
+		return errors.Wrapf(err, "astiarchive: opening file %s failed", p)
+	}
+	defer f.Close()
+
+	// Copy
+	if _, err = astiio.Copy(ctx, r, f); err != nil {
+		return errors.Wrapf(err, "astiarchive: copying %s into %s failed", h.Name, p)
+	}
+
+	// Make sure the mode is not altered by the umask
+	if err = f.Chmod(m); err != nil {
+		return errors.Wrapf(err, "astiarchive: chmoding %s failed", p)
+	}
+	return
+}
@@ -0,0 +1,152 @@
+package astiarchive_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/asticode/go-astitools/archive"
+	"github.com/asticode/go-astitools/zip"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTarGz(t *testing.T) {
+	// Init
+	dir, err := ioutil.TempDir("", "astiarchive")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "src")
+	os.MkdirAll(filepath.Join(src, "d"), 0755)
+	ioutil.WriteFile(filepath.Join(src, "d", "f"), []byte("content"), 0600)
+	os.Symlink("d/f", filepath.Join(src, "l"))
+
+	// Create
+	a := filepath.Join(dir, "archive.tar.gz")
+	err = astiarchive.CreateTarGz(context.Background(), src, a, "root")
+	assert.NoError(t, err)
+	f, err := astiarchive.DetectFormat(a)
+	assert.NoError(t, err)
+	assert.Equal(t, astiarchive.FormatTarGz, f)
+
+	// Extract
+	dst := filepath.Join(dir, "dst")
+	err = astiarchive.Extract(context.Background(), a, dst)
+	assert.NoError(t, err)
+	b, err := ioutil.ReadFile(filepath.Join(dst, "root", "d", "f"))
+	assert.NoError(t, err)
+	assert.Equal(t, "content", string(b))
+	fi, err := os.Stat(filepath.Join(dst, "root", "d", "f"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+	l, err := os.Readlink(filepath.Join(dst, "root", "l"))
+	assert.NoError(t, err)
+	assert.Equal(t, "d/f", l)
+
+	// Zip
+	a = filepath.Join(dir, "archive.zip")
+	err = astizip.Zip(context.Background(), filepath.Join(src, "d"), a, "")
+	assert.NoError(t, err)
+	dst = filepath.Join(dir, "dst-zip")
+	err = astiarchive.Extract(context.Background(), a, dst)
+	assert.NoError(t, err)
+	b, err = ioutil.ReadFile(filepath.Join(dst, "f"))
+	assert.NoError(t, err)
+	assert.Equal(t, "content", string(b))
+}
+
+func writeTarGz(t *testing.T, path string, hs ...*tar.Header) {
+	f, err := os.Create(path)
+	assert.NoError(t, err)
+	defer f.Close()
+	gw := gzip.NewWriter(f)
+	defer gw.Close()
+	tw := tar.NewWriter(gw)
+	defer tw.Close()
+	for _, h := range hs {
+		assert.NoError(t, tw.WriteHeader(h))
+		if h.Size > 0 {
+			tw.Write(bytes.Repeat([]byte("a"), int(h.Size)))
+		}
+	}
+}
+
+func TestExtractTarGz(t *testing.T) {
+	// Init
+	dir, err := ioutil.TempDir("", "astiarchive")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	outside := filepath.Join(dir, "outside")
+	os.Mkdir(outside, 0755)
+
+	// Symlink escaping destination
+	a := filepath.Join(dir, "symlink.tar.gz")
+	writeTarGz(t, a,
+		&tar.Header{Linkname: outside, Mode: 0777, Name: "l", Typeflag: tar.TypeSymlink},
+		&tar.Header{Mode: 0644, Name: "l/pwned", Size: 1, Typeflag: tar.TypeReg},
+	)
+	err = astiarchive.ExtractTarGz(context.Background(), a, filepath.Join(dir, "dst-symlink"))
+	assert.Error(t, err)
+	_, err = os.Stat(filepath.Join(outside, "pwned"))
+	assert.True(t, os.IsNotExist(err))
+
+	// Chained symlinks escaping destination
+	a = filepath.Join(dir, "chained.tar.gz")
+	writeTarGz(t, a,
+		&tar.Header{Linkname: ".", Mode: 0777, Name: "chained/s1", Typeflag: tar.TypeSymlink},
+		&tar.Header{Linkname: "..", Mode: 0777, Name: "chained/s1/s2", Typeflag: tar.TypeSymlink},
+	)
+	for i := 0; i < 10; i++ {
+		err = astiarchive.ExtractTarGz(context.Background(), a, filepath.Join(dir, "dst-chained"))
+		assert.EqualError(t, err, "astios: parent "+filepath.Join(dir, "dst-chained", "chained", "s1")+" of "+
+			filepath.Join(dir, "dst-chained", "chained", "s1", "s2")+" is a symlink")
+		_, err = os.Lstat(filepath.Join(dir, "dst-chained", "chained", "s2"))
+		assert.True(t, os.IsNotExist(err))
+		os.RemoveAll(filepath.Join(dir, "dst-chained"))
+	}
+
+	// Read-only dir
+	a = filepath.Join(dir, "readonly.tar.gz")
+	writeTarGz(t, a,
+		&tar.Header{Mode: 0555, Name: "d/", Typeflag: tar.TypeDir},
+		&tar.Header{Mode: 0644, Name: "d/f", Size: 1, Typeflag: tar.TypeReg},
+	)
+	dst := filepath.Join(dir, "dst-readonly")
+	assert.NoError(t, astiarchive.ExtractTarGz(context.Background(), a, dst))
+	defer os.Chmod(filepath.Join(dst, "d"), 0755)
+	fi, err := os.Stat(filepath.Join(dst, "d"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0555), fi.Mode().Perm())
+	_, err = os.Stat(filepath.Join(dst, "d", "f"))
+	assert.NoError(t, err)
+
+	// Hard link
+	a = filepath.Join(dir, "hardlink.tar.gz")
+	writeTarGz(t, a,
+		&tar.Header{Mode: 0644, Name: "f", Size: 1, Typeflag: tar.TypeReg},
+		&tar.Header{Linkname: "f", Mode: 0644, Name: "d/h", Typeflag: tar.TypeLink},
+	)
+	dst = filepath.Join(dir, "dst-hardlink")
+	assert.NoError(t, astiarchive.ExtractTarGz(context.Background(), a, dst))
+	b, err := ioutil.ReadFile(filepath.Join(dst, "d", "h"))
+	assert.NoError(t, err)
+	assert.Equal(t, "a", string(b))
+
+	// Hard link escaping destination
+	ioutil.WriteFile(filepath.Join(outside, "secret"), []byte("secret"), 0600)
+	a = filepath.Join(dir, "hardlink-escape.tar.gz")
+	writeTarGz(t, a, &tar.Header{Linkname: "../outside/secret", Mode: 0644, Name: "h", Typeflag: tar.TypeLink})
+	dst = filepath.Join(dir, "dst-hardlink-escape")
+	assert.Error(t, astiarchive.ExtractTarGz(context.Background(), a, dst))
+	_, err = os.Lstat(filepath.Join(dst, "h"))
+	assert.True(t, os.IsNotExist(err))
+
+	// Unsupported type
+	a = filepath.Join(dir, "fifo.tar.gz")
+	writeTarGz(t, a, &tar.Header{Mode: 0644, Name: "fifo", Typeflag: tar.TypeFifo})
+	assert.Error(t, astiarchive.ExtractTarGz(context.Background(), a, filepath.Join(dir, "dst-fifo")))
+}
@@ -0,0 +1,209 @@
+package astiarchive
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/asticode/go-astitools/io"
+	"github.com/pkg/errors"
+)
+
+// Entry represents an entry added to an archive
+type Entry struct {
+	// Target of the symlink if Mode has os.ModeSymlink set
+	Linkname string
+	Mode     os.FileMode
+	ModTime  time.Time
+	// Slash separated name of the entry. Directories names can end with a slash
+	Name string
+	// Content of regular files
+	Reader io.Reader
+	// Size in bytes of the content of regular files. It must match the content of Reader for tar archives
+	Size int64
+}
+
+// AddFunc represents a func adding an entry to an archive
+type AddFunc func(e Entry) error
+
+// WalkFunc represents a func that adds all entries of an archive using the provided AddFunc
+type WalkFunc func(add AddFunc) error
+
+// WalkDir returns a WalkFunc adding all entries of a directory src, preserving file modes and symlinks
+// dstRoot can be used to create root directories so that the content is archived in /root/path
+func WalkDir(ctx context.Context, src, dstRoot string) WalkFunc {
+	return func(add AddFunc) error {
+		return filepath.Walk(src, func(path string, info os.FileInfo, e1 error) (e2 error) {
+			// Process error
+			if e1 != nil {
+				return e1
+			}
+
+			// Check context
+			if e2 = ctx.Err(); e2 != nil {
+				return
+			}
+
+			// Get relative path
+			var rel string
+			if rel, e2 = filepath.Rel(src, path); e2 != nil {
+				return errors.Wrapf(e2, "astiarchive: getting relative path of %s failed", path)
+			}
+
+			// Create entry
+			var e = Entry{
+				Mode:    info.Mode(),
+				ModTime: info.ModTime(),
+				Name:    strings.TrimPrefix(filepath.ToSlash(filepath.Join(dstRoot, rel)), "/"),
+			}
+			if e.Name == "" || e.Name == "." {
+				return
+			}
+
+			// Switch on type
+			switch {
+			case info.IsDir():
+				e.Name += "/"
+			case info.Mode()&os.ModeSymlink != 0:
+				if e.Linkname, e2 = os.Readlink(path); e2 != nil {
+					return errors.Wrapf(e2, "astiarchive: reading link %s failed", path)
+				}
+			case info.Mode().IsRegular():
+				// Open path
+				var f *os.File
+				if f, e2 = os.Open(path); e2 != nil {
+					return errors.Wrapf(e2, "astiarchive: opening %s failed", path)
+				}
+				defer f.Close()
+				e.Reader = f
+				e.Size = info.Size()
+			default:
+				return
+			}
+
+			// Add entry
+			return add(e)
+		})
+	}
+}
+
+// WriteTarGz writes a .tar.gz archive into a writer with the entries added by walk
+func WriteTarGz(ctx context.Context, w io.Writer, walk WalkFunc) (err error) {
+	// Create writers
+	var gw = gzip.NewWriter(w)
+	var tw = tar.NewWriter(gw)
+
+	// Walk
+	if err = walk(func(e Entry) (err error) {
+		// Check context
+		if err = ctx.Err(); err != nil {
+			return
+		}
+
+		// Create header
+		var h = &tar.Header{
+			Linkname: e.Linkname,
+			Mode:     int64(e.Mode.Perm()),
+			ModTime:  e.ModTime,
+			Name:     e.Name,
+		}
+		switch {
+		case e.Mode.IsDir() || strings.HasSuffix(e.Name, "/"):
+			h.Typeflag = tar.TypeDir
+			if !strings.HasSuffix(h.Name, "/") {
+				h.Name += "/"
+			}
+		case e.Mode&os.ModeSymlink != 0:
+			h.Typeflag = tar.TypeSymlink
+		default:
+			h.Size = e.Size
+			h.Typeflag = tar.TypeReg
+		}
+
+		// Write header
+		if err = tw.WriteHeader(h); err != nil {
+			return errors.Wrapf(err, "astiarchive: writing tar header for %s failed", e.Name)
+		}
+
+		// Copy
+		if h.Typeflag == tar.TypeReg && e.Reader != nil {
+			if _, err = astiio.Copy(ctx, e.Reader, tw); err != nil {
+				return errors.Wrapf(err, "astiarchive: copying %s failed", e.Name)
+			}
+		}
+		return
+	}); err != nil {
+		return errors.Wrap(err, "astiarchive: walking failed")
+	}
+
+	// Close writers
+	if err = tw.Close(); err != nil {
+		return errors.Wrap(err, "astiarchive: closing tar writer failed")
+	}
+	if err = gw.Close(); err != nil {
+		return errors.Wrap(err, "astiarchive: closing gzip writer failed")
+	}
+	return
+}
+
+// WriteZip writes a .zip archive into a writer with the entries added by walk
+func WriteZip(ctx context.Context, w io.Writer, walk WalkFunc) (err error) {
+	// Create writer
+	var zw = zip.NewWriter(w)
+
+	// Walk
+	if err = walk(func(e Entry) (err error) {
+		// Check context
+		if err = ctx.Err(); err != nil {
+			return
+		}
+
+		// Create header
+		var h = &zip.FileHeader{
+			Modified: e.ModTime,
+			Name:     e.Name,
+		}
+		var r = e.Reader
+		switch {
+		case e.Mode.IsDir() || strings.HasSuffix(e.Name, "/"):
+			h.SetMode(e.Mode | os.ModeDir)
+			if !strings.HasSuffix(h.Name, "/") {
+				h.Name += "/"
+			}
+		case e.Mode&os.ModeSymlink != 0:
+			h.SetMode(e.Mode)
+			r = strings.NewReader(e.Linkname)
+		default:
+			h.Method = zip.Deflate
+			h.SetMode(e.Mode)
+		}
+
+		// Create entry writer
+		var ew io.Writer
+		if ew, err = zw.CreateHeader(h); err != nil {
+			return errors.Wrapf(err, "astiarchive: creating zip header for %s failed", e.Name)
+		}
+
+		// Copy
+		if r != nil && !strings.HasSuffix(h.Name, "/") {
+			if _, err = astiio.Copy(ctx, r, ew); err != nil {
+				return errors.Wrapf(err, "astiarchive: copying %s failed", e.Name)
+			}
+		}
+		return
+	}); err != nil {
+		return errors.Wrap(err, "astiarchive: walking failed")
+	}
+
+	// Close writer
+	if err = zw.Close(); err != nil {
+		return errors.Wrap(err, "astiarchive: closing zip writer failed")
+	}
+	return
+}
@@ -0,0 +1,84 @@
+package astiarchive_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/asticode/go-astitools/archive"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteZip(t *testing.T) {
+	// Write
+	buf := &bytes.Buffer{}
+	err := astiarchive.WriteZip(context.Background(), buf, func(add astiarchive.AddFunc) (err error) {
+		if err = add(astiarchive.Entry{Mode: os.ModeDir | 0755, Name: "d"}); err != nil {
+			return
+		}
+		return add(astiarchive.Entry{Mode: 0644, Name: "d/f", Reader: strings.NewReader("content")})
+	})
+	assert.NoError(t, err)
+
+	// Read
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	assert.NoError(t, err)
+	assert.Len(t, zr.File, 2)
+	assert.Equal(t, "d/", zr.File[0].Name)
+	assert.Equal(t, "d/f", zr.File[1].Name)
+	r, err := zr.File[1].Open()
+	assert.NoError(t, err)
+	defer r.Close()
+	b, err := ioutil.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "content", string(b))
+}
+
+func TestWriteTarGz(t *testing.T) {
+	// Init
+	dir, err := ioutil.TempDir("", "astiarchive")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// Write
+	src := filepath.Join(dir, "archive.tar.gz")
+	f, err := os.Create(src)
+	assert.NoError(t, err)
+	err = astiarchive.WriteTarGz(context.Background(), f, func(add astiarchive.AddFunc) error {
+		return add(astiarchive.Entry{Mode: 0644, Name: "d/f", Reader: strings.NewReader("content"), Size: 7})
+	})
+	f.Close()
+	assert.NoError(t, err)
+
+	// Extract
+	err = astiarchive.Extract(context.Background(), src, filepath.Join(dir, "dst"))
+	assert.NoError(t, err)
+	b, err := ioutil.ReadFile(filepath.Join(dir, "dst", "d", "f"))
+	assert.NoError(t, err)
+	assert.Equal(t, "content", string(b))
+}
+
+func TestWalkDir(t *testing.T) {
+	// Init
+	dir, err := ioutil.TempDir("", "astiarchive")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	os.MkdirAll(filepath.Join(dir, "src", "d"), 0755)
+	ioutil.WriteFile(filepath.Join(dir, "src", "d", "f"), []byte("content"), 0644)
+
+	// Loop through unclean sources
+	for _, src := range []string{filepath.Join(dir, "src") + "/", filepath.Join(dir, ".", "x", "..", "src") + "/."} {
+		var names []string
+		err = astiarchive.WalkDir(context.Background(), src, "root")(func(e astiarchive.Entry) error {
+			names = append(names, e.Name)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"root/", "root/d/", "root/d/f"}, names)
+	}
+}
@@ -0,0 +1,140 @@
+package astiaudio
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// opusFrameDurations are the frame durations supported by Opus
+var opusFrameDurations = []time.Duration{
+	2500 * time.Microsecond,
+	5 * time.Millisecond,
+	10 * time.Millisecond,
+	20 * time.Millisecond,
+	40 * time.Millisecond,
+	60 * time.Millisecond,
+}
+
+// ChunkerConfiguration represents a chunker configuration
+type ChunkerConfiguration struct {
+	// Number of interleaved channels. Defaults to 1
+	Channels int `toml:"channels"`
+	// Must be one of the durations supported by Opus: 2.5, 5, 10, 20, 40 or 60ms. Defaults to 20ms
+	FrameDuration time.Duration `toml:"frame_duration"`
+	SampleRate    int           `toml:"sample_rate"`
+}
+
+// ChunkerFrame represents a fixed-duration frame, its start being relative to the first added sample
+type ChunkerFrame struct {
+	// Interleaved samples
+	Samples []int32
+	// Starts at 0 and is incremented for each frame
+	Sequence uint64
+	Start    time.Duration
+}
+
+// Chunker re-slices a PCM stream into frames of the exact duration required by Opus and WebRTC encoders
+// Samples that don't fill a whole frame are buffered until the next call.
+type Chunker struct {
+	buf       []int32
+	c         ChunkerConfiguration
+	frameSize int
+	sequence  uint64
+}
+
+// NewChunker creates a new chunker
+func NewChunker(c ChunkerConfiguration) (ch *Chunker, err error) {
+	// Default configuration values
+	if c.Channels <= 0 {
+		c.Channels = 1
+	}
+	if c.FrameDuration == 0 {
+		c.FrameDuration = 20 * time.Millisecond
+	}
+
+	// Check frame duration
+	var ok bool
+	for _, d := range opusFrameDurations {
+		if d == c.FrameDuration {
+			ok = true
+			break
+		}
+	}
+	if !ok {
+		err = errors.Errorf("astiaudio: frame duration %s is not supported", c.FrameDuration)
+		return
+	}
+
+	// Check sample rate
+	if c.SampleRate <= 0 {
+		err = errors.New("astiaudio: sample rate must be > 0")
+		return
+	}
+	var n = int64(c.SampleRate) * int64(c.FrameDuration)
+	if n%int64(time.Second) != 0 {
+		err = errors.Errorf("astiaudio: %s frames don't contain a whole number of samples at %dHz", c.FrameDuration, c.SampleRate)
+		return
+	}
+
+	// Create chunker
+	ch = &Chunker{
+		c:         c,
+		frameSize: int(n/int64(time.Second)) * c.Channels,
+	}
+	return
+}
+
+// FrameSize returns the number of interleaved samples per frame
+func (c *Chunker) FrameSize() int {
+	return c.frameSize
+}
+
+// Add adds interleaved samples and returns the frames they complete
+// Returned frames don't share memory with samples.
+func (c *Chunker) Add(samples []int32) (fs []ChunkerFrame) {
+	// Buffer
+	c.buf = append(c.buf, samples...)
+
+	// Loop through whole frames
+	var i int
+	for ; i+c.frameSize <= len(c.buf); i += c.frameSize {
+		fs = append(fs, c.frame(append([]int32(nil), c.buf[i:i+c.frameSize]...)))
+	}
+
+	// Keep remainder
+	c.buf = append(c.buf[:0], c.buf[i:]...)
+	return
+}
+
+// Flush returns the buffered samples, padded with silence to make a whole frame, and resets the buffer
+// ok is false if no samples were buffered.
+func (c *Chunker) Flush() (f ChunkerFrame, ok bool) {
+	// Nothing to flush
+	if len(c.buf) == 0 {
+		return
+	}
+
+	// Pad
+	var samples = make([]int32, c.frameSize)
+	copy(samples, c.buf)
+	c.buf = c.buf[:0]
+	return c.frame(samples), true
+}
+
+// Reset resets the chunker, dropping buffered samples and restarting sequence numbers
+func (c *Chunker) Reset() {
+	c.buf = c.buf[:0]
+	c.sequence = 0
+}
+
+// frame creates a new frame and increments the sequence number
+func (c *Chunker) frame(samples []int32) (f ChunkerFrame) {
+	f = ChunkerFrame{
+		Samples:  samples,
+		Sequence: c.sequence,
+		Start:    time.Duration(c.sequence) * c.c.FrameDuration,
+	}
+	c.sequence++
+	return
+}
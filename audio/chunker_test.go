@@ -0,0 +1,57 @@
+package astiaudio_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/audio"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChunker(t *testing.T) {
+	// Invalid configurations
+	_, err := astiaudio.NewChunker(astiaudio.ChunkerConfiguration{SampleRate: 48000, FrameDuration: 30 * time.Millisecond})
+	assert.Error(t, err)
+	_, err = astiaudio.NewChunker(astiaudio.ChunkerConfiguration{})
+	assert.Error(t, err)
+	_, err = astiaudio.NewChunker(astiaudio.ChunkerConfiguration{SampleRate: 44100, FrameDuration: 2500 * time.Microsecond})
+	assert.Error(t, err)
+
+	// Frame size
+	c, err := astiaudio.NewChunker(astiaudio.ChunkerConfiguration{Channels: 2, SampleRate: 48000})
+	assert.NoError(t, err)
+	assert.Equal(t, 1920, c.FrameSize())
+
+	// Re-slice
+	c, err = astiaudio.NewChunker(astiaudio.ChunkerConfiguration{FrameDuration: 10 * time.Millisecond, SampleRate: 400})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, c.FrameSize())
+	var samples = func(start, end int32) (s []int32) {
+		for i := start; i < end; i++ {
+			s = append(s, i)
+		}
+		return
+	}
+	assert.Empty(t, c.Add(samples(1, 3)))
+	fs := c.Add(samples(3, 12))
+	assert.Equal(t, []astiaudio.ChunkerFrame{
+		{Samples: []int32{1, 2, 3, 4}, Sequence: 0, Start: 0},
+		{Samples: []int32{5, 6, 7, 8}, Sequence: 1, Start: 10 * time.Millisecond},
+	}, fs)
+	fs = c.Add(samples(12, 13))
+	assert.Equal(t, []astiaudio.ChunkerFrame{{Samples: []int32{9, 10, 11, 12}, Sequence: 2, Start: 20 * time.Millisecond}}, fs)
+
+	// Flush
+	_, ok := c.Flush()
+	assert.False(t, ok)
+	c.Add(samples(13, 15))
+	f, ok := c.Flush()
+	assert.True(t, ok)
+	assert.Equal(t, astiaudio.ChunkerFrame{Samples: []int32{13, 14, 0, 0}, Sequence: 3, Start: 30 * time.Millisecond}, f)
+
+	// Reset
+	c.Add(samples(0, 2))
+	c.Reset()
+	fs = c.Add(samples(0, 4))
+	assert.Equal(t, []astiaudio.ChunkerFrame{{Samples: []int32{0, 1, 2, 3}}}, fs)
+}
@@ -0,0 +1,238 @@
+package astiaudio
+
+import (
+	"math"
+)
+
+// DefaultTargetLoudness represents the default target loudness in LUFS, as recommended by EBU R128
+const DefaultTargetLoudness = -23
+
+// LoudnessMeter measures the integrated loudness of mono PCM samples, in LUFS, following the EBU R128/ITU-R BS.1770
+// model: samples are K-weighted, split in 400ms blocks overlapping by 75%, and blocks are gated both absolutely and
+// relatively
+// It's an approximation in that K-weighting filters are computed for the sample rate instead of using the reference
+// 48kHz coefficients.
+type LoudnessMeter struct {
+	blocks     []float64
+	fs         []*biquad
+	max        float64
+	peak       float64
+	sub        []float64
+	subCount   int
+	subSamples int
+	subSum     float64
+}
+
+// NewLoudnessMeter creates a new loudness meter
+func NewLoudnessMeter(sampleRate, bitDepth int) *LoudnessMeter {
+	var fs = float64(sampleRate)
+	return &LoudnessMeter{
+		fs: []*biquad{
+			newHighShelfBiquad(fs, 1500, 4, 1/math.Sqrt2),
+			newHighPassBiquad(fs, 38, 0.5),
+		},
+		max:        math.Pow(2, float64(bitDepth-1)),
+		subSamples: int(math.Max(1, math.Round(fs/10))),
+	}
+}
+
+// Add adds samples
+func (m *LoudnessMeter) Add(samples []int32) {
+	for _, s := range samples {
+		// Normalize
+		var v = float64(s) / m.max
+		if a := math.Abs(v); a > m.peak {
+			m.peak = a
+		}
+
+		// K-weight
+		for _, f := range m.fs {
+			v = f.process(v)
+		}
+
+		// Update 100ms sub block
+		m.subSum += v * v
+		if m.subCount++; m.subCount < m.subSamples {
+			continue
+		}
+		m.sub = append(m.sub, m.subSum/float64(m.subCount))
+		m.subCount, m.subSum = 0, 0
+
+		// A 400ms block is complete
+		if len(m.sub) >= 4 {
+			var z float64
+			for _, v := range m.sub[len(m.sub)-4:] {
+				z += v
+			}
+			m.blocks = append(m.blocks, z/4)
+			m.sub = m.sub[len(m.sub)-3:]
+		}
+	}
+}
+
+// Integrated returns the integrated loudness in LUFS, or -Inf if not enough samples have been added
+func (m *LoudnessMeter) Integrated() float64 {
+	// Absolute gate
+	var bs []float64
+	for _, b := range m.blocks {
+		if blockLoudness(b) > -70 {
+			bs = append(bs, b)
+		}
+	}
+	if len(bs) == 0 {
+		return math.Inf(-1)
+	}
+
+	// Relative gate
+	var t = blockLoudness(mean(bs)) - 10
+	var gs []float64
+	for _, b := range bs {
+		if blockLoudness(b) > t {
+			gs = append(gs, b)
+		}
+	}
+	if len(gs) == 0 {
+		return math.Inf(-1)
+	}
+	return blockLoudness(mean(gs))
+}
+
+// Peak returns the sample peak, between 0 and 1
+func (m *LoudnessMeter) Peak() float64 {
+	return m.peak
+}
+
+// blockLoudness converts a mean square to LUFS
+func blockLoudness(z float64) float64 {
+	return -0.691 + 10*math.Log10(z)
+}
+
+func mean(vs []float64) (m float64) {
+	for _, v := range vs {
+		m += v
+	}
+	return m / float64(len(vs))
+}
+
+// LoudnessNormalizerOptions represents loudness normalizer options
+type LoudnessNormalizerOptions struct {
+	BitDepth int
+	// Maximum sample peak after normalization, in dBFS. Defaults to -1.
+	PeakCeiling float64
+	SampleRate  int
+	// In LUFS. Defaults to DefaultTargetLoudness.
+	TargetLoudness float64
+}
+
+// LoudnessNormalizer applies gain to samples so that they hit a target loudness
+// Samples are first measured by calling Measure, possibly with several chunks, and gain is then applied to them by
+// calling Apply. When both are called on each chunk of a stream, the gain is based on the loudness measured so far.
+type LoudnessNormalizer struct {
+	m *LoudnessMeter
+	o LoudnessNormalizerOptions
+}
+
+// NewLoudnessNormalizer creates a new loudness normalizer
+func NewLoudnessNormalizer(o LoudnessNormalizerOptions) *LoudnessNormalizer {
+	// Default options values
+	if o.BitDepth == 0 {
+		o.BitDepth = 16
+	}
+	if o.PeakCeiling == 0 {
+		o.PeakCeiling = -1
+	}
+	if o.TargetLoudness == 0 {
+		o.TargetLoudness = DefaultTargetLoudness
+	}
+	return &LoudnessNormalizer{
+		m: NewLoudnessMeter(o.SampleRate, o.BitDepth),
+		o: o,
+	}
+}
+
+// Measure measures samples
+func (n *LoudnessNormalizer) Measure(samples []int32) {
+	n.m.Add(samples)
+}
+
+// Loudness returns the integrated loudness measured so far in LUFS
+func (n *LoudnessNormalizer) Loudness() float64 {
+	return n.m.Integrated()
+}
+
+// Gain returns the linear gain needed to hit the target loudness, limited so that the peak stays below the ceiling
+// It returns 1 if loudness can't be measured yet.
+func (n *LoudnessNormalizer) Gain() float64 {
+	// Loudness can't be measured yet
+	var l = n.m.Integrated()
+	if math.IsInf(l, -1) {
+		return 1
+	}
+
+	// Compute gain
+	var g = math.Pow(10, (n.o.TargetLoudness-l)/20)
+
+	// Limit gain
+	if p := n.m.Peak(); p > 0 {
+		if max := math.Pow(10, n.o.PeakCeiling/20) / p; g > max {
+			g = max
+		}
+	}
+	return g
+}
+
+// Apply applies the gain to samples, clipping them if needed
+func (n *LoudnessNormalizer) Apply(samples []int32) (o []int32) {
+	var g = n.Gain()
+	var max = n.m.max - 1
+	o = make([]int32, len(samples))
+	for i, s := range samples {
+		o[i] = int32(math.Round(math.Max(-max-1, math.Min(max, float64(s)*g))))
+	}
+	return
+}
+
+// biquad represents a biquad filter in direct form I
+type biquad struct {
+	a1, a2, b0, b1, b2 float64
+	x1, x2, y1, y2     float64
+}
+
+// newHighShelfBiquad creates a high shelf filter, gain being in dB
+// https://www.w3.org/TR/audio-eq-cookbook/
+func newHighShelfBiquad(fs, fc, gain, q float64) *biquad {
+	var a = math.Pow(10, gain/40)
+	var w0 = 2 * math.Pi * fc / fs
+	var cos, alpha = math.Cos(w0), math.Sin(w0) / (2 * q)
+	var a0 = (a + 1) - (a-1)*cos + 2*math.Sqrt(a)*alpha
+	return &biquad{
+		a1: 2 * ((a - 1) - (a+1)*cos) / a0,
+		a2: ((a + 1) - (a-1)*cos - 2*math.Sqrt(a)*alpha) / a0,
+		b0: a * ((a + 1) + (a-1)*cos + 2*math.Sqrt(a)*alpha) / a0,
+		b1: -2 * a * ((a - 1) + (a+1)*cos) / a0,
+		b2: a * ((a + 1) + (a-1)*cos - 2*math.Sqrt(a)*alpha) / a0,
+	}
+}
+
+// newHighPassBiquad creates a high pass filter
+// https://www.w3.org/TR/audio-eq-cookbook/
+func newHighPassBiquad(fs, fc, q float64) *biquad {
+	var w0 = 2 * math.Pi * fc / fs
+	var cos, alpha = math.Cos(w0), math.Sin(w0) / (2 * q)
+	var a0 = 1 + alpha
+	return &biquad{
+		a1: -2 * cos / a0,
+		a2: (1 - alpha) / a0,
+		b0: (1 + cos) / 2 / a0,
+		b1: -(1 + cos) / a0,
+		b2: (1 + cos) / 2 / a0,
+	}
+}
+
+// process filters a sample
+func (f *biquad) process(x float64) (y float64) {
+	y = f.b0*x + f.b1*f.x1 + f.b2*f.x2 - f.a1*f.y1 - f.a2*f.y2
+	f.x1, f.x2 = x, f.x1
+	f.y1, f.y2 = y, f.y1
+	return
+}
@@ -0,0 +1,42 @@
+package astiaudio_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/asticode/go-astitools/audio"
+	"github.com/stretchr/testify/assert"
+)
+
+// sine generates 16 bits samples of a 1kHz sine at 48kHz, amplitude being between 0 and 1
+func sine(seconds, amplitude float64) (s []int32) {
+	for i := 0; i < int(seconds*48000); i++ {
+		s = append(s, int32(amplitude*32767*math.Sin(2*math.Pi*1000*float64(i)/48000)))
+	}
+	return
+}
+
+func TestLoudness(t *testing.T) {
+	// Meter
+	m := astiaudio.NewLoudnessMeter(48000, 16)
+	assert.True(t, math.IsInf(m.Integrated(), -1))
+	m.Add(sine(3, 1))
+	assert.InDelta(t, -3.01, m.Integrated(), 0.2)
+	m = astiaudio.NewLoudnessMeter(48000, 16)
+	m.Add(sine(3, 0.1))
+	assert.InDelta(t, -23.01, m.Integrated(), 0.2)
+
+	// Normalizer
+	var s = sine(3, 0.01)
+	n := astiaudio.NewLoudnessNormalizer(astiaudio.LoudnessNormalizerOptions{SampleRate: 48000})
+	n.Measure(s)
+	assert.InDelta(t, 10, n.Gain(), 0.3)
+	m = astiaudio.NewLoudnessMeter(48000, 16)
+	m.Add(n.Apply(s))
+	assert.InDelta(t, -23, m.Integrated(), 0.2)
+
+	// Peak ceiling
+	n = astiaudio.NewLoudnessNormalizer(astiaudio.LoudnessNormalizerOptions{SampleRate: 48000, TargetLoudness: -2})
+	n.Measure(s)
+	assert.InDelta(t, math.Pow(10, -1.0/20)/0.01, n.Gain(), 1)
+}
@@ -7,13 +7,20 @@ import (
 
 // SilenceDetector represents a silence detector
 type SilenceDetector struct {
-	audioLevels *[]float64
-	c           SilenceDetectorConfiguration
-	samples     *[]int32
+	audioLevels          *[]float64
+	c                    SilenceDetectorConfiguration
+	samples              *[]int32
+	samplesCount         int
+	silenceMaxAudioLevel float64
 }
 
 // SilenceDetectorConfiguration represents a silence detector configuration
+// Paddings are rounded up to the step duration. Segments always keep at least 1 step of leading silence.
 type SilenceDetectorConfiguration struct {
+	// Amount of audio kept after the detected speech
+	PostPadding time.Duration `toml:"post_padding"`
+	// Amount of audio kept before the detected speech
+	PrePadding         time.Duration `toml:"pre_padding"`
 	SilenceMinDuration time.Duration `toml:"silence_min_duration"`
 	StepDuration       time.Duration `toml:"step_duration"`
 }
@@ -48,6 +55,10 @@ func (d *SilenceDetector) Add(samples []int32, sampleRate int, silenceMaxAudioLe
 	// Get number of samples per audio level analysis
 	var audioLevelAnalysisSamplesCount = int(math.Floor(float64(sampleRate) * d.c.StepDuration.Seconds()))
 
+	// Store parameters for Flush
+	d.samplesCount = audioLevelAnalysisSamplesCount
+	d.silenceMaxAudioLevel = silenceMaxAudioLevel
+
 	// Get number of processed samples
 	var processedSamplesCount = len(*d.audioLevels) * audioLevelAnalysisSamplesCount
 
@@ -79,10 +90,10 @@ func (d *SilenceDetector) Add(samples []int32, sampleRate int, silenceMaxAudioLe
 		}
 	}
 
-	// Keep 1 silence at the start
-	if silencesCount > 1 {
-		*d.audioLevels = (*d.audioLevels)[silencesCount-1:]
-		*d.samples = (*d.samples)[(silencesCount-1)*audioLevelAnalysisSamplesCount:]
+	// Keep pre padding silences at the start
+	if prePaddingCount := d.paddingCount(d.c.PrePadding, 1); silencesCount > prePaddingCount {
+		*d.audioLevels = (*d.audioLevels)[silencesCount-prePaddingCount:]
+		*d.samples = (*d.samples)[(silencesCount-prePaddingCount)*audioLevelAnalysisSamplesCount:]
 	}
 
 	// Not enough audio levels to process silences in the middle
@@ -101,23 +112,86 @@ func (d *SilenceDetector) Add(samples []int32, sampleRate int, silenceMaxAudioLe
 		}
 
 		// Process silences
-		d.processSilencesInTheMiddle(audioLevelAnalysisSamplesCount, i, silencesCount, &validSamples)
+		d.processSilencesInTheMiddle(audioLevelAnalysisSamplesCount, i, silencesCount, false, &validSamples)
 
 		// Reset
 		silencesCount = 0
 	}
 
 	// Process remaining silences
-	d.processSilencesInTheMiddle(audioLevelAnalysisSamplesCount, i, silencesCount, &validSamples)
+	d.processSilencesInTheMiddle(audioLevelAnalysisSamplesCount, i, silencesCount, true, &validSamples)
+	return
+}
+
+// Flush returns buffered samples that were never followed by enough silences as valid samples, typically once the
+// input stream has ended, and resets the silence detector
+// Trailing silences are trimmed according to the post padding. It returns nil if buffered samples only contain
+// silences.
+func (d *SilenceDetector) Flush() (validSamples []int32) {
+	// Reset
+	defer d.Reset()
+
+	// Nothing was added
+	if d.samplesCount == 0 {
+		return
+	}
+
+	// Get audio levels, including samples that were not processed yet
+	var audioLevels = *d.audioLevels
+	if processedSamplesCount := len(audioLevels) * d.samplesCount; len(*d.samples) > processedSamplesCount {
+		audioLevels = append(audioLevels, AudioLevel((*d.samples)[processedSamplesCount:]))
+	}
+
+	// Get last speech
+	var last = -1
+	for i := len(audioLevels) - 1; i >= 0; i-- {
+		if audioLevels[i] >= d.silenceMaxAudioLevel {
+			last = i
+			break
+		}
+	}
+
+	// Only silences
+	if last < 0 {
+		return
+	}
+
+	// Keep post padding silences at the end
+	var end = (last + 1 + d.paddingCount(d.c.PostPadding, 0)) * d.samplesCount
+	if end > len(*d.samples) {
+		end = len(*d.samples)
+	}
+
+	// Add valid samples
+	validSamples = make([]int32, end)
+	copy(validSamples, (*d.samples)[:end])
+	return
+}
+
+// paddingCount returns the number of steps needed to cover the padding
+func (d *SilenceDetector) paddingCount(padding time.Duration, min int) (c int) {
+	if c = int((padding + d.c.StepDuration - 1) / d.c.StepDuration); c < min {
+		c = min
+	}
 	return
 }
 
 // processSilencesInTheMiddle processes silences in the middle
-func (d *SilenceDetector) processSilencesInTheMiddle(audioLevelAnalysisSamplesCount, i, silencesCount int, validSamples *[][]int32) {
+// When silences are the last audio levels, more audio may follow: valid samples are only added once there are
+// enough silences to cover the post padding
+func (d *SilenceDetector) processSilencesInTheMiddle(audioLevelAnalysisSamplesCount, i, silencesCount int, last bool, validSamples *[][]int32) {
+	// Get post padding
+	var postPaddingCount = d.paddingCount(d.c.PostPadding, 0)
+	if last && silencesCount < postPaddingCount {
+		return
+	} else if postPaddingCount > silencesCount {
+		postPaddingCount = silencesCount
+	}
+
 	// Too many silences, we have valid samples!
 	if time.Duration(silencesCount)*d.c.StepDuration >= d.c.SilenceMinDuration {
-		// Keep 1 silence at the end
-		end := (i - silencesCount) * audioLevelAnalysisSamplesCount
+		// Keep post padding silences at the end
+		end := (i - silencesCount + postPaddingCount) * audioLevelAnalysisSamplesCount
 
 		// Add valid samples
 		var samples = make([]int32, end)
@@ -125,7 +199,8 @@ func (d *SilenceDetector) processSilencesInTheMiddle(audioLevelAnalysisSamplesCo
 		*validSamples = append(*validSamples, samples)
 
 		// Reset
+		// Silences are kept so that they can be used as the next valid samples' pre padding
 		*d.audioLevels = (*d.audioLevels)[(i - silencesCount):]
-		*d.samples = (*d.samples)[end:]
+		*d.samples = (*d.samples)[(i-silencesCount)*audioLevelAnalysisSamplesCount:]
 	}
 }
@@ -0,0 +1,45 @@
+package astiaudio_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/audio"
+	"github.com/stretchr/testify/assert"
+)
+
+// samples returns steps of 10 samples, 0 being a silence and 1 being speech
+func samples(steps ...int) (s []int32) {
+	for _, v := range steps {
+		for i := 0; i < 10; i++ {
+			s = append(s, int32(v*1000))
+		}
+	}
+	return
+}
+
+func TestSilenceDetector(t *testing.T) {
+	// No padding
+	var c = astiaudio.SilenceDetectorConfiguration{SilenceMinDuration: 300 * time.Millisecond, StepDuration: 100 * time.Millisecond}
+	var d = astiaudio.NewSilenceDetector(c)
+	assert.Equal(t, [][]int32{samples(0, 1, 1)}, d.Add(samples(0, 0, 0, 1, 1, 0, 0, 0, 0), 100, 10))
+
+	// Padding
+	c.PostPadding = 150 * time.Millisecond
+	c.PrePadding = 200 * time.Millisecond
+	d = astiaudio.NewSilenceDetector(c)
+	assert.Empty(t, d.Add(samples(0, 0, 0, 1, 1, 0), 100, 10))
+	assert.Equal(t, [][]int32{samples(0, 0, 1, 1, 0, 0)}, d.Add(samples(0, 0, 0), 100, 10))
+
+	// Flush
+	d = astiaudio.NewSilenceDetector(c)
+	assert.Nil(t, d.Flush())
+	assert.Empty(t, d.Add(samples(0, 0, 0, 1, 1, 0), 100, 10))
+	assert.Equal(t, samples(0, 0, 1, 1, 0), d.Flush())
+	assert.Nil(t, d.Flush())
+	assert.Empty(t, d.Add(samples(0, 0, 0), 100, 10))
+	assert.Nil(t, d.Flush())
+	assert.Empty(t, d.Add(samples(0, 0, 0), 100, 10))
+	assert.Empty(t, d.Add([]int32{1000, 1000, 1000}, 100, 10))
+	assert.Equal(t, append(samples(0, 0), 1000, 1000, 1000), d.Flush())
+}
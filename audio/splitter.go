@@ -0,0 +1,223 @@
+package astiaudio
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultSplitterNameTemplate represents the default splitter name template
+const DefaultSplitterNameTemplate = "{{.Index}}.pcm"
+
+// SplitterOptions represents splitter options
+// Input must be mono signed PCM, except for 8 bits which is unsigned. Segments are written in the same format.
+type SplitterOptions struct {
+	// Defaults to 16
+	BitDepth int
+	// Defaults to little endian
+	ByteOrder binary.ByteOrder
+	// Directory segments are written in. Defaults to the current directory.
+	Dir string
+	// Segments longer than this are split in several segments. 0 means no maximum.
+	MaxSegmentDuration time.Duration
+	// Segments shorter than this are dropped
+	MinSegmentDuration time.Duration
+	// Template of segments file names, executed with a SplitterSegment. Defaults to DefaultSplitterNameTemplate.
+	NameTemplate         string
+	SampleRate           int
+	SilenceDetector      SilenceDetectorConfiguration
+	SilenceMaxAudioLevel float64
+}
+
+// SplitterSegment represents a segment written by a splitter
+type SplitterSegment struct {
+	Duration time.Duration
+	Index    int
+	Path     string
+}
+
+// Splitter splits PCM audio in speech segments, based on silences, and writes each of them in its own file
+type Splitter struct {
+	o SplitterOptions
+	t *template.Template
+}
+
+// NewSplitter creates a new splitter
+func NewSplitter(o SplitterOptions) (s *Splitter, err error) {
+	// Default options values
+	if o.BitDepth == 0 {
+		o.BitDepth = 16
+	}
+	if o.ByteOrder == nil {
+		o.ByteOrder = binary.LittleEndian
+	}
+	if o.NameTemplate == "" {
+		o.NameTemplate = DefaultSplitterNameTemplate
+	}
+
+	// Check options
+	switch o.BitDepth {
+	case 8, 16, 24, 32:
+	default:
+		err = errors.Errorf("astiaudio: invalid bit depth %d", o.BitDepth)
+		return
+	}
+	if o.SampleRate <= 0 {
+		err = errors.Errorf("astiaudio: invalid sample rate %d", o.SampleRate)
+		return
+	}
+
+	// Create splitter
+	s = &Splitter{o: o}
+
+	// Parse template
+	if s.t, err = template.New("name").Parse(o.NameTemplate); err != nil {
+		err = errors.Wrapf(err, "astiaudio: parsing name template %s failed", o.NameTemplate)
+		return
+	}
+	return
+}
+
+// Split reads PCM audio until io.EOF and writes speech segments
+// Speech that is still buffered once the input has ended is written as a final segment.
+func (s *Splitter) Split(ctx context.Context, r io.Reader) (ss []SplitterSegment, err error) {
+	// Create silence detector
+	var d = NewSilenceDetector(s.o.SilenceDetector)
+
+	// Loop
+	var bytesPerSample = s.o.BitDepth / 8
+	var buf = make([]byte, s.o.SampleRate*bytesPerSample)
+	for {
+		// Check context
+		if err = ctx.Err(); err != nil {
+			return
+		}
+
+		// Read
+		var n int
+		n, err = io.ReadFull(r, buf)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			err = errors.Wrap(err, "astiaudio: reading failed")
+			return
+		}
+		var eof = err != nil
+		err = nil
+
+		// Add samples
+		for _, samples := range d.Add(s.decode(buf[:n-n%bytesPerSample]), s.o.SampleRate, s.o.SilenceMaxAudioLevel) {
+			if err = s.write(samples, &ss); err != nil {
+				return
+			}
+		}
+
+		// End of stream
+		if eof {
+			if samples := d.Flush(); len(samples) > 0 {
+				err = s.write(samples, &ss)
+			}
+			return
+		}
+	}
+}
+
+// write splits samples according to the max segment duration and writes the resulting segments
+func (s *Splitter) write(samples []int32, ss *[]SplitterSegment) (err error) {
+	// Get max samples count
+	var max = len(samples)
+	if s.o.MaxSegmentDuration > 0 {
+		if max = int(s.o.MaxSegmentDuration.Seconds() * float64(s.o.SampleRate)); max <= 0 {
+			max = 1
+		}
+	}
+
+	// Loop through chunks
+	for start := 0; start < len(samples); start += max {
+		// Get chunk
+		var end = start + max
+		if end > len(samples) {
+			end = len(samples)
+		}
+
+		// Segment is too short
+		var sg = SplitterSegment{
+			Duration: time.Duration(end-start) * time.Second / time.Duration(s.o.SampleRate),
+			Index:    len(*ss),
+		}
+		if sg.Duration < s.o.MinSegmentDuration {
+			continue
+		}
+
+		// Get path
+		var b = &bytes.Buffer{}
+		if err = s.t.Execute(b, sg); err != nil {
+			err = errors.Wrap(err, "astiaudio: executing name template failed")
+			return
+		}
+		sg.Path = filepath.Join(s.o.Dir, b.String())
+
+		// Write
+		if err = os.WriteFile(sg.Path, s.encode(samples[start:end]), 0644); err != nil {
+			err = errors.Wrapf(err, "astiaudio: writing %s failed", sg.Path)
+			return
+		}
+		*ss = append(*ss, sg)
+	}
+	return
+}
+
+// decode decodes PCM bytes
+func (s *Splitter) decode(b []byte) (samples []int32) {
+	var bytesPerSample = s.o.BitDepth / 8
+	samples = make([]int32, len(b)/bytesPerSample)
+	for i := range samples {
+		var p = b[i*bytesPerSample : (i+1)*bytesPerSample]
+		switch s.o.BitDepth {
+		case 8:
+			samples[i] = int32(p[0]) - 128
+		case 16:
+			samples[i] = int32(int16(s.o.ByteOrder.Uint16(p)))
+		case 24:
+			var v uint32
+			if s.o.ByteOrder == binary.BigEndian {
+				v = uint32(p[0])<<16 | uint32(p[1])<<8 | uint32(p[2])
+			} else {
+				v = uint32(p[2])<<16 | uint32(p[1])<<8 | uint32(p[0])
+			}
+			samples[i] = int32(v<<8) >> 8
+		case 32:
+			samples[i] = int32(s.o.ByteOrder.Uint32(p))
+		}
+	}
+	return
+}
+
+// encode encodes samples to PCM bytes
+func (s *Splitter) encode(samples []int32) (b []byte) {
+	var bytesPerSample = s.o.BitDepth / 8
+	b = make([]byte, len(samples)*bytesPerSample)
+	for i, v := range samples {
+		var p = b[i*bytesPerSample : (i+1)*bytesPerSample]
+		switch s.o.BitDepth {
+		case 8:
+			p[0] = byte(v + 128)
+		case 16:
+			s.o.ByteOrder.PutUint16(p, uint16(v))
+		case 24:
+			if s.o.ByteOrder == binary.BigEndian {
+				p[0], p[1], p[2] = byte(v>>16), byte(v>>8), byte(v)
+			} else {
+				p[0], p[1], p[2] = byte(v), byte(v>>8), byte(v>>16)
+			}
+		case 32:
+			s.o.ByteOrder.PutUint32(p, uint32(v))
+		}
+	}
+	return
+}
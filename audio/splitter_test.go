@@ -0,0 +1,56 @@
+package astiaudio_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/audio"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitter(t *testing.T) {
+	// Create input: 1s silence, 2s speech, 1s silence, 0.5s speech, 1s silence
+	var b = &bytes.Buffer{}
+	var add = func(d time.Duration, v int16) {
+		for i := 0; i < int(d.Seconds()*1000); i++ {
+			if i%2 == 1 {
+				binary.Write(b, binary.LittleEndian, -v)
+			} else {
+				binary.Write(b, binary.LittleEndian, v)
+			}
+		}
+	}
+	add(time.Second, 0)
+	add(2*time.Second, 1000)
+	add(time.Second, 0)
+	add(500*time.Millisecond, 1000)
+	add(time.Second, 0)
+
+	// Split
+	var dir = t.TempDir()
+	s, err := astiaudio.NewSplitter(astiaudio.SplitterOptions{
+		Dir:                  dir,
+		MaxSegmentDuration:   1500 * time.Millisecond,
+		MinSegmentDuration:   time.Second,
+		NameTemplate:         "segment-{{.Index}}.pcm",
+		SampleRate:           1000,
+		SilenceDetector:      astiaudio.SilenceDetectorConfiguration{SilenceMinDuration: 500 * time.Millisecond, StepDuration: 100 * time.Millisecond},
+		SilenceMaxAudioLevel: 10,
+	})
+	assert.NoError(t, err)
+	ss, err := s.Split(context.Background(), b)
+	assert.NoError(t, err)
+
+	// 2.1s speech segment is split in 1.5s + 0.6s and the 0.6s chunk as well as the 0.6s second segment are dropped
+	assert.Len(t, ss, 1)
+	assert.Equal(t, filepath.Join(dir, "segment-0.pcm"), ss[0].Path)
+	assert.Equal(t, 1500*time.Millisecond, ss[0].Duration)
+	c, err := os.ReadFile(ss[0].Path)
+	assert.NoError(t, err)
+	assert.Len(t, c, 3000)
+}
@@ -0,0 +1,286 @@
+package astiaudio
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+// ToneDetectorConfiguration represents a tone detector configuration
+type ToneDetectorConfiguration struct {
+	// Frequencies, in Hz, to detect. Ignored by DTMF detectors.
+	Frequencies []float64 `toml:"frequencies"`
+	// Steps whose audio level is below this are considered silent
+	MinAudioLevel float64 `toml:"min_audio_level"`
+	// Tones shorter than this are ignored. Defaults to 40ms.
+	MinDuration time.Duration `toml:"min_duration"`
+	// Defaults to 20ms
+	StepDuration time.Duration `toml:"step_duration"`
+	// Minimum share, between 0 and 1, of the step's energy a frequency must have to be considered present.
+	// Defaults to 0.25.
+	Threshold float64 `toml:"threshold"`
+}
+
+// ToneEvent represents a detected tone, timestamps being relative to the first added sample
+type ToneEvent struct {
+	Duration  time.Duration
+	Frequency float64
+	Start     time.Duration
+}
+
+// DTMFEvent represents a detected DTMF digit, timestamps being relative to the first added sample
+type DTMFEvent struct {
+	Digit    rune
+	Duration time.Duration
+	Start    time.Duration
+}
+
+// DTMF frequencies
+var (
+	dtmfDigits          = [4][4]rune{{'1', '2', '3', 'A'}, {'4', '5', '6', 'B'}, {'7', '8', '9', 'C'}, {'*', '0', '#', 'D'}}
+	dtmfHighFrequencies = []float64{1209, 1336, 1477, 1633}
+	dtmfLowFrequencies  = []float64{697, 770, 852, 941}
+)
+
+// newToneDetectorConfiguration sets default configuration values
+func newToneDetectorConfiguration(c ToneDetectorConfiguration) ToneDetectorConfiguration {
+	if c.MinDuration == 0 {
+		c.MinDuration = 40 * time.Millisecond
+	}
+	if c.StepDuration == 0 {
+		c.StepDuration = 20 * time.Millisecond
+	}
+	if c.Threshold == 0 {
+		c.Threshold = 0.25
+	}
+	return c
+}
+
+// toneSteps splits added samples in steps analyzed with the Goertzel algorithm
+// It's shared by tone and DTMF detectors.
+type toneSteps struct {
+	c          ToneDetectorConfiguration
+	offset     int64
+	sampleRate int
+	samples    []int32
+}
+
+// add buffers samples and executes fn for every complete step with the relative power of each frequency, powers
+// being nil if the step is silent
+func (s *toneSteps) add(samples []int32, sampleRate int, frequencies []float64, fn func(start, end int64, powers []float64)) {
+	// Append new samples
+	s.samples = append(s.samples, samples...)
+	s.sampleRate = sampleRate
+
+	// Get number of samples per step
+	var n = int(math.Floor(float64(sampleRate) * s.c.StepDuration.Seconds()))
+	if n <= 0 {
+		return
+	}
+
+	// Loop through steps
+	var i int
+	for ; i+n <= len(s.samples); i += n {
+		var start = s.offset + int64(i)
+		fn(start, start+int64(n), s.powers(s.samples[i:i+n], frequencies))
+	}
+
+	// Remove processed samples
+	s.offset += int64(i)
+	s.samples = append([]int32{}, s.samples[i:]...)
+}
+
+// powers computes the share of the block's energy of each frequency
+func (s *toneSteps) powers(block []int32, frequencies []float64) (ps []float64) {
+	// Block is silent
+	if AudioLevel(block) < s.c.MinAudioLevel {
+		return
+	}
+
+	// Compute energy
+	var energy float64
+	for _, v := range block {
+		energy += float64(v) * float64(v)
+	}
+	if energy == 0 {
+		return
+	}
+
+	// Loop through frequencies
+	ps = make([]float64, len(frequencies))
+	for idx, f := range frequencies {
+		ps[idx] = 2 * goertzel(block, f, s.sampleRate) / (float64(len(block)) * energy)
+	}
+	return
+}
+
+// duration converts a number of samples to a duration
+func (s *toneSteps) duration(samples int64) time.Duration {
+	if s.sampleRate == 0 {
+		return 0
+	}
+	return time.Duration(samples) * time.Second / time.Duration(s.sampleRate)
+}
+
+// reset resets the steps
+func (s *toneSteps) reset() {
+	s.offset = 0
+	s.samples = []int32{}
+}
+
+// goertzel computes the power of a frequency in a block
+// https://en.wikipedia.org/wiki/Goertzel_algorithm
+func goertzel(block []int32, frequency float64, sampleRate int) float64 {
+	var coeff = 2 * math.Cos(2*math.Pi*frequency/float64(sampleRate))
+	var s1, s2 float64
+	for _, v := range block {
+		s1, s2 = float64(v)+coeff*s1-s2, s1
+	}
+	return s1*s1 + s2*s2 - coeff*s1*s2
+}
+
+// ToneDetector represents a detector of configured frequencies in PCM samples
+type ToneDetector struct {
+	active map[float64][2]int64
+	s      *toneSteps
+}
+
+// NewToneDetector creates a new tone detector
+func NewToneDetector(c ToneDetectorConfiguration) (d *ToneDetector) {
+	d = &ToneDetector{s: &toneSteps{c: newToneDetectorConfiguration(c)}}
+	d.Reset()
+	return
+}
+
+// Reset resets the tone detector
+func (d *ToneDetector) Reset() {
+	d.active = make(map[float64][2]int64)
+	d.s.reset()
+}
+
+// Add adds samples and returns tones that have ended, ordered by start
+func (d *ToneDetector) Add(samples []int32, sampleRate int) (es []ToneEvent) {
+	d.s.add(samples, sampleRate, d.s.c.Frequencies, func(start, end int64, ps []float64) {
+		for idx, f := range d.s.c.Frequencies {
+			// Tone is present
+			if ps != nil && ps[idx] >= d.s.c.Threshold {
+				if a, ok := d.active[f]; ok {
+					d.active[f] = [2]int64{a[0], end}
+				} else {
+					d.active[f] = [2]int64{start, end}
+				}
+				continue
+			}
+
+			// Tone has ended
+			if a, ok := d.active[f]; ok {
+				d.appendEvent(&es, f, a)
+				delete(d.active, f)
+			}
+		}
+	})
+	sortToneEvents(es)
+	return
+}
+
+// Flush returns tones that are still active and resets the tone detector
+func (d *ToneDetector) Flush() (es []ToneEvent) {
+	for f, a := range d.active {
+		d.appendEvent(&es, f, a)
+	}
+	sortToneEvents(es)
+	d.Reset()
+	return
+}
+
+// appendEvent appends an event if the tone is long enough
+func (d *ToneDetector) appendEvent(es *[]ToneEvent, f float64, a [2]int64) {
+	if e := (ToneEvent{Duration: d.s.duration(a[1] - a[0]), Frequency: f, Start: d.s.duration(a[0])}); e.Duration >= d.s.c.MinDuration {
+		*es = append(*es, e)
+	}
+}
+
+// sortToneEvents sorts tone events by start then frequency
+func sortToneEvents(es []ToneEvent) {
+	sort.Slice(es, func(i, j int) bool {
+		if es[i].Start == es[j].Start {
+			return es[i].Frequency < es[j].Frequency
+		}
+		return es[i].Start < es[j].Start
+	})
+}
+
+// DTMFDetector represents a detector of DTMF digits in PCM samples
+type DTMFDetector struct {
+	digit rune
+	end   int64
+	s     *toneSteps
+	start int64
+}
+
+// NewDTMFDetector creates a new DTMF detector
+func NewDTMFDetector(c ToneDetectorConfiguration) (d *DTMFDetector) {
+	d = &DTMFDetector{s: &toneSteps{c: newToneDetectorConfiguration(c)}}
+	d.Reset()
+	return
+}
+
+// Reset resets the DTMF detector
+func (d *DTMFDetector) Reset() {
+	d.digit = 0
+	d.s.reset()
+}
+
+// Add adds samples and returns digits that have ended
+func (d *DTMFDetector) Add(samples []int32, sampleRate int) (es []DTMFEvent) {
+	var fs = append(append([]float64{}, dtmfLowFrequencies...), dtmfHighFrequencies...)
+	d.s.add(samples, sampleRate, fs, func(start, end int64, ps []float64) {
+		// Get digit
+		var digit rune
+		if ps != nil {
+			if l, h := maxIndex(ps[:4]), maxIndex(ps[4:]); ps[l] >= d.s.c.Threshold && ps[4+h] >= d.s.c.Threshold {
+				digit = dtmfDigits[l][h]
+			}
+		}
+
+		// Same digit
+		if digit == d.digit {
+			d.end = end
+			return
+		}
+
+		// Digit has ended
+		d.appendEvent(&es)
+
+		// Update digit
+		d.digit, d.start, d.end = digit, start, end
+	})
+	return
+}
+
+// Flush returns the digit that is still active, if any, and resets the DTMF detector
+func (d *DTMFDetector) Flush() (es []DTMFEvent) {
+	d.appendEvent(&es)
+	d.Reset()
+	return
+}
+
+// appendEvent appends an event for the current digit if it's long enough
+func (d *DTMFDetector) appendEvent(es *[]DTMFEvent) {
+	if d.digit == 0 {
+		return
+	}
+	if e := (DTMFEvent{Digit: d.digit, Duration: d.s.duration(d.end - d.start), Start: d.s.duration(d.start)}); e.Duration >= d.s.c.MinDuration {
+		*es = append(*es, e)
+	}
+}
+
+// maxIndex returns the index of the max value
+func maxIndex(vs []float64) (idx int) {
+	for i, v := range vs {
+		if v > vs[idx] {
+			idx = i
+		}
+	}
+	return
+}
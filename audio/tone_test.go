@@ -0,0 +1,47 @@
+package astiaudio_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/audio"
+	"github.com/stretchr/testify/assert"
+)
+
+const toneSampleRate = 8000
+
+// tone generates samples of the sum of frequencies
+func tone(d time.Duration, fs ...float64) (s []int32) {
+	for i := 0; i < int(d.Seconds()*toneSampleRate); i++ {
+		var v float64
+		for _, f := range fs {
+			v += 5000 * math.Sin(2*math.Pi*f*float64(i)/toneSampleRate)
+		}
+		s = append(s, int32(v))
+	}
+	return
+}
+
+func TestToneDetector(t *testing.T) {
+	d := astiaudio.NewToneDetector(astiaudio.ToneDetectorConfiguration{Frequencies: []float64{440, 1000}, MinAudioLevel: 100})
+	var s = append(tone(100*time.Millisecond), tone(200*time.Millisecond, 440)...)
+	s = append(s, tone(100*time.Millisecond)...)
+	assert.Empty(t, d.Add(s[:1000], toneSampleRate))
+	es := d.Add(s[1000:], toneSampleRate)
+	assert.Equal(t, []astiaudio.ToneEvent{{Duration: 200 * time.Millisecond, Frequency: 440, Start: 100 * time.Millisecond}}, es)
+	assert.Empty(t, d.Add(tone(20*time.Millisecond, 100), toneSampleRate))
+	assert.Empty(t, d.Add(tone(60*time.Millisecond, 1000), toneSampleRate))
+	assert.Equal(t, []astiaudio.ToneEvent{{Duration: 60 * time.Millisecond, Frequency: 1000, Start: 420 * time.Millisecond}}, d.Flush())
+}
+
+func TestDTMFDetector(t *testing.T) {
+	d := astiaudio.NewDTMFDetector(astiaudio.ToneDetectorConfiguration{MinAudioLevel: 100})
+	var s []int32
+	s = append(s, tone(100*time.Millisecond, 697, 1209)...)
+	s = append(s, tone(60*time.Millisecond)...)
+	s = append(s, tone(100*time.Millisecond, 941, 1477)...)
+	es := d.Add(s, toneSampleRate)
+	assert.Equal(t, []astiaudio.DTMFEvent{{Digit: '1', Duration: 100 * time.Millisecond}}, es)
+	assert.Equal(t, []astiaudio.DTMFEvent{{Digit: '#', Duration: 100 * time.Millisecond, Start: 160 * time.Millisecond}}, d.Flush())
+}
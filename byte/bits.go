@@ -0,0 +1,204 @@
+package astibyte
+
+import (
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// BitReader reads bits, most significant bit first, from a reader
+type BitReader struct {
+	b     [1]byte
+	cur   byte
+	n     uint // Number of bits left in cur
+	r     io.Reader
+	total uint64
+}
+
+// NewBitReader creates a new bit reader, byte slices being read through bytes.NewReader
+func NewBitReader(r io.Reader) *BitReader {
+	return &BitReader{r: r}
+}
+
+// BitsRead returns the number of bits read so far
+func (r *BitReader) BitsRead() uint64 {
+	return r.total
+}
+
+// ByteAligned checks whether the reader is aligned on a byte boundary
+func (r *BitReader) ByteAligned() bool {
+	return r.n == 0
+}
+
+// Align skips the bits left in the current byte
+func (r *BitReader) Align() {
+	r.total += uint64(r.n)
+	r.n = 0
+}
+
+// ReadBit reads a single bit
+func (r *BitReader) ReadBit() (bool, error) {
+	v, err := r.ReadBits(1)
+	return v == 1, err
+}
+
+// ReadBits reads n bits, n being at most 64
+// io.ErrUnexpectedEOF is returned if the reader ends in the middle of the bits.
+func (r *BitReader) ReadBits(n uint) (v uint64, err error) {
+	// Check n
+	if n > 64 {
+		err = errors.Errorf("astibyte: can't read %d bits at once", n)
+		return
+	}
+
+	// Loop
+	for i := uint(0); i < n; {
+		// Read next byte
+		if r.n == 0 {
+			if _, err = io.ReadFull(r.r, r.b[:]); err != nil {
+				if err == io.EOF && i > 0 {
+					err = io.ErrUnexpectedEOF
+				}
+				return
+			}
+			r.cur, r.n = r.b[0], 8
+		}
+
+		// Take as many bits as possible from the current byte
+		var c = min(n-i, r.n)
+		v = v<<c | uint64(r.cur>>(r.n-c))&(1<<c-1)
+		r.n -= c
+		r.total += uint64(c)
+		i += c
+	}
+	return
+}
+
+// ReadUE reads an unsigned exponential-Golomb code
+func (r *BitReader) ReadUE() (v uint64, err error) {
+	// Count leading zeros
+	var zeros uint
+	for {
+		var b bool
+		if b, err = r.ReadBit(); err != nil {
+			return
+		}
+		if b {
+			break
+		}
+		if zeros++; zeros > 63 {
+			err = errors.New("astibyte: invalid exponential-Golomb code")
+			return
+		}
+	}
+
+	// Read suffix
+	if v, err = r.ReadBits(zeros); err != nil {
+		return
+	}
+	v += 1<<zeros - 1
+	return
+}
+
+// ReadSE reads a signed exponential-Golomb code
+func (r *BitReader) ReadSE() (v int64, err error) {
+	var u uint64
+	if u, err = r.ReadUE(); err != nil {
+		return
+	}
+	if u%2 == 1 {
+		v = int64((u + 1) / 2)
+	} else {
+		v = -int64(u / 2)
+	}
+	return
+}
+
+// BitWriter writes bits, most significant bit first, to a writer
+type BitWriter struct {
+	cur byte
+	n   uint // Number of bits used in cur
+	w   io.Writer
+}
+
+// NewBitWriter creates a new bit writer
+func NewBitWriter(w io.Writer) *BitWriter {
+	return &BitWriter{w: w}
+}
+
+// ByteAligned checks whether the writer is aligned on a byte boundary
+func (w *BitWriter) ByteAligned() bool {
+	return w.n == 0
+}
+
+// Align pads the current byte with zeros and writes it
+func (w *BitWriter) Align() (err error) {
+	if w.n == 0 {
+		return
+	}
+	return w.WriteBits(0, 8-w.n)
+}
+
+// WriteBit writes a single bit
+func (w *BitWriter) WriteBit(b bool) error {
+	if b {
+		return w.WriteBits(1, 1)
+	}
+	return w.WriteBits(0, 1)
+}
+
+// WriteBits writes the n least significant bits of v, n being at most 64
+// Bits are written to the underlying writer every time a byte is complete. Use Align to write the last bits.
+func (w *BitWriter) WriteBits(v uint64, n uint) (err error) {
+	// Check n
+	if n > 64 {
+		err = errors.Errorf("astibyte: can't write %d bits at once", n)
+		return
+	}
+
+	// Loop
+	for n > 0 {
+		// Put as many bits as possible in the current byte
+		var c = min(n, 8-w.n)
+		w.cur |= byte(v>>(n-c)&(1<<c-1)) << (8 - w.n - c)
+		w.n += c
+		n -= c
+
+		// Byte is complete
+		if w.n == 8 {
+			if _, err = w.w.Write([]byte{w.cur}); err != nil {
+				err = errors.Wrap(err, "astibyte: writing byte failed")
+				return
+			}
+			w.cur, w.n = 0, 0
+		}
+	}
+	return
+}
+
+// WriteUE writes an unsigned exponential-Golomb code
+func (w *BitWriter) WriteUE(v uint64) (err error) {
+	// Get number of bits
+	if v == 1<<64-1 {
+		return errors.New("astibyte: value is too big for an exponential-Golomb code")
+	}
+	var x = v + 1
+	var n uint
+	for t := x; t > 1; t >>= 1 {
+		n++
+	}
+
+	// Write
+	if err = w.WriteBits(0, n); err != nil {
+		return
+	}
+	return w.WriteBits(x, n+1)
+}
+
+// WriteSE writes a signed exponential-Golomb code
+func (w *BitWriter) WriteSE(v int64) error {
+	if v > 0 {
+		return w.WriteUE(uint64(v)*2 - 1)
+	}
+	return w.WriteUE(uint64(-v) * 2)
+}
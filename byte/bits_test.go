@@ -0,0 +1,76 @@
+package astibyte_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/asticode/go-astitools/byte"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBitReader(t *testing.T) {
+	var r = astibyte.NewBitReader(bytes.NewReader([]byte{0xa5, 0x0f, 0x80}))
+	b, err := r.ReadBit()
+	assert.NoError(t, err)
+	assert.True(t, b)
+	v, err := r.ReadBits(3)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0x2), v)
+	assert.False(t, r.ByteAligned())
+	v, err = r.ReadBits(8)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0x50), v)
+	r.Align()
+	assert.True(t, r.ByteAligned())
+	assert.Equal(t, uint64(16), r.BitsRead())
+	v, err = r.ReadBits(4)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0x8), v)
+	_, err = r.ReadBits(8)
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+	_, err = r.ReadBits(1)
+	assert.Equal(t, io.EOF, err)
+}
+
+func TestBitWriter(t *testing.T) {
+	// Write
+	var buf = &bytes.Buffer{}
+	var w = astibyte.NewBitWriter(buf)
+	assert.NoError(t, w.WriteBit(true))
+	assert.NoError(t, w.WriteBits(0x2, 3))
+	assert.NoError(t, w.WriteBits(0x50f, 12))
+	assert.True(t, w.ByteAligned())
+	assert.NoError(t, w.WriteBits(0x1, 1))
+	assert.NoError(t, w.Align())
+	assert.Equal(t, []byte{0xa5, 0x0f, 0x80}, buf.Bytes())
+
+	// Exponential-Golomb round trip
+	buf.Reset()
+	var ues = []uint64{0, 1, 2, 3, 7, 8, 255, 1 << 40}
+	var ses = []int64{0, 1, -1, 2, -2, 1000, -1000}
+	for _, v := range ues {
+		assert.NoError(t, w.WriteUE(v))
+	}
+	for _, v := range ses {
+		assert.NoError(t, w.WriteSE(v))
+	}
+	assert.NoError(t, w.Align())
+	var r = astibyte.NewBitReader(bytes.NewReader(buf.Bytes()))
+	for _, e := range ues {
+		v, err := r.ReadUE()
+		assert.NoError(t, err)
+		assert.Equal(t, e, v)
+	}
+	for _, e := range ses {
+		v, err := r.ReadSE()
+		assert.NoError(t, err)
+		assert.Equal(t, e, v)
+	}
+
+	// Known codes
+	buf.Reset()
+	assert.NoError(t, w.WriteUE(3))
+	assert.NoError(t, w.Align())
+	assert.Equal(t, []byte{0x20}, buf.Bytes())
+}
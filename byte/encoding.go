@@ -0,0 +1,225 @@
+package astibyte
+
+import (
+	"bytes"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// Encodings
+const (
+	EncodingBase32 Encoding = iota
+	EncodingBase64
+	// Base64 with the URL and filename safe alphabet, without padding
+	EncodingBase64RawURL
+	EncodingHex
+)
+
+// Encoding represents a binary-to-text encoding
+type Encoding int
+
+// decodeFunc decodes src into dst and returns the number of bytes written
+type decodeFunc func(dst, src []byte) (int, error)
+
+// newEncoder returns a writer encoding into w, whose Close method flushes partially written blocks
+func (e Encoding) newEncoder(w io.Writer) io.WriteCloser {
+	switch e {
+	case EncodingBase32:
+		return base32.NewEncoder(base32.StdEncoding, w)
+	case EncodingBase64:
+		return base64.NewEncoder(base64.StdEncoding, w)
+	case EncodingBase64RawURL:
+		return base64.NewEncoder(base64.RawURLEncoding, w)
+	default:
+		return nopWriteCloser{Writer: hex.NewEncoder(w)}
+	}
+}
+
+// decoder returns the decode func, the maximum decoded length func and the number of encoded bytes per block
+func (e Encoding) decoder() (fn decodeFunc, maxLen func(n int) int, block int) {
+	switch e {
+	case EncodingBase32:
+		return base32.StdEncoding.Decode, base32.StdEncoding.DecodedLen, 8
+	case EncodingBase64:
+		return base64.StdEncoding.Decode, base64.StdEncoding.DecodedLen, 4
+	case EncodingBase64RawURL:
+		return base64.RawURLEncoding.Decode, base64.RawURLEncoding.DecodedLen, 4
+	default:
+		return hex.Decode, hex.DecodedLen, 2
+	}
+}
+
+// nopWriteCloser adds a no-op Close method to a writer
+type nopWriteCloser struct {
+	io.Writer
+}
+
+// Close implements the io.Closer interface
+func (nopWriteCloser) Close() error { return nil }
+
+// NewEncodeWriter creates a writer encoding what's written to it into w
+// Close must be called once writing is over so that the last partial block is flushed. It doesn't close w.
+func NewEncodeWriter(w io.Writer, e Encoding) io.WriteCloser {
+	return e.newEncoder(w)
+}
+
+// NewDecodeReader creates a reader decoding what's read from r
+// Newlines are ignored.
+func NewDecodeReader(r io.Reader, e Encoding) io.Reader {
+	switch e {
+	case EncodingBase32:
+		return base32.NewDecoder(base32.StdEncoding, r)
+	case EncodingBase64:
+		return base64.NewDecoder(base64.StdEncoding, r)
+	case EncodingBase64RawURL:
+		return base64.NewDecoder(base64.RawURLEncoding, r)
+	default:
+		return hex.NewDecoder(&newlineFilterReader{r: r})
+	}
+}
+
+// newlineFilterReader removes newlines from what's read from r
+type newlineFilterReader struct {
+	r io.Reader
+}
+
+// Read implements the io.Reader interface
+func (r *newlineFilterReader) Read(p []byte) (n int, err error) {
+	for n == 0 && err == nil {
+		if n, err = r.r.Read(p); n > 0 {
+			n = len(removeNewlines(p[:n]))
+		}
+	}
+	return
+}
+
+// removeNewlines removes \r and \n in place
+func removeNewlines(b []byte) []byte {
+	var o = b[:0]
+	for _, c := range b {
+		if c != '\r' && c != '\n' {
+			o = append(o, c)
+		}
+	}
+	return o
+}
+
+// EncodeReader represents a reader encoding what's read from an underlying reader
+type EncodeReader struct {
+	buf *bytes.Buffer
+	eof bool
+	enc io.WriteCloser
+	r   io.Reader
+	raw []byte
+}
+
+// NewEncodeReader creates a reader encoding what's read from r
+func NewEncodeReader(r io.Reader, e Encoding) *EncodeReader {
+	var buf = &bytes.Buffer{}
+	return &EncodeReader{
+		buf: buf,
+		enc: e.newEncoder(buf),
+		r:   r,
+		raw: make([]byte, 3072),
+	}
+}
+
+// Read implements the io.Reader interface
+func (r *EncodeReader) Read(p []byte) (n int, err error) {
+	// Fill buffer
+	for r.buf.Len() == 0 && !r.eof {
+		// Read
+		var rn int
+		rn, err = r.r.Read(r.raw)
+
+		// Encode
+		if rn > 0 {
+			r.enc.Write(r.raw[:rn])
+		}
+
+		// Handle error
+		if err == io.EOF {
+			r.eof = true
+			r.enc.Close()
+		} else if err != nil {
+			return
+		}
+		err = nil
+	}
+
+	// Nothing left
+	if r.buf.Len() == 0 {
+		return 0, io.EOF
+	}
+	return r.buf.Read(p)
+}
+
+// DecodeWriter represents a writer decoding what's written to it into an underlying writer
+type DecodeWriter struct {
+	block  int
+	buf    []byte
+	fn     decodeFunc
+	maxLen func(n int) int
+	w      io.Writer
+}
+
+// NewDecodeWriter creates a writer decoding what's written to it into w
+// Newlines are ignored. Close must be called once writing is over so that the last block is decoded. It doesn't close
+// w.
+func NewDecodeWriter(w io.Writer, e Encoding) *DecodeWriter {
+	var fn, maxLen, block = e.decoder()
+	return &DecodeWriter{
+		block:  block,
+		fn:     fn,
+		maxLen: maxLen,
+		w:      w,
+	}
+}
+
+// Write implements the io.Writer interface
+func (w *DecodeWriter) Write(p []byte) (n int, err error) {
+	// Buffer
+	n = len(p)
+	w.buf = append(w.buf, p...)
+	w.buf = removeNewlines(w.buf)
+
+	// Decode complete blocks
+	var l = len(w.buf) - len(w.buf)%w.block
+	if l == 0 {
+		return
+	}
+	if err = w.decode(w.buf[:l]); err != nil {
+		return
+	}
+	w.buf = append(w.buf[:0], w.buf[l:]...)
+	return
+}
+
+// Close implements the io.Closer interface
+func (w *DecodeWriter) Close() (err error) {
+	if len(w.buf) == 0 {
+		return
+	}
+	err = w.decode(w.buf)
+	w.buf = w.buf[:0]
+	return
+}
+
+// decode decodes and writes encoded bytes
+func (w *DecodeWriter) decode(src []byte) (err error) {
+	var dst = make([]byte, w.maxLen(len(src)))
+	var n int
+	if n, err = w.fn(dst, src); err != nil {
+		err = errors.Wrap(err, "astibyte: decoding failed")
+		return
+	}
+	if _, err = w.w.Write(dst[:n]); err != nil {
+		err = errors.Wrap(err, "astibyte: writing failed")
+		return
+	}
+	return
+}
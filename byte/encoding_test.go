@@ -0,0 +1,56 @@
+package astibyte_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/asticode/go-astitools/byte"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncoding(t *testing.T) {
+	var raw = []byte(strings.Repeat("astibyte\x00\xff", 500))
+	for _, e := range []astibyte.Encoding{astibyte.EncodingBase32, astibyte.EncodingBase64, astibyte.EncodingBase64RawURL, astibyte.EncodingHex} {
+		// Encode writer
+		buf := &bytes.Buffer{}
+		w := astibyte.NewEncodeWriter(buf, e)
+		for _, b := range bytes.SplitAfter(raw, []byte("e")) {
+			w.Write(b)
+		}
+		assert.NoError(t, w.Close())
+		encoded := buf.Bytes()
+
+		// Encode reader
+		b, err := ioutil.ReadAll(astibyte.NewEncodeReader(iotest.OneByteReader(bytes.NewReader(raw)), e))
+		assert.NoError(t, err)
+		assert.Equal(t, encoded, b)
+
+		// Decode reader
+		withNewlines := bytes.Join([][]byte{encoded[:8], encoded[8:]}, []byte("\r\n"))
+		b, err = ioutil.ReadAll(astibyte.NewDecodeReader(bytes.NewReader(withNewlines), e))
+		assert.NoError(t, err)
+		assert.Equal(t, raw, b)
+
+		// Decode writer
+		buf.Reset()
+		dw := astibyte.NewDecodeWriter(buf, e)
+		for i := 0; i < len(withNewlines); i += 7 {
+			end := i + 7
+			if end > len(withNewlines) {
+				end = len(withNewlines)
+			}
+			_, err = dw.Write(withNewlines[i:end])
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, dw.Close())
+		assert.Equal(t, raw, buf.Bytes())
+	}
+
+	// Invalid
+	dw := astibyte.NewDecodeWriter(&bytes.Buffer{}, astibyte.EncodingHex)
+	_, err := dw.Write([]byte("zz"))
+	assert.Error(t, err)
+}
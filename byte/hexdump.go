@@ -0,0 +1,111 @@
+package astibyte
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// HexdumpOptions represents hexdump options
+type HexdumpOptions struct {
+	// Offset displayed for the first byte
+	Offset int64
+	// Number of bytes per line. Defaults to 16
+	Width int
+}
+
+// HexdumpWriter represents a writer producing a canonical hexdump of what's written to it, in the same format as
+// "hexdump -C": offset, hex values grouped by 8 and ASCII representation.
+// It's typically used with the byte splitter to debug binary protocols, one frame at a time.
+type HexdumpWriter struct {
+	buf    []byte
+	line   []byte
+	o      HexdumpOptions
+	offset int64
+	w      io.Writer
+}
+
+// NewHexdumpWriter creates a new hexdump writer
+// Close must be called once writing is over so that the last partial line is written. It doesn't close w.
+func NewHexdumpWriter(w io.Writer, o HexdumpOptions) *HexdumpWriter {
+	// Default options values
+	if o.Width <= 0 {
+		o.Width = 16
+	}
+	return &HexdumpWriter{
+		o:      o,
+		offset: o.Offset,
+		w:      w,
+	}
+}
+
+// Hexdump returns the canonical hexdump of b
+func Hexdump(b []byte) string {
+	var buf = &bytes.Buffer{}
+	var w = NewHexdumpWriter(buf, HexdumpOptions{})
+	w.Write(b)
+	w.Close()
+	return buf.String()
+}
+
+// Write implements the io.Writer interface
+func (w *HexdumpWriter) Write(p []byte) (n int, err error) {
+	n = len(p)
+	w.buf = append(w.buf, p...)
+	for len(w.buf) >= w.o.Width {
+		if err = w.writeLine(w.buf[:w.o.Width]); err != nil {
+			return
+		}
+		w.buf = w.buf[w.o.Width:]
+	}
+	return
+}
+
+// Close implements the io.Closer interface
+func (w *HexdumpWriter) Close() (err error) {
+	if len(w.buf) == 0 {
+		return
+	}
+	err = w.writeLine(w.buf)
+	w.buf = nil
+	return
+}
+
+// writeLine writes a line holding at most the configured number of bytes
+func (w *HexdumpWriter) writeLine(b []byte) (err error) {
+	// Offset
+	w.line = append(w.line[:0], fmt.Sprintf("%08x  ", w.offset)...)
+
+	// Hex
+	const hexChars = "0123456789abcdef"
+	for i := 0; i < w.o.Width; i++ {
+		if i < len(b) {
+			w.line = append(w.line, hexChars[b[i]>>4], hexChars[b[i]&0xf], ' ')
+		} else {
+			w.line = append(w.line, "   "...)
+		}
+		if i%8 == 7 || i == w.o.Width-1 {
+			w.line = append(w.line, ' ')
+		}
+	}
+
+	// ASCII
+	w.line = append(w.line, '|')
+	for _, c := range b {
+		if c < 0x20 || c > 0x7e {
+			c = '.'
+		}
+		w.line = append(w.line, c)
+	}
+	w.line = append(w.line, '|', '\n')
+
+	// Write
+	if _, err = w.w.Write(w.line); err != nil {
+		err = errors.Wrap(err, "astibyte: writing hexdump line failed")
+		return
+	}
+	w.offset += int64(len(b))
+	return
+}
@@ -0,0 +1,24 @@
+package astibyte_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/asticode/go-astitools/byte"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHexdump(t *testing.T) {
+	// Canonical
+	var b = []byte("Go is an open source programming language.\x00\x01\xff")
+	assert.Equal(t, hex.Dump(b), astibyte.Hexdump(b))
+
+	// Options
+	buf := &bytes.Buffer{}
+	w := astibyte.NewHexdumpWriter(buf, astibyte.HexdumpOptions{Offset: 0x10, Width: 4})
+	w.Write([]byte("ab"))
+	w.Write([]byte("cd\nf"))
+	assert.NoError(t, w.Close())
+	assert.Equal(t, "00000010  61 62 63 64  |abcd|\n00000014  0a 66        |.f|\n", buf.String())
+}
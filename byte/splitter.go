@@ -0,0 +1,192 @@
+package astibyte
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// Length prefixes
+const (
+	LengthPrefixUint16BigEndian LengthPrefix = iota
+	LengthPrefixUint16LittleEndian
+	LengthPrefixUint32BigEndian
+	LengthPrefixUint32LittleEndian
+	LengthPrefixUvarint
+)
+
+// DefaultMaxFrameSize represents the default maximum frame size
+const DefaultMaxFrameSize = 1 << 20
+
+// ErrFrameTooLarge is returned when a frame exceeds the maximum frame size
+var ErrFrameTooLarge = errors.New("astibyte: frame too large")
+
+// LengthPrefix represents the encoding of the length preceding a frame
+type LengthPrefix int
+
+// SplitterOptions represents splitter options
+type SplitterOptions struct {
+	// Maximum frame size, length prefix excluded. Defaults to DefaultMaxFrameSize
+	MaxFrameSize int
+}
+
+// ByteSplitter reads frames from a reader
+type ByteSplitter struct {
+	s *bufio.Scanner
+}
+
+// newByteSplitter creates a new splitter
+// overhead is the number of bytes the buffer must be able to hold on top of the biggest frame, such as its length
+// prefix or its delimiter.
+func newByteSplitter(r io.Reader, fn bufio.SplitFunc, o SplitterOptions, overhead int) *ByteSplitter {
+	// Default options values
+	if o.MaxFrameSize <= 0 {
+		o.MaxFrameSize = DefaultMaxFrameSize
+	}
+
+	// Create scanner
+	var s = bufio.NewScanner(r)
+	s.Buffer(make([]byte, 0, 4096), o.MaxFrameSize+overhead)
+	s.Split(fn)
+	return &ByteSplitter{s: s}
+}
+
+// NewDelimiterSplitter creates a splitter emitting frames separated by a delimiter, which is not included in frames
+// Data following the last delimiter is emitted as a last frame. An empty delimiter returns an error.
+func NewDelimiterSplitter(r io.Reader, delimiter []byte, o SplitterOptions) (s *ByteSplitter, err error) {
+	// Check delimiter
+	if len(delimiter) == 0 {
+		err = errors.New("astibyte: delimiter is empty")
+		return
+	}
+
+	// Default options values
+	var max = o.MaxFrameSize
+	if max <= 0 {
+		max = DefaultMaxFrameSize
+	}
+	s = newByteSplitter(r, func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		if i := bytes.Index(data, delimiter); i >= 0 {
+			if i > max {
+				return 0, nil, ErrFrameTooLarge
+			}
+			return i + len(delimiter), data[:i], nil
+		}
+		// Data may end with the beginning of a delimiter split across reads
+		if len(data) > max+len(delimiter)-1 || (atEOF && len(data) > max) {
+			return 0, nil, ErrFrameTooLarge
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	}, o, len(delimiter))
+	return
+}
+
+// NewFixedLengthSplitter creates a splitter emitting frames of a fixed length
+// A truncated last frame returns io.ErrUnexpectedEOF. A length <= 0 returns an error.
+func NewFixedLengthSplitter(r io.Reader, length int, o SplitterOptions) (s *ByteSplitter, err error) {
+	// Check length
+	if length <= 0 {
+		err = errors.Errorf("astibyte: invalid length %d", length)
+		return
+	}
+
+	// The maximum frame size must be able to hold a frame
+	if o.MaxFrameSize < length {
+		o.MaxFrameSize = length
+	}
+	s = newByteSplitter(r, func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		if len(data) >= length {
+			return length, data[:length], nil
+		}
+		if atEOF && len(data) > 0 {
+			return 0, nil, io.ErrUnexpectedEOF
+		}
+		return 0, nil, nil
+	}, o, 0)
+	return
+}
+
+// NewLengthPrefixSplitter creates a splitter emitting frames preceded by their length
+// Lengths exceeding the maximum frame size return ErrFrameTooLarge as soon as they're read, and a truncated last
+// frame returns io.ErrUnexpectedEOF.
+func NewLengthPrefixSplitter(r io.Reader, p LengthPrefix, o SplitterOptions) *ByteSplitter {
+	var max = o.MaxFrameSize
+	if max <= 0 {
+		max = DefaultMaxFrameSize
+	}
+	return newByteSplitter(r, func(data []byte, atEOF bool) (advance int, token []byte, err error) {
+		// Read length
+		var l uint64
+		var n int
+		switch p {
+		case LengthPrefixUint16BigEndian, LengthPrefixUint16LittleEndian:
+			if n = 2; len(data) >= n {
+				if p == LengthPrefixUint16BigEndian {
+					l = uint64(binary.BigEndian.Uint16(data))
+				} else {
+					l = uint64(binary.LittleEndian.Uint16(data))
+				}
+			}
+		case LengthPrefixUint32BigEndian, LengthPrefixUint32LittleEndian:
+			if n = 4; len(data) >= n {
+				if p == LengthPrefixUint32BigEndian {
+					l = uint64(binary.BigEndian.Uint32(data))
+				} else {
+					l = uint64(binary.LittleEndian.Uint32(data))
+				}
+			}
+		case LengthPrefixUvarint:
+			if l, n = binary.Uvarint(data); n < 0 {
+				return 0, nil, errors.New("astibyte: invalid varint length prefix")
+			} else if n == 0 {
+				// Not enough data
+				n = len(data) + 1
+			}
+		default:
+			return 0, nil, errors.Errorf("astibyte: invalid length prefix %d", p)
+		}
+
+		// Not enough data to read the length
+		if len(data) < n {
+			if atEOF && len(data) > 0 {
+				return 0, nil, io.ErrUnexpectedEOF
+			}
+			return 0, nil, nil
+		}
+
+		// Frame is too large
+		if l > uint64(max) {
+			return 0, nil, ErrFrameTooLarge
+		}
+
+		// Not enough data to read the frame
+		if uint64(len(data)-n) < l {
+			if atEOF {
+				return 0, nil, io.ErrUnexpectedEOF
+			}
+			return 0, nil, nil
+		}
+		return n + int(l), data[n : n+int(l)], nil
+	}, o, binary.MaxVarintLen64)
+}
+
+// Next returns the next frame, or io.EOF once the reader has no more frames
+// The returned slice is only valid until the next call.
+func (s *ByteSplitter) Next() (b []byte, err error) {
+	if s.s.Scan() {
+		b = s.s.Bytes()
+		return
+	}
+	if err = s.s.Err(); err == nil {
+		err = io.EOF
+	} else if err == bufio.ErrTooLong {
+		err = ErrFrameTooLarge
+	}
+	return
+}
@@ -0,0 +1,92 @@
+package astibyte_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"testing/iotest"
+
+	"github.com/asticode/go-astitools/byte"
+	"github.com/stretchr/testify/assert"
+)
+
+func frames(s *astibyte.ByteSplitter) (fs []string, err error) {
+	for {
+		var b []byte
+		if b, err = s.Next(); err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			return
+		}
+		fs = append(fs, string(b))
+	}
+}
+
+func TestByteSplitter(t *testing.T) {
+	// Delimiter
+	s, err := astibyte.NewDelimiterSplitter(bytes.NewReader([]byte("a\r\nbc\r\n\r\nd")), []byte("\r\n"), astibyte.SplitterOptions{})
+	assert.NoError(t, err)
+	fs, err := frames(s)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "bc", "", "d"}, fs)
+	s, err = astibyte.NewDelimiterSplitter(bytes.NewReader([]byte("abcdef\n")), []byte("\n"), astibyte.SplitterOptions{MaxFrameSize: 3})
+	assert.NoError(t, err)
+	_, err = frames(s)
+	assert.Equal(t, astibyte.ErrFrameTooLarge, err)
+	_, err = astibyte.NewDelimiterSplitter(bytes.NewReader([]byte("a")), nil, astibyte.SplitterOptions{})
+	assert.Error(t, err)
+
+	// Frames of the max size followed by a delimiter split across reads
+	s, err = astibyte.NewDelimiterSplitter(iotest.OneByteReader(bytes.NewReader([]byte("abcdefgh\r\nijklmnop\r\n"))), []byte("\r\n"), astibyte.SplitterOptions{MaxFrameSize: 8})
+	assert.NoError(t, err)
+	fs, err = frames(s)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"abcdefgh", "ijklmnop"}, fs)
+	s, err = astibyte.NewDelimiterSplitter(iotest.OneByteReader(bytes.NewReader([]byte("abcdefghi\r\n"))), []byte("\r\n"), astibyte.SplitterOptions{MaxFrameSize: 8})
+	assert.NoError(t, err)
+	_, err = frames(s)
+	assert.Equal(t, astibyte.ErrFrameTooLarge, err)
+	s, err = astibyte.NewDelimiterSplitter(bytes.NewReader([]byte("abcdefghi")), []byte("\r\n"), astibyte.SplitterOptions{MaxFrameSize: 8})
+	assert.NoError(t, err)
+	_, err = frames(s)
+	assert.Equal(t, astibyte.ErrFrameTooLarge, err)
+
+	// Fixed length
+	s, err = astibyte.NewFixedLengthSplitter(bytes.NewReader([]byte("abcdef")), 2, astibyte.SplitterOptions{})
+	assert.NoError(t, err)
+	fs, err = frames(s)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"ab", "cd", "ef"}, fs)
+	s, err = astibyte.NewFixedLengthSplitter(bytes.NewReader([]byte("abcde")), 2, astibyte.SplitterOptions{})
+	assert.NoError(t, err)
+	fs, err = frames(s)
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+	assert.Equal(t, []string{"ab", "cd"}, fs)
+	for _, l := range []int{0, -1} {
+		_, err = astibyte.NewFixedLengthSplitter(bytes.NewReader([]byte("a")), l, astibyte.SplitterOptions{})
+		assert.Error(t, err)
+	}
+
+	// Length prefix
+	for p, b := range map[astibyte.LengthPrefix][]byte{
+		astibyte.LengthPrefixUint16BigEndian:    {0, 1, 'a', 0, 2, 'b', 'c', 0, 0},
+		astibyte.LengthPrefixUint16LittleEndian: {1, 0, 'a', 2, 0, 'b', 'c', 0, 0},
+		astibyte.LengthPrefixUint32BigEndian:    {0, 0, 0, 1, 'a', 0, 0, 0, 2, 'b', 'c', 0, 0, 0, 0},
+		astibyte.LengthPrefixUint32LittleEndian: {1, 0, 0, 0, 'a', 2, 0, 0, 0, 'b', 'c', 0, 0, 0, 0},
+		astibyte.LengthPrefixUvarint:            {1, 'a', 2, 'b', 'c', 0},
+	} {
+		fs, err = frames(astibyte.NewLengthPrefixSplitter(bytes.NewReader(b), p, astibyte.SplitterOptions{}))
+		assert.NoError(t, err, "prefix %d", p)
+		assert.Equal(t, []string{"a", "bc", ""}, fs, "prefix %d", p)
+	}
+	var big = append([]byte{0xac, 0x02}, make([]byte, 300)...)
+	fs, err = frames(astibyte.NewLengthPrefixSplitter(bytes.NewReader(big), astibyte.LengthPrefixUvarint, astibyte.SplitterOptions{}))
+	assert.NoError(t, err)
+	assert.Len(t, fs, 1)
+	assert.Len(t, fs[0], 300)
+	_, err = frames(astibyte.NewLengthPrefixSplitter(bytes.NewReader([]byte{0xff, 0xff, 'a'}), astibyte.LengthPrefixUint16BigEndian, astibyte.SplitterOptions{MaxFrameSize: 10}))
+	assert.Equal(t, astibyte.ErrFrameTooLarge, err)
+	_, err = frames(astibyte.NewLengthPrefixSplitter(bytes.NewReader([]byte{0, 3, 'a'}), astibyte.LengthPrefixUint16BigEndian, astibyte.SplitterOptions{}))
+	assert.Equal(t, io.ErrUnexpectedEOF, err)
+}
@@ -0,0 +1,221 @@
+package asticonfig
+
+import (
+	"encoding"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/imdario/mergo"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// LoadOptions represents load options
+type LoadOptions struct {
+	// Prefix of the environment variables overriding values, e.g. "APP_". Field "Server.Port" is then overridden by
+	// "APP_SERVER_PORT". The variable name can be customized with the "env" struct tag. Environment overrides are
+	// disabled if empty.
+	EnvPrefix string
+	// Ptr to a struct of the same type as the configuration whose non-zero values override all other sources, usually
+	// filled by flags
+	Flags interface{}
+	// Path to an optional file whose format is deduced from its extension: .json, .toml, .yaml or .yml
+	Path string
+}
+
+// Load loads a configuration into dst, a ptr to a struct that may already contain default values
+// Values come from, in priority order, default values, the file, environment variables and flags. The configuration
+// is validated once loaded, see Validate.
+func Load(dst interface{}, o LoadOptions) (err error) {
+	// File
+	if o.Path != "" {
+		if err = decodeFile(dst, o.Path); err != nil {
+			return
+		}
+	}
+
+	// Environment
+	if o.EnvPrefix != "" {
+		if err = applyEnv(reflect.ValueOf(dst).Elem(), o.EnvPrefix, ""); err != nil {
+			return
+		}
+	}
+
+	// Flags
+	if o.Flags != nil {
+		if err = mergo.Merge(dst, o.Flags, mergo.WithOverride); err != nil {
+			err = errors.Wrap(err, "asticonfig: merging flags failed")
+			return
+		}
+	}
+
+	// Validate
+	return Validate(dst)
+}
+
+// decodeFile decodes a file based on its extension
+func decodeFile(dst interface{}, path string) (err error) {
+	// Read file
+	var b []byte
+	if b, err = os.ReadFile(path); err != nil {
+		err = errors.Wrapf(err, "asticonfig: reading %s failed", path)
+		return
+	}
+
+	// Decode
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		if err = json.Unmarshal(b, dst); err != nil {
+			err = errors.Wrapf(err, "asticonfig: json decoding %s failed", path)
+		}
+	case ".toml":
+		if _, err = toml.Decode(string(b), dst); err != nil {
+			err = errors.Wrapf(err, "asticonfig: toml decoding %s failed", path)
+		}
+	case ".yaml", ".yml":
+		if err = yaml.Unmarshal(b, dst); err != nil {
+			err = errors.Wrapf(err, "asticonfig: yaml decoding %s failed", path)
+		}
+	default:
+		err = errors.Errorf("asticonfig: unsupported extension %s for %s", ext, path)
+	}
+	return
+}
+
+// applyEnv overrides struct fields with environment variables
+func applyEnv(v reflect.Value, prefix, path string) (err error) {
+	var t = v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		// Field is not exported
+		var f = t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		// Get names
+		var k = joinKey(path, keyName(f))
+		var n = f.Tag.Get("env")
+		if n == "-" {
+			continue
+		} else if n == "" {
+			n = envName(prefix, k)
+		}
+
+		// Nested struct
+		var fv = v.Field(i)
+		if fv.Kind() == reflect.Struct && !isTextUnmarshaler(fv) && fv.Type() != reflect.TypeOf(time.Time{}) {
+			if err = applyEnv(fv, prefix, k); err != nil {
+				return
+			}
+			continue
+		}
+
+		// Environment variable is not set
+		s, ok := os.LookupEnv(n)
+		if !ok {
+			continue
+		}
+
+		// Set value
+		if err = setValue(fv, s); err != nil {
+			err = errors.Wrapf(err, "asticonfig: setting key %s from env variable %s failed", k, n)
+			return
+		}
+	}
+	return
+}
+
+// envName returns the name of the environment variable of a key
+func envName(prefix, key string) string {
+	return prefix + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
+
+// isTextUnmarshaler checks whether a value implements the encoding.TextUnmarshaler interface
+func isTextUnmarshaler(v reflect.Value) bool {
+	_, ok := v.Addr().Interface().(encoding.TextUnmarshaler)
+	return ok
+}
+
+// setValue sets a value from a string
+func setValue(v reflect.Value, s string) (err error) {
+	// Text unmarshaler
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(s))
+	}
+
+	// Switch on kind
+	switch v.Kind() {
+	case reflect.Bool:
+		var b bool
+		if b, err = strconv.ParseBool(s); err != nil {
+			return
+		}
+		v.SetBool(b)
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		if f, err = strconv.ParseFloat(s, 64); err != nil {
+			return
+		}
+		v.SetFloat(f)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		// Duration
+		if v.Type() == reflect.TypeOf(time.Duration(0)) {
+			var d time.Duration
+			if d, err = time.ParseDuration(s); err != nil {
+				return
+			}
+			v.SetInt(int64(d))
+			return
+		}
+
+		var i int64
+		if i, err = strconv.ParseInt(s, 10, 64); err != nil {
+			return
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		var i uint64
+		if i, err = strconv.ParseUint(s, 10, 64); err != nil {
+			return
+		}
+		v.SetUint(i)
+	case reflect.Slice:
+		if v.Type().Elem().Kind() != reflect.String {
+			return errors.Errorf("unsupported slice of %s", v.Type().Elem())
+		}
+		var ss []string
+		for _, p := range strings.Split(s, ",") {
+			ss = append(ss, strings.TrimSpace(p))
+		}
+		v.Set(reflect.ValueOf(ss).Convert(v.Type()))
+	case reflect.String:
+		v.SetString(s)
+	default:
+		return errors.Errorf("unsupported kind %s", v.Kind())
+	}
+	return
+}
+
+// keyName returns the key name of a field, based on its toml, json or yaml tag, or on its name otherwise
+func keyName(f reflect.StructField) string {
+	for _, t := range []string{"toml", "json", "yaml"} {
+		if n := strings.Split(f.Tag.Get(t), ",")[0]; n != "" && n != "-" {
+			return n
+		}
+	}
+	return f.Name
+}
+
+// joinKey joins a key to its parent
+func joinKey(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
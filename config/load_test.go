@@ -0,0 +1,71 @@
+package asticonfig_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/config"
+	"github.com/stretchr/testify/assert"
+)
+
+type testConfig struct {
+	Name    string        `json:"name" toml:"name" yaml:"name" validate:"required"`
+	Server  testServer    `json:"server" toml:"server" yaml:"server"`
+	Tags    []string      `json:"tags" toml:"tags" yaml:"tags"`
+	Timeout time.Duration `json:"timeout" toml:"timeout" yaml:"timeout" validate:"min=1s,max=1m"`
+}
+
+type testServer struct {
+	Host string `json:"host" toml:"host" yaml:"host" env:"ASTICONFIG_TEST_HOST"`
+	Port int    `json:"port" toml:"port" yaml:"port" validate:"min=1,max=65535"`
+}
+
+func TestLoad(t *testing.T) {
+	// Write files
+	var dir = t.TempDir()
+	var files = map[string]string{
+		"config.json": `{"name":"json","server":{"host":"file","port":1}}`,
+		"config.toml": "name = \"toml\"\n[server]\nhost = \"file\"\nport = 1\n",
+		"config.yaml": "name: yaml\nserver:\n  host: file\n  port: 1\n",
+	}
+	for n, c := range files {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, n), []byte(c), 0600))
+	}
+
+	// Priority
+	os.Setenv("ASTICONFIG_TEST_SERVER_PORT", "8080")
+	os.Setenv("ASTICONFIG_TEST_HOST", "env")
+	os.Setenv("ASTICONFIG_TEST_TAGS", "a, b")
+	defer os.Unsetenv("ASTICONFIG_TEST_SERVER_PORT")
+	defer os.Unsetenv("ASTICONFIG_TEST_HOST")
+	defer os.Unsetenv("ASTICONFIG_TEST_TAGS")
+	for _, n := range []string{"json", "toml", "yaml"} {
+		var c = testConfig{Timeout: time.Second}
+		assert.NoError(t, asticonfig.Load(&c, asticonfig.LoadOptions{
+			EnvPrefix: "ASTICONFIG_TEST_",
+			Flags:     &testConfig{Server: testServer{Port: 9090}},
+			Path:      filepath.Join(dir, "config."+n),
+		}))
+		assert.Equal(t, testConfig{
+			Name:    n,
+			Server:  testServer{Host: "env", Port: 9090},
+			Tags:    []string{"a", "b"},
+			Timeout: time.Second,
+		}, c)
+	}
+
+	// Invalid env
+	os.Setenv("ASTICONFIG_TEST_TIMEOUT", "invalid")
+	assert.EqualError(t, asticonfig.Load(&testConfig{}, asticonfig.LoadOptions{EnvPrefix: "ASTICONFIG_TEST_"}), "asticonfig: setting key timeout from env variable ASTICONFIG_TEST_TIMEOUT failed: time: invalid duration \"invalid\"")
+	os.Unsetenv("ASTICONFIG_TEST_TIMEOUT")
+
+	// Unsupported extension
+	assert.Error(t, asticonfig.Load(&testConfig{}, asticonfig.LoadOptions{Path: "config.ini"}))
+}
+
+func TestValidate(t *testing.T) {
+	assert.NoError(t, asticonfig.Validate(&testConfig{Name: "name", Server: testServer{Port: 1}, Timeout: time.Second}))
+	assert.EqualError(t, asticonfig.Validate(&testConfig{Server: testServer{Port: 70000}, Timeout: time.Hour}), "asticonfig: validation failed: key name: is required, key server.port: must be <= 65535, key timeout: must be <= 1m")
+}
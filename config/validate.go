@@ -0,0 +1,122 @@
+package asticonfig
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ValidationError represents an error listing all invalid keys
+type ValidationError struct {
+	Errors []error
+}
+
+// Error implements the error interface
+func (e ValidationError) Error() string {
+	var ss []string
+	for _, err := range e.Errors {
+		ss = append(ss, err.Error())
+	}
+	return fmt.Sprintf("asticonfig: validation failed: %s", strings.Join(ss, ", "))
+}
+
+// Validate validates a ptr to a struct based on its "validate" struct tags
+// Tags contain comma-separated rules: "required" checks the value is not zero, "min=x" and "max=x" check numbers,
+// durations (parsed with time.ParseDuration) and lengths of strings, slices and maps.
+func Validate(v interface{}) error {
+	var errs []error
+	validate(reflect.Indirect(reflect.ValueOf(v)), "", &errs)
+	if len(errs) > 0 {
+		return ValidationError{Errors: errs}
+	}
+	return nil
+}
+
+// validate validates a struct and gathers errors
+func validate(v reflect.Value, path string, errs *[]error) {
+	var t = v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		// Field is not exported
+		var f = t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		// Nested struct
+		var k = joinKey(path, keyName(f))
+		var fv = v.Field(i)
+		if fv.Kind() == reflect.Struct && fv.Type() != reflect.TypeOf(time.Time{}) {
+			validate(fv, k, errs)
+		}
+
+		// Loop through rules
+		var tag = f.Tag.Get("validate")
+		if tag == "" {
+			continue
+		}
+		for _, r := range strings.Split(tag, ",") {
+			if err := validateRule(fv, strings.TrimSpace(r)); err != nil {
+				*errs = append(*errs, errors.Wrapf(err, "key %s", k))
+			}
+		}
+	}
+}
+
+// validateRule validates a value against a single rule
+func validateRule(v reflect.Value, r string) (err error) {
+	// Required
+	var name, arg = r, ""
+	if i := strings.Index(r, "="); i >= 0 {
+		name, arg = r[:i], r[i+1:]
+	}
+	if name == "required" {
+		if v.IsZero() {
+			return errors.New("is required")
+		}
+		return
+	}
+	if name != "min" && name != "max" {
+		return errors.Errorf("unknown rule %s", name)
+	}
+
+	// Get value and limit
+	var value, limit float64
+	switch v.Kind() {
+	case reflect.Float32, reflect.Float64:
+		value = v.Float()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		value = float64(v.Int())
+		if v.Type() == reflect.TypeOf(time.Duration(0)) {
+			var d time.Duration
+			if d, err = time.ParseDuration(arg); err != nil {
+				return errors.Wrapf(err, "parsing %s limit %s failed", name, arg)
+			}
+			limit = float64(d)
+			return compare(name, arg, value, limit)
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		value = float64(v.Uint())
+	case reflect.Map, reflect.Slice, reflect.String:
+		value = float64(v.Len())
+	default:
+		return errors.Errorf("rule %s doesn't support kind %s", name, v.Kind())
+	}
+	if limit, err = strconv.ParseFloat(arg, 64); err != nil {
+		return errors.Wrapf(err, "parsing %s limit %s failed", name, arg)
+	}
+	return compare(name, arg, value, limit)
+}
+
+// compare compares a value to a limit
+func compare(name, arg string, value, limit float64) error {
+	if name == "min" && value < limit {
+		return errors.Errorf("must be >= %s", arg)
+	} else if name == "max" && value > limit {
+		return errors.Errorf("must be <= %s", arg)
+	}
+	return nil
+}
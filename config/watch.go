@@ -0,0 +1,170 @@
+package asticonfig
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/asticode/go-astilog"
+	"github.com/asticode/go-astitools/os"
+	"github.com/pkg/errors"
+)
+
+// Subscriber represents a func notified with the new configuration and the keys that have changed
+type Subscriber[T any] func(c *T, changed []string)
+
+// Watcher represents an object capable of reloading a configuration and notifying subscribers when it changes
+type Watcher[T any] struct {
+	c    *T
+	fn   func() *T
+	m    *sync.Mutex // Locks c and subs
+	mr   *sync.Mutex // Locks reloads
+	o    LoadOptions
+	subs []Subscriber[T]
+}
+
+// NewWatcher creates a new watcher and loads the configuration for the first time
+// fn must return a new ptr to the configuration, filled with default values.
+func NewWatcher[T any](fn func() *T, o LoadOptions) (w *Watcher[T], err error) {
+	// Create watcher
+	w = &Watcher[T]{
+		fn: fn,
+		m:  &sync.Mutex{},
+		mr: &sync.Mutex{},
+		o:  o,
+	}
+
+	// Load
+	var c = fn()
+	if err = Load(c, o); err != nil {
+		return
+	}
+	w.c = c
+	return
+}
+
+// Config returns the current configuration
+// It must not be modified since it is shared with all callers.
+func (w *Watcher[T]) Config() *T {
+	w.m.Lock()
+	defer w.m.Unlock()
+	return w.c
+}
+
+// Subscribe registers a subscriber that will be notified every time the configuration changes
+func (w *Watcher[T]) Subscribe(fn Subscriber[T]) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	w.subs = append(w.subs, fn)
+}
+
+// Reload reloads the configuration and notifies subscribers if it has changed
+// If the new configuration is invalid, the current one is kept. It can be registered as a worker reloader.
+func (w *Watcher[T]) Reload() (err error) {
+	// Lock
+	w.mr.Lock()
+	defer w.mr.Unlock()
+
+	// Load
+	var c = w.fn()
+	if err = Load(c, w.o); err != nil {
+		return
+	}
+
+	// Diff
+	w.m.Lock()
+	var changed = diff(reflect.ValueOf(w.c).Elem(), reflect.ValueOf(c).Elem(), "")
+	if len(changed) == 0 {
+		w.m.Unlock()
+		return
+	}
+	sort.Strings(changed)
+	w.c = c
+	var subs = make([]Subscriber[T], len(w.subs))
+	copy(subs, w.subs)
+	w.m.Unlock()
+
+	// Notify
+	for _, s := range subs {
+		s(c, changed)
+	}
+	return
+}
+
+// Watch reloads the configuration every time its file changes or SIGHUP is received, until ctx is cancelled
+// Reload errors are logged and don't stop the watch.
+func (w *Watcher[T]) Watch(ctx context.Context) (err error) {
+	// Watch signal
+	var chanSignal = make(chan os.Signal, 1)
+	signal.Notify(chanSignal, syscall.SIGHUP)
+	defer signal.Stop(chanSignal)
+
+	// Watch file
+	var events <-chan astios.WatchEvent
+	if w.o.Path != "" {
+		// Create watcher
+		// The directory is watched rather than the file since editors usually replace files when saving them
+		var fw *astios.Watcher
+		if fw, err = astios.NewWatcher(ctx, astios.WatcherOptions{
+			Debounce: 100 * time.Millisecond,
+			Include:  []string{filepath.Base(w.o.Path)},
+		}); err != nil {
+			err = errors.Wrap(err, "asticonfig: creating watcher failed")
+			return
+		}
+
+		// Add directory
+		if err = fw.Add(filepath.Dir(w.o.Path)); err != nil {
+			err = errors.Wrapf(err, "asticonfig: watching %s failed", filepath.Dir(w.o.Path))
+			return
+		}
+		events = fw.Events()
+	}
+
+	// Loop
+	for {
+		select {
+		case <-chanSignal:
+		case _, ok := <-events:
+			if !ok {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+		if errReload := w.Reload(); errReload != nil {
+			astilog.Error(errors.Wrap(errReload, "asticonfig: reloading config failed"))
+		}
+	}
+}
+
+// diff returns the keys whose values are different
+func diff(a, b reflect.Value, path string) (keys []string) {
+	var t = a.Type()
+	for i := 0; i < t.NumField(); i++ {
+		// Field is not exported
+		var f = t.Field(i)
+		if f.PkgPath != "" {
+			continue
+		}
+
+		// Nested struct
+		var k = joinKey(path, keyName(f))
+		if a.Field(i).Kind() == reflect.Struct && f.Type != reflect.TypeOf(time.Time{}) {
+			keys = append(keys, diff(a.Field(i), b.Field(i), k)...)
+			continue
+		}
+
+		// Compare
+		if !reflect.DeepEqual(a.Field(i).Interface(), b.Field(i).Interface()) {
+			keys = append(keys, k)
+		}
+	}
+	return
+}
@@ -0,0 +1,48 @@
+package asticonfig_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatcher(t *testing.T) {
+	// Write file
+	var p = filepath.Join(t.TempDir(), "config.toml")
+	assert.NoError(t, os.WriteFile(p, []byte("name = \"a\"\n[server]\nport = 1\n"), 0600))
+
+	// Create watcher
+	w, err := asticonfig.NewWatcher(func() *testConfig { return &testConfig{Timeout: time.Second} }, asticonfig.LoadOptions{Path: p})
+	assert.NoError(t, err)
+	assert.Equal(t, "a", w.Config().Name)
+	var chanChanged = make(chan []string, 1)
+	w.Subscribe(func(c *testConfig, changed []string) { chanChanged <- changed })
+
+	// Nothing has changed
+	assert.NoError(t, w.Reload())
+	assert.Len(t, chanChanged, 0)
+
+	// Watch
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Watch(ctx)
+	time.Sleep(50 * time.Millisecond)
+	assert.NoError(t, os.WriteFile(p, []byte("name = \"b\"\n[server]\nport = 2\n"), 0600))
+	select {
+	case changed := <-chanChanged:
+		assert.Equal(t, []string{"name", "server.port"}, changed)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timeout")
+	}
+	assert.Equal(t, "b", w.Config().Name)
+
+	// Invalid config is not applied
+	assert.NoError(t, os.WriteFile(p, []byte("[server]\nport = 3\n"), 0600))
+	assert.Error(t, w.Reload())
+	assert.Equal(t, "b", w.Config().Name)
+}
@@ -0,0 +1,84 @@
+package asticontext
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Cancellation reasons
+const (
+	ReasonDependencyFailure Reason = "dependency failure"
+	ReasonSignal            Reason = "signal"
+	ReasonTimeout           Reason = "timeout"
+	ReasonUnknown           Reason = "unknown"
+	ReasonUserStop          Reason = "user stop"
+)
+
+// Reason represents the reason why a context has been cancelled
+type Reason string
+
+// CancelFunc cancels a context with a reason, err providing optional details
+type CancelFunc func(r Reason, err error)
+
+// CancellationError represents the cause of a context cancellation
+type CancellationError struct {
+	Err    error
+	Reason Reason
+}
+
+// Error implements the error interface
+func (e CancellationError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("asticontext: cancelled because of %s", e.Reason)
+	}
+	return fmt.Sprintf("asticontext: cancelled because of %s: %s", e.Reason, e.Err)
+}
+
+// Unwrap allows using errors.Is and errors.As on the details
+func (e CancellationError) Unwrap() error {
+	return e.Err
+}
+
+// WithCancel returns a context that can be cancelled with a reason
+// Children contexts inherit the reason when they're cancelled by their parent.
+func WithCancel(parent context.Context) (context.Context, CancelFunc) {
+	ctx, cancel := context.WithCancelCause(parent)
+	return ctx, func(r Reason, err error) { cancel(CancellationError{Err: err, Reason: r}) }
+}
+
+// WithTimeout returns a context cancelled with the timeout reason once d has elapsed
+func WithTimeout(parent context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	return context.WithTimeoutCause(parent, d, CancellationError{Reason: ReasonTimeout})
+}
+
+// Cause returns why a context has been cancelled, or nil if it hasn't
+func Cause(ctx context.Context) error {
+	if ctx.Err() == nil {
+		return nil
+	}
+	return context.Cause(ctx)
+}
+
+// CauseReason returns the reason why a context has been cancelled, or an empty reason if it hasn't
+func CauseReason(ctx context.Context) Reason {
+	// Not cancelled
+	var err = Cause(ctx)
+	if err == nil {
+		return ""
+	}
+
+	// Cancelled with a reason
+	var e CancellationError
+	if errors.As(err, &e) {
+		return e.Reason
+	}
+
+	// Deadline exceeded
+	if err == context.DeadlineExceeded {
+		return ReasonTimeout
+	}
+	return ReasonUnknown
+}
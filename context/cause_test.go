@@ -0,0 +1,42 @@
+package asticontext_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/context"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCause(t *testing.T) {
+	// Cancel with reason
+	var errTest = errors.New("test")
+	ctx, cancel := asticontext.WithCancel(context.Background())
+	child, cancelChild := context.WithCancel(ctx)
+	defer cancelChild()
+	assert.NoError(t, asticontext.Cause(ctx))
+	assert.Equal(t, asticontext.Reason(""), asticontext.CauseReason(ctx))
+	cancel(asticontext.ReasonDependencyFailure, errTest)
+	assert.Equal(t, context.Canceled, ctx.Err())
+	assert.Equal(t, asticontext.ReasonDependencyFailure, asticontext.CauseReason(ctx))
+	assert.Equal(t, asticontext.ReasonDependencyFailure, asticontext.CauseReason(child))
+	assert.True(t, errors.Is(asticontext.Cause(child), errTest))
+	assert.Equal(t, "asticontext: cancelled because of dependency failure: test", asticontext.Cause(ctx).Error())
+
+	// Timeout
+	ctx, cancelTimeout := asticontext.WithTimeout(context.Background(), time.Millisecond)
+	defer cancelTimeout()
+	<-ctx.Done()
+	assert.Equal(t, asticontext.ReasonTimeout, asticontext.CauseReason(ctx))
+	ctx, cancelTimeout = context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancelTimeout()
+	<-ctx.Done()
+	assert.Equal(t, asticontext.ReasonTimeout, asticontext.CauseReason(ctx))
+
+	// Unknown
+	ctx, cancelStd := context.WithCancel(context.Background())
+	cancelStd()
+	assert.Equal(t, asticontext.ReasonUnknown, asticontext.CauseReason(ctx))
+}
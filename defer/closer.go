@@ -0,0 +1,255 @@
+package astidefer
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// CloseFunc is a func that closes something
+type CloseFunc func() error
+
+// CloserOptions represents closer options
+type CloserOptions struct {
+	// Order is the order in which groups are closed. Groups that are not listed are closed afterwards, in the
+	// order they were created. The default group, whose name is "", is always closed last unless listed.
+	Order []string
+}
+
+// Closer is an object that closes things, grouped by names, and aggregates errors
+type Closer struct {
+	gs map[string]*CloserGroup
+	m  *sync.Mutex // Locks gs and ns
+	ns []string
+	o  CloserOptions
+}
+
+// NewCloser creates a new closer
+func NewCloser() *Closer {
+	return NewCloserWithOptions(CloserOptions{})
+}
+
+// NewCloserWithOptions creates a new closer with options
+func NewCloserWithOptions(o CloserOptions) *Closer {
+	return &Closer{
+		gs: make(map[string]*CloserGroup),
+		m:  &sync.Mutex{},
+		o:  o,
+	}
+}
+
+// Add adds a close func to the default group
+func (c *Closer) Add(f CloseFunc) {
+	c.Group("").Add(f)
+}
+
+// AddNamed adds a named close func to the default group
+func (c *Closer) AddNamed(name string, f CloseFunc) {
+	c.Group("").AddNamed(name, f)
+}
+
+// AddGroup adds a group with options or updates the options of an existing group
+func (c *Closer) AddGroup(name string, o CloserGroupOptions) (g *CloserGroup) {
+	g = c.Group(name)
+	g.m.Lock()
+	g.o = o
+	g.m.Unlock()
+	return
+}
+
+// Group returns the group with the provided name and creates it if it doesn't exist
+func (c *Closer) Group(name string) (g *CloserGroup) {
+	// Lock
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	// Group exists
+	var ok bool
+	if g, ok = c.gs[name]; ok {
+		return
+	}
+
+	// Create group
+	g = &CloserGroup{
+		m:    &sync.Mutex{},
+		name: name,
+	}
+	c.gs[name] = g
+	if name != "" {
+		c.ns = append(c.ns, name)
+	}
+	return
+}
+
+// Close implements the io.Closer interface
+// Groups are closed in the chosen order and close funcs of a group are closed in the reverse order they were added.
+// It returns a CloseError listing every resource that failed to close.
+func (c *Closer) Close() (err error) {
+	// Get groups
+	var gs = c.groups()
+
+	// Loop through groups
+	var errs []ResourceError
+	for _, g := range gs {
+		errs = append(errs, g.close()...)
+	}
+
+	// Process errors
+	if len(errs) > 0 {
+		err = CloseError{Errors: errs}
+	}
+	return
+}
+
+// groups returns groups in the order they should be closed in and removes them from the closer
+func (c *Closer) groups() (gs []*CloserGroup) {
+	// Lock
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	// Chosen order
+	var done = make(map[string]bool)
+	for _, n := range c.o.Order {
+		if g, ok := c.gs[n]; ok && !done[n] {
+			gs = append(gs, g)
+			done[n] = true
+		}
+	}
+
+	// Creation order
+	for _, n := range append(c.ns, "") {
+		if g, ok := c.gs[n]; ok && !done[n] {
+			gs = append(gs, g)
+			done[n] = true
+		}
+	}
+
+	// Reset
+	c.gs = make(map[string]*CloserGroup)
+	c.ns = []string{}
+	return
+}
+
+// CloserGroupOptions represents closer group options
+type CloserGroupOptions struct {
+	// Timeout is the max duration each close func of the group is given. Close funcs that haven't returned
+	// after it are reported as failed and are left running in the background.
+	Timeout time.Duration
+}
+
+// CloserGroup represents a group of close funcs
+type CloserGroup struct {
+	fs   []namedCloseFunc
+	m    *sync.Mutex // Locks fs and o
+	name string
+	o    CloserGroupOptions
+}
+
+type namedCloseFunc struct {
+	f    CloseFunc
+	name string
+}
+
+// Add adds a close func to the group
+func (g *CloserGroup) Add(f CloseFunc) {
+	g.AddNamed("", f)
+}
+
+// AddNamed adds a named close func to the group, the name being used in errors
+func (g *CloserGroup) AddNamed(name string, f CloseFunc) {
+	g.m.Lock()
+	defer g.m.Unlock()
+	g.fs = append(g.fs, namedCloseFunc{f: f, name: name})
+}
+
+// close closes the group's funcs in reverse order
+func (g *CloserGroup) close() (errs []ResourceError) {
+	// Lock
+	g.m.Lock()
+	fs, o := g.fs, g.o
+	g.fs = []namedCloseFunc{}
+	g.m.Unlock()
+
+	// Loop through funcs
+	for i := len(fs) - 1; i >= 0; i-- {
+		if err := closeWithTimeout(fs[i].f, o.Timeout); err != nil {
+			errs = append(errs, ResourceError{Err: err, Group: g.name, Name: fs[i].name})
+		}
+	}
+	return
+}
+
+// closeWithTimeout executes the close func and returns an error if it hasn't returned after the timeout
+func closeWithTimeout(f CloseFunc, timeout time.Duration) (err error) {
+	// No timeout
+	if timeout <= 0 {
+		return f()
+	}
+
+	// Execute
+	var c = make(chan error, 1)
+	go func() { c <- f() }()
+
+	// Wait
+	var t = time.NewTimer(timeout)
+	defer t.Stop()
+	select {
+	case err = <-c:
+	case <-t.C:
+		err = errors.Errorf("astidefer: timed out after %s", timeout)
+	}
+	return
+}
+
+// ResourceError represents an error returned while closing a resource
+type ResourceError struct {
+	Err   error
+	Group string
+	Name  string
+}
+
+// Error implements the error interface
+func (e ResourceError) Error() string {
+	var ss []string
+	if e.Group != "" {
+		ss = append(ss, "group "+e.Group)
+	}
+	if e.Name != "" {
+		ss = append(ss, "resource "+e.Name)
+	}
+	if len(ss) == 0 {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s: %s", strings.Join(ss, " "), e.Err)
+}
+
+// Unwrap allows using errors.Is and errors.As on the underlying error
+func (e ResourceError) Unwrap() error {
+	return e.Err
+}
+
+// CloseError represents an error aggregating errors returned by close funcs
+type CloseError struct {
+	Errors []ResourceError
+}
+
+// Error implements the error interface
+func (e CloseError) Error() string {
+	var ss []string
+	for _, err := range e.Errors {
+		ss = append(ss, err.Error())
+	}
+	return fmt.Sprintf("astidefer: closing failed: %s", strings.Join(ss, ", "))
+}
+
+// Unwrap allows using errors.Is and errors.As on the aggregated errors
+func (e CloseError) Unwrap() []error {
+	var errs []error
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
@@ -0,0 +1,57 @@
+package astidefer_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/defer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCloser(t *testing.T) {
+	// Setup
+	var c = astidefer.NewCloserWithOptions(astidefer.CloserOptions{Order: []string{"servers", "db"}})
+	var o []string
+	var errDB = errors.New("db error")
+	var block = make(chan struct{})
+	defer close(block)
+	c.Add(func() error {
+		o = append(o, "default")
+		return nil
+	})
+	c.Group("db").AddNamed("postgres", func() error {
+		o = append(o, "postgres")
+		return errDB
+	})
+	c.Group("cache").Add(func() error {
+		o = append(o, "cache")
+		return nil
+	})
+	var s = c.AddGroup("servers", astidefer.CloserGroupOptions{Timeout: 10 * time.Millisecond})
+	s.AddNamed("http", func() error {
+		o = append(o, "http")
+		return nil
+	})
+	s.AddNamed("grpc", func() error {
+		<-block
+		return nil
+	})
+
+	// Close
+	err := c.Close()
+	assert.Equal(t, []string{"http", "postgres", "cache", "default"}, o)
+	var cerr astidefer.CloseError
+	assert.True(t, errors.As(err, &cerr))
+	assert.Len(t, cerr.Errors, 2)
+	assert.Equal(t, "servers", cerr.Errors[0].Group)
+	assert.Equal(t, "grpc", cerr.Errors[0].Name)
+	assert.Equal(t, "postgres", cerr.Errors[1].Name)
+	assert.True(t, errors.Is(err, errDB))
+	assert.Equal(t, "astidefer: closing failed: group servers resource grpc: astidefer: timed out after 10ms, group db resource postgres: db error", err.Error())
+
+	// Funcs are only closed once
+	o = []string{}
+	assert.NoError(t, c.Close())
+	assert.Empty(t, o)
+}
@@ -6,7 +6,7 @@ import (
 	"strings"
 	"time"
 
-	"github.com/asticode/go-astilog"
+	"github.com/asticode/go-astitools/logger"
 )
 
 // Cmd represents a command
@@ -40,7 +40,7 @@ var Exec = func(cmd *Cmd) (o []byte, d time.Duration, err error) {
 	execCmd := exec.CommandContext(cmd.ctx, cmd.Args[0], cmd.Args[1:]...)
 
 	// Execute command
-	astilog.Debugf("Executing %s", cmd)
+	astilogger.WithContext(astilogger.Default(), cmd.ctx).Debug("astiexec: executing command", "cmd", cmd)
 	o, err = execCmd.CombinedOutput()
 	return
 }
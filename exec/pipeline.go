@@ -0,0 +1,265 @@
+package astiexec
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/asticode/go-astitools/logger"
+	"github.com/pkg/errors"
+)
+
+// Pipeline represents a chain of commands, each command's stdout being connected to the next command's stdin, like
+// cmd1 | cmd2 | cmd3 in a shell
+type Pipeline struct {
+	ctx    context.Context
+	stages [][]string
+}
+
+// NewPipeline creates a new pipeline
+// Stages are added with Pipe.
+func NewPipeline(ctx context.Context) *Pipeline {
+	return &Pipeline{ctx: ctx}
+}
+
+// Pipe adds a stage to the pipeline
+func (p *Pipeline) Pipe(args ...string) *Pipeline {
+	p.stages = append(p.stages, args)
+	return p
+}
+
+// String implements the fmt.Stringer interface
+func (p *Pipeline) String() string {
+	var ss []string
+	for _, s := range p.stages {
+		ss = append(ss, strings.Join(s, " "))
+	}
+	return strings.Join(ss, " | ")
+}
+
+// PipelineOptions represents pipeline options
+type PipelineOptions struct {
+	// Working directory of the commands. Defaults to the current directory
+	Dir string
+	// Environment variables, in the form "key=value", added to the environment of the current process
+	Env []string
+	// Delay between the SIGTERM sent to every stage once the context is done and the SIGKILL sent to stages that are
+	// still running. On Windows, stages are killed right away. Defaults to 5s
+	KillTimeout time.Duration
+	// Messages are logged with the fields of the pipeline's context. Defaults to astilogger.Default()
+	Logger astilogger.Logger
+	// Maximum number of bytes of the last stage's stdout and of each stage's stderr kept in the result. Only the last
+	// bytes are kept. Defaults to 64KB
+	OutputMaxSize int
+	// Stderr is called for every line written on stderr by a stage, without its EOL
+	Stderr func(stage int, line []byte)
+	// Stdin of the first stage
+	Stdin io.Reader
+	// Stdout is called for every line written on stdout by the last stage, without its EOL
+	Stdout func(line []byte)
+}
+
+// PipelineResult represents the result of a pipeline
+type PipelineResult struct {
+	Duration time.Duration
+	// Results of each stage. Their Stdout is only set for the last stage
+	Stages []Result
+}
+
+// StageError represents an error returned when a pipeline stage fails
+type StageError struct {
+	Cmd string
+	Err error
+	// -1 if the stage has not exited or has been terminated by a signal
+	ExitCode int
+	Stage    int
+	Stderr   []byte
+}
+
+// Error implements the error interface
+func (e StageError) Error() string {
+	return fmt.Sprintf("astiexec: stage #%d (%s) failed: %s", e.Stage, e.Cmd, e.Err)
+}
+
+// Unwrap allows using errors.Is and errors.As on the underlying error
+func (e StageError) Unwrap() error {
+	return e.Err
+}
+
+// Run runs the pipeline and returns when all stages have exited
+// Once the context is done, every stage and its process group are terminated. If a stage can't be started or exits
+// with a non-zero code, a StageError is returned for the first failing stage. Stages terminated by a SIGPIPE because
+// a downstream stage has stopped reading, as in "yes | head -1", are not considered failed. If the context is done,
+// the error cause is the context error.
+func (p *Pipeline) Run(o PipelineOptions) (r PipelineResult, err error) {
+	// Default options values
+	if o.KillTimeout <= 0 {
+		o.KillTimeout = 5 * time.Second
+	}
+	if o.Logger == nil {
+		o.Logger = astilogger.Default()
+	}
+	if o.OutputMaxSize <= 0 {
+		o.OutputMaxSize = 64 << 10
+	}
+	var l = astilogger.WithContext(o.Logger, p.ctx)
+
+	// Init
+	defer func(t time.Time) {
+		r.Duration = time.Since(t)
+	}(time.Now())
+
+	// No stages
+	if len(p.stages) == 0 {
+		err = errors.New("astiexec: no stages provided")
+		return
+	}
+	for idx, s := range p.stages {
+		if len(s) == 0 {
+			err = errors.Errorf("astiexec: no args provided for stage #%d", idx)
+			return
+		}
+	}
+
+	// Create exec commands
+	var cmds = make([]*exec.Cmd, len(p.stages))
+	var stderrs = make([]*outputWriter, len(p.stages))
+	var stdout = newOutputWriter(o.OutputMaxSize, o.Stdout)
+	r.Stages = make([]Result, len(p.stages))
+	for idx, s := range p.stages {
+		// Create command
+		cmds[idx] = exec.Command(s[0], s[1:]...)
+		cmds[idx].Dir = o.Dir
+		if len(o.Env) > 0 {
+			cmds[idx].Env = append(os.Environ(), o.Env...)
+		}
+		setProcessGroup(cmds[idx])
+		cmds[idx].WaitDelay = o.KillTimeout
+		r.Stages[idx].ExitCode = -1
+
+		// Stderr
+		var fn func(line []byte)
+		if o.Stderr != nil {
+			var stage = idx
+			fn = func(line []byte) { o.Stderr(stage, line) }
+		}
+		stderrs[idx] = newOutputWriter(o.OutputMaxSize, fn)
+		cmds[idx].Stderr = stderrs[idx]
+	}
+	cmds[0].Stdin = o.Stdin
+	cmds[len(cmds)-1].Stdout = stdout
+
+	// Connect stages
+	var files []*os.File
+	defer func() {
+		for _, f := range files {
+			f.Close()
+		}
+	}()
+	for idx := 0; idx < len(cmds)-1; idx++ {
+		var pr, pw *os.File
+		if pr, pw, err = os.Pipe(); err != nil {
+			err = errors.Wrap(err, "astiexec: creating pipe failed")
+			return
+		}
+		files = append(files, pr, pw)
+		cmds[idx].Stdout = pw
+		cmds[idx+1].Stdin = pr
+	}
+
+	// Start stages
+	l.Debug("astiexec: executing pipeline", "pipeline", p)
+	var started int
+	var startErr error
+	for idx, c := range cmds {
+		if startErr = c.Start(); startErr != nil {
+			startErr = StageError{Cmd: strings.Join(p.stages[idx], " "), Err: startErr, ExitCode: -1, Stage: idx}
+			break
+		}
+		started++
+	}
+
+	// Close pipes in the parent process so that stages see EOF once their upstream stage has exited
+	for _, f := range files {
+		f.Close()
+	}
+	files = nil
+
+	// Terminate stages once the context is done or if a stage could not be started
+	var done = make(chan bool)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if startErr == nil {
+			select {
+			case <-p.ctx.Done():
+			case <-done:
+				return
+			}
+		}
+		for _, c := range cmds[:started] {
+			if err := terminate(c.Process); err != nil {
+				l.Debug("astiexec: terminating stage failed", "cmd", c, astilogger.KeyError, err)
+			}
+		}
+		select {
+		case <-time.After(o.KillTimeout):
+			for _, c := range cmds[:started] {
+				if err := kill(c.Process); err != nil {
+					l.Debug("astiexec: killing stage failed", "cmd", c, astilogger.KeyError, err)
+				}
+			}
+		case <-done:
+		}
+	}()
+
+	// Wait for stages
+	var waitErrs = make([]error, started)
+	for idx, c := range cmds[:started] {
+		if waitErrs[idx] = c.Wait(); errors.Cause(waitErrs[idx]) == exec.ErrWaitDelay {
+			waitErrs[idx] = nil
+		}
+	}
+	close(done)
+	wg.Wait()
+	stdout.close()
+
+	// Build result
+	for idx, c := range cmds {
+		stderrs[idx].close()
+		if c.ProcessState != nil {
+			r.Stages[idx].ExitCode = c.ProcessState.ExitCode()
+		}
+		r.Stages[idx].Stderr, r.Stages[idx].StderrTruncated = stderrs[idx].tail, stderrs[idx].truncated
+	}
+	r.Stages[len(cmds)-1].Stdout, r.Stages[len(cmds)-1].StdoutTruncated = stdout.tail, stdout.truncated
+
+	// Process error
+	if p.ctx.Err() != nil {
+		err = errors.Wrapf(p.ctx.Err(), "astiexec: running %s failed", p)
+		return
+	} else if startErr != nil {
+		err = startErr
+		return
+	}
+	for idx, e := range waitErrs {
+		if e == nil || (idx < len(cmds)-1 && isBrokenPipe(cmds[idx].ProcessState)) {
+			continue
+		}
+		err = StageError{
+			Cmd:      strings.Join(p.stages[idx], " "),
+			Err:      e,
+			ExitCode: r.Stages[idx].ExitCode,
+			Stage:    idx,
+			Stderr:   r.Stages[idx].Stderr,
+		}
+		return
+	}
+	return
+}
@@ -0,0 +1,50 @@
+package astiexec_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/exec"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPipeline(t *testing.T) {
+	// Success
+	var lines []string
+	r, err := astiexec.NewPipeline(context.Background()).
+		Pipe("sh", "-c", "printf 'b\\na\\nc\\n'; echo warn >&2").
+		Pipe("sort").
+		Pipe("tr", "a-z", "A-Z").
+		Run(astiexec.PipelineOptions{Stdout: func(line []byte) { lines = append(lines, string(line)) }})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"A", "B", "C"}, lines)
+	assert.Len(t, r.Stages, 3)
+	assert.Equal(t, "warn\n", string(r.Stages[0].Stderr))
+	assert.Equal(t, "A\nB\nC\n", string(r.Stages[2].Stdout))
+
+	// Stdin and broken pipe
+	r, err = astiexec.NewPipeline(context.Background()).Pipe("cat").Pipe("yes").Pipe("head", "-n", "1").Run(astiexec.PipelineOptions{Stdin: strings.NewReader("ignored")})
+	assert.NoError(t, err)
+	assert.Equal(t, "y\n", string(r.Stages[2].Stdout))
+
+	// Stage error
+	_, err = astiexec.NewPipeline(context.Background()).Pipe("echo", "1").Pipe("sh", "-c", "echo failed >&2; exit 2").Pipe("cat").Run(astiexec.PipelineOptions{})
+	var se astiexec.StageError
+	assert.True(t, errors.As(err, &se))
+	assert.Equal(t, 1, se.Stage)
+	assert.Equal(t, 2, se.ExitCode)
+	assert.Equal(t, "failed\n", string(se.Stderr))
+	_, err = astiexec.NewPipeline(context.Background()).Pipe("echo").Pipe("astiexec-does-not-exist").Run(astiexec.PipelineOptions{})
+	assert.True(t, errors.As(err, &se))
+	assert.Equal(t, 1, se.Stage)
+
+	// Context
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	r, err = astiexec.NewPipeline(ctx).Pipe("sleep", "5").Pipe("sleep", "5").Run(astiexec.PipelineOptions{})
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Less(t, r.Duration, time.Second)
+}
@@ -0,0 +1,182 @@
+package astiexec
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/asticode/go-astitools/logger"
+	"github.com/pkg/errors"
+)
+
+// RunOptions represents run options
+type RunOptions struct {
+	// Working directory of the command. Defaults to the current directory
+	Dir string
+	// Environment variables, in the form "key=value", added to the environment of the current process
+	Env []string
+	// Delay between the SIGTERM sent once the context is done and the SIGKILL sent if the command is still running.
+	// On Windows, the command is killed right away. Defaults to 5s
+	KillTimeout time.Duration
+	// Messages are logged with the fields of the command's context, e.g. the ID of the astiworker task it's run in.
+	// Defaults to astilogger.Default()
+	Logger astilogger.Logger
+	// Maximum number of bytes of each output kept in the result. Only the last bytes are kept. Defaults to 64KB
+	OutputMaxSize int
+	// Stderr is called for every line written on stderr, without its EOL
+	Stderr func(line []byte)
+	Stdin  io.Reader
+	// Stdout is called for every line written on stdout, without its EOL
+	// Stdout and Stderr may be called concurrently
+	Stdout func(line []byte)
+}
+
+// Result represents the result of a command
+type Result struct {
+	Duration time.Duration
+	// -1 if the command has not exited or has been terminated by a signal
+	ExitCode        int
+	Stderr          []byte
+	StderrTruncated bool
+	Stdout          []byte
+	StdoutTruncated bool
+}
+
+// Run runs a command, streams its output line by line and returns a structured result
+// Once the command context is done, the command and its process group are sent a SIGTERM and, if the command is still
+// running after KillTimeout, a SIGKILL. An error is returned if the command can't be started, exits with a non-zero
+// code or if the context is done, in which case its cause is the context error.
+func Run(cmd *Cmd, o RunOptions) (r Result, err error) {
+	// Default options values
+	if o.KillTimeout <= 0 {
+		o.KillTimeout = 5 * time.Second
+	}
+	if o.Logger == nil {
+		o.Logger = astilogger.Default()
+	}
+	if o.OutputMaxSize <= 0 {
+		o.OutputMaxSize = 64 << 10
+	}
+
+	// Init
+	var l = astilogger.WithContext(o.Logger, cmd.ctx)
+	r.ExitCode = -1
+	defer func(t time.Time) {
+		r.Duration = time.Since(t)
+	}(time.Now())
+
+	// No args
+	if len(cmd.Args) == 0 {
+		err = errors.New("astiexec: no args provided")
+		return
+	}
+
+	// Create exec command
+	var stdout, stderr = newOutputWriter(o.OutputMaxSize, o.Stdout), newOutputWriter(o.OutputMaxSize, o.Stderr)
+	execCmd := exec.Command(cmd.Args[0], cmd.Args[1:]...)
+	execCmd.Dir = o.Dir
+	if len(o.Env) > 0 {
+		execCmd.Env = append(os.Environ(), o.Env...)
+	}
+	execCmd.Stderr = stderr
+	execCmd.Stdin = o.Stdin
+	execCmd.Stdout = stdout
+
+	// Children holding stdout or stderr must not keep us waiting once the command has exited or has been killed
+	setProcessGroup(execCmd)
+	execCmd.WaitDelay = o.KillTimeout
+
+	// Start command
+	l.Debug("astiexec: executing command", "cmd", cmd)
+	if err = execCmd.Start(); err != nil {
+		err = errors.Wrapf(err, "astiexec: starting %s failed", cmd)
+		return
+	}
+
+	// Terminate the command once the context is done
+	var done = make(chan bool)
+	go func() {
+		select {
+		case <-cmd.ctx.Done():
+		case <-done:
+			return
+		}
+		if err := terminate(execCmd.Process); err != nil {
+			l.Error("astiexec: terminating command failed", "cmd", cmd, astilogger.KeyError, err)
+		}
+		select {
+		case <-time.After(o.KillTimeout):
+			l.Warn("astiexec: command is still running after being terminated, killing it", "cmd", cmd,
+				"timeout", o.KillTimeout)
+			if err := kill(execCmd.Process); err != nil {
+				l.Error("astiexec: killing command failed", "cmd", cmd, astilogger.KeyError, err)
+			}
+		case <-done:
+		}
+	}()
+
+	// Wait
+	if err = execCmd.Wait(); errors.Cause(err) == exec.ErrWaitDelay {
+		l.Warn("astiexec: outputs of command were still open after it exited", "cmd", cmd, "timeout", o.KillTimeout)
+		err = nil
+	}
+	close(done)
+	stdout.close()
+	stderr.close()
+
+	// Build result
+	if execCmd.ProcessState != nil {
+		r.ExitCode = execCmd.ProcessState.ExitCode()
+	}
+	r.Stderr, r.StderrTruncated = stderr.tail, stderr.truncated
+	r.Stdout, r.StdoutTruncated = stdout.tail, stdout.truncated
+
+	// Process error
+	if cmd.ctx.Err() != nil {
+		err = errors.Wrapf(cmd.ctx.Err(), "astiexec: running %s failed", cmd)
+	} else if err != nil {
+		err = errors.Wrapf(err, "astiexec: running %s failed", cmd)
+	}
+	return
+}
+
+// outputWriter represents a writer streaming lines to a callback and keeping the last bytes written
+type outputWriter struct {
+	max       int
+	sw        *StdWriter
+	tail      []byte
+	truncated bool
+}
+
+// newOutputWriter creates a new output writer
+func newOutputWriter(max int, fn func(line []byte)) (w *outputWriter) {
+	w = &outputWriter{max: max}
+	if fn != nil {
+		w.sw = NewStdWriter(fn)
+	}
+	return
+}
+
+// Write implements the io.Writer interface
+func (w *outputWriter) Write(i []byte) (n int, err error) {
+	// Stream lines
+	if w.sw != nil {
+		w.sw.Write(i)
+	}
+
+	// Keep last bytes
+	w.tail = append(w.tail, i...)
+	if len(w.tail) > w.max {
+		w.tail = append([]byte{}, w.tail[len(w.tail)-w.max:]...)
+		w.truncated = true
+	}
+	return len(i), nil
+}
+
+// close streams the last line if it has no EOL
+func (w *outputWriter) close() {
+	if w.sw != nil {
+		w.sw.Close()
+	}
+}
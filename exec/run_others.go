@@ -0,0 +1,35 @@
+//go:build !windows
+// +build !windows
+
+package astiexec
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup makes sure the command is started in its own process group so that its children can be signaled
+// as well
+func setProcessGroup(c *exec.Cmd) {
+	c.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// terminate asks a process and its process group to terminate gracefully
+func terminate(p *os.Process) error {
+	return syscall.Kill(-p.Pid, syscall.SIGTERM)
+}
+
+// isBrokenPipe checks whether a process has been terminated by a SIGPIPE
+func isBrokenPipe(s *os.ProcessState) bool {
+	if s == nil {
+		return false
+	}
+	ws, ok := s.Sys().(syscall.WaitStatus)
+	return ok && ws.Signaled() && ws.Signal() == syscall.SIGPIPE
+}
+
+// kill kills a process and its process group
+func kill(p *os.Process) error {
+	return syscall.Kill(-p.Pid, syscall.SIGKILL)
+}
@@ -0,0 +1,71 @@
+package astiexec_test
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/exec"
+	"github.com/asticode/go-astitools/logger"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRun(t *testing.T) {
+	// Success
+	var stdout, stderr []string
+	r, err := astiexec.Run(astiexec.NewCmd(context.Background(), "sh", "-c", "echo 1; echo 2 >&2; printf \"$ASTIEXEC\""), astiexec.RunOptions{
+		Env:    []string{"ASTIEXEC=3"},
+		Stderr: func(line []byte) { stderr = append(stderr, string(line)) },
+		Stdout: func(line []byte) { stdout = append(stdout, string(line)) },
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, r.ExitCode)
+	assert.Equal(t, []string{"1", "3"}, stdout)
+	assert.Equal(t, []string{"2"}, stderr)
+	assert.Equal(t, "1\n3", string(r.Stdout))
+	assert.False(t, r.StdoutTruncated)
+
+	// Exit code and truncated output
+	r, err = astiexec.Run(astiexec.NewCmd(context.Background(), "sh", "-c", "echo 123456; exit 3"), astiexec.RunOptions{OutputMaxSize: 3})
+	assert.Error(t, err)
+	assert.Equal(t, 3, r.ExitCode)
+	assert.Equal(t, "56\n", string(r.Stdout))
+	assert.True(t, r.StdoutTruncated)
+
+	// Terminate
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	r, err = astiexec.Run(astiexec.NewCmd(ctx, "sleep", "5"), astiexec.RunOptions{})
+	assert.Equal(t, context.DeadlineExceeded, errors.Cause(err))
+	assert.Equal(t, -1, r.ExitCode)
+	assert.True(t, r.Duration < time.Second)
+
+	// Kill
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	r, err = astiexec.Run(astiexec.NewCmd(ctx, "sh", "-c", "trap '' TERM; exec sleep 5"), astiexec.RunOptions{KillTimeout: 100 * time.Millisecond})
+	assert.Equal(t, context.DeadlineExceeded, errors.Cause(err))
+	assert.True(t, r.Duration >= 150*time.Millisecond && r.Duration < time.Second)
+
+	// Children are terminated as well
+	ctx, cancel = context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	r, err = astiexec.Run(astiexec.NewCmd(ctx, "sh", "-c", "sleep 3; echo done"), astiexec.RunOptions{KillTimeout: 100 * time.Millisecond})
+	assert.Equal(t, context.DeadlineExceeded, errors.Cause(err))
+	assert.True(t, r.Duration < time.Second)
+
+	// No args
+	_, err = astiexec.Run(astiexec.NewCmd(context.Background()), astiexec.RunOptions{})
+	assert.Error(t, err)
+}
+
+func TestRun_Logger(t *testing.T) {
+	var buf = &bytes.Buffer{}
+	ctx := astilogger.ContextWithFields(context.Background(), astilogger.KeyTaskID, 1)
+	_, err := astiexec.Run(astiexec.NewCmd(ctx, "true"), astiexec.RunOptions{Logger: astilogger.NewStdLogger(log.New(buf, "", 0), astilogger.LevelDebug)})
+	assert.NoError(t, err)
+	assert.Equal(t, "DEBUG astiexec: executing command task_id=1 cmd=true\n", buf.String())
+}
@@ -0,0 +1,27 @@
+//go:build windows
+// +build windows
+
+package astiexec
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setProcessGroup does nothing since process groups can't be signaled on Windows
+func setProcessGroup(c *exec.Cmd) {}
+
+// terminate kills a process since signals can't be sent on Windows
+func terminate(p *os.Process) error {
+	return p.Kill()
+}
+
+// isBrokenPipe always returns false since there are no signals on Windows
+func isBrokenPipe(s *os.ProcessState) bool {
+	return false
+}
+
+// kill kills a process
+func kill(p *os.Process) error {
+	return p.Kill()
+}
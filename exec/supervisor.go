@@ -0,0 +1,248 @@
+package astiexec
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/asticode/go-astitools/logger"
+	"github.com/asticode/go-astitools/time"
+	"github.com/asticode/go-astitools/worker"
+	"github.com/pkg/errors"
+)
+
+// Supervisor states
+const (
+	SupervisorStateBackingOff SupervisorState = "backing_off"
+	SupervisorStateFailed     SupervisorState = "failed"
+	SupervisorStateRunning    SupervisorState = "running"
+	SupervisorStateStopped    SupervisorState = "stopped"
+	SupervisorStateUnhealthy  SupervisorState = "unhealthy"
+)
+
+// ErrMaxRestartsReached is returned when a supervised process has been restarted too many times
+var ErrMaxRestartsReached = errors.New("astiexec: max restarts reached")
+
+// SupervisorState represents a supervisor state
+type SupervisorState string
+
+// Supervisor represents an object capable of keeping a process running
+type Supervisor struct {
+	args     []string
+	m        sync.Mutex // Locks restarts and state
+	o        SupervisorOptions
+	restarts int
+	state    SupervisorState
+}
+
+// SupervisorOptions represents supervisor options
+type SupervisorOptions struct {
+	// HealthProbe is executed periodically while the process is running. The process is restarted once it has failed
+	// HealthProbeMaxFailures times in a row
+	HealthProbe func(ctx context.Context) error
+	// Defaults to 3
+	HealthProbeMaxFailures int
+	// Defaults to 10s
+	HealthProbePeriod time.Duration
+	// Maximum number of restarts after which the supervisor gives up. 0 means no maximum
+	MaxRestarts int
+	// OnStateChange is called every time the supervisor state changes, with the error that caused it, if any
+	OnStateChange func(s SupervisorState, err error)
+	// Delay before the first restart. It doubles after each restart, up to RestartDelayMax, and is reset once the
+	// process has been running longer than RestartDelayMax. Defaults to 1s
+	RestartDelay time.Duration
+	// Defaults to 1m
+	RestartDelayMax time.Duration
+	// Options used to run the process. Its logger is used by the supervisor as well
+	Run RunOptions
+}
+
+// NewSupervisor creates a new supervisor
+func NewSupervisor(o SupervisorOptions, args ...string) *Supervisor {
+	// Default options values
+	if o.HealthProbeMaxFailures <= 0 {
+		o.HealthProbeMaxFailures = 3
+	}
+	if o.HealthProbePeriod <= 0 {
+		o.HealthProbePeriod = 10 * time.Second
+	}
+	if o.RestartDelay <= 0 {
+		o.RestartDelay = time.Second
+	}
+	if o.RestartDelayMax <= 0 {
+		o.RestartDelayMax = time.Minute
+	}
+	if o.Run.Logger == nil {
+		o.Run.Logger = astilogger.Default()
+	}
+	return &Supervisor{
+		args:  args,
+		o:     o,
+		state: SupervisorStateStopped,
+	}
+}
+
+// String allows Supervisor to implements the stringify interface
+func (s *Supervisor) String() string {
+	return strings.Join(s.args, " ")
+}
+
+// Restarts returns the number of times the process has been restarted
+func (s *Supervisor) Restarts() int {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.restarts
+}
+
+// State returns the supervisor state
+func (s *Supervisor) State() SupervisorState {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.state
+}
+
+// Start starts the process and restarts it every time it exits or becomes unhealthy
+// This is a blocking pattern that returns nil once the context is cancelled or an error whose cause is
+// ErrMaxRestartsReached once the process has been restarted too many times.
+func (s *Supervisor) Start(ctx context.Context) (err error) {
+	var l = astilogger.WithContext(s.o.Run.Logger, ctx)
+	for attempt := uint(0); ; {
+		// Run
+		var t = time.Now()
+		var e = s.run(ctx)
+
+		// Context has been cancelled
+		if ctx.Err() != nil {
+			s.setState(SupervisorStateStopped, nil)
+			return nil
+		}
+
+		// A process exiting on its own is a failure as well
+		if e == nil {
+			e = errors.Errorf("astiexec: %s exited", s)
+		}
+		l.Error("astiexec: process failed", "cmd", s, astilogger.KeyError, e)
+
+		// Max restarts have been reached
+		if s.o.MaxRestarts > 0 && s.Restarts() >= s.o.MaxRestarts {
+			s.setState(SupervisorStateFailed, e)
+			return errors.Wrapf(ErrMaxRestartsReached, "astiexec: supervising %s failed", s)
+		}
+
+		// Get delay
+		if time.Since(t) > s.o.RestartDelayMax {
+			attempt = 0
+		}
+		var d = s.o.RestartDelay << attempt
+		if d <= 0 || d > s.o.RestartDelayMax {
+			d = s.o.RestartDelayMax
+		} else {
+			attempt++
+		}
+
+		// Back off
+		s.setState(SupervisorStateBackingOff, e)
+		l.Info("astiexec: restarting process", "cmd", s, "delay", d)
+		if astitime.Sleep(ctx, d) != nil {
+			s.setState(SupervisorStateStopped, nil)
+			return nil
+		}
+
+		// Increment restarts
+		s.m.Lock()
+		s.restarts++
+		s.m.Unlock()
+	}
+}
+
+// StartTask starts the supervisor in a worker task so that the process is stopped when the worker stops
+func (s *Supervisor) StartTask(w *astiworker.Worker) (t *astiworker.Task) {
+	// The task is given enough time for the process to be killed
+	var kt = s.o.Run.KillTimeout
+	if kt <= 0 {
+		kt = 5 * time.Second
+	}
+	t = w.NewTask(astiworker.TaskConfiguration{
+		Name:        s.String(),
+		StopTimeout: kt + time.Second,
+	})
+	t.Do(func(ctx context.Context) {
+		if err := s.Start(ctx); err != nil {
+			t.Logger().Error("astiexec: supervising process failed", "cmd", s, astilogger.KeyError, err)
+		}
+	})
+	return
+}
+
+// run runs the process once while probing its health
+func (s *Supervisor) run(ctx context.Context) (err error) {
+	// Create context
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Probe health
+	s.setState(SupervisorStateRunning, nil)
+	var chanErr = make(chan error, 1)
+	var wg sync.WaitGroup
+	if s.o.HealthProbe != nil {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.probe(ctx); err != nil {
+				chanErr <- err
+				cancel()
+			}
+		}()
+	}
+
+	// Run
+	_, err = Run(NewCmd(ctx, s.args...), s.o.Run)
+	cancel()
+	wg.Wait()
+
+	// Process has been restarted because it was unhealthy
+	select {
+	case err = <-chanErr:
+	default:
+	}
+	return
+}
+
+// probe probes the process health until the context is cancelled or the process is considered unhealthy
+func (s *Supervisor) probe(ctx context.Context) (err error) {
+	var t = time.NewTicker(s.o.HealthProbePeriod)
+	defer t.Stop()
+	for failures := 0; ; {
+		select {
+		case <-t.C:
+			if err = s.o.HealthProbe(ctx); err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				failures++
+				err = errors.Wrapf(err, "astiexec: probing health of %s failed", s)
+				s.setState(SupervisorStateUnhealthy, err)
+				if failures >= s.o.HealthProbeMaxFailures {
+					return
+				}
+			} else if failures > 0 {
+				failures = 0
+				s.setState(SupervisorStateRunning, nil)
+			}
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// setState sets the supervisor state and executes the hook if it has changed
+func (s *Supervisor) setState(st SupervisorState, err error) {
+	s.m.Lock()
+	var changed = s.state != st
+	s.state = st
+	s.m.Unlock()
+	if (changed || err != nil) && s.o.OnStateChange != nil {
+		s.o.OnStateChange(st, err)
+	}
+}
@@ -0,0 +1,62 @@
+package astiexec_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/exec"
+	"github.com/asticode/go-astitools/worker"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSupervisor(t *testing.T) {
+	// Max restarts
+	var m sync.Mutex
+	var states []astiexec.SupervisorState
+	s := astiexec.NewSupervisor(astiexec.SupervisorOptions{
+		MaxRestarts: 2,
+		OnStateChange: func(s astiexec.SupervisorState, err error) {
+			m.Lock()
+			states = append(states, s)
+			m.Unlock()
+		},
+		RestartDelay: time.Millisecond,
+	}, "sh", "-c", "exit 1")
+	err := s.Start(context.Background())
+	assert.Equal(t, astiexec.ErrMaxRestartsReached, errors.Cause(err))
+	assert.Equal(t, 2, s.Restarts())
+	assert.Equal(t, astiexec.SupervisorStateFailed, s.State())
+	assert.Equal(t, []astiexec.SupervisorState{
+		astiexec.SupervisorStateRunning,
+		astiexec.SupervisorStateBackingOff,
+		astiexec.SupervisorStateRunning,
+		astiexec.SupervisorStateBackingOff,
+		astiexec.SupervisorStateRunning,
+		astiexec.SupervisorStateFailed,
+	}, states)
+
+	// Health probe
+	s = astiexec.NewSupervisor(astiexec.SupervisorOptions{
+		HealthProbe:            func(ctx context.Context) error { return errors.New("unhealthy") },
+		HealthProbeMaxFailures: 2,
+		HealthProbePeriod:      10 * time.Millisecond,
+		MaxRestarts:            1,
+		RestartDelay:           time.Millisecond,
+	}, "sleep", "5")
+	n := time.Now()
+	err = s.Start(context.Background())
+	assert.Equal(t, astiexec.ErrMaxRestartsReached, errors.Cause(err))
+	assert.True(t, time.Since(n) < time.Second)
+
+	// Worker
+	w := astiworker.NewWorker()
+	s = astiexec.NewSupervisor(astiexec.SupervisorOptions{}, "sleep", "5")
+	s.StartTask(w)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, astiexec.SupervisorStateRunning, s.State())
+	assert.NoError(t, w.Stop())
+	assert.Equal(t, astiexec.SupervisorStateStopped, s.State())
+}
@@ -0,0 +1,231 @@
+package astiflag
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+)
+
+// Command represents a command that can have nested commands and its own flags
+// It can be created with NewCommand or as a struct literal, its flag set being created on first use.
+type Command struct {
+	commands []*Command
+	// Description displayed in the usage
+	Description string
+	// Prefix of the environment variables used as fallbacks for flags that are not set, e.g. "APP_". Flag "log-level"
+	// then falls back to "APP_LOG_LEVEL". If empty, the parent's prefix is used. Fallbacks are disabled if no prefix
+	// is found.
+	EnvPrefix string
+	flags     *flag.FlagSet
+	Name      string
+	// Output the usage is written to. If nil, the parent's output or os.Stderr is used.
+	Output io.Writer
+	parent *Command
+	// Run executes the command with the remaining args. If nil, a nested command is required.
+	Run func(ctx context.Context, args []string) error
+}
+
+// NewCommand creates a new command
+func NewCommand(name, description string, run func(ctx context.Context, args []string) error) *Command {
+	return &Command{
+		Description: description,
+		flags:       flag.NewFlagSet(name, flag.ContinueOnError),
+		Name:        name,
+		Run:         run,
+	}
+}
+
+// AddCommand adds a nested command and returns it
+func (c *Command) AddCommand(sub *Command) *Command {
+	sub.parent = c
+	c.commands = append(c.commands, sub)
+	return sub
+}
+
+// Flags returns the command flag set
+func (c *Command) Flags() *flag.FlagSet {
+	if c.flags == nil {
+		c.flags = flag.NewFlagSet(c.Name, flag.ContinueOnError)
+	}
+	return c.flags
+}
+
+// Execute parses args and executes the matching command
+// args shouldn't contain the program name, e.g. os.Args[1:]. If help is requested, the usage is written and
+// flag.ErrHelp is returned.
+func (c *Command) Execute(ctx context.Context, args []string) (err error) {
+	// Parse flags
+	var fs = c.Flags()
+	fs.SetOutput(io.Discard)
+	if err = fs.Parse(args); err != nil {
+		if err != flag.ErrHelp {
+			err = errors.Wrapf(err, "astiflag: parsing flags of command %s failed", c.path())
+		}
+		c.writeUsage()
+		return
+	}
+
+	// Apply environment fallbacks
+	if err = c.applyEnv(); err != nil {
+		c.writeUsage()
+		return
+	}
+
+	// Nested command
+	args = fs.Args()
+	if len(args) > 0 {
+		for _, sub := range c.commands {
+			if sub.Name == args[0] {
+				return sub.Execute(ctx, args[1:])
+			}
+		}
+	}
+
+	// No run
+	if c.Run == nil {
+		if len(args) > 0 {
+			err = errors.Errorf("astiflag: unknown command %s %s", c.path(), args[0])
+		} else {
+			err = errors.Errorf("astiflag: command %s requires a nested command", c.path())
+		}
+		c.writeUsage()
+		return
+	}
+
+	// Run
+	return c.Run(ctx, args)
+}
+
+// Usage returns the command usage
+func (c *Command) Usage() string {
+	// Usage line
+	var buf = &bytes.Buffer{}
+	fmt.Fprintf(buf, "Usage: %s", c.path())
+	var fs = c.Flags()
+	var hasFlags bool
+	fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+	if hasFlags {
+		buf.WriteString(" [flags]")
+	}
+	if len(c.commands) > 0 {
+		if c.Run == nil {
+			buf.WriteString(" <command>")
+		} else {
+			buf.WriteString(" [command]")
+		}
+	}
+	buf.WriteString("\n")
+
+	// Description
+	if c.Description != "" {
+		fmt.Fprintf(buf, "\n%s\n", c.Description)
+	}
+
+	// Commands
+	if len(c.commands) > 0 {
+		buf.WriteString("\nCommands:\n")
+		var w = tabwriter.NewWriter(buf, 0, 0, 2, ' ', 0)
+		for _, sub := range c.commands {
+			fmt.Fprintf(w, "  %s\t%s\n", sub.Name, sub.Description)
+		}
+		w.Flush()
+	}
+
+	// Flags
+	if hasFlags {
+		buf.WriteString("\nFlags:\n")
+		var w = tabwriter.NewWriter(buf, 0, 0, 2, ' ', 0)
+		var p = c.envPrefix()
+		fs.VisitAll(func(f *flag.Flag) {
+			name, usage := flag.UnquoteUsage(f)
+			var s = "  -" + f.Name
+			if name != "" {
+				s += " " + name
+			}
+			s += "\t" + usage
+			if f.DefValue != "" && f.DefValue != "false" {
+				s += fmt.Sprintf(" (default %s)", f.DefValue)
+			}
+			if p != "" {
+				s += fmt.Sprintf(" [$%s]", envName(p, f.Name))
+			}
+			fmt.Fprintln(w, s)
+		})
+		w.Flush()
+	}
+	return buf.String()
+}
+
+// applyEnv sets the flags that are not set from the environment
+func (c *Command) applyEnv() (err error) {
+	// No prefix
+	var p = c.envPrefix()
+	if p == "" {
+		return
+	}
+
+	// Get flags that are set
+	var fs = c.Flags()
+	var set = make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	// Loop through flags
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil || set[f.Name] {
+			return
+		}
+		var n = envName(p, f.Name)
+		if v, ok := os.LookupEnv(n); ok {
+			if errSet := fs.Set(f.Name, v); errSet != nil {
+				err = errors.Wrapf(errSet, "astiflag: setting flag %s of command %s from env variable %s failed", f.Name, c.path(), n)
+			}
+		}
+	})
+	return
+}
+
+// envPrefix returns the environment prefix of the command
+func (c *Command) envPrefix() string {
+	for p := c; p != nil; p = p.parent {
+		if p.EnvPrefix != "" {
+			return p.EnvPrefix
+		}
+	}
+	return ""
+}
+
+// output returns the output of the command
+func (c *Command) output() io.Writer {
+	for p := c; p != nil; p = p.parent {
+		if p.Output != nil {
+			return p.Output
+		}
+	}
+	return os.Stderr
+}
+
+// path returns the names of the command and of its parents
+func (c *Command) path() string {
+	var ns []string
+	for p := c; p != nil; p = p.parent {
+		ns = append([]string{p.Name}, ns...)
+	}
+	return strings.Join(ns, " ")
+}
+
+// writeUsage writes the usage to the output
+func (c *Command) writeUsage() {
+	fmt.Fprint(c.output(), c.Usage())
+}
+
+// envName returns the name of the environment variable of a flag
+func envName(prefix, name string) string {
+	return prefix + strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(name))
+}
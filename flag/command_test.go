@@ -0,0 +1,73 @@
+package astiflag_test
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"testing"
+
+	"github.com/asticode/go-astitools/flag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCommand(t *testing.T) {
+	// Init
+	var buf = &bytes.Buffer{}
+	var root = astiflag.NewCommand("app", "App description", nil)
+	root.EnvPrefix = "ASTIFLAG_TEST_"
+	root.Output = buf
+	var verbose = root.Flags().Bool("v", false, "verbose")
+	var ranArgs []string
+	var sub = root.AddCommand(astiflag.NewCommand("sub", "Sub description", nil))
+	var logLevel = sub.Flags().String("log-level", "info", "log `level`")
+	var run = sub.AddCommand(astiflag.NewCommand("run", "Run description", func(ctx context.Context, args []string) error {
+		ranArgs = args
+		return nil
+	}))
+	var count = run.Flags().Int("count", 1, "count")
+
+	// Nested command with env fallback
+	os.Setenv("ASTIFLAG_TEST_LOG_LEVEL", "debug")
+	defer os.Unsetenv("ASTIFLAG_TEST_LOG_LEVEL")
+	assert.NoError(t, root.Execute(context.Background(), []string{"-v", "sub", "run", "-count", "3", "arg"}))
+	assert.True(t, *verbose)
+	assert.Equal(t, "debug", *logLevel)
+	assert.Equal(t, 3, *count)
+	assert.Equal(t, []string{"arg"}, ranArgs)
+
+	// Flags take precedence over env
+	assert.NoError(t, root.Execute(context.Background(), []string{"sub", "-log-level", "warn", "run"}))
+	assert.Equal(t, "warn", *logLevel)
+
+	// Invalid env
+	var c = astiflag.NewCommand("app", "", func(ctx context.Context, args []string) error { return nil })
+	c.EnvPrefix = "ASTIFLAG_TEST_"
+	c.Output = buf
+	c.Flags().Int("count", 1, "count")
+	os.Setenv("ASTIFLAG_TEST_COUNT", "invalid")
+	defer os.Unsetenv("ASTIFLAG_TEST_COUNT")
+	assert.Error(t, c.Execute(context.Background(), nil))
+
+	// Missing or unknown command
+	buf.Reset()
+	assert.EqualError(t, root.Execute(context.Background(), []string{"sub"}), "astiflag: command app sub requires a nested command")
+	assert.Equal(t, "Usage: app sub [flags] <command>\n\nSub description\n\nCommands:\n  run  Run description\n\nFlags:\n  -log-level level  log level (default info) [$ASTIFLAG_TEST_LOG_LEVEL]\n", buf.String())
+	assert.EqualError(t, root.Execute(context.Background(), []string{"unknown"}), "astiflag: unknown command app unknown")
+
+	// Help
+	assert.Equal(t, flag.ErrHelp, root.Execute(context.Background(), []string{"-h"}))
+}
+
+func TestCommand_StructLiteral(t *testing.T) {
+	var ranArgs []string
+	var c = &astiflag.Command{Name: "app", Output: &bytes.Buffer{}, Run: func(ctx context.Context, args []string) error {
+		ranArgs = args
+		return nil
+	}}
+	assert.Equal(t, "Usage: app\n", c.Usage())
+	var count = c.Flags().Int("count", 1, "count")
+	assert.NoError(t, c.Execute(context.Background(), []string{"-count", "2", "arg"}))
+	assert.Equal(t, 2, *count)
+	assert.Equal(t, []string{"arg"}, ranArgs)
+}
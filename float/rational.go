@@ -0,0 +1,182 @@
+package astifloat
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Rational represents a rational number, such as a timebase or a frame rate
+// It's always reduced and its denominator is always positive. The zero value is 0/1.
+type Rational struct {
+	den int64
+	num int64
+}
+
+// NewRational creates a new reduced rational
+// It panics if den is 0.
+func NewRational(num, den int64) Rational {
+	if den == 0 {
+		panic("astifloat: rational with a zero denominator")
+	}
+	if den < 0 {
+		num, den = -num, -den
+	}
+	var g = gcd(num, den)
+	return Rational{den: den / g, num: num / g}
+}
+
+// ParseRational parses a rational formatted as "num/den" or "num"
+func ParseRational(s string) (r Rational, err error) {
+	// Split
+	var ps = strings.SplitN(s, "/", 2)
+	var num, den int64 = 0, 1
+	if num, err = strconv.ParseInt(strings.TrimSpace(ps[0]), 10, 64); err != nil {
+		err = errors.Wrapf(err, "astifloat: parsing numerator of %s failed", s)
+		return
+	}
+	if len(ps) == 2 {
+		if den, err = strconv.ParseInt(strings.TrimSpace(ps[1]), 10, 64); err != nil {
+			err = errors.Wrapf(err, "astifloat: parsing denominator of %s failed", s)
+			return
+		}
+		if den == 0 {
+			err = errors.Errorf("astifloat: denominator of %s is 0", s)
+			return
+		}
+	}
+	r = NewRational(num, den)
+	return
+}
+
+// RationalFromDuration converts a duration to a rational number of seconds
+func RationalFromDuration(d time.Duration) Rational {
+	return NewRational(int64(d), int64(time.Second))
+}
+
+// gcd returns the greatest common divisor, which is always positive
+func gcd(a, b int64) int64 {
+	if a < 0 {
+		a = -a
+	}
+	if b < 0 {
+		b = -b
+	}
+	for b != 0 {
+		a, b = b, a%b
+	}
+	if a == 0 {
+		return 1
+	}
+	return a
+}
+
+// Den returns the denominator
+func (r Rational) Den() int64 {
+	if r.den == 0 {
+		return 1
+	}
+	return r.den
+}
+
+// Num returns the numerator
+func (r Rational) Num() int64 {
+	return r.num
+}
+
+// Add returns r + o
+func (r Rational) Add(o Rational) Rational {
+	// Reduce denominators first to limit overflows
+	var g = gcd(r.Den(), o.Den())
+	return NewRational(r.num*(o.Den()/g)+o.num*(r.Den()/g), r.Den()/g*o.Den())
+}
+
+// Sub returns r - o
+func (r Rational) Sub(o Rational) Rational {
+	return r.Add(Rational{den: o.Den(), num: -o.num})
+}
+
+// Mul returns r * o
+func (r Rational) Mul(o Rational) Rational {
+	// Cross reduce first to limit overflows
+	var g1, g2 = gcd(r.num, o.Den()), gcd(o.num, r.Den())
+	return NewRational((r.num/g1)*(o.num/g2), (r.Den()/g2)*(o.Den()/g1))
+}
+
+// Div returns r / o
+// It panics if o is 0.
+func (r Rational) Div(o Rational) Rational {
+	return r.Mul(o.Inv())
+}
+
+// Inv returns 1 / r
+// It panics if r is 0.
+func (r Rational) Inv() Rational {
+	return NewRational(r.Den(), r.num)
+}
+
+// Cmp compares r and o and returns -1 if r < o, 0 if r == o and 1 if r > o
+func (r Rational) Cmp(o Rational) int {
+	return r.big().Cmp(o.big())
+}
+
+// Float64 returns the closest float64
+func (r Rational) Float64() float64 {
+	f, _ := r.big().Float64()
+	return f
+}
+
+// Duration converts r, as a number of seconds, to the nearest duration
+func (r Rational) Duration() time.Duration {
+	return time.Duration(r.Scale(int64(time.Second)))
+}
+
+// Scale returns the integer nearest to r * i, rounding halves away from zero
+// It's computed with arbitrary precision, which means it doesn't overflow as long as the result fits in an int64.
+func (r Rational) Scale(i int64) int64 {
+	var n = new(big.Int).Mul(big.NewInt(r.num), big.NewInt(i))
+	var d = big.NewInt(r.Den())
+	var q, m = new(big.Int).QuoRem(n, d, new(big.Int))
+	if new(big.Int).Mul(new(big.Int).Abs(m), big.NewInt(2)).Cmp(d) >= 0 {
+		if n.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q.Int64()
+}
+
+// Timestamp converts a timestamp expressed in the timebase r, e.g. 1/90000, to a duration
+func (r Rational) Timestamp(ts int64) time.Duration {
+	return r.Mul(NewRational(ts, 1)).Duration()
+}
+
+// Rescale converts a timestamp expressed in the timebase r to the timebase o, rounding to the nearest integer
+func (r Rational) Rescale(ts int64, o Rational) int64 {
+	return r.Div(o).Scale(ts)
+}
+
+// String implements the fmt.Stringer interface
+func (r Rational) String() string {
+	return strconv.FormatInt(r.num, 10) + "/" + strconv.FormatInt(r.Den(), 10)
+}
+
+// big returns the rational as a *big.Rat
+func (r Rational) big() *big.Rat {
+	return big.NewRat(r.num, r.Den())
+}
+
+// MarshalText implements the TextMarshaler interface
+func (r Rational) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// UnmarshalText implements the TextUnmarshaler interface
+func (r *Rational) UnmarshalText(text []byte) (err error) {
+	*r, err = ParseRational(string(text))
+	return
+}
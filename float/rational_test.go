@@ -0,0 +1,58 @@
+package astifloat_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/float"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRational(t *testing.T) {
+	// Reduction
+	var r = astifloat.NewRational(6, -4)
+	assert.Equal(t, int64(-3), r.Num())
+	assert.Equal(t, int64(2), r.Den())
+	assert.Equal(t, "-3/2", r.String())
+	assert.Equal(t, "0/1", astifloat.Rational{}.String())
+
+	// Arithmetic
+	var a, b = astifloat.NewRational(1, 3), astifloat.NewRational(1, 6)
+	assert.Equal(t, astifloat.NewRational(1, 2), a.Add(b))
+	assert.Equal(t, astifloat.NewRational(1, 6), a.Sub(b))
+	assert.Equal(t, astifloat.NewRational(1, 18), a.Mul(b))
+	assert.Equal(t, astifloat.NewRational(2, 1), a.Div(b))
+	assert.Equal(t, 1, a.Cmp(b))
+	assert.Equal(t, -1, b.Cmp(a))
+	assert.Equal(t, 0, a.Cmp(astifloat.NewRational(2, 6)))
+	assert.Equal(t, 0.5, astifloat.NewRational(1, 2).Float64())
+
+	// No drift when accumulating
+	var fps = astifloat.NewRational(1001, 30000)
+	var total astifloat.Rational
+	for i := 0; i < 30000; i++ {
+		total = total.Add(fps)
+	}
+	assert.Equal(t, astifloat.NewRational(1001, 1), total)
+	assert.Equal(t, 1001*time.Second, total.Duration())
+
+	// Timestamps
+	var tb = astifloat.NewRational(1, 90000)
+	assert.Equal(t, 2*time.Second, tb.Timestamp(180000))
+	assert.Equal(t, time.Duration(11111), tb.Timestamp(1))
+	assert.Equal(t, int64(2000), tb.Rescale(180000, astifloat.NewRational(1, 1000)))
+	assert.Equal(t, int64(-1), astifloat.NewRational(-1, 2).Scale(1))
+	assert.Equal(t, astifloat.NewRational(3, 2), astifloat.RationalFromDuration(1500*time.Millisecond))
+
+	// Parse
+	r, err := astifloat.ParseRational("30000/1001")
+	assert.NoError(t, err)
+	assert.Equal(t, astifloat.NewRational(30000, 1001), r)
+	r, err = astifloat.ParseRational("25")
+	assert.NoError(t, err)
+	assert.Equal(t, astifloat.NewRational(25, 1), r)
+	_, err = astifloat.ParseRational("1/0")
+	assert.Error(t, err)
+	assert.NoError(t, r.UnmarshalText([]byte("1/2")))
+	assert.Equal(t, astifloat.NewRational(1, 2), r)
+}
@@ -0,0 +1,506 @@
+package astihttp
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/asticode/go-astitools/os"
+	"github.com/asticode/go-astitools/time"
+	"github.com/pkg/errors"
+)
+
+// Cache statuses, set in the CacheStatusHeader of responses returned by the cache
+const (
+	CacheStatusHit         = "HIT"
+	CacheStatusMiss        = "MISS"
+	CacheStatusRevalidated = "REVALIDATED"
+)
+
+// CacheStatusHeader is the header in which the cache status of a response is set
+const CacheStatusHeader = "X-Astihttp-Cache"
+
+// CacheOptions represents cache options
+type CacheOptions struct {
+	// Defaults to astitime.RealClock
+	Clock astitime.Clock
+	// Responses whose body is bigger than this number of bytes are not stored. 0 means no maximum.
+	MaxBodySize int64
+	// Defaults to a memory storage
+	Storage CacheStorage
+	// Transport used to send real requests. Defaults to http.DefaultTransport.
+	Transport http.RoundTripper
+}
+
+// CacheEntry represents a cached response
+type CacheEntry struct {
+	Body       []byte      `json:"body,omitempty"`
+	Headers    http.Header `json:"headers,omitempty"`
+	StatusCode int         `json:"status_code"`
+	StoredAt   time.Time   `json:"stored_at"`
+	// Values of the request headers listed in the response's Vary header
+	Vary http.Header `json:"vary,omitempty"`
+}
+
+// CacheStorage represents an object capable of storing cache entries
+type CacheStorage interface {
+	Delete(key string) error
+	Get(key string) (e CacheEntry, ok bool, err error)
+	Set(key string, e CacheEntry) error
+}
+
+// Cache is an http.RoundTripper caching GET responses in a private cache that honors Cache-Control, Expires, ETag
+// and Last-Modified. Stale entries with validators are revalidated with conditional requests. Use it as the
+// transport of the Sender's client, which also makes it available to the Downloader when it downloads in one go:
+// range requests are never cached.
+type Cache struct {
+	c astitime.Clock
+	o CacheOptions
+}
+
+// NewCache creates a new cache
+func NewCache(o CacheOptions) *Cache {
+	// Default options values
+	if o.Clock == nil {
+		o.Clock = astitime.RealClock{}
+	}
+	if o.Storage == nil {
+		o.Storage = NewMemoryCacheStorage()
+	}
+	if o.Transport == nil {
+		o.Transport = http.DefaultTransport
+	}
+	return &Cache{
+		c: o.Clock,
+		o: o,
+	}
+}
+
+// Client returns an http.Client using the cache as transport
+func (c *Cache) Client() *http.Client {
+	return &http.Client{Transport: c}
+}
+
+// RoundTrip implements the http.RoundTripper interface
+func (c *Cache) RoundTrip(req *http.Request) (resp *http.Response, err error) {
+	// Unsafe methods invalidate the cached response
+	var key = cacheKey(req)
+	if req.Method != http.MethodGet {
+		if resp, err = c.o.Transport.RoundTrip(req); err != nil {
+			return
+		}
+		if req.Method != http.MethodHead && req.Method != http.MethodOptions && resp.StatusCode < 400 {
+			if err = c.o.Storage.Delete(key); err != nil {
+				resp.Body.Close()
+				err = errors.Wrapf(err, "astihttp: deleting cache entry of %s failed", req.URL)
+				return
+			}
+		}
+		return
+	}
+
+	// Request can't be served from or stored in the cache
+	var rcc = parseCacheControl(req.Header)
+	if _, ok := rcc["no-store"]; ok || req.Header.Get("Range") != "" || req.Header.Get("If-None-Match") != "" ||
+		req.Header.Get("If-Modified-Since") != "" {
+		return c.o.Transport.RoundTrip(req)
+	}
+
+	// Get entry
+	var e CacheEntry
+	var ok bool
+	if e, ok, err = c.o.Storage.Get(key); err != nil {
+		err = errors.Wrapf(err, "astihttp: getting cache entry of %s failed", req.URL)
+		return
+	} else if ok && !e.matchesVary(req) {
+		ok = false
+	}
+
+	// Entry is fresh
+	if ok && !requiresRevalidation(rcc) && c.freshness(e) > c.age(e) {
+		return e.response(req, CacheStatusHit), nil
+	}
+
+	// Add validators
+	var r = req
+	if ok {
+		if etag, lastModified := e.Headers.Get("ETag"), e.Headers.Get("Last-Modified"); etag != "" || lastModified != "" {
+			r = req.Clone(req.Context())
+			if etag != "" {
+				r.Header.Set("If-None-Match", etag)
+			}
+			if lastModified != "" {
+				r.Header.Set("If-Modified-Since", lastModified)
+			}
+		}
+	}
+
+	// Send request
+	if resp, err = c.o.Transport.RoundTrip(r); err != nil {
+		return
+	}
+
+	// Entry has been revalidated
+	if ok && resp.StatusCode == http.StatusNotModified {
+		// Close body
+		resp.Body.Close()
+
+		// Update entry
+		// Headers are cloned since they may be shared with the storage and concurrent requests
+		e.Headers = e.Headers.Clone()
+		if e.Headers == nil {
+			e.Headers = make(http.Header)
+		}
+		for k, vs := range resp.Header {
+			e.Headers[k] = vs
+		}
+		e.StoredAt = c.c.Now()
+		if err = c.o.Storage.Set(key, e); err != nil {
+			err = errors.Wrapf(err, "astihttp: setting cache entry of %s failed", req.URL)
+			return
+		}
+		return e.response(req, CacheStatusRevalidated), nil
+	}
+
+	// Response can't be stored
+	resp.Header.Set(CacheStatusHeader, CacheStatusMiss)
+	if !c.storable(resp) {
+		return
+	}
+
+	// Store response once its body has been read entirely
+	resp.Body = &cacheBody{
+		c:   c,
+		key: key,
+		rc:  resp.Body,
+		req: req,
+		resp: CacheEntry{
+			Headers:    resp.Header.Clone(),
+			StatusCode: resp.StatusCode,
+		},
+	}
+	return
+}
+
+// cacheKey returns the key of the cache entry of a request
+func cacheKey(req *http.Request) string {
+	return req.URL.String()
+}
+
+// requiresRevalidation checks whether request directives prevent serving the entry without revalidation
+func requiresRevalidation(rcc map[string]string) bool {
+	if _, ok := rcc["no-cache"]; ok {
+		return true
+	}
+	if v, ok := rcc["max-age"]; ok && v == "0" {
+		return true
+	}
+	return false
+}
+
+// storable checks whether a response can be stored
+func (c *Cache) storable(resp *http.Response) bool {
+	// Check status code
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNonAuthoritativeInfo, http.StatusMovedPermanently, http.StatusNotFound, http.StatusGone:
+	default:
+		return false
+	}
+
+	// Check directives
+	var cc = parseCacheControl(resp.Header)
+	if _, ok := cc["no-store"]; ok {
+		return false
+	}
+	if strings.TrimSpace(resp.Header.Get("Vary")) == "*" {
+		return false
+	}
+
+	// Check size
+	if c.o.MaxBodySize > 0 && resp.ContentLength > c.o.MaxBodySize {
+		return false
+	}
+
+	// Response must either have a freshness lifetime or validators
+	if _, ok := cc["max-age"]; ok {
+		return true
+	}
+	return resp.Header.Get("Expires") != "" || resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != ""
+}
+
+// age returns the current age of an entry
+func (c *Cache) age(e CacheEntry) (d time.Duration) {
+	d = c.c.Now().Sub(e.StoredAt)
+	if i, err := strconv.Atoi(e.Headers.Get("Age")); err == nil && i > 0 {
+		d += time.Duration(i) * time.Second
+	}
+	return
+}
+
+// freshness returns the freshness lifetime of an entry
+func (c *Cache) freshness(e CacheEntry) time.Duration {
+	// Check directives
+	var cc = parseCacheControl(e.Headers)
+	if _, ok := cc["no-cache"]; ok {
+		return 0
+	}
+	if v, ok := cc["max-age"]; ok {
+		if i, err := strconv.Atoi(v); err == nil {
+			return time.Duration(i) * time.Second
+		}
+		return 0
+	}
+
+	// Get date
+	var date = e.StoredAt
+	if t, err := http.ParseTime(e.Headers.Get("Date")); err == nil {
+		date = t
+	}
+
+	// Check expires
+	if v := e.Headers.Get("Expires"); v != "" {
+		t, err := http.ParseTime(v)
+		if err != nil {
+			return 0
+		}
+		return t.Sub(date)
+	}
+
+	// Use 10% of the time since the last modification as heuristic
+	if t, err := http.ParseTime(e.Headers.Get("Last-Modified")); err == nil && date.After(t) {
+		return date.Sub(t) / 10
+	}
+	return 0
+}
+
+// parseCacheControl parses the Cache-Control header
+func parseCacheControl(h http.Header) (o map[string]string) {
+	o = make(map[string]string)
+	for _, v := range h.Values("Cache-Control") {
+		for _, d := range strings.Split(v, ",") {
+			if d = strings.TrimSpace(d); d == "" {
+				continue
+			}
+			var k, v = d, ""
+			if idx := strings.Index(d, "="); idx >= 0 {
+				k, v = d[:idx], strings.Trim(d[idx+1:], "\"")
+			}
+			o[strings.ToLower(strings.TrimSpace(k))] = v
+		}
+	}
+	return
+}
+
+// matchesVary checks whether the request headers listed in the Vary header match the stored ones
+func (e CacheEntry) matchesVary(req *http.Request) bool {
+	for k, vs := range e.Vary {
+		if strings.Join(req.Header.Values(k), ", ") != strings.Join(vs, ", ") {
+			return false
+		}
+	}
+	return true
+}
+
+// copy returns a deep copy of an entry
+func (e CacheEntry) copy() CacheEntry {
+	if e.Body != nil {
+		e.Body = append([]byte(nil), e.Body...)
+	}
+	e.Headers = e.Headers.Clone()
+	e.Vary = e.Vary.Clone()
+	return e
+}
+
+// response creates a response out of an entry
+func (e CacheEntry) response(req *http.Request, status string) (resp *http.Response) {
+	resp = &http.Response{
+		Body:          ioutil.NopCloser(bytes.NewReader(e.Body)),
+		ContentLength: int64(len(e.Body)),
+		Header:        e.Headers.Clone(),
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Request:       req,
+		Status:        strconv.Itoa(e.StatusCode) + " " + http.StatusText(e.StatusCode),
+		StatusCode:    e.StatusCode,
+	}
+	if resp.Header == nil {
+		resp.Header = make(http.Header)
+	}
+	resp.Header.Set(CacheStatusHeader, status)
+	return
+}
+
+// cacheBody buffers a response body and stores the response once the body has been read entirely
+type cacheBody struct {
+	buf      bytes.Buffer
+	c        *Cache
+	key      string
+	rc       io.ReadCloser
+	req      *http.Request
+	resp     CacheEntry
+	tooLarge bool
+}
+
+// Read implements the io.Reader interface
+func (b *cacheBody) Read(p []byte) (n int, err error) {
+	// Read
+	n, err = b.rc.Read(p)
+
+	// Buffer
+	if n > 0 && !b.tooLarge {
+		if b.c.o.MaxBodySize > 0 && int64(b.buf.Len()+n) > b.c.o.MaxBodySize {
+			b.tooLarge = true
+			b.buf = bytes.Buffer{}
+		} else {
+			b.buf.Write(p[:n])
+		}
+	}
+
+	// Store
+	if err == io.EOF && !b.tooLarge {
+		b.resp.Body = b.buf.Bytes()
+		b.resp.StoredAt = b.c.c.Now()
+		b.resp.Headers.Del(CacheStatusHeader)
+		for _, k := range strings.Split(b.resp.Headers.Get("Vary"), ",") {
+			if k = http.CanonicalHeaderKey(strings.TrimSpace(k)); k != "" {
+				if b.resp.Vary == nil {
+					b.resp.Vary = make(http.Header)
+				}
+				b.resp.Vary[k] = b.req.Header.Values(k)
+			}
+		}
+		if errSet := b.c.o.Storage.Set(b.key, b.resp); errSet != nil {
+			err = errors.Wrapf(errSet, "astihttp: setting cache entry of %s failed", b.req.URL)
+		}
+		b.tooLarge = true
+	}
+	return
+}
+
+// Close implements the io.Closer interface
+func (b *cacheBody) Close() error {
+	return b.rc.Close()
+}
+
+// MemoryCacheStorage represents a cache storage keeping entries in memory
+// Entries are copied on Get and Set so that callers never share them with other requests.
+type MemoryCacheStorage struct {
+	es map[string]CacheEntry
+	m  *sync.Mutex // Locks es
+}
+
+// NewMemoryCacheStorage creates a new memory cache storage
+func NewMemoryCacheStorage() *MemoryCacheStorage {
+	return &MemoryCacheStorage{
+		es: make(map[string]CacheEntry),
+		m:  &sync.Mutex{},
+	}
+}
+
+// Delete implements the CacheStorage interface
+func (s *MemoryCacheStorage) Delete(key string) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	delete(s.es, key)
+	return nil
+}
+
+// Get implements the CacheStorage interface
+func (s *MemoryCacheStorage) Get(key string) (e CacheEntry, ok bool, err error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if e, ok = s.es[key]; ok {
+		e = e.copy()
+	}
+	return
+}
+
+// Set implements the CacheStorage interface
+func (s *MemoryCacheStorage) Set(key string, e CacheEntry) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.es[key] = e.copy()
+	return nil
+}
+
+// DiskCacheStorage represents a cache storage keeping entries as JSON files in a directory
+type DiskCacheStorage struct {
+	dir string
+}
+
+// NewDiskCacheStorage creates a new disk cache storage and its directory
+func NewDiskCacheStorage(dir string) (s *DiskCacheStorage, err error) {
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		err = errors.Wrapf(err, "astihttp: mkdirall %s failed", dir)
+		return
+	}
+	s = &DiskCacheStorage{dir: dir}
+	return
+}
+
+// path returns the path of the file an entry is stored in
+func (s *DiskCacheStorage) path(key string) string {
+	var h = sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(h[:])+".json")
+}
+
+// Delete implements the CacheStorage interface
+func (s *DiskCacheStorage) Delete(key string) (err error) {
+	if err = os.Remove(s.path(key)); err != nil && !os.IsNotExist(err) {
+		err = errors.Wrapf(err, "astihttp: removing %s failed", s.path(key))
+		return
+	}
+	err = nil
+	return
+}
+
+// Get implements the CacheStorage interface
+func (s *DiskCacheStorage) Get(key string) (e CacheEntry, ok bool, err error) {
+	// Read
+	var b []byte
+	if b, err = ioutil.ReadFile(s.path(key)); err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+			return
+		}
+		err = errors.Wrapf(err, "astihttp: reading %s failed", s.path(key))
+		return
+	}
+
+	// Unmarshal
+	if err = json.Unmarshal(b, &e); err != nil {
+		err = errors.Wrapf(err, "astihttp: unmarshaling %s failed", s.path(key))
+		return
+	}
+	ok = true
+	return
+}
+
+// Set implements the CacheStorage interface
+// Entries are written atomically, see astios.WriteFileAtomic, so that concurrent readers never see partial entries.
+func (s *DiskCacheStorage) Set(key string, e CacheEntry) (err error) {
+	// Marshal
+	var b []byte
+	if b, err = json.Marshal(e); err != nil {
+		err = errors.Wrap(err, "astihttp: marshaling cache entry failed")
+		return
+	}
+
+	// Write
+	if err = astios.WriteFileAtomic(s.path(key), b, 0644); err != nil {
+		err = errors.Wrapf(err, "astihttp: writing %s failed", s.path(key))
+		return
+	}
+	return
+}
@@ -0,0 +1,183 @@
+package astihttp_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/http"
+	"github.com/asticode/go-astitools/time"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	// Create server
+	var count, revalidated int32
+	s := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		switch r.URL.Path {
+		case "/max-age":
+			rw.Header().Set("Cache-Control", "max-age=60")
+		case "/etag":
+			if r.Header.Get("If-None-Match") == `"v1"` {
+				atomic.AddInt32(&revalidated, 1)
+				rw.WriteHeader(http.StatusNotModified)
+				return
+			}
+			rw.Header().Set("Cache-Control", "no-cache")
+			rw.Header().Set("ETag", `"v1"`)
+		case "/no-store":
+			rw.Header().Set("Cache-Control", "no-store")
+		}
+		if r.Method == http.MethodPost {
+			return
+		}
+		rw.Write([]byte("body " + r.URL.Path))
+	}))
+	defer s.Close()
+
+	// Loop through storages
+	ds, err := astihttp.NewDiskCacheStorage(t.TempDir())
+	assert.NoError(t, err)
+	for _, st := range []astihttp.CacheStorage{astihttp.NewMemoryCacheStorage(), ds} {
+		// Reset
+		atomic.StoreInt32(&count, 0)
+		atomic.StoreInt32(&revalidated, 0)
+
+		// Create cache
+		clk := astitime.NewFakeClock(time.Now())
+		c := astihttp.NewCache(astihttp.CacheOptions{
+			Clock:   clk,
+			Storage: st,
+		})
+		var send = func(method, path string) (status, body string) {
+			req, _ := http.NewRequest(method, s.URL+path, nil)
+			resp, err := astihttp.NewSender(astihttp.SenderOptions{Client: c.Client()}).Send(req)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, strconv.Itoa(resp.StatusCode)+" "+http.StatusText(resp.StatusCode), resp.Status)
+			b, _ := ioutil.ReadAll(resp.Body)
+			return resp.Header.Get(astihttp.CacheStatusHeader), string(b)
+		}
+
+		// Fresh
+		status, body := send(http.MethodGet, "/max-age")
+		assert.Equal(t, astihttp.CacheStatusMiss, status)
+		assert.Equal(t, "body /max-age", body)
+		status, body = send(http.MethodGet, "/max-age")
+		assert.Equal(t, astihttp.CacheStatusHit, status)
+		assert.Equal(t, "body /max-age", body)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+
+		// Stale
+		clk.Add(time.Minute)
+		status, _ = send(http.MethodGet, "/max-age")
+		assert.Equal(t, astihttp.CacheStatusMiss, status)
+		assert.Equal(t, int32(2), atomic.LoadInt32(&count))
+
+		// Invalidate
+		send(http.MethodPost, "/max-age")
+		status, _ = send(http.MethodGet, "/max-age")
+		assert.Equal(t, astihttp.CacheStatusMiss, status)
+		assert.Equal(t, int32(4), atomic.LoadInt32(&count))
+
+		// Revalidate
+		status, _ = send(http.MethodGet, "/etag")
+		assert.Equal(t, astihttp.CacheStatusMiss, status)
+		status, body = send(http.MethodGet, "/etag")
+		assert.Equal(t, astihttp.CacheStatusRevalidated, status)
+		assert.Equal(t, "body /etag", body)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&revalidated))
+
+		// No store
+		send(http.MethodGet, "/no-store")
+		status, _ = send(http.MethodGet, "/no-store")
+		assert.Equal(t, astihttp.CacheStatusMiss, status)
+		assert.Equal(t, int32(8), atomic.LoadInt32(&count))
+	}
+}
+
+func TestCacheMaxBodySize(t *testing.T) {
+	// Create server
+	var count int32
+	s := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		rw.Header().Set("Cache-Control", "max-age=60")
+		rw.Write([]byte(strings.Repeat("a", 10)))
+	}))
+	defer s.Close()
+
+	// Send
+	c := astihttp.NewCache(astihttp.CacheOptions{MaxBodySize: 5})
+	for i := 0; i < 2; i++ {
+		resp, err := c.Client().Get(s.URL)
+		assert.NoError(t, err)
+		b, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		assert.Len(t, b, 10)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&count))
+}
+
+func TestCacheParallelRevalidations(t *testing.T) {
+	// Create server
+	s := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Cache-Control", "no-cache")
+		rw.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			rw.Header().Set("X-Revalidated", "true")
+			rw.WriteHeader(http.StatusNotModified)
+			return
+		}
+		rw.Write([]byte("body"))
+	}))
+	defer s.Close()
+
+	// Store entry
+	c := astihttp.NewCache(astihttp.CacheOptions{})
+	resp, err := c.Client().Get(s.URL)
+	assert.NoError(t, err)
+	ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	// Revalidate in parallel
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := c.Client().Get(s.URL)
+			assert.NoError(t, err)
+			defer resp.Body.Close()
+			b, _ := ioutil.ReadAll(resp.Body)
+			assert.Equal(t, "body", string(b))
+			assert.Equal(t, astihttp.CacheStatusRevalidated, resp.Header.Get(astihttp.CacheStatusHeader))
+			assert.Equal(t, "true", resp.Header.Get("X-Revalidated"))
+		}()
+	}
+	wg.Wait()
+}
+
+func TestDiskCacheStorage_ConcurrentSets(t *testing.T) {
+	s, err := astihttp.NewDiskCacheStorage(t.TempDir())
+	assert.NoError(t, err)
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			assert.NoError(t, s.Set("key", astihttp.CacheEntry{Body: []byte(strings.Repeat("a", 1<<16)), StatusCode: 200 + i}))
+		}(i)
+	}
+	wg.Wait()
+	e, ok, err := s.Get("key")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Len(t, e.Body, 1<<16)
+}
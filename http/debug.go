@@ -0,0 +1,62 @@
+package astihttp
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+	"strings"
+
+	"github.com/asticode/go-astitools/stat"
+)
+
+// DebugHandlerOptions represents debug handler options
+type DebugHandlerOptions struct {
+	// Basic auth is enabled when both username and password are set
+	Password string
+	// Defaults to "/debug"
+	Prefix string
+	// If set, its last stats are exposed in the Prometheus text format
+	Stater *astistat.Stater
+	// Namespace of the Prometheus metrics
+	StatsNamespace string
+	Username       string
+}
+
+// DebugHandler returns a handler serving, under the prefix:
+//   - /pprof/ the net/http/pprof profiles
+//   - /vars the expvar variables
+//   - /stats the stater's stats, if any
+//
+// The handler can be chained with other middlewares, see ChainMiddlewares.
+func DebugHandler(o DebugHandlerOptions) http.Handler {
+	// Default options values
+	if o.Prefix == "" {
+		o.Prefix = "/debug"
+	}
+	o.Prefix = strings.TrimSuffix(o.Prefix, "/")
+
+	// Create mux
+	var m = http.NewServeMux()
+	m.HandleFunc(o.Prefix+"/pprof/", func(rw http.ResponseWriter, r *http.Request) {
+		// pprof.Index expects profiles to be served under /debug/pprof/ which is why profiles are dispatched here
+		switch n := strings.TrimPrefix(r.URL.Path, o.Prefix+"/pprof/"); n {
+		case "":
+			pprof.Index(rw, r)
+		case "cmdline":
+			pprof.Cmdline(rw, r)
+		case "profile":
+			pprof.Profile(rw, r)
+		case "symbol":
+			pprof.Symbol(rw, r)
+		case "trace":
+			pprof.Trace(rw, r)
+		default:
+			pprof.Handler(n).ServeHTTP(rw, r)
+		}
+	})
+	m.Handle(o.Prefix+"/vars", expvar.Handler())
+	if o.Stater != nil {
+		m.Handle(o.Prefix+"/stats", astistat.PrometheusHandler(o.Stater, o.StatsNamespace))
+	}
+	return ChainMiddlewares(m, MiddlewareBasicAuth(o.Username, o.Password))
+}
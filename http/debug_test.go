@@ -0,0 +1,47 @@
+package astihttp_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/http"
+	"github.com/asticode/go-astitools/stat"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebugHandler(t *testing.T) {
+	s := httptest.NewServer(astihttp.DebugHandler(astihttp.DebugHandlerOptions{
+		Password: "password",
+		Prefix:   "/admin/debug/",
+		Stater:   astistat.NewStater(time.Second, nil),
+		Username: "username",
+	}))
+	defer s.Close()
+	var get = func(path string, auth bool) (code int, body string) {
+		req, _ := http.NewRequest(http.MethodGet, s.URL+path, nil)
+		if auth {
+			req.SetBasicAuth("username", "password")
+		}
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		defer resp.Body.Close()
+		b, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, string(b)
+	}
+	code, _ := get("/admin/debug/vars", false)
+	assert.Equal(t, http.StatusUnauthorized, code)
+	code, b := get("/admin/debug/vars", true)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, b, "memstats")
+	code, b = get("/admin/debug/pprof/", true)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, b, "goroutine")
+	code, b = get("/admin/debug/pprof/goroutine?debug=1", true)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, b, "goroutine profile")
+	code, _ = get("/admin/debug/stats", true)
+	assert.Equal(t, http.StatusOK, code)
+}
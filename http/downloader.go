@@ -0,0 +1,372 @@
+package astihttp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/asticode/go-astitools/io"
+	"github.com/pkg/errors"
+)
+
+// Downloader errors
+var (
+	ErrRangeNotSupported = errors.New("astihttp: range requests are not supported")
+	ErrRemoteChanged     = errors.New("astihttp: remote content has changed")
+)
+
+// Downloader represents an object capable of downloading content in parallel chunks and resuming partial downloads
+type Downloader struct {
+	o DownloaderOptions
+}
+
+// DownloaderOptions represents downloader options
+type DownloaderOptions struct {
+	// Size in bytes of each chunk. Defaults to 10MB
+	ChunkSize int64
+	// Number of chunks downloaded in parallel. Defaults to 1
+	Concurrency int
+	// Progress is called every time content is written to the disk
+	Progress func(p DownloadProgress)
+	// Defaults to a sender with default options
+	Sender *Sender
+}
+
+// DownloadProgress represents a download progress
+type DownloadProgress struct {
+	// Number of bytes written to the disk, including bytes downloaded in a previous attempt
+	Downloaded int64
+	// Total number of bytes, -1 if unknown
+	Total int64
+}
+
+// NewDownloader creates a new downloader
+func NewDownloader(o DownloaderOptions) *Downloader {
+	// Default options values
+	if o.ChunkSize <= 0 {
+		o.ChunkSize = 10 << 20
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = 1
+	}
+	if o.Sender == nil {
+		o.Sender = NewSender(SenderOptions{})
+	}
+	return &Downloader{o: o}
+}
+
+// downloadState represents the state of a partial download persisted next to its destination
+type downloadState struct {
+	Chunks map[int64]bool `json:"chunks"`
+	ETag   string         `json:"etag"`
+	Size   int64          `json:"size"`
+}
+
+// Download downloads a src into a dst
+// Content is first written to dst.part and the download state to dst.state so that an interrupted download can be
+// resumed by calling Download again. If the server doesn't support range requests, the content is downloaded in one
+// go and can't be resumed.
+func (d *Downloader) Download(ctx context.Context, src, dst string) (err error) {
+	// Get remote info
+	var size int64
+	var etag string
+	if size, etag, err = d.head(ctx, src); err != nil {
+		if err != ErrRangeNotSupported {
+			return
+		}
+		return d.downloadFull(ctx, src, dst)
+	}
+
+	// Load state
+	var partPath, statePath = dst + ".part", dst + ".state"
+	var s = d.loadState(statePath, size, etag)
+
+	// Open part file
+	var f *os.File
+	if f, err = os.OpenFile(partPath, os.O_RDWR|os.O_CREATE, 0644); err != nil {
+		return errors.Wrapf(err, "astihttp: opening file %s failed", partPath)
+	}
+	defer f.Close()
+
+	// Resize part file
+	if err = f.Truncate(size); err != nil {
+		return errors.Wrapf(err, "astihttp: truncating file %s failed", partPath)
+	}
+
+	// Get chunks left to download and initial progress
+	var p = newProgress(d.progressFunc(), size)
+	var offsets []int64
+	for o := int64(0); o < size; o += d.o.ChunkSize {
+		if s.Chunks[o] {
+			p.done += d.chunkLength(o, size)
+		} else {
+			offsets = append(offsets, o)
+		}
+	}
+	p.report()
+
+	// Download chunks
+	if err = d.downloadChunks(ctx, src, f, s, statePath, offsets, p); err != nil {
+		return
+	}
+
+	// Close part file
+	if err = f.Close(); err != nil {
+		return errors.Wrapf(err, "astihttp: closing file %s failed", partPath)
+	}
+
+	// Rename part file
+	if err = os.Rename(partPath, dst); err != nil {
+		return errors.Wrapf(err, "astihttp: renaming %s into %s failed", partPath, dst)
+	}
+
+	// Remove state
+	if err = os.Remove(statePath); err != nil && !os.IsNotExist(err) {
+		return errors.Wrapf(err, "astihttp: removing %s failed", statePath)
+	}
+	return nil
+}
+
+// head retrieves the size and the etag of the remote content
+// It returns ErrRangeNotSupported if the server doesn't support range requests or doesn't provide the content length
+func (d *Downloader) head(ctx context.Context, src string) (size int64, etag string, err error) {
+	// Create request
+	var req *http.Request
+	if req, err = http.NewRequest(http.MethodHead, src, nil); err != nil {
+		err = errors.Wrapf(err, "astihttp: creating HEAD request to %s failed", src)
+		return
+	}
+
+	// Send request
+	var resp *http.Response
+	if resp, err = d.o.Sender.Send(req.WithContext(ctx)); err != nil {
+		return
+	}
+	resp.Body.Close()
+
+	// Range requests are not supported
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Accept-Ranges") != "bytes" || resp.ContentLength < 0 {
+		err = ErrRangeNotSupported
+		return
+	}
+	return resp.ContentLength, resp.Header.Get("ETag"), nil
+}
+
+// downloadFull downloads the whole content in one go
+func (d *Downloader) downloadFull(ctx context.Context, src, dst string) (err error) {
+	// Create request
+	var req *http.Request
+	if req, err = http.NewRequest(http.MethodGet, src, nil); err != nil {
+		return errors.Wrapf(err, "astihttp: creating GET request to %s failed", src)
+	}
+
+	// Send request
+	var resp *http.Response
+	if resp, err = d.o.Sender.Send(req.WithContext(ctx)); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	// Validate status code
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("astihttp: getting %s returned %d status code", src, resp.StatusCode)
+	}
+
+	// Create the dst file
+	var f *os.File
+	if f, err = os.Create(dst); err != nil {
+		return errors.Wrapf(err, "astihttp: creating file %s failed", dst)
+	}
+	defer f.Close()
+
+	// Copy
+	var p = newProgress(d.progressFunc(), resp.ContentLength)
+	if _, err = astiio.Copy(ctx, resp.Body, &progressWriter{p: p, w: f}); err != nil {
+		return errors.Wrapf(err, "astihttp: copying content from %s to %s failed", src, dst)
+	}
+	return
+}
+
+// downloadChunks downloads chunks in parallel and persists the state every time a chunk is over
+func (d *Downloader) downloadChunks(ctx context.Context, src string, f *os.File, s *downloadState, statePath string, offsets []int64, p *progress) (err error) {
+	// Create context so that all chunks stop as soon as one of them fails
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Feed offsets
+	var chanOffsets = make(chan int64)
+	go func() {
+		defer close(chanOffsets)
+		for _, o := range offsets {
+			select {
+			case chanOffsets <- o:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// Loop through workers
+	var m sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < d.o.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for o := range chanOffsets {
+				// Download chunk
+				if e := d.downloadChunk(ctx, src, f, o, s.Size, s.ETag, p); e != nil {
+					m.Lock()
+					if err == nil {
+						err = e
+					}
+					m.Unlock()
+					cancel()
+					return
+				}
+
+				// Make sure the chunk is on the disk before persisting the state
+				if e := f.Sync(); e != nil {
+					m.Lock()
+					if err == nil {
+						err = errors.Wrapf(e, "astihttp: syncing %s failed", f.Name())
+					}
+					m.Unlock()
+					cancel()
+					return
+				}
+
+				// Persist state
+				m.Lock()
+				s.Chunks[o] = true
+				e := d.saveState(statePath, s)
+				if e != nil && err == nil {
+					err = e
+				}
+				m.Unlock()
+				if e != nil {
+					cancel()
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return
+}
+
+// downloadChunk downloads a chunk starting at offset o
+func (d *Downloader) downloadChunk(ctx context.Context, src string, f *os.File, o, size int64, etag string, p *progress) (err error) {
+	// Create request
+	var req *http.Request
+	if req, err = http.NewRequest(http.MethodGet, src, nil); err != nil {
+		return errors.Wrapf(err, "astihttp: creating GET request to %s failed", src)
+	}
+	var l = d.chunkLength(o, size)
+	req.Header.Set("Range", "bytes="+strconv.FormatInt(o, 10)+"-"+strconv.FormatInt(o+l-1, 10))
+	if etag != "" && !isWeakETag(etag) {
+		// Weak etags never satisfy If-Range
+		req.Header.Set("If-Range", etag)
+	}
+
+	// Send request
+	var resp *http.Response
+	if resp, err = d.o.Sender.Send(req.WithContext(ctx)); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	// Validate response
+	if resp.StatusCode == http.StatusOK {
+		return ErrRemoteChanged
+	} else if resp.StatusCode != http.StatusPartialContent {
+		return fmt.Errorf("astihttp: getting range %s of %s returned %d status code", req.Header.Get("Range"), src, resp.StatusCode)
+	} else if resp.ContentLength != l {
+		return fmt.Errorf("astihttp: getting range %s of %s returned %d bytes instead of %d", req.Header.Get("Range"), src, resp.ContentLength, l)
+	} else if etag != "" && resp.Header.Get("ETag") != "" && !etagsMatch(resp.Header.Get("ETag"), etag) {
+		return ErrRemoteChanged
+	}
+
+	// Copy
+	var w = &progressWriter{p: p, w: &offsetWriter{f: f, o: o}}
+	if _, err = astiio.Copy(ctx, resp.Body, w); err != nil {
+		return errors.Wrapf(err, "astihttp: copying range %s of %s failed", req.Header.Get("Range"), src)
+	}
+	return
+}
+
+// isWeakETag checks whether an etag is weak
+func isWeakETag(etag string) bool {
+	return strings.HasPrefix(etag, "W/")
+}
+
+// etagsMatch checks whether two etags match using the weak comparison
+func etagsMatch(a, b string) bool {
+	return strings.TrimPrefix(a, "W/") == strings.TrimPrefix(b, "W/")
+}
+
+// chunkLength returns the length of the chunk starting at offset o
+func (d *Downloader) chunkLength(o, size int64) int64 {
+	if o+d.o.ChunkSize > size {
+		return size - o
+	}
+	return d.o.ChunkSize
+}
+
+// loadState loads the state of a partial download
+// A fresh state is returned if there's no state or if it doesn't match the remote content
+func (d *Downloader) loadState(path string, size int64, etag string) (s *downloadState) {
+	// Read state
+	if b, err := ioutil.ReadFile(path); err == nil {
+		if err = json.Unmarshal(b, &s); err == nil && s.Chunks != nil && s.Size == size && s.ETag == etag {
+			return
+		}
+	}
+	return &downloadState{
+		Chunks: make(map[int64]bool),
+		ETag:   etag,
+		Size:   size,
+	}
+}
+
+// saveState saves the state of a partial download
+func (d *Downloader) saveState(path string, s *downloadState) (err error) {
+	// Marshal
+	var b []byte
+	if b, err = json.Marshal(s); err != nil {
+		return errors.Wrap(err, "astihttp: marshaling download state failed")
+	}
+
+	// Write
+	if err = ioutil.WriteFile(path, b, 0644); err != nil {
+		return errors.Wrapf(err, "astihttp: writing download state to %s failed", path)
+	}
+	return
+}
+
+// progressFunc adapts the progress option to the internal progress
+func (d *Downloader) progressFunc() func(done, total int64) {
+	if d.o.Progress == nil {
+		return nil
+	}
+	return func(done, total int64) { d.o.Progress(DownloadProgress{Downloaded: done, Total: total}) }
+}
+
+// offsetWriter represents a writer writing in a file at an offset that is incremented after each write
+type offsetWriter struct {
+	f *os.File
+	o int64
+}
+
+// Write implements the io.Writer interface
+func (w *offsetWriter) Write(b []byte) (n int, err error) {
+	n, err = w.f.WriteAt(b, w.o)
+	w.o += int64(n)
+	return
+}
@@ -0,0 +1,91 @@
+package astihttp_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/http"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDownloader_Download(t *testing.T) {
+	// Init
+	var content = []byte(strings.Repeat("0123456789", 10))
+	var ranges []string
+	var etag = `"etag"`
+	var failAt string
+	s := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			if r.Header.Get("Range") == failAt {
+				rw.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			ranges = append(ranges, r.Header.Get("Range"))
+		}
+		rw.Header().Set("ETag", etag)
+		http.ServeContent(rw, r, "", time.Time{}, bytes.NewReader(content))
+	}))
+	defer s.Close()
+	dir, err := ioutil.TempDir("", "astihttp")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	dst := filepath.Join(dir, "dst")
+
+	// Interrupted download
+	var ps []astihttp.DownloadProgress
+	d := astihttp.NewDownloader(astihttp.DownloaderOptions{
+		ChunkSize: 40,
+		Progress:  func(p astihttp.DownloadProgress) { ps = append(ps, p) },
+	})
+	failAt = "bytes=40-79"
+	err = d.Download(context.Background(), s.URL, dst)
+	assert.Error(t, err)
+	assert.Equal(t, []string{"bytes=0-39"}, ranges)
+	assert.Equal(t, astihttp.DownloadProgress{Downloaded: 40, Total: 100}, ps[len(ps)-1])
+	_, err = os.Stat(dst)
+	assert.True(t, os.IsNotExist(err))
+
+	// Resumed download
+	failAt = ""
+	ranges = []string{}
+	ps = []astihttp.DownloadProgress{}
+	err = d.Download(context.Background(), s.URL, dst)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"bytes=40-79", "bytes=80-99"}, ranges)
+	assert.Equal(t, astihttp.DownloadProgress{Downloaded: 40, Total: 100}, ps[0])
+	assert.Equal(t, astihttp.DownloadProgress{Downloaded: 100, Total: 100}, ps[len(ps)-1])
+	b, err := ioutil.ReadFile(dst)
+	assert.NoError(t, err)
+	assert.Equal(t, content, b)
+	_, err = os.Stat(dst + ".state")
+	assert.True(t, os.IsNotExist(err))
+
+	// Parallel download
+	os.Remove(dst)
+	d = astihttp.NewDownloader(astihttp.DownloaderOptions{
+		ChunkSize:   7,
+		Concurrency: 4,
+	})
+	err = d.Download(context.Background(), s.URL, dst)
+	assert.NoError(t, err)
+	b, err = ioutil.ReadFile(dst)
+	assert.NoError(t, err)
+	assert.Equal(t, content, b)
+
+	// Weak etag
+	os.Remove(dst)
+	etag = `W/"etag"`
+	err = d.Download(context.Background(), s.URL, dst)
+	assert.NoError(t, err)
+	b, err = ioutil.ReadFile(dst)
+	assert.NoError(t, err)
+	assert.Equal(t, content, b)
+}
@@ -1,12 +1,20 @@
 package astihttp
 
 import (
+	"bufio"
+	"compress/gzip"
 	"context"
+	"encoding/json"
+	"fmt"
+	"net"
 	"net/http"
+	"runtime/debug"
+	"strconv"
 	"strings"
 	"time"
 
-	"github.com/asticode/go-astilog"
+	"github.com/asticode/go-astitools/logger"
+	"github.com/asticode/go-astitools/string"
 	"github.com/julienschmidt/httprouter"
 	"github.com/pkg/errors"
 )
@@ -143,7 +151,7 @@ func RouterMiddlewareContentType(contentType string) RouterMiddleware {
 }
 
 // handleTimeout handles timeout
-func handleTimeout(timeout time.Duration, rw http.ResponseWriter, fn func()) {
+func handleTimeout(timeout time.Duration, rw http.ResponseWriter, r *http.Request, fn func()) {
 	// Init context
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
@@ -159,7 +167,7 @@ func handleTimeout(timeout time.Duration, rw http.ResponseWriter, fn func()) {
 	for {
 		select {
 		case <-ctx.Done():
-			astilog.Error(errors.Wrap(ctx.Err(), "astihttp: serving HTTP failed"))
+			requestLogger(r).Error("astihttp: serving HTTP failed", astilogger.KeyError, ctx.Err())
 			rw.WriteHeader(http.StatusGatewayTimeout)
 			return
 		case <-done:
@@ -172,7 +180,7 @@ func handleTimeout(timeout time.Duration, rw http.ResponseWriter, fn func()) {
 func MiddlewareTimeout(timeout time.Duration) Middleware {
 	return func(h http.Handler) http.Handler {
 		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
-			handleTimeout(timeout, rw, func() { h.ServeHTTP(rw, r) })
+			handleTimeout(timeout, rw, r, func() { h.ServeHTTP(rw, r) })
 		})
 	}
 }
@@ -181,7 +189,459 @@ func MiddlewareTimeout(timeout time.Duration) Middleware {
 func RouterMiddlewareTimeout(timeout time.Duration) RouterMiddleware {
 	return func(h httprouter.Handle) httprouter.Handle {
 		return func(rw http.ResponseWriter, r *http.Request, p httprouter.Params) {
-			handleTimeout(timeout, rw, func() { h(rw, r, p) })
+			handleTimeout(timeout, rw, r, func() { h(rw, r, p) })
+		}
+	}
+}
+
+// statusResponseWriter represents a response writer that keeps track of the status code and the number of bytes written
+type statusResponseWriter struct {
+	http.ResponseWriter
+	n          int
+	statusCode int
+}
+
+// WriteHeader implements the http.ResponseWriter interface
+func (w *statusResponseWriter) WriteHeader(statusCode int) {
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+// Write implements the http.ResponseWriter interface
+func (w *statusResponseWriter) Write(b []byte) (n int, err error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	n, err = w.ResponseWriter.Write(b)
+	w.n += n
+	return
+}
+
+// Flush implements the http.Flusher interface
+func (w *statusResponseWriter) Flush() {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements the http.Hijacker interface
+func (w *statusResponseWriter) Hijack() (c net.Conn, rw *bufio.ReadWriter, err error) {
+	if c, rw, err = hijack(w.ResponseWriter); err == nil && w.statusCode == 0 {
+		w.statusCode = http.StatusSwitchingProtocols
+	}
+	return
+}
+
+// Unwrap returns the underlying response writer so that http.ResponseController can reach it
+func (w *statusResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// hijack hijacks a response writer's connection if it implements the http.Hijacker interface
+func hijack(rw http.ResponseWriter) (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := rw.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("astihttp: response writer doesn't implement http.Hijacker")
+	}
+	return h.Hijack()
+}
+
+// handleLogging handles logging
+func handleLogging(rw http.ResponseWriter, r *http.Request, fn func(rw http.ResponseWriter)) {
+	// Serve
+	var w = &statusResponseWriter{ResponseWriter: rw}
+	var t = time.Now()
+	fn(w)
+
+	// Log
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	requestLogger(r).Info("astihttp: request served", "method", r.Method, "uri", r.URL.RequestURI(),
+		"status_code", w.statusCode, "bytes", w.n, "duration", time.Since(t))
+}
+
+// MiddlewareLogging logs requests with their status code and latency
+func MiddlewareLogging() Middleware {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			handleLogging(rw, r, func(rw http.ResponseWriter) { h.ServeHTTP(rw, r) })
+		})
+	}
+}
+
+// RouterMiddlewareLogging logs router requests with their status code and latency
+func RouterMiddlewareLogging() RouterMiddleware {
+	return func(h httprouter.Handle) httprouter.Handle {
+		return func(rw http.ResponseWriter, r *http.Request, p httprouter.Params) {
+			handleLogging(rw, r, func(rw http.ResponseWriter) { h(rw, r, p) })
+		}
+	}
+}
+
+// handleRecover handles panic recovery
+// http.ErrAbortHandler is re-panicked so that the server aborts the response as intended, and no error is written
+// once the response has started since it would be appended to a partial body.
+func handleRecover(rw http.ResponseWriter, r *http.Request, fn func(rw http.ResponseWriter)) {
+	var w = &statusResponseWriter{ResponseWriter: rw}
+	defer func() {
+		if e := recover(); e != nil {
+			// Abort
+			if e == http.ErrAbortHandler {
+				panic(e)
+			}
+
+			// Log
+			requestLogger(r).Error("astihttp: recovered from panic", "method", r.Method, "uri", r.URL.RequestURI(),
+				"panic", e, "stack", string(debug.Stack()))
+
+			// Response has already started
+			if w.statusCode != 0 {
+				return
+			}
+
+			// Write error
+			rw.Header().Set("Content-Type", "application/json")
+			rw.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(rw).Encode(map[string]string{"message": http.StatusText(http.StatusInternalServerError)})
+		}
+	}()
+	fn(w)
+}
+
+// MiddlewareRecover recovers from panics in a handler and returns a 500 JSON error
+func MiddlewareRecover() Middleware {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			handleRecover(rw, r, func(rw http.ResponseWriter) { h.ServeHTTP(rw, r) })
+		})
+	}
+}
+
+// RouterMiddlewareRecover recovers from panics in a router handler and returns a 500 JSON error
+func RouterMiddlewareRecover() RouterMiddleware {
+	return func(h httprouter.Handle) httprouter.Handle {
+		return func(rw http.ResponseWriter, r *http.Request, p httprouter.Params) {
+			handleRecover(rw, r, func(rw http.ResponseWriter) { h(rw, r, p) })
+		}
+	}
+}
+
+// gzipResponseWriter represents a response writer that compresses its content
+// The gzip writer is created lazily on the first non-empty write so that bodyless responses are left untouched.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	head       bool
+	hijacked   bool
+	level      int
+	started    bool
+	statusCode int
+	w          *gzip.Writer
+}
+
+// WriteHeader implements the http.ResponseWriter interface
+func (w *gzipResponseWriter) WriteHeader(statusCode int) {
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+}
+
+// Write implements the http.ResponseWriter interface
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	// Nothing to write
+	if len(b) == 0 {
+		return 0, nil
+	}
+
+	// Start
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", http.DetectContentType(b))
+	}
+	w.start()
+
+	// Write
+	if w.w == nil {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.w.Write(b)
+}
+
+// Flush implements the http.Flusher interface
+func (w *gzipResponseWriter) Flush() {
+	w.start()
+	if w.w != nil {
+		w.w.Flush()
+	}
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack implements the http.Hijacker interface
+func (w *gzipResponseWriter) Hijack() (c net.Conn, rw *bufio.ReadWriter, err error) {
+	if c, rw, err = hijack(w.ResponseWriter); err == nil {
+		w.hijacked = true
+	}
+	return
+}
+
+// Unwrap returns the underlying response writer so that http.ResponseController can reach it
+func (w *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// start decides whether the response is compressed and writes the header
+func (w *gzipResponseWriter) start() {
+	// Already started
+	if w.started {
+		return
+	}
+	w.started = true
+
+	// Default status code
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+
+	// Create gzip writer unless the response has no body or is already encoded
+	if !w.head && bodyAllowedForStatus(w.statusCode) && w.Header().Get("Content-Encoding") == "" {
+		if gw, err := gzip.NewWriterLevel(w.ResponseWriter, w.level); err != nil {
+			astilogger.Default().Error("astihttp: creating gzip writer failed", astilogger.KeyError, err)
+		} else {
+			w.w = gw
+			w.Header().Set("Content-Encoding", "gzip")
+			w.Header().Del("Content-Length")
+		}
+	}
+
+	// Write header
+	w.ResponseWriter.WriteHeader(w.statusCode)
+}
+
+// close writes the header if nothing has been written and closes the gzip writer
+func (w *gzipResponseWriter) close() {
+	// Connection has been hijacked
+	if w.hijacked {
+		return
+	}
+
+	// Nothing has been written
+	if !w.started {
+		if w.statusCode > 0 {
+			w.ResponseWriter.WriteHeader(w.statusCode)
+		}
+		return
+	}
+
+	// Close gzip writer
+	if w.w != nil {
+		if err := w.w.Close(); err != nil {
+			astilogger.Default().Error("astihttp: closing gzip writer failed", astilogger.KeyError, err)
+		}
+	}
+}
+
+// bodyAllowedForStatus checks whether a status code allows a body
+func bodyAllowedForStatus(statusCode int) bool {
+	return statusCode >= 200 && statusCode != http.StatusNoContent && statusCode != http.StatusNotModified
+}
+
+// handleGzip handles gzip compression
+func handleGzip(level int, rw http.ResponseWriter, r *http.Request, fn func(rw http.ResponseWriter)) {
+	// Client doesn't accept gzip
+	rw.Header().Add("Vary", "Accept-Encoding")
+	if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+		fn(rw)
+		return
+	}
+
+	// Serve
+	var gw = &gzipResponseWriter{
+		ResponseWriter: rw,
+		head:           r.Method == http.MethodHead,
+		level:          level,
+	}
+	defer gw.close()
+	fn(gw)
+}
+
+// MiddlewareGzip compresses responses of a handler with gzip when the client accepts it
+// level is a compress/gzip level such as gzip.DefaultCompression
+func MiddlewareGzip(level int) Middleware {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			handleGzip(level, rw, r, func(rw http.ResponseWriter) { h.ServeHTTP(rw, r) })
+		})
+	}
+}
+
+// RouterMiddlewareGzip compresses responses of a router handler with gzip when the client accepts it
+// level is a compress/gzip level such as gzip.DefaultCompression
+func RouterMiddlewareGzip(level int) RouterMiddleware {
+	return func(h httprouter.Handle) httprouter.Handle {
+		return func(rw http.ResponseWriter, r *http.Request, p httprouter.Params) {
+			handleGzip(level, rw, r, func(rw http.ResponseWriter) { h(rw, r, p) })
+		}
+	}
+}
+
+// CORSOptions represents CORS options
+type CORSOptions struct {
+	AllowCredentials bool
+	// Defaults to Accept, Authorization, Content-Type
+	AllowedHeaders []string
+	// Defaults to GET, POST, PUT, PATCH, DELETE, HEAD
+	AllowedMethods []string
+	// "*" allows all origins, in which case credentials are never allowed
+	AllowedOrigins []string
+	MaxAge         time.Duration
+}
+
+// handleCORS handles CORS and returns true if the request is a preflight request that has been answered
+func handleCORS(o CORSOptions, rw http.ResponseWriter, r *http.Request) bool {
+	// No origin
+	var origin = r.Header.Get("Origin")
+	rw.Header().Add("Vary", "Origin")
+	if origin == "" {
+		return false
+	}
+
+	// Check origin
+	var allowed, allowAll bool
+	for _, v := range o.AllowedOrigins {
+		if v == "*" {
+			allowAll = true
+		}
+		if v == "*" || v == origin {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return false
+	}
+
+	// Set headers
+	// When all origins are allowed, origins are not reflected and credentials are never allowed, otherwise any
+	// website could make credentialed requests
+	if allowAll {
+		rw.Header().Set("Access-Control-Allow-Origin", "*")
+	} else {
+		rw.Header().Set("Access-Control-Allow-Origin", origin)
+		if o.AllowCredentials {
+			rw.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+	}
+
+	// Not a preflight request
+	if r.Method != http.MethodOptions || r.Header.Get("Access-Control-Request-Method") == "" {
+		return false
+	}
+
+	// Default options values
+	if len(o.AllowedHeaders) == 0 {
+		o.AllowedHeaders = []string{"Accept", "Authorization", "Content-Type"}
+	}
+	if len(o.AllowedMethods) == 0 {
+		o.AllowedMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete, http.MethodHead}
+	}
+
+	// Answer preflight request
+	rw.Header().Set("Access-Control-Allow-Headers", strings.Join(o.AllowedHeaders, ", "))
+	rw.Header().Set("Access-Control-Allow-Methods", strings.Join(o.AllowedMethods, ", "))
+	if o.MaxAge > 0 {
+		rw.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(o.MaxAge.Seconds())))
+	}
+	rw.WriteHeader(http.StatusNoContent)
+	return true
+}
+
+// MiddlewareCORS adds CORS headers to a handler and answers preflight requests
+func MiddlewareCORS(o CORSOptions) Middleware {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			// CORS
+			if handleCORS(o, rw, r) {
+				return
+			}
+
+			// Next handler
+			h.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// RouterMiddlewareCORS adds CORS headers to a router handler and answers preflight requests
+func RouterMiddlewareCORS(o CORSOptions) RouterMiddleware {
+	return func(h httprouter.Handle) httprouter.Handle {
+		return func(rw http.ResponseWriter, r *http.Request, p httprouter.Params) {
+			// CORS
+			if handleCORS(o, rw, r) {
+				return
+			}
+
+			// Next handler
+			h(rw, r, p)
+		}
+	}
+}
+
+// RequestIDHeader is the header request IDs are read from and written to
+const RequestIDHeader = "X-Request-Id"
+
+// requestLogger returns the default logger with the fields of the request's context
+func requestLogger(r *http.Request) astilogger.Logger {
+	return astilogger.WithContext(astilogger.Default(), r.Context())
+}
+
+// handleRequestID handles request IDs
+func handleRequestID(rw http.ResponseWriter, r *http.Request) *http.Request {
+	// Get request ID
+	var id = r.Header.Get(RequestIDHeader)
+	if id == "" {
+		id = astistring.UUIDv4().String()
+	}
+
+	// Update response and context
+	rw.Header().Set(RequestIDHeader, id)
+	return r.WithContext(astilogger.ContextWithFields(r.Context(), astilogger.KeyRequestID, id))
+}
+
+// setRequestIDHeader sets the request ID held by the request's context, if any, in the request's headers
+func setRequestIDHeader(req *http.Request) {
+	if req.Header.Get(RequestIDHeader) != "" {
+		return
+	}
+	if id, ok := astilogger.Field(req.Context(), astilogger.KeyRequestID); ok {
+		if req.Header == nil {
+			req.Header = make(http.Header)
+		}
+		req.Header.Set(RequestIDHeader, fmt.Sprint(id))
+	}
+}
+
+// MiddlewareRequestID reads the request ID from the RequestIDHeader header or generates one, writes it in the
+// response header and adds it to the request's context as an astilogger field
+// Messages logged by other middlewares and by senders using this context are correlated with it, which is why it
+// should be the first middleware.
+func MiddlewareRequestID() Middleware {
+	return func(h http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			h.ServeHTTP(rw, handleRequestID(rw, r))
+		})
+	}
+}
+
+// RouterMiddlewareRequestID is the router version of MiddlewareRequestID
+func RouterMiddlewareRequestID() RouterMiddleware {
+	return func(h httprouter.Handle) httprouter.Handle {
+		return func(rw http.ResponseWriter, r *http.Request, p httprouter.Params) {
+			h(rw, handleRequestID(rw, r), p)
 		}
 	}
 }
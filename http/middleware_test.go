@@ -0,0 +1,212 @@
+package astihttp_test
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/http"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddlewareRecover(t *testing.T) {
+	h := astihttp.ChainMiddlewares(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		panic("test")
+	}), astihttp.MiddlewareRecover(), astihttp.MiddlewareLogging())
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "{\"message\":\"Internal Server Error\"}\n", rec.Body.String())
+
+	// Response has already started
+	h = astihttp.ChainMiddlewares(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusAccepted)
+		rw.Write([]byte("partial"))
+		panic("test")
+	}), astihttp.MiddlewareRecover())
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Equal(t, "partial", rec.Body.String())
+
+	// Abort
+	h = astihttp.ChainMiddlewares(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}), astihttp.MiddlewareRecover())
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+}
+
+func TestMiddlewaresWebSocket(t *testing.T) {
+	// Server that echoes messages through middlewares
+	s := httptest.NewServer(astihttp.ChainMiddlewares(astihttp.WebSocketHandler(context.Background(), websocket.Upgrader{}, astihttp.WebSocketOptions{}, func(ws *astihttp.WebSocket) {
+		ws.AddListener("ping", func(ws *astihttp.WebSocket, eventName string, payload json.RawMessage) error {
+			return ws.Write("pong", nil)
+		})
+	}), astihttp.MiddlewareRecover(), astihttp.MiddlewareGzip(gzip.DefaultCompression), astihttp.MiddlewareLogging()))
+	defer s.Close()
+
+	// Client
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var pongs = make(chan bool, 1)
+	ws := astihttp.NewWebSocket(astihttp.WebSocketOptions{})
+	ws.AddListener("pong", func(ws *astihttp.WebSocket, eventName string, payload json.RawMessage) error {
+		pongs <- true
+		return nil
+	})
+	ws.AddListener(astihttp.WebSocketEventNameConnected, func(ws *astihttp.WebSocket, eventName string, payload json.RawMessage) error {
+		return ws.Write("ping", nil)
+	})
+	var errs = make(chan error, 1)
+	go func() {
+		errs <- ws.Dial(ctx, "ws"+strings.TrimPrefix(s.URL, "http"), http.Header{"Accept-Encoding": []string{"gzip"}})
+	}()
+
+	// Wait for pong
+	select {
+	case <-pongs:
+	case err := <-errs:
+		t.Fatalf("dialing failed: %v", err)
+	case <-time.After(time.Second):
+		t.Fatal("no pong received")
+	}
+}
+
+func TestMiddlewareGzip(t *testing.T) {
+	h := astihttp.ChainMiddlewares(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte("content"))
+	}), astihttp.MiddlewareGzip(gzip.DefaultCompression))
+
+	// Client doesn't accept gzip
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "", rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, "content", rec.Body.String())
+
+	// Client accepts gzip
+	rec = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Accept-Encoding", "gzip, deflate")
+	h.ServeHTTP(rec, r)
+	assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+	gr, err := gzip.NewReader(rec.Body)
+	assert.NoError(t, err)
+	b, err := ioutil.ReadAll(gr)
+	assert.NoError(t, err)
+	assert.Equal(t, "content", string(b))
+
+	// Bodyless responses are not compressed
+	for _, v := range []struct {
+		h      http.HandlerFunc
+		method string
+	}{
+		{h: func(rw http.ResponseWriter, r *http.Request) { rw.WriteHeader(http.StatusNoContent) }, method: http.MethodGet},
+		{h: func(rw http.ResponseWriter, r *http.Request) {}, method: http.MethodHead},
+		{h: func(rw http.ResponseWriter, r *http.Request) {
+			rw.Header().Set("Content-Encoding", "br")
+			rw.Write([]byte("content"))
+		}, method: http.MethodGet},
+	} {
+		rec = httptest.NewRecorder()
+		r = httptest.NewRequest(v.method, "/", nil)
+		r.Header.Set("Accept-Encoding", "gzip")
+		astihttp.ChainMiddlewares(v.h, astihttp.MiddlewareGzip(gzip.DefaultCompression)).ServeHTTP(rec, r)
+		assert.NotEqual(t, "gzip", rec.Header().Get("Content-Encoding"))
+	}
+	assert.Equal(t, "content", rec.Body.String())
+}
+
+func TestMiddlewareCORS(t *testing.T) {
+	var called bool
+	h := astihttp.ChainMiddlewares(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		called = true
+	}), astihttp.MiddlewareCORS(astihttp.CORSOptions{
+		AllowCredentials: true,
+		AllowedOrigins:   []string{"http://allowed"},
+		MaxAge:           time.Minute,
+	}))
+
+	// Origin is not allowed
+	rec := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Origin", "http://notallowed")
+	h.ServeHTTP(rec, r)
+	assert.True(t, called)
+	assert.Equal(t, "", rec.Header().Get("Access-Control-Allow-Origin"))
+
+	// Origin is allowed
+	called = false
+	rec = httptest.NewRecorder()
+	r.Header.Set("Origin", "http://allowed")
+	h.ServeHTTP(rec, r)
+	assert.True(t, called)
+	assert.Equal(t, "http://allowed", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "true", rec.Header().Get("Access-Control-Allow-Credentials"))
+
+	// Preflight
+	called = false
+	rec = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodOptions, "/", nil)
+	r.Header.Set("Origin", "http://allowed")
+	r.Header.Set("Access-Control-Request-Method", http.MethodPut)
+	h.ServeHTTP(rec, r)
+	assert.False(t, called)
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "GET, POST, PUT, PATCH, DELETE, HEAD", rec.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "60", rec.Header().Get("Access-Control-Max-Age"))
+
+	// All origins are allowed without credentials
+	h = astihttp.ChainMiddlewares(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {}), astihttp.MiddlewareCORS(astihttp.CORSOptions{
+		AllowCredentials: true,
+		AllowedOrigins:   []string{"*"},
+	}))
+	rec = httptest.NewRecorder()
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Origin", "http://evil")
+	h.ServeHTTP(rec, r)
+	assert.Equal(t, "*", rec.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "", rec.Header().Get("Access-Control-Allow-Credentials"))
+}
+
+func TestMiddlewareRequestID(t *testing.T) {
+	// Create upstream
+	var ids []string
+	u := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		ids = append(ids, r.Header.Get(astihttp.RequestIDHeader))
+	}))
+	defer u.Close()
+
+	// Create handler
+	s := astihttp.NewSender(astihttp.SenderOptions{})
+	defer s.Close()
+	h := astihttp.ChainMiddlewares(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		req, _ := http.NewRequestWithContext(r.Context(), http.MethodGet, u.URL, nil)
+		if resp, err := s.Send(req); assert.NoError(t, err) {
+			resp.Body.Close()
+		}
+	}), astihttp.MiddlewareLogging(), astihttp.MiddlewareRequestID())
+
+	// Generated
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Len(t, rec.Header().Get(astihttp.RequestIDHeader), 36)
+
+	// Provided
+	rec = httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(astihttp.RequestIDHeader, "id")
+	h.ServeHTTP(rec, r)
+	assert.Equal(t, "id", rec.Header().Get(astihttp.RequestIDHeader))
+	assert.Equal(t, []string{ids[0], "id"}, ids)
+	assert.Equal(t, rec.Header().Get(astihttp.RequestIDHeader), ids[1])
+}
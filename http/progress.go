@@ -0,0 +1,51 @@
+package astihttp
+
+import (
+	"io"
+	"sync"
+)
+
+// progress represents a progress shared between goroutines
+type progress struct {
+	done  int64
+	fn    func(done, total int64)
+	m     sync.Mutex // Locks done
+	total int64
+}
+
+// newProgress creates a new progress
+func newProgress(fn func(done, total int64), total int64) *progress {
+	return &progress{
+		fn:    fn,
+		total: total,
+	}
+}
+
+// add adds processed bytes and reports the progress
+func (p *progress) add(n int) {
+	p.m.Lock()
+	defer p.m.Unlock()
+	p.done += int64(n)
+	p.report()
+}
+
+// report reports the progress
+// Assumption is made that p.m is locked or that no other goroutine is using the progress
+func (p *progress) report() {
+	if p.fn != nil {
+		p.fn(p.done, p.total)
+	}
+}
+
+// progressWriter represents a writer that reports the number of bytes it has written
+type progressWriter struct {
+	p *progress
+	w io.Writer
+}
+
+// Write implements the io.Writer interface
+func (w *progressWriter) Write(b []byte) (n int, err error) {
+	n, err = w.w.Write(b)
+	w.p.add(n)
+	return
+}
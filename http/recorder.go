@@ -0,0 +1,267 @@
+package astihttp
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Recorder modes
+const (
+	// Replays interactions if the cassette exists, records them otherwise
+	RecorderModeAuto = "auto"
+	// Sends real requests and records interactions
+	RecorderModeRecord = "record"
+	// Replays recorded interactions and never sends real requests
+	RecorderModeReplay = "replay"
+)
+
+// RedactedHeaderValue represents the value redacted headers are recorded with
+const RedactedHeaderValue = "xxxxx"
+
+// RecorderOptions represents recorder options
+type RecorderOptions struct {
+	// Matches a request against a recorded interaction. Defaults to matching method, URL and body.
+	Matcher func(r *http.Request, body []byte, i RecordedInteraction) bool
+	// Defaults to RecorderModeAuto
+	Mode string
+	// Path of the cassette, the file interactions are recorded in
+	Path string
+	// Headers whose values are redacted, in both requests and responses, when recording. Defaults to
+	// Authorization, Cookie and Set-Cookie.
+	RedactHeaders []string
+	// Transport used to send real requests. Defaults to http.DefaultTransport.
+	Transport http.RoundTripper
+}
+
+// RecordedInteraction represents a recorded request and its response
+type RecordedInteraction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest represents a recorded request
+type RecordedRequest struct {
+	Body    []byte      `json:"body,omitempty"`
+	Headers http.Header `json:"headers,omitempty"`
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+}
+
+// RecordedResponse represents a recorded response
+type RecordedResponse struct {
+	Body       []byte      `json:"body,omitempty"`
+	Headers    http.Header `json:"headers,omitempty"`
+	StatusCode int         `json:"status_code"`
+}
+
+// Recorder is an http.RoundTripper recording real HTTP interactions in a cassette and replaying them
+// deterministically, which allows writing offline tests. Use it as the transport of the Sender's client.
+type Recorder struct {
+	is   []RecordedInteraction
+	m    *sync.Mutex // Locks is and used
+	o    RecorderOptions
+	used map[int]bool
+}
+
+// NewRecorder creates a new recorder and loads the cassette when replaying
+func NewRecorder(o RecorderOptions) (r *Recorder, err error) {
+	// Default options values
+	if o.Matcher == nil {
+		o.Matcher = defaultRecorderMatcher
+	}
+	if o.Mode == "" {
+		o.Mode = RecorderModeAuto
+	}
+	if o.RedactHeaders == nil {
+		o.RedactHeaders = []string{"Authorization", "Cookie", "Set-Cookie"}
+	}
+	if o.Transport == nil {
+		o.Transport = http.DefaultTransport
+	}
+
+	// Create recorder
+	r = &Recorder{
+		m:    &sync.Mutex{},
+		o:    o,
+		used: make(map[int]bool),
+	}
+
+	// Auto mode
+	if r.o.Mode == RecorderModeAuto {
+		if _, err = os.Stat(o.Path); err == nil {
+			r.o.Mode = RecorderModeReplay
+		} else if os.IsNotExist(err) {
+			r.o.Mode = RecorderModeRecord
+			err = nil
+		} else {
+			err = errors.Wrapf(err, "astihttp: stating %s failed", o.Path)
+			return
+		}
+	}
+
+	// Load cassette
+	if r.o.Mode == RecorderModeReplay {
+		var b []byte
+		if b, err = ioutil.ReadFile(o.Path); err != nil {
+			err = errors.Wrapf(err, "astihttp: reading %s failed", o.Path)
+			return
+		}
+		if err = json.Unmarshal(b, &r.is); err != nil {
+			err = errors.Wrapf(err, "astihttp: unmarshaling %s failed", o.Path)
+			return
+		}
+	}
+	return
+}
+
+// defaultRecorderMatcher matches method, URL and body
+func defaultRecorderMatcher(r *http.Request, body []byte, i RecordedInteraction) bool {
+	return r.Method == i.Request.Method && r.URL.String() == i.Request.URL && bytes.Equal(body, i.Request.Body)
+}
+
+// Client returns an http.Client using the recorder as transport
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// Mode returns the recorder's mode, which is never RecorderModeAuto
+func (r *Recorder) Mode() string {
+	return r.o.Mode
+}
+
+// RoundTrip implements the http.RoundTripper interface
+func (r *Recorder) RoundTrip(req *http.Request) (resp *http.Response, err error) {
+	// Read body
+	var body []byte
+	if req.Body != nil {
+		body, err = ioutil.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			err = errors.Wrapf(err, "astihttp: reading body of request to %s failed", req.URL)
+			return
+		}
+	}
+
+	// Replay
+	if r.o.Mode == RecorderModeReplay {
+		return r.replay(req, body)
+	}
+
+	// Send request
+	var c = req.Clone(req.Context())
+	if req.Body != nil {
+		c.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+	if resp, err = r.o.Transport.RoundTrip(c); err != nil {
+		return
+	}
+
+	// Read response body
+	var respBody []byte
+	respBody, err = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		err = errors.Wrapf(err, "astihttp: reading body of response from %s failed", req.URL)
+		return
+	}
+	resp.Body = ioutil.NopCloser(bytes.NewReader(respBody))
+
+	// Record
+	r.m.Lock()
+	r.is = append(r.is, RecordedInteraction{
+		Request: RecordedRequest{
+			Body:    body,
+			Headers: r.redact(req.Header),
+			Method:  req.Method,
+			URL:     req.URL.String(),
+		},
+		Response: RecordedResponse{
+			Body:       respBody,
+			Headers:    r.redact(resp.Header),
+			StatusCode: resp.StatusCode,
+		},
+	})
+	r.m.Unlock()
+	return
+}
+
+// replay returns the first unused recorded interaction matching the request
+func (r *Recorder) replay(req *http.Request, body []byte) (resp *http.Response, err error) {
+	// Lock
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	// Loop through interactions
+	for idx, i := range r.is {
+		// Interaction has already been used or doesn't match
+		if r.used[idx] || !r.o.Matcher(req, body, i) {
+			continue
+		}
+		r.used[idx] = true
+
+		// Create response
+		resp = &http.Response{
+			Body:          ioutil.NopCloser(bytes.NewReader(i.Response.Body)),
+			ContentLength: int64(len(i.Response.Body)),
+			Header:        i.Response.Headers.Clone(),
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Request:       req,
+			Status:        strconv.Itoa(i.Response.StatusCode) + " " + http.StatusText(i.Response.StatusCode),
+			StatusCode:    i.Response.StatusCode,
+		}
+		if resp.Header == nil {
+			resp.Header = make(http.Header)
+		}
+		return
+	}
+	err = errors.Errorf("astihttp: no recorded interaction for %s %s", req.Method, req.URL)
+	return
+}
+
+// redact clones headers and redacts their values
+func (r *Recorder) redact(h http.Header) (o http.Header) {
+	o = h.Clone()
+	for _, k := range r.o.RedactHeaders {
+		if vs, ok := o[http.CanonicalHeaderKey(k)]; ok {
+			for i := range vs {
+				vs[i] = RedactedHeaderValue
+			}
+		}
+	}
+	return
+}
+
+// Save writes recorded interactions in the cassette
+// It does nothing when replaying.
+func (r *Recorder) Save() (err error) {
+	// Nothing to do
+	if r.o.Mode != RecorderModeRecord {
+		return
+	}
+
+	// Marshal
+	r.m.Lock()
+	var b []byte
+	b, err = json.MarshalIndent(r.is, "", "  ")
+	r.m.Unlock()
+	if err != nil {
+		err = errors.Wrap(err, "astihttp: marshaling interactions failed")
+		return
+	}
+
+	// Write
+	if err = ioutil.WriteFile(r.o.Path, b, 0644); err != nil {
+		err = errors.Wrapf(err, "astihttp: writing %s failed", r.o.Path)
+		return
+	}
+	return
+}
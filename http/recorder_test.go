@@ -0,0 +1,72 @@
+package astihttp_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/asticode/go-astitools/http"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecorder(t *testing.T) {
+	// Create server
+	var count int
+	s := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		count++
+		b, _ := ioutil.ReadAll(r.Body)
+		rw.Header().Set("Set-Cookie", "session=secret")
+		rw.WriteHeader(http.StatusCreated)
+		rw.Write([]byte("echo " + string(b)))
+	}))
+	defer s.Close()
+
+	// Record
+	var p = filepath.Join(t.TempDir(), "cassette.json")
+	r, err := astihttp.NewRecorder(astihttp.RecorderOptions{Path: p})
+	assert.NoError(t, err)
+	assert.Equal(t, astihttp.RecorderModeRecord, r.Mode())
+	var send = func(r *astihttp.Recorder, body string) (code int, respBody string, err error) {
+		req, _ := http.NewRequest(http.MethodPost, s.URL+"/path", strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer token")
+		var resp *http.Response
+		if resp, err = astihttp.NewSender(astihttp.SenderOptions{Client: r.Client()}).Send(req); err != nil {
+			return
+		}
+		defer resp.Body.Close()
+		assert.Equal(t, strconv.Itoa(resp.StatusCode)+" "+http.StatusText(resp.StatusCode), resp.Status)
+		b, _ := ioutil.ReadAll(resp.Body)
+		return resp.StatusCode, string(b), nil
+	}
+	code, b, err := send(r, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, "echo 1", b)
+	_, _, err = send(r, "2")
+	assert.NoError(t, err)
+	assert.NoError(t, r.Save())
+	assert.Equal(t, 2, count)
+	c, err := ioutil.ReadFile(p)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(c), "secret")
+	assert.NotContains(t, string(c), "token")
+
+	// Replay
+	r, err = astihttp.NewRecorder(astihttp.RecorderOptions{Path: p})
+	assert.NoError(t, err)
+	assert.Equal(t, astihttp.RecorderModeReplay, r.Mode())
+	_, b, err = send(r, "2")
+	assert.NoError(t, err)
+	assert.Equal(t, "echo 2", b)
+	code, b, err = send(r, "1")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, code)
+	assert.Equal(t, "echo 1", b)
+	_, _, err = send(r, "1")
+	assert.Error(t, err)
+	assert.Equal(t, 2, count)
+}
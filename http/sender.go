@@ -0,0 +1,279 @@
+package astihttp
+
+import (
+	"crypto/tls"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/asticode/go-astitools/limiter"
+	"github.com/asticode/go-astitools/logger"
+	"github.com/asticode/go-astitools/time"
+	"github.com/pkg/errors"
+)
+
+// Sender represents an object capable of sending http requests with retries
+type Sender struct {
+	breakers map[string]*astilimiter.CircuitBreaker
+	client   *http.Client
+	l        astilogger.Logger
+	limiter  *astilimiter.Limiter
+	ma       sync.Mutex // Locks reauthenticatedAt
+	mb       sync.Mutex // Locks breakers
+	mt       sync.Mutex // Locks tlsClients
+	o        SenderOptions
+	// Time of the last re-authentication
+	reauthenticatedAt time.Time
+	slots             chan bool
+	tlsClients        map[*tls.Config]*http.Client
+}
+
+// SenderOptions represents sender options
+type SenderOptions struct {
+	// Cooldown after which an open circuit breaker lets a trial request through
+	CircuitBreakerCooldown time.Duration
+	// Number of consecutive failed requests to a host after which the host's circuit breaker opens. 0 disables
+	// circuit breakers
+	CircuitBreakerMaxFailures int
+	// Defaults to http.DefaultClient
+	Client *http.Client
+	// Cookie jar used by the client, see NewMemoryCookieJar and NewFileCookieJar. The client is copied so that it's
+	// left untouched.
+	CookieJar http.CookieJar
+	// Headers set on every request that doesn't set them already
+	Headers http.Header
+	// Messages are logged with the fields of the request's context, see astilogger.WithContext. Defaults to
+	// astilogger.Default()
+	Logger astilogger.Logger
+	// Maximum number of concurrent requests. 0 means no maximum
+	MaxConcurrentRequests int
+	// Proxy requests are sent through. Supported schemes are http, https and socks5. Like TLSConfig, it requires the
+	// client's transport to be an *http.Transport, which is copied so that it's left untouched.
+	Proxy *url.URL
+	// If true and Proxy is nil, the proxy is read from the HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables
+	ProxyFromEnvironment bool
+	// Maximum number of requests per host during RateLimitPeriod. 0 disables rate limiting
+	RateLimitCap    int
+	RateLimitPeriod time.Duration
+	// Executed once a request has returned a 401 status code, after which the request is resent once
+	Reauthenticate ReauthenticateFunc
+	// Maximum size in bytes of response bodies read by helpers such as SendJSON. 0 means no maximum
+	ResponseMaxSize int64
+	// Defaults to a 1s constant backoff
+	RetryBackoff astitime.Backoff
+	// Number of retries after the first attempt
+	RetryMax int
+	// Maximum amount of time honored in a Retry-After response header. 0 means Retry-After headers are honored
+	// whatever their value, a negative value means they are ignored.
+	RetryAfterMax time.Duration
+	// Defaults to DefaultRetryPredicate
+	RetryPredicate RetryPredicate
+	// TLS configuration, e.g. with custom root CAs or a client certificate, see NewTLSConfig. It can be overridden per
+	// request with WithTLSConfig.
+	TLSConfig *tls.Config
+}
+
+// NewSender creates a new sender
+func NewSender(o SenderOptions) (s *Sender) {
+	// Default options values
+	if o.Client == nil {
+		o.Client = http.DefaultClient
+	}
+	if o.CookieJar != nil {
+		var c = *o.Client
+		c.Jar = o.CookieJar
+		o.Client = &c
+	}
+	if o.Logger == nil {
+		o.Logger = astilogger.Default()
+	}
+	o.Client = configureTransport(o)
+	if o.RetryBackoff == nil {
+		o.RetryBackoff = astitime.ConstantBackoff(time.Second)
+	}
+	if o.RetryPredicate == nil {
+		o.RetryPredicate = DefaultRetryPredicate
+	}
+
+	if o.RateLimitCap > 0 && o.RateLimitPeriod == 0 {
+		o.RateLimitPeriod = time.Second
+	}
+
+	// Create sender
+	s = &Sender{
+		breakers:   make(map[string]*astilimiter.CircuitBreaker),
+		client:     o.Client,
+		l:          o.Logger,
+		o:          o,
+		tlsClients: make(map[*tls.Config]*http.Client),
+	}
+
+	// Create limiter
+	if o.RateLimitCap > 0 {
+		s.limiter = astilimiter.New()
+	}
+
+	// Create slots
+	if o.MaxConcurrentRequests > 0 {
+		s.slots = make(chan bool, o.MaxConcurrentRequests)
+	}
+	return
+}
+
+// Close closes the sender properly
+func (s *Sender) Close() {
+	if s.limiter != nil {
+		s.limiter.Close()
+	}
+	s.mt.Lock()
+	for _, c := range s.tlsClients {
+		c.CloseIdleConnections()
+	}
+	s.mt.Unlock()
+}
+
+// RetryPredicate represents a func that decides whether a request should be retried based on its outcome
+type RetryPredicate func(resp *http.Response, err error) bool
+
+// DefaultRetryPredicate retries requests that failed, that are rate limited or that returned a 5xx status code
+func DefaultRetryPredicate(resp *http.Response, err error) bool {
+	return err != nil || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+}
+
+// RetryOnStatusCodes returns a predicate that retries requests that failed or that returned one of the status codes
+func RetryOnStatusCodes(codes ...int) RetryPredicate {
+	return func(resp *http.Response, err error) bool {
+		if err != nil {
+			return true
+		}
+		for _, c := range codes {
+			if resp.StatusCode == c {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Send sends a new *http.Request and retries it if needed
+// Retries stop as soon as the request's context is cancelled. If retries are exhausted, the last response is returned.
+// Requests with a body can only be retried if their GetBody attribute is set, which is the case when using
+// http.NewRequest with a *bytes.Buffer, a *bytes.Reader or a *strings.Reader.
+// If the request's context holds a request ID, e.g. because it's sent while serving a request with
+// MiddlewareRequestID, it's sent in the RequestIDHeader header unless the request already has one.
+// Headers are set on a clone of the request so that the caller's request is left untouched.
+func (s *Sender) Send(req *http.Request) (resp *http.Response, err error) {
+	// Clone request
+	req = req.Clone(req.Context())
+
+	// Set default headers
+	s.setDefaultHeaders(req)
+	setRequestIDHeader(req)
+
+	// Get logger
+	var l = astilogger.WithContext(s.l, req.Context())
+
+	// Loop
+	for n := 0; ; n++ {
+		// Reset body
+		if n > 0 && req.Body != nil {
+			var b io.ReadCloser
+			if b, err = req.GetBody(); err != nil {
+				err = errors.Wrapf(err, "astihttp: getting body of request to %s failed", req.URL)
+				return
+			}
+			req.Body = b
+		}
+
+		// Send request
+		l.Debug("astihttp: sending request", "url", req.URL, "attempt", n+1)
+		var sentAt = time.Now()
+		if resp, err = s.do(req); err == ErrCircuitBreakerOpen {
+			return
+		}
+
+		// Re-authenticate and resend
+		if s.shouldReauthenticate(req, resp, err) {
+			if err = s.reauthenticate(req, resp, sentAt); err != nil {
+				resp = nil
+				return
+			}
+			if resp, err = s.do(req); err == ErrCircuitBreakerOpen {
+				return
+			}
+		}
+
+		// No retry needed
+		if !s.o.RetryPredicate(resp, err) {
+			return
+		}
+
+		// Retries are exhausted
+		if n >= s.o.RetryMax || (req.Body != nil && req.GetBody == nil) {
+			if err != nil {
+				err = errors.Wrapf(err, "astihttp: sending request to %s failed", req.URL)
+			}
+			return
+		}
+
+		// Get sleep duration
+		var d = s.o.RetryBackoff.Duration(n + 1)
+		if resp != nil {
+			if ra, ok := retryAfter(resp); ok && s.o.RetryAfterMax >= 0 {
+				if s.o.RetryAfterMax > 0 && ra > s.o.RetryAfterMax {
+					ra = s.o.RetryAfterMax
+				}
+				d = ra
+			}
+
+			// Drain and close body so that the connection can be reused
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		// Sleep
+		if err != nil {
+			l.Debug("astihttp: sending request failed, retrying", "url", req.URL, "delay", d, astilogger.KeyError, err)
+		} else {
+			l.Debug("astihttp: request returned a retryable status code, retrying", "url", req.URL,
+				"status_code", resp.StatusCode, "delay", d)
+		}
+		if err = astitime.Sleep(req.Context(), d); err != nil {
+			err = errors.Wrapf(err, "astihttp: sleeping before retrying request to %s failed", req.URL)
+			resp = nil
+			return
+		}
+	}
+}
+
+// retryAfter parses the Retry-After header of a response
+// It can either be a number of seconds or an HTTP date
+func retryAfter(resp *http.Response) (d time.Duration, ok bool) {
+	// Get header
+	var h = resp.Header.Get("Retry-After")
+	if h == "" {
+		return
+	}
+
+	// Seconds
+	if s, err := strconv.Atoi(h); err == nil {
+		if s < 0 {
+			return
+		}
+		return time.Duration(s) * time.Second, true
+	}
+
+	// HTTP date
+	t, err := http.ParseTime(h)
+	if err != nil {
+		return
+	}
+	if d = time.Until(t); d < 0 {
+		d = 0
+	}
+	return d, true
+}
@@ -0,0 +1,140 @@
+package astihttp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// ErrResponseTooLarge is returned when a response body exceeds the sender's maximum response size
+var ErrResponseTooLarge = errors.New("astihttp: response is too large")
+
+// StatusError represents an error returned when a response has an unexpected status code
+type StatusError struct {
+	Body       []byte
+	StatusCode int
+	URL        string
+}
+
+// Error implements the error interface
+func (e StatusError) Error() string {
+	return fmt.Sprintf("astihttp: sending request to %s returned %d status code", e.URL, e.StatusCode)
+}
+
+// encoding represents a request/response encoding
+type encoding struct {
+	contentType string
+	marshal     func(v interface{}) ([]byte, error)
+	unmarshal   func(data []byte, v interface{}) error
+}
+
+// Encodings
+var (
+	encodingJSON = encoding{contentType: "application/json", marshal: json.Marshal, unmarshal: json.Unmarshal}
+	encodingXML  = encoding{contentType: "application/xml", marshal: xml.Marshal, unmarshal: xml.Unmarshal}
+)
+
+// SendJSON sends a request whose body is the JSON encoding of reqPayload and decodes the response JSON body into
+// respPayload
+// reqPayload and respPayload can be nil. A StatusError is returned if the response status code is not 2xx.
+func (s *Sender) SendJSON(ctx context.Context, method, url string, reqPayload, respPayload interface{}) error {
+	return s.sendWithEncoding(ctx, method, url, reqPayload, respPayload, encodingJSON)
+}
+
+// SendXML sends a request whose body is the XML encoding of reqPayload and decodes the response XML body into
+// respPayload
+// reqPayload and respPayload can be nil. A StatusError is returned if the response status code is not 2xx.
+func (s *Sender) SendXML(ctx context.Context, method, url string, reqPayload, respPayload interface{}) error {
+	return s.sendWithEncoding(ctx, method, url, reqPayload, respPayload, encodingXML)
+}
+
+// sendWithEncoding sends a request and decodes its response using a specific encoding
+func (s *Sender) sendWithEncoding(ctx context.Context, method, url string, reqPayload, respPayload interface{}, e encoding) (err error) {
+	// Marshal request payload
+	var body io.Reader
+	if reqPayload != nil {
+		var b []byte
+		if b, err = e.marshal(reqPayload); err != nil {
+			return errors.Wrapf(err, "astihttp: marshaling payload of request to %s failed", url)
+		}
+		body = bytes.NewReader(b)
+	}
+
+	// Create request
+	var req *http.Request
+	if req, err = http.NewRequest(method, url, body); err != nil {
+		return errors.Wrapf(err, "astihttp: creating %s request to %s failed", method, url)
+	}
+	req = req.WithContext(ctx)
+
+	// Set headers
+	if reqPayload != nil {
+		req.Header.Set("Content-Type", e.contentType)
+	}
+	if respPayload != nil {
+		req.Header.Set("Accept", e.contentType)
+	}
+
+	// Send request
+	var resp *http.Response
+	if resp, err = s.Send(req); err != nil {
+		return
+	}
+	defer resp.Body.Close()
+
+	// Read body
+	var b []byte
+	if b, err = s.readBody(resp); err != nil {
+		return errors.Wrapf(err, "astihttp: reading body of response from %s failed", url)
+	}
+
+	// Validate status code
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return StatusError{
+			Body:       b,
+			StatusCode: resp.StatusCode,
+			URL:        url,
+		}
+	}
+
+	// Unmarshal response payload
+	if respPayload != nil && len(b) > 0 {
+		if err = e.unmarshal(b, respPayload); err != nil {
+			return errors.Wrapf(err, "astihttp: unmarshaling payload of response from %s failed", url)
+		}
+	}
+	return
+}
+
+// readBody reads the body of a response while making sure it doesn't exceed the maximum response size
+func (s *Sender) readBody(resp *http.Response) (b []byte, err error) {
+	// No limit
+	if s.o.ResponseMaxSize <= 0 {
+		return ioutil.ReadAll(resp.Body)
+	}
+
+	// Response is too large
+	if resp.ContentLength > s.o.ResponseMaxSize {
+		err = ErrResponseTooLarge
+		return
+	}
+
+	// Read
+	if b, err = ioutil.ReadAll(io.LimitReader(resp.Body, s.o.ResponseMaxSize+1)); err != nil {
+		return
+	}
+
+	// Response is too large
+	if int64(len(b)) > s.o.ResponseMaxSize {
+		b = nil
+		err = ErrResponseTooLarge
+	}
+	return
+}
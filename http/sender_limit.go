@@ -0,0 +1,114 @@
+package astihttp
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/asticode/go-astitools/limiter"
+	"github.com/pkg/errors"
+)
+
+// ErrCircuitBreakerOpen is returned when the circuit breaker of the request's host is open
+var ErrCircuitBreakerOpen = errors.New("astihttp: circuit breaker is open")
+
+// do sends a single request while enforcing the circuit breaker, the rate limit and the maximum number of concurrent
+// requests
+func (s *Sender) do(req *http.Request) (resp *http.Response, err error) {
+	// Get client
+	var c *http.Client
+	if c, err = s.clientFor(req); err != nil {
+		return
+	}
+
+	// Wait for rate limit
+	if err = s.waitRateLimit(req.Context(), req.URL.Host); err != nil {
+		err = errors.Wrapf(err, "astihttp: waiting for rate limit of %s failed", req.URL.Host)
+		return
+	}
+
+	// Acquire slot
+	var release = func() {}
+	if s.slots != nil {
+		select {
+		case s.slots <- true:
+			release = func() { <-s.slots }
+		case <-req.Context().Done():
+			err = errors.Wrap(req.Context().Err(), "astihttp: acquiring request slot failed")
+			return
+		}
+	}
+
+	// Check circuit breaker
+	var b = s.breaker(req.URL.Host)
+	if b != nil && !b.Allow() {
+		release()
+		err = ErrCircuitBreakerOpen
+		return
+	}
+
+	// Send request
+	if resp, err = c.Do(req); err != nil {
+		release()
+	} else {
+		resp.Body = &releaseBody{ReadCloser: resp.Body, release: release}
+	}
+
+	// Update circuit breaker
+	if b != nil {
+		if err != nil || resp.StatusCode >= http.StatusInternalServerError {
+			b.Failure()
+		} else {
+			b.Success()
+		}
+	}
+	return
+}
+
+// breaker retrieves the circuit breaker of a host
+func (s *Sender) breaker(host string) (b *astilimiter.CircuitBreaker) {
+	// Circuit breakers are disabled
+	if s.o.CircuitBreakerMaxFailures <= 0 {
+		return
+	}
+
+	// Lock
+	s.mb.Lock()
+	defer s.mb.Unlock()
+
+	// Get or create circuit breaker
+	var ok bool
+	if b, ok = s.breakers[host]; !ok {
+		b = astilimiter.NewCircuitBreaker(s.o.CircuitBreakerMaxFailures, s.o.CircuitBreakerCooldown)
+		s.breakers[host] = b
+	}
+	return
+}
+
+// waitRateLimit blocks until the rate limit of a host allows a new request
+func (s *Sender) waitRateLimit(ctx context.Context, host string) (err error) {
+	// Rate limiting is disabled
+	if s.limiter == nil {
+		return
+	}
+
+	// Wait until the bucket can be incremented
+	err = s.limiter.Add(host, s.o.RateLimitCap, s.o.RateLimitPeriod).Wait(ctx)
+	return
+}
+
+// releaseBody represents a response body that releases its request slot once closed
+type releaseBody struct {
+	closed  bool
+	release func()
+	io.ReadCloser
+}
+
+// Close implements the io.Closer interface
+func (b *releaseBody) Close() error {
+	if !b.closed {
+		b.closed = true
+		b.release()
+	}
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,231 @@
+package astihttp
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/asticode/go-astitools/logger"
+	"github.com/pkg/errors"
+)
+
+// ReauthenticateFunc represents a func re-authenticating the sender once a request has returned a 401 status code,
+// typically by sending a login request whose cookies are stored in the cookie jar
+// Requests sent with the provided context don't trigger re-authentication.
+type ReauthenticateFunc func(ctx context.Context, s *Sender) error
+
+type ctxKeyReauthenticating struct{}
+
+// shouldReauthenticate checks whether the request has returned a 401 status code and can be resent after
+// re-authenticating
+func (s *Sender) shouldReauthenticate(req *http.Request, resp *http.Response, err error) bool {
+	return s.o.Reauthenticate != nil && err == nil && resp.StatusCode == http.StatusUnauthorized &&
+		req.Context().Value(ctxKeyReauthenticating{}) == nil && (req.Body == nil || req.GetBody != nil)
+}
+
+// reauthenticate closes the 401 response, executes the re-authentication hook and resets the request's body so that
+// it can be resent
+// Concurrent requests returning a 401 status code while the hook is being executed wait for it and are resent
+// without executing it again.
+func (s *Sender) reauthenticate(req *http.Request, resp *http.Response, sentAt time.Time) (err error) {
+	// Drain and close body so that the connection can be reused
+	io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+
+	// Lock
+	s.ma.Lock()
+	defer s.ma.Unlock()
+
+	// Re-authenticate unless it has been done since the request was sent
+	if s.reauthenticatedAt.Before(sentAt) {
+		astilogger.WithContext(s.l, req.Context()).Debug("astihttp: request returned 401 status code, re-authenticating",
+			"url", req.URL)
+		if err = s.o.Reauthenticate(context.WithValue(req.Context(), ctxKeyReauthenticating{}, true), s); err != nil {
+			err = errors.Wrap(err, "astihttp: re-authenticating failed")
+			return
+		}
+		s.reauthenticatedAt = time.Now()
+	}
+
+	// Reset body
+	if req.Body != nil {
+		var b io.ReadCloser
+		if b, err = req.GetBody(); err != nil {
+			err = errors.Wrapf(err, "astihttp: getting body of request to %s failed", req.URL)
+			return
+		}
+		req.Body = b
+	}
+	return
+}
+
+// setDefaultHeaders sets the sender's default headers that are not already set on the request
+func (s *Sender) setDefaultHeaders(req *http.Request) {
+	for k, vs := range s.o.Headers {
+		if _, ok := req.Header[http.CanonicalHeaderKey(k)]; ok {
+			continue
+		}
+		if req.Header == nil {
+			req.Header = make(http.Header)
+		}
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+}
+
+// NewMemoryCookieJar creates a new in-memory cookie jar
+func NewMemoryCookieJar() http.CookieJar {
+	j, _ := cookiejar.New(nil)
+	return j
+}
+
+// FileCookieJar represents a cookie jar persisted in a file so that sessions survive restarts
+// Cookies are saved every time they're set.
+type FileCookieJar struct {
+	cs   map[string]fileCookie
+	j    *cookiejar.Jar
+	m    *sync.Mutex // Locks cs and writes
+	path string
+}
+
+type fileCookie struct {
+	Cookie *http.Cookie `json:"cookie"`
+	URL    string       `json:"url"`
+}
+
+// NewFileCookieJar creates a new file cookie jar and loads cookies from the file if it exists
+func NewFileCookieJar(path string) (j *FileCookieJar, err error) {
+	// Create jar
+	j = &FileCookieJar{
+		cs:   make(map[string]fileCookie),
+		m:    &sync.Mutex{},
+		path: path,
+	}
+	if j.j, err = cookiejar.New(nil); err != nil {
+		err = errors.Wrap(err, "astihttp: creating cookie jar failed")
+		return
+	}
+
+	// Read file
+	var b []byte
+	if b, err = ioutil.ReadFile(path); err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		} else {
+			err = errors.Wrapf(err, "astihttp: reading %s failed", path)
+		}
+		return
+	}
+
+	// Unmarshal
+	var cs []fileCookie
+	if err = json.Unmarshal(b, &cs); err != nil {
+		err = errors.Wrapf(err, "astihttp: unmarshaling %s failed", path)
+		return
+	}
+
+	// Load cookies
+	var now = time.Now()
+	for _, c := range cs {
+		// Cookie has expired
+		if !c.Cookie.Expires.IsZero() && c.Cookie.Expires.Before(now) {
+			continue
+		}
+
+		// Parse url
+		var u *url.URL
+		if u, err = url.Parse(c.URL); err != nil {
+			err = errors.Wrapf(err, "astihttp: parsing url %s failed", c.URL)
+			return
+		}
+
+		// Set cookie
+		j.j.SetCookies(u, []*http.Cookie{c.Cookie})
+		j.cs[fileCookieKey(u, c.Cookie)] = c
+	}
+	return
+}
+
+// fileCookieKey returns the key uniquely identifying a cookie
+func fileCookieKey(u *url.URL, c *http.Cookie) string {
+	var d = c.Domain
+	if d == "" {
+		d = u.Hostname()
+	}
+	return d + ";" + c.Path + ";" + c.Name
+}
+
+// Cookies implements the http.CookieJar interface
+func (j *FileCookieJar) Cookies(u *url.URL) []*http.Cookie {
+	return j.j.Cookies(u)
+}
+
+// SetCookies implements the http.CookieJar interface
+func (j *FileCookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	// Set cookies
+	j.j.SetCookies(u, cookies)
+
+	// Lock
+	j.m.Lock()
+	defer j.m.Unlock()
+
+	// Update cookies
+	var now = time.Now()
+	for _, c := range cookies {
+		// Copy cookie
+		var cc = *c
+		if cc.MaxAge > 0 {
+			cc.Expires = now.Add(time.Duration(cc.MaxAge) * time.Second)
+			cc.MaxAge = 0
+		}
+
+		// Cookie is deleted
+		var k = fileCookieKey(u, &cc)
+		if cc.MaxAge < 0 || (!cc.Expires.IsZero() && cc.Expires.Before(now)) {
+			delete(j.cs, k)
+			continue
+		}
+		j.cs[k] = fileCookie{Cookie: &cc, URL: u.Scheme + "://" + u.Host + "/"}
+	}
+
+	// Save
+	if err := j.save(); err != nil {
+		astilogger.Default().Error("astihttp: saving cookies failed", astilogger.KeyError, err)
+	}
+}
+
+// Save saves the cookies in the file
+func (j *FileCookieJar) Save() error {
+	j.m.Lock()
+	defer j.m.Unlock()
+	return j.save()
+}
+
+// save saves the cookies in the file, assuming the lock is held
+func (j *FileCookieJar) save() (err error) {
+	// Marshal
+	var cs = []fileCookie{}
+	for _, c := range j.cs {
+		cs = append(cs, c)
+	}
+	var b []byte
+	if b, err = json.Marshal(cs); err != nil {
+		err = errors.Wrap(err, "astihttp: marshaling cookies failed")
+		return
+	}
+
+	// Write
+	if err = ioutil.WriteFile(j.path, b, 0600); err != nil {
+		err = errors.Wrapf(err, "astihttp: writing %s failed", j.path)
+		return
+	}
+	return
+}
@@ -0,0 +1,76 @@
+package astihttp_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/asticode/go-astitools/http"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSender_Session(t *testing.T) {
+	// Create server
+	var logins int
+	s := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "test", r.Header.Get("User-Agent"))
+		switch r.URL.Path {
+		case "/login":
+			logins++
+			http.SetCookie(rw, &http.Cookie{Name: "session", Path: "/", Value: "valid"})
+		default:
+			if c, err := r.Cookie("session"); err != nil || c.Value != "valid" {
+				rw.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			rw.Write([]byte("ok"))
+		}
+	}))
+	defer s.Close()
+
+	// Create sender
+	var p = filepath.Join(t.TempDir(), "cookies.json")
+	j, err := astihttp.NewFileCookieJar(p)
+	assert.NoError(t, err)
+	var o = astihttp.SenderOptions{
+		CookieJar: j,
+		Headers:   http.Header{"User-Agent": []string{"test"}},
+		Reauthenticate: func(ctx context.Context, snd *astihttp.Sender) error {
+			req, _ := http.NewRequestWithContext(ctx, http.MethodPost, s.URL+"/login", nil)
+			resp, err := snd.Send(req)
+			if err != nil {
+				return err
+			}
+			return resp.Body.Close()
+		},
+	}
+	var ctx = context.Background()
+	snd := astihttp.NewSender(o)
+	assert.Nil(t, http.DefaultClient.Jar)
+
+	// Re-authentication
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, s.URL+"/data", nil)
+	resp, err := snd.Send(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+	assert.Equal(t, 1, logins)
+	assert.Equal(t, http.Header{}, req.Header)
+
+	// Cookies are persisted
+	j, err = astihttp.NewFileCookieJar(p)
+	assert.NoError(t, err)
+	u, _ := url.Parse(s.URL)
+	assert.Len(t, j.Cookies(u), 1)
+	o.CookieJar = j
+	snd = astihttp.NewSender(o)
+	req, _ = http.NewRequestWithContext(ctx, http.MethodGet, s.URL+"/data", nil)
+	resp, err = snd.Send(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	resp.Body.Close()
+	assert.Equal(t, 1, logins)
+}
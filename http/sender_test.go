@@ -0,0 +1,173 @@
+package astihttp_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/http"
+	"github.com/asticode/go-astitools/time"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSender_Send(t *testing.T) {
+	// Init
+	var count int
+	var bodies []string
+	s := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		bodies = append(bodies, string(b))
+		count++
+		switch count {
+		case 1:
+			rw.WriteHeader(http.StatusInternalServerError)
+		case 2:
+			rw.Header().Set("Retry-After", "0")
+			rw.WriteHeader(http.StatusTooManyRequests)
+		default:
+			rw.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer s.Close()
+
+	// Retries succeed
+	snd := astihttp.NewSender(astihttp.SenderOptions{
+		RetryBackoff: astitime.ConstantBackoff(time.Millisecond),
+		RetryMax:     2,
+	})
+	req, _ := http.NewRequest(http.MethodPost, s.URL, bytes.NewReader([]byte("body")))
+	resp, err := snd.Send(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 3, count)
+	assert.Equal(t, []string{"body", "body", "body"}, bodies)
+
+	// Retries are exhausted
+	count = 0
+	snd = astihttp.NewSender(astihttp.SenderOptions{
+		RetryBackoff: astitime.ConstantBackoff(time.Millisecond),
+		RetryMax:     1,
+	})
+	req, _ = http.NewRequest(http.MethodGet, s.URL, nil)
+	resp, err = snd.Send(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, 2, count)
+
+	// Custom predicate
+	count = 0
+	snd = astihttp.NewSender(astihttp.SenderOptions{
+		RetryBackoff:   astitime.ConstantBackoff(time.Millisecond),
+		RetryMax:       2,
+		RetryPredicate: astihttp.RetryOnStatusCodes(http.StatusTooManyRequests),
+	})
+	resp, err = snd.Send(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	assert.Equal(t, 1, count)
+
+	// Context is cancelled
+	count = 0
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	snd = astihttp.NewSender(astihttp.SenderOptions{RetryMax: 2})
+	req, _ = http.NewRequest(http.MethodGet, s.URL, nil)
+	_, err = snd.Send(req.WithContext(ctx))
+	assert.Error(t, err)
+	assert.Equal(t, 0, count)
+}
+
+func TestSender_SendJSON(t *testing.T) {
+	// Init
+	type payload struct {
+		Value string `json:"value" xml:"value"`
+	}
+	s := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/error":
+			rw.WriteHeader(http.StatusBadRequest)
+			rw.Write([]byte("bad request"))
+		case "/large":
+			rw.Write(bytes.Repeat([]byte("a"), 20))
+		case "/json":
+			var p payload
+			json.NewDecoder(r.Body).Decode(&p)
+			rw.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+			json.NewEncoder(rw).Encode(payload{Value: p.Value + "-response"})
+		case "/xml":
+			var p payload
+			xml.NewDecoder(r.Body).Decode(&p)
+			xml.NewEncoder(rw).Encode(payload{Value: p.Value + "-response"})
+		}
+	}))
+	defer s.Close()
+	snd := astihttp.NewSender(astihttp.SenderOptions{})
+
+	// JSON
+	var p payload
+	err := snd.SendJSON(context.Background(), http.MethodPost, s.URL+"/json", payload{Value: "json"}, &p)
+	assert.NoError(t, err)
+	assert.Equal(t, payload{Value: "json-response"}, p)
+
+	// XML
+	err = snd.SendXML(context.Background(), http.MethodPost, s.URL+"/xml", payload{Value: "xml"}, &p)
+	assert.NoError(t, err)
+	assert.Equal(t, payload{Value: "xml-response"}, p)
+
+	// Status error
+	err = snd.SendJSON(context.Background(), http.MethodGet, s.URL+"/error", nil, &p)
+	assert.Equal(t, astihttp.StatusError{Body: []byte("bad request"), StatusCode: http.StatusBadRequest, URL: s.URL + "/error"}, err)
+
+	// Response is too large
+	snd = astihttp.NewSender(astihttp.SenderOptions{ResponseMaxSize: 10})
+	err = snd.SendJSON(context.Background(), http.MethodGet, s.URL+"/large", nil, &p)
+	assert.Error(t, err)
+	assert.Equal(t, astihttp.ErrResponseTooLarge, errors.Cause(err))
+}
+
+func TestSender_Limits(t *testing.T) {
+	// Init
+	var count int
+	s := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		count++
+		rw.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer s.Close()
+
+	// Circuit breaker
+	snd := astihttp.NewSender(astihttp.SenderOptions{
+		CircuitBreakerCooldown:    time.Minute,
+		CircuitBreakerMaxFailures: 2,
+		RetryBackoff:              astitime.ConstantBackoff(time.Millisecond),
+		RetryMax:                  5,
+	})
+	defer snd.Close()
+	req, _ := http.NewRequest(http.MethodGet, s.URL, nil)
+	_, err := snd.Send(req)
+	assert.Equal(t, astihttp.ErrCircuitBreakerOpen, err)
+	assert.Equal(t, 2, count)
+
+	// Rate limit
+	count = 0
+	snd = astihttp.NewSender(astihttp.SenderOptions{
+		MaxConcurrentRequests: 1,
+		RateLimitCap:          2,
+		RateLimitPeriod:       50 * time.Millisecond,
+		RetryBackoff:          astitime.ConstantBackoff(0),
+		RetryMax:              2,
+	})
+	defer snd.Close()
+	n := time.Now()
+	resp, err := snd.Send(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, 3, count)
+	assert.True(t, time.Since(n) >= 40*time.Millisecond)
+}
@@ -0,0 +1,158 @@
+package astihttp
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// TLSOptions represents TLS options
+type TLSOptions struct {
+	// Paths of PEM encoded root CAs. They replace the system root CAs unless SystemCAs is true.
+	CAFiles []string
+	// Paths of the PEM encoded client certificate and its key
+	CertFile string
+	KeyFile  string
+	// Disables server certificate verification, which should only be used for tests
+	InsecureSkipVerify bool
+	// Overrides the server name used to verify the server certificate
+	ServerName string
+	// If true, CAFiles are added to the system root CAs
+	SystemCAs bool
+}
+
+// NewTLSConfig creates a TLS configuration for SenderOptions.TLSConfig or WithTLSConfig
+func NewTLSConfig(o TLSOptions) (c *tls.Config, err error) {
+	// Create config
+	c = &tls.Config{
+		InsecureSkipVerify: o.InsecureSkipVerify,
+		ServerName:         o.ServerName,
+	}
+
+	// Add root CAs
+	if len(o.CAFiles) > 0 {
+		// Create pool
+		if o.SystemCAs {
+			if c.RootCAs, err = x509.SystemCertPool(); err != nil {
+				err = errors.Wrap(err, "astihttp: loading system cert pool failed")
+				return
+			}
+		} else {
+			c.RootCAs = x509.NewCertPool()
+		}
+
+		// Loop through files
+		for _, p := range o.CAFiles {
+			var b []byte
+			if b, err = ioutil.ReadFile(p); err != nil {
+				err = errors.Wrapf(err, "astihttp: reading %s failed", p)
+				return
+			}
+			if !c.RootCAs.AppendCertsFromPEM(b) {
+				err = errors.Errorf("astihttp: no certificate found in %s", p)
+				return
+			}
+		}
+	}
+
+	// Add client certificate
+	if o.CertFile != "" || o.KeyFile != "" {
+		var cert tls.Certificate
+		if cert, err = tls.LoadX509KeyPair(o.CertFile, o.KeyFile); err != nil {
+			err = errors.Wrapf(err, "astihttp: loading key pair %s/%s failed", o.CertFile, o.KeyFile)
+			return
+		}
+		c.Certificates = []tls.Certificate{cert}
+	}
+	return
+}
+
+type ctxKeyTLSConfig struct{}
+
+// WithTLSConfig returns a context overriding the sender's TLS configuration for requests sent with it
+func WithTLSConfig(ctx context.Context, c *tls.Config) context.Context {
+	return context.WithValue(ctx, ctxKeyTLSConfig{}, c)
+}
+
+// configureTransport copies the client and its transport when proxy or TLS options are set
+// It does nothing if the client's transport is not an *http.Transport, e.g. a Cache or a Recorder, whose own
+// transport must be configured instead.
+func configureTransport(o SenderOptions) *http.Client {
+	// Nothing to configure
+	if o.Proxy == nil && !o.ProxyFromEnvironment && o.TLSConfig == nil {
+		return o.Client
+	}
+
+	// Get transport
+	t, ok := baseTransport(o.Client)
+	if !ok {
+		o.Logger.Warn("astihttp: client's transport is not an *http.Transport, proxy and TLS options are ignored")
+		return o.Client
+	}
+
+	// Configure
+	if o.Proxy != nil {
+		t.Proxy = http.ProxyURL(o.Proxy)
+	} else if o.ProxyFromEnvironment {
+		t.Proxy = http.ProxyFromEnvironment
+	}
+	if o.TLSConfig != nil {
+		t.TLSClientConfig = o.TLSConfig
+	}
+
+	// Copy client
+	var c = *o.Client
+	c.Transport = t
+	return &c
+}
+
+// baseTransport returns a clone of the client's transport
+func baseTransport(c *http.Client) (t *http.Transport, ok bool) {
+	var rt = c.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	if t, ok = rt.(*http.Transport); ok {
+		t = t.Clone()
+	}
+	return
+}
+
+// clientFor returns the client a request must be sent with, taking per-request TLS overrides into account
+// Clients are cached per TLS configuration so that connections are reused.
+func (s *Sender) clientFor(req *http.Request) (c *http.Client, err error) {
+	// No override
+	tc, ok := req.Context().Value(ctxKeyTLSConfig{}).(*tls.Config)
+	if !ok || tc == nil {
+		c = s.client
+		return
+	}
+
+	// Lock
+	s.mt.Lock()
+	defer s.mt.Unlock()
+
+	// Client is cached
+	if c, ok = s.tlsClients[tc]; ok {
+		return
+	}
+
+	// Get transport
+	var t *http.Transport
+	if t, ok = baseTransport(s.client); !ok {
+		err = errors.New("astihttp: per-request TLS configuration requires the client's transport to be an *http.Transport")
+		return
+	}
+	t.TLSClientConfig = tc
+
+	// Create client
+	var cc = *s.client
+	cc.Transport = t
+	c = &cc
+	s.tlsClients[tc] = c
+	return
+}
@@ -0,0 +1,72 @@
+package astihttp_test
+
+import (
+	"context"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+
+	"github.com/asticode/go-astitools/http"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSender_TLS(t *testing.T) {
+	// Create server
+	s := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {}))
+	defer s.Close()
+	p := filepath.Join(t.TempDir(), "ca.pem")
+	assert.NoError(t, ioutil.WriteFile(p, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: s.Certificate().Raw}), 0600))
+	var send = func(sd *astihttp.Sender, ctx context.Context) error {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+		resp, err := sd.Send(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	// Unknown CA
+	assert.Error(t, send(astihttp.NewSender(astihttp.SenderOptions{}), context.Background()))
+
+	// Custom CA
+	c, err := astihttp.NewTLSConfig(astihttp.TLSOptions{CAFiles: []string{p}})
+	assert.NoError(t, err)
+	sd := astihttp.NewSender(astihttp.SenderOptions{TLSConfig: c})
+	assert.NoError(t, send(sd, context.Background()))
+	sd.Close()
+
+	// Per-request override
+	sd = astihttp.NewSender(astihttp.SenderOptions{})
+	defer sd.Close()
+	assert.NoError(t, send(sd, astihttp.WithTLSConfig(context.Background(), c)))
+
+	// Invalid files
+	_, err = astihttp.NewTLSConfig(astihttp.TLSOptions{CAFiles: []string{filepath.Join(t.TempDir(), "missing.pem")}})
+	assert.Error(t, err)
+	_, err = astihttp.NewTLSConfig(astihttp.TLSOptions{CertFile: p, KeyFile: p})
+	assert.Error(t, err)
+}
+
+func TestSender_Proxy(t *testing.T) {
+	// Create proxy
+	var host string
+	p := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		host = r.URL.Host
+		rw.WriteHeader(http.StatusNoContent)
+	}))
+	defer p.Close()
+	u, _ := url.Parse(p.URL)
+
+	// Send
+	sd := astihttp.NewSender(astihttp.SenderOptions{Proxy: u})
+	req, _ := http.NewRequest(http.MethodGet, "http://example.invalid/path", nil)
+	resp, err := sd.Send(req)
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, "example.invalid", host)
+}
@@ -0,0 +1,256 @@
+package astihttp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/asticode/go-astitools/logger"
+	"github.com/pkg/errors"
+)
+
+// sseFieldSanitizer removes line breaks from single line fields so that they can't inject other fields or events
+var sseFieldSanitizer = strings.NewReplacer("\r", "", "\n", "")
+
+// sseLineBreakNormalizer replaces line breaks with "\n" since clients end lines on "\r\n", "\r" and "\n"
+var sseLineBreakNormalizer = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+// ServerSentEvent represents a server-sent event
+// Line breaks are stripped from ID and Name, and each line of Data is sent in its own data field.
+type ServerSentEvent struct {
+	Data string
+	ID   string
+	Name string
+	// Reconnection time sent to the client. 0 means it's not sent
+	Retry time.Duration
+}
+
+// bytes returns the event in the text/event-stream format
+func (e ServerSentEvent) bytes() []byte {
+	var buf = &bytes.Buffer{}
+	if id := sseFieldSanitizer.Replace(e.ID); id != "" {
+		fmt.Fprintf(buf, "id: %s\n", id)
+	}
+	if n := sseFieldSanitizer.Replace(e.Name); n != "" {
+		fmt.Fprintf(buf, "event: %s\n", n)
+	}
+	if e.Retry > 0 {
+		fmt.Fprintf(buf, "retry: %d\n", e.Retry/time.Millisecond)
+	}
+	for _, l := range strings.Split(sseLineBreakNormalizer.Replace(e.Data), "\n") {
+		fmt.Fprintf(buf, "data: %s\n", l)
+	}
+	buf.WriteString("\n")
+	return buf.Bytes()
+}
+
+// EventWriter represents an object capable of writing server-sent events to an http.ResponseWriter
+type EventWriter struct {
+	f           http.Flusher
+	lastEventID string
+	m           sync.Mutex // Locks rw
+	rw          http.ResponseWriter
+}
+
+// NewEventWriter creates a new event writer and writes the event stream headers
+func NewEventWriter(rw http.ResponseWriter, r *http.Request) (w *EventWriter, err error) {
+	// Check flusher
+	f, ok := rw.(http.Flusher)
+	if !ok {
+		err = errors.New("astihttp: response writer doesn't implement http.Flusher")
+		return
+	}
+
+	// Create writer
+	w = &EventWriter{
+		f:           f,
+		lastEventID: r.Header.Get("Last-Event-ID"),
+		rw:          rw,
+	}
+
+	// Write headers
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.Header().Set("Connection", "keep-alive")
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.WriteHeader(http.StatusOK)
+	f.Flush()
+	return
+}
+
+// LastEventID returns the ID of the last event received by the client before it reconnected, if any
+func (w *EventWriter) LastEventID() string {
+	return w.lastEventID
+}
+
+// Write writes an event and flushes it
+func (w *EventWriter) Write(e ServerSentEvent) error {
+	return w.write(e.bytes())
+}
+
+// Heartbeat writes a comment so that the connection isn't closed by proxies
+func (w *EventWriter) Heartbeat() error {
+	return w.write([]byte(": heartbeat\n\n"))
+}
+
+// KeepAlive writes heartbeats periodically until the context is cancelled or a heartbeat fails
+func (w *EventWriter) KeepAlive(ctx context.Context, period time.Duration) (err error) {
+	var t = time.NewTicker(period)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			if err = w.Heartbeat(); err != nil {
+				return
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// write writes bytes and flushes them
+func (w *EventWriter) write(b []byte) (err error) {
+	w.m.Lock()
+	defer w.m.Unlock()
+	if _, err = w.rw.Write(b); err != nil {
+		return errors.Wrap(err, "astihttp: writing event failed")
+	}
+	w.f.Flush()
+	return
+}
+
+// EventBroadcaster represents an object capable of fanning out server-sent events to many clients
+// It implements the http.Handler interface.
+type EventBroadcaster struct {
+	clients map[chan ServerSentEvent]bool
+	history []ServerSentEvent
+	id      uint64
+	m       sync.Mutex // Locks clients, history and id
+	o       EventBroadcasterOptions
+}
+
+// EventBroadcasterOptions represents event broadcaster options
+type EventBroadcasterOptions struct {
+	// Number of events buffered per client. Events are dropped for clients whose buffer is full. Defaults to 16
+	BufferSize int
+	// Defaults to 15s
+	HeartbeatPeriod time.Duration
+	// Number of events kept so that clients reconnecting with a Last-Event-ID header receive events they've missed
+	HistorySize int
+	// Defaults to astilogger.Default()
+	Logger astilogger.Logger
+}
+
+// NewEventBroadcaster creates a new event broadcaster
+func NewEventBroadcaster(o EventBroadcasterOptions) *EventBroadcaster {
+	// Default options values
+	if o.BufferSize <= 0 {
+		o.BufferSize = 16
+	}
+	if o.HeartbeatPeriod <= 0 {
+		o.HeartbeatPeriod = 15 * time.Second
+	}
+	if o.Logger == nil {
+		o.Logger = astilogger.Default()
+	}
+	return &EventBroadcaster{
+		clients: make(map[chan ServerSentEvent]bool),
+		o:       o,
+	}
+}
+
+// Broadcast sends an event to all connected clients
+// If the event has no ID, an incrementing ID is assigned to it.
+func (b *EventBroadcaster) Broadcast(e ServerSentEvent) {
+	// Lock
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	// Set ID
+	// It's sanitized the way it's sent so that it matches the Last-Event-ID header of reconnecting clients
+	if e.ID = sseFieldSanitizer.Replace(e.ID); e.ID == "" {
+		b.id++
+		e.ID = strconv.FormatUint(b.id, 10)
+	}
+
+	// Add to history
+	if b.o.HistorySize > 0 {
+		b.history = append(b.history, e)
+		if len(b.history) > b.o.HistorySize {
+			b.history = b.history[len(b.history)-b.o.HistorySize:]
+		}
+	}
+
+	// Loop through clients
+	for ch := range b.clients {
+		select {
+		case ch <- e:
+		default:
+			b.o.Logger.Warn("astihttp: buffer of event broadcaster client is full, dropping event", "event_id", e.ID)
+		}
+	}
+}
+
+// ServeHTTP implements the http.Handler interface
+func (b *EventBroadcaster) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	// Create writer
+	w, err := NewEventWriter(rw, r)
+	if err != nil {
+		astilogger.WithContext(b.o.Logger, r.Context()).Error("astihttp: creating event writer failed",
+			astilogger.KeyError, err)
+		rw.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	// Register client and get missed events
+	var ch = make(chan ServerSentEvent, b.o.BufferSize)
+	b.m.Lock()
+	b.clients[ch] = true
+	var missed []ServerSentEvent
+	if id := w.LastEventID(); id != "" {
+		for idx, e := range b.history {
+			if e.ID == id {
+				missed = append(missed, b.history[idx+1:]...)
+				break
+			}
+		}
+	}
+	b.m.Unlock()
+
+	// Unregister client
+	defer func() {
+		b.m.Lock()
+		delete(b.clients, ch)
+		b.m.Unlock()
+	}()
+
+	// Write missed events
+	for _, e := range missed {
+		if err = w.Write(e); err != nil {
+			return
+		}
+	}
+
+	// Loop
+	var t = time.NewTicker(b.o.HeartbeatPeriod)
+	defer t.Stop()
+	for {
+		select {
+		case e := <-ch:
+			if err = w.Write(e); err != nil {
+				return
+			}
+		case <-t.C:
+			if err = w.Heartbeat(); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
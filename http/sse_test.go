@@ -0,0 +1,85 @@
+package astihttp_test
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/http"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEventWriter(t *testing.T) {
+	rec := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Last-Event-ID", "3")
+	w, err := astihttp.NewEventWriter(rec, r)
+	assert.NoError(t, err)
+	assert.Equal(t, "3", w.LastEventID())
+	assert.Equal(t, "text/event-stream", rec.Header().Get("Content-Type"))
+	w.Write(astihttp.ServerSentEvent{Data: "line1\nline2", ID: "4", Name: "name", Retry: time.Second})
+	w.Heartbeat()
+	assert.Equal(t, "id: 4\nevent: name\nretry: 1000\ndata: line1\ndata: line2\n\n: heartbeat\n\n", rec.Body.String())
+
+	// Line breaks are stripped from ID and Name
+	rec.Body.Reset()
+	w.Write(astihttp.ServerSentEvent{Data: "d", ID: "5\r\ndata: injected", Name: "na\nme\r"})
+	assert.Equal(t, "id: 5data: injected\nevent: name\ndata: d\n\n", rec.Body.String())
+
+	// Line breaks of Data are normalized
+	rec.Body.Reset()
+	w.Write(astihttp.ServerSentEvent{Data: "a\revent: evil\r\nb"})
+	assert.Equal(t, "data: a\ndata: event: evil\ndata: b\n\n", rec.Body.String())
+}
+
+func TestEventBroadcaster(t *testing.T) {
+	// Init
+	b := astihttp.NewEventBroadcaster(astihttp.EventBroadcasterOptions{HistorySize: 2})
+	s := httptest.NewServer(b)
+	defer s.Close()
+	b.Broadcast(astihttp.ServerSentEvent{Data: "1"})
+	b.Broadcast(astihttp.ServerSentEvent{Data: "2"})
+	b.Broadcast(astihttp.ServerSentEvent{Data: "3"})
+
+	// Connect with Last-Event-ID
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequest(http.MethodGet, s.URL, nil)
+	req.Header.Set("Last-Event-ID", "2")
+	resp, err := http.DefaultClient.Do(req.WithContext(ctx))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	rd := bufio.NewReader(resp.Body)
+	var readEvent = func() (lines []string) {
+		for {
+			l, err := rd.ReadString('\n')
+			if err != nil || l == "\n" {
+				return
+			}
+			lines = append(lines, l)
+		}
+	}
+	assert.Equal(t, []string{"id: 3\n", "data: 3\n"}, readEvent())
+
+	// Broadcast
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		b.Broadcast(astihttp.ServerSentEvent{Data: "4"})
+	}()
+	assert.Equal(t, []string{"id: 4\n", "data: 4\n"}, readEvent())
+	cancel()
+	resp.Body.Close()
+
+	// Replay events following an ID with line breaks
+	b.Broadcast(astihttp.ServerSentEvent{Data: "5", ID: "fi\r\nve"})
+	b.Broadcast(astihttp.ServerSentEvent{Data: "6"})
+	req.Header.Set("Last-Event-ID", "five")
+	resp, err = http.DefaultClient.Do(req.WithContext(context.Background()))
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	rd = bufio.NewReader(resp.Body)
+	assert.Equal(t, []string{"id: 5\n", "data: 6\n"}, readEvent())
+}
@@ -0,0 +1,156 @@
+package astihttp
+
+import (
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/asticode/go-astitools/io"
+	"github.com/pkg/errors"
+)
+
+// Uploader represents an object capable of streaming files as multipart/form-data
+type Uploader struct {
+	o UploaderOptions
+}
+
+// UploaderOptions represents uploader options
+type UploaderOptions struct {
+	// Progress is called every time file content is sent
+	Progress func(p UploadProgress)
+	// Defaults to a sender with default options. Since upload bodies are streamed, requests are never retried.
+	Sender *Sender
+}
+
+// UploadProgress represents an upload progress
+type UploadProgress struct {
+	// Number of bytes of file content sent
+	Uploaded int64
+	// Total number of bytes of file content
+	Total int64
+}
+
+// UploadFile represents a file to upload
+type UploadFile struct {
+	// Name of the form field
+	FieldName string
+	// Name of the file as seen by the server. Defaults to the base of Path
+	FileName string
+	Path     string
+}
+
+// NewUploader creates a new uploader
+func NewUploader(o UploaderOptions) *Uploader {
+	// Default options values
+	if o.Sender == nil {
+		o.Sender = NewSender(SenderOptions{})
+	}
+	return &Uploader{o: o}
+}
+
+// Upload streams files and additional form fields as multipart/form-data to url using a POST request
+// Files are never buffered in memory as a whole. Closing the response body is the caller's responsibility.
+func (u *Uploader) Upload(ctx context.Context, url string, fields map[string]string, files ...UploadFile) (resp *http.Response, err error) {
+	// Get total size
+	var total int64
+	for _, f := range files {
+		var fi os.FileInfo
+		if fi, err = os.Stat(f.Path); err != nil {
+			err = errors.Wrapf(err, "astihttp: stating %s failed", f.Path)
+			return
+		}
+		total += fi.Size()
+	}
+
+	// Create pipe
+	pr, pw := io.Pipe()
+	var mw = multipart.NewWriter(pw)
+
+	// Create request
+	var req *http.Request
+	if req, err = http.NewRequest(http.MethodPost, url, pr); err != nil {
+		err = errors.Wrapf(err, "astihttp: creating POST request to %s failed", url)
+		return
+	}
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+
+	// Write body
+	var p = newProgress(u.progressFunc(), total)
+	p.report()
+	go func() {
+		pw.CloseWithError(u.write(ctx, mw, fields, files, p))
+	}()
+
+	// Send request
+	if resp, err = u.o.Sender.Send(req.WithContext(ctx)); err != nil {
+		pr.CloseWithError(err)
+		return
+	}
+	return
+}
+
+// write writes the multipart body
+func (u *Uploader) write(ctx context.Context, mw *multipart.Writer, fields map[string]string, files []UploadFile, p *progress) (err error) {
+	// Write fields in a deterministic order
+	var ks []string
+	for k := range fields {
+		ks = append(ks, k)
+	}
+	sort.Strings(ks)
+	for _, k := range ks {
+		if err = mw.WriteField(k, fields[k]); err != nil {
+			return errors.Wrapf(err, "astihttp: writing field %s failed", k)
+		}
+	}
+
+	// Write files
+	for _, f := range files {
+		if err = u.writeFile(ctx, mw, f, p); err != nil {
+			return
+		}
+	}
+
+	// Close
+	if err = mw.Close(); err != nil {
+		return errors.Wrap(err, "astihttp: closing multipart writer failed")
+	}
+	return
+}
+
+// writeFile writes a file part
+func (u *Uploader) writeFile(ctx context.Context, mw *multipart.Writer, f UploadFile, p *progress) (err error) {
+	// Open file
+	var fl *os.File
+	if fl, err = os.Open(f.Path); err != nil {
+		return errors.Wrapf(err, "astihttp: opening %s failed", f.Path)
+	}
+	defer fl.Close()
+
+	// Create part
+	var n = f.FileName
+	if n == "" {
+		n = filepath.Base(f.Path)
+	}
+	var w io.Writer
+	if w, err = mw.CreateFormFile(f.FieldName, n); err != nil {
+		return errors.Wrapf(err, "astihttp: creating form file for %s failed", f.Path)
+	}
+
+	// Copy
+	if _, err = astiio.Copy(ctx, fl, &progressWriter{p: p, w: w}); err != nil {
+		return errors.Wrapf(err, "astihttp: copying %s failed", f.Path)
+	}
+	return
+}
+
+// progressFunc adapts the progress option to the internal progress
+func (u *Uploader) progressFunc() func(done, total int64) {
+	if u.o.Progress == nil {
+		return nil
+	}
+	return func(done, total int64) { u.o.Progress(UploadProgress{Uploaded: done, Total: total}) }
+}
@@ -0,0 +1,51 @@
+package astihttp_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/asticode/go-astitools/http"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUploader_Upload(t *testing.T) {
+	// Init
+	dir, err := ioutil.TempDir("", "astihttp")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	p1, p2 := filepath.Join(dir, "1.txt"), filepath.Join(dir, "2.txt")
+	ioutil.WriteFile(p1, []byte("content1"), 0644)
+	ioutil.WriteFile(p2, []byte("content22"), 0644)
+	var fields = make(map[string]string)
+	var files = make(map[string]string)
+	s := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		r.ParseMultipartForm(1 << 20)
+		for k, v := range r.MultipartForm.Value {
+			fields[k] = v[0]
+		}
+		for k, v := range r.MultipartForm.File {
+			f, _ := v[0].Open()
+			b, _ := ioutil.ReadAll(f)
+			f.Close()
+			files[k] = v[0].Filename + ":" + string(b)
+		}
+	}))
+	defer s.Close()
+
+	// Upload
+	var ps []astihttp.UploadProgress
+	u := astihttp.NewUploader(astihttp.UploaderOptions{Progress: func(p astihttp.UploadProgress) { ps = append(ps, p) }})
+	resp, err := u.Upload(context.Background(), s.URL, map[string]string{"key": "value"}, astihttp.UploadFile{FieldName: "f1", Path: p1}, astihttp.UploadFile{FieldName: "f2", FileName: "custom.txt", Path: p2})
+	assert.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, map[string]string{"key": "value"}, fields)
+	assert.Equal(t, map[string]string{"f1": "1.txt:content1", "f2": "custom.txt:content22"}, files)
+	assert.Equal(t, astihttp.UploadProgress{Total: 17}, ps[0])
+	assert.Equal(t, astihttp.UploadProgress{Uploaded: 17, Total: 17}, ps[len(ps)-1])
+}
@@ -0,0 +1,311 @@
+package astihttp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/asticode/go-astitools/logger"
+	"github.com/asticode/go-astitools/time"
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+)
+
+// WebSocket event names dispatched internally
+const (
+	WebSocketEventNameConnected    = "astihttp.connected"
+	WebSocketEventNameDisconnected = "astihttp.disconnected"
+)
+
+// WebSocketMessage represents a websocket message
+type WebSocketMessage struct {
+	EventName string          `json:"event_name"`
+	Payload   json.RawMessage `json:"payload,omitempty"`
+}
+
+// WebSocketListener represents a func executed when a message with a specific event name is received
+type WebSocketListener func(ws *WebSocket, eventName string, payload json.RawMessage) error
+
+// WebSocket represents an object capable of reading and writing JSON messages routed by event name on a websocket
+// connection, either as a client or as a server
+type WebSocket struct {
+	c         *websocket.Conn
+	listeners map[string][]WebSocketListener
+	mc        sync.Mutex // Locks c
+	ml        sync.Mutex // Locks listeners
+	mw        sync.Mutex // Locks writes
+	o         WebSocketOptions
+}
+
+// WebSocketOptions represents websocket options
+type WebSocketOptions struct {
+	// Defaults to astilogger.Default()
+	Logger astilogger.Logger
+	// Defaults to 30s
+	PingPeriod time.Duration
+	// Amount of time after which the connection is considered dead if no pong has been received. Defaults to
+	// 2 * PingPeriod
+	PongWait time.Duration
+	// Maximum size in bytes of a message. 0 means no maximum
+	ReadLimit int64
+	// Defaults to 10s
+	WriteTimeout time.Duration
+}
+
+// NewWebSocket creates a new websocket
+func NewWebSocket(o WebSocketOptions) *WebSocket {
+	// Default options values
+	if o.Logger == nil {
+		o.Logger = astilogger.Default()
+	}
+	if o.PingPeriod <= 0 {
+		o.PingPeriod = 30 * time.Second
+	}
+	if o.PongWait <= 0 {
+		o.PongWait = 2 * o.PingPeriod
+	}
+	if o.WriteTimeout <= 0 {
+		o.WriteTimeout = 10 * time.Second
+	}
+	return &WebSocket{
+		listeners: make(map[string][]WebSocketListener),
+		o:         o,
+	}
+}
+
+// AddListener adds a listener for a specific event name
+func (w *WebSocket) AddListener(eventName string, l WebSocketListener) {
+	w.ml.Lock()
+	defer w.ml.Unlock()
+	w.listeners[eventName] = append(w.listeners[eventName], l)
+}
+
+// Write writes a message with a specific event name and a payload that will be marshaled to JSON
+func (w *WebSocket) Write(eventName string, payload interface{}) (err error) {
+	// Get connection
+	w.mc.Lock()
+	c := w.c
+	w.mc.Unlock()
+	if c == nil {
+		return errors.New("astihttp: websocket is not connected")
+	}
+
+	// Marshal payload
+	var m = WebSocketMessage{EventName: eventName}
+	if payload != nil {
+		if m.Payload, err = json.Marshal(payload); err != nil {
+			return errors.Wrapf(err, "astihttp: marshaling payload of event %s failed", eventName)
+		}
+	}
+
+	// Write
+	w.mw.Lock()
+	defer w.mw.Unlock()
+	c.SetWriteDeadline(time.Now().Add(w.o.WriteTimeout))
+	if err = c.WriteJSON(m); err != nil {
+		return errors.Wrapf(err, "astihttp: writing event %s failed", eventName)
+	}
+	return
+}
+
+// Dial connects to a websocket server and reads messages until the connection is lost or the context is cancelled
+// Once ctx is cancelled, the connection is closed gracefully.
+func (w *WebSocket) Dial(ctx context.Context, addr string, h http.Header) (err error) {
+	// Dial
+	var c *websocket.Conn
+	if c, _, err = websocket.DefaultDialer.DialContext(ctx, addr, h); err != nil {
+		return errors.Wrapf(err, "astihttp: dialing %s failed", addr)
+	}
+
+	// Read
+	return w.read(ctx, c)
+}
+
+// DialAndReconnect connects to a websocket server, reads messages and reconnects with a backoff every time the
+// connection is lost, until the context is cancelled
+// This is a blocking pattern that can be executed in an astiworker task so that it stops with the worker.
+func (w *WebSocket) DialAndReconnect(ctx context.Context, addr string, h http.Header, b astitime.Backoff) {
+	for n := 0; ctx.Err() == nil; {
+		// Dial
+		var t = time.Now()
+		err := w.Dial(ctx, addr, h)
+		if ctx.Err() != nil {
+			return
+		}
+
+		// Reset attempts if connection was up for a while
+		if time.Since(t) > w.o.PongWait {
+			n = 0
+		}
+		n++
+
+		// Sleep
+		d := b.Duration(n)
+		if l := astilogger.WithContext(w.o.Logger, ctx); err != nil {
+			l.Error("astihttp: websocket connection lost, reconnecting", "addr", addr, "delay", d, astilogger.KeyError, err)
+		} else {
+			l.Info("astihttp: websocket connection closed, reconnecting", "addr", addr, "delay", d)
+		}
+		if astitime.Sleep(ctx, d) != nil {
+			return
+		}
+	}
+}
+
+// WebSocketHandler returns a handler that upgrades requests to websocket connections
+// fn is executed with a new *WebSocket for every connection, so that listeners can be added. Messages are then read
+// until the connection is lost or either the request context or ctx is cancelled. Since hijacked connections are not
+// closed by http.Server.Shutdown, ctx should be cancelled on shutdown (e.g. the worker's context) so that connections
+// are closed gracefully.
+// Messages are logged with the fields of the request's context, see MiddlewareRequestID.
+func WebSocketHandler(ctx context.Context, u websocket.Upgrader, o WebSocketOptions, fn func(ws *WebSocket)) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		// Get logger
+		var wo = o
+		if wo.Logger == nil {
+			wo.Logger = astilogger.Default()
+		}
+		wo.Logger = astilogger.WithContext(wo.Logger, r.Context())
+
+		// Upgrade
+		c, err := u.Upgrade(rw, r, nil)
+		if err != nil {
+			wo.Logger.Error("astihttp: upgrading websocket connection failed", astilogger.KeyError, err)
+			return
+		}
+
+		// Create context
+		rctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		go func() {
+			select {
+			case <-ctx.Done():
+				cancel()
+			case <-rctx.Done():
+			}
+		}()
+
+		// Create websocket
+		var ws = NewWebSocket(wo)
+		fn(ws)
+
+		// Read
+		if err = ws.read(rctx, c); err != nil {
+			wo.Logger.Debug("astihttp: reading websocket failed", astilogger.KeyError, err)
+		}
+	})
+}
+
+// Close closes the connection gracefully
+func (w *WebSocket) Close() (err error) {
+	// Get connection
+	w.mc.Lock()
+	c := w.c
+	w.mc.Unlock()
+	if c == nil {
+		return
+	}
+	return w.close(c)
+}
+
+// close closes a connection gracefully
+func (w *WebSocket) close(c *websocket.Conn) (err error) {
+	// Send close message
+	w.mw.Lock()
+	c.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(w.o.WriteTimeout))
+	w.mw.Unlock()
+
+	// Close
+	if err = c.Close(); err != nil {
+		return errors.Wrap(err, "astihttp: closing websocket connection failed")
+	}
+	return
+}
+
+// read reads messages on a connection until it's lost or the context is cancelled
+func (w *WebSocket) read(ctx context.Context, c *websocket.Conn) (err error) {
+	// Set connection
+	w.mc.Lock()
+	w.c = c
+	w.mc.Unlock()
+
+	// Create context
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Handle keepalive
+	if w.o.ReadLimit > 0 {
+		c.SetReadLimit(w.o.ReadLimit)
+	}
+	c.SetReadDeadline(time.Now().Add(w.o.PongWait))
+	c.SetPongHandler(func(string) error { return c.SetReadDeadline(time.Now().Add(w.o.PongWait)) })
+	go w.ping(ctx, c)
+
+	// Close gracefully once the context is cancelled or the connection is lost
+	go func() {
+		<-ctx.Done()
+		w.close(c)
+	}()
+
+	// Dispatch connected event
+	w.dispatch(WebSocketEventNameConnected, nil)
+	defer func() {
+		w.mc.Lock()
+		w.c = nil
+		w.mc.Unlock()
+		w.dispatch(WebSocketEventNameDisconnected, nil)
+	}()
+
+	// Loop
+	for {
+		// Read message
+		var m WebSocketMessage
+		if err = c.ReadJSON(&m); err != nil {
+			if ctx.Err() != nil || websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				err = nil
+			} else {
+				err = errors.Wrap(err, "astihttp: reading websocket message failed")
+			}
+			return
+		}
+
+		// Dispatch
+		w.dispatch(m.EventName, m.Payload)
+	}
+}
+
+// ping sends pings periodically until the context is cancelled
+func (w *WebSocket) ping(ctx context.Context, c *websocket.Conn) {
+	var t = time.NewTicker(w.o.PingPeriod)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			w.mw.Lock()
+			err := c.WriteControl(websocket.PingMessage, nil, time.Now().Add(w.o.WriteTimeout))
+			w.mw.Unlock()
+			if err != nil {
+				w.o.Logger.Debug("astihttp: writing websocket ping failed", astilogger.KeyError, err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// dispatch executes the listeners of an event name
+func (w *WebSocket) dispatch(eventName string, payload json.RawMessage) {
+	// Get listeners
+	w.ml.Lock()
+	ls := append([]WebSocketListener{}, w.listeners[eventName]...)
+	w.ml.Unlock()
+
+	// Execute listeners
+	for _, l := range ls {
+		if err := l(w, eventName, payload); err != nil {
+			w.o.Logger.Error("astihttp: executing websocket listener failed", "event", eventName, astilogger.KeyError, err)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package astihttp_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/http"
+	"github.com/asticode/go-astitools/time"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebSocket(t *testing.T) {
+	// Server that echoes messages
+	var closed = make(chan bool)
+	s := httptest.NewServer(astihttp.WebSocketHandler(context.Background(), websocket.Upgrader{}, astihttp.WebSocketOptions{}, func(ws *astihttp.WebSocket) {
+		ws.AddListener("ping", func(ws *astihttp.WebSocket, eventName string, payload json.RawMessage) error {
+			var v string
+			json.Unmarshal(payload, &v)
+			return ws.Write("pong", v+"-pong")
+		})
+		ws.AddListener(astihttp.WebSocketEventNameDisconnected, func(ws *astihttp.WebSocket, eventName string, payload json.RawMessage) error {
+			close(closed)
+			return nil
+		})
+	}))
+	defer s.Close()
+
+	// Client
+	ctx, cancel := context.WithCancel(context.Background())
+	var pongs = make(chan string, 1)
+	ws := astihttp.NewWebSocket(astihttp.WebSocketOptions{PingPeriod: 10 * time.Millisecond})
+	ws.AddListener(astihttp.WebSocketEventNameConnected, func(ws *astihttp.WebSocket, eventName string, payload json.RawMessage) error {
+		return ws.Write("ping", "test")
+	})
+	ws.AddListener("pong", func(ws *astihttp.WebSocket, eventName string, payload json.RawMessage) error {
+		var v string
+		json.Unmarshal(payload, &v)
+		pongs <- v
+		return nil
+	})
+	var done = make(chan bool)
+	go func() {
+		defer close(done)
+		ws.DialAndReconnect(ctx, "ws"+strings.TrimPrefix(s.URL, "http"), nil, astitime.ConstantBackoff(time.Millisecond))
+	}()
+
+	// Wait for pong
+	select {
+	case v := <-pongs:
+		assert.Equal(t, "test-pong", v)
+	case <-time.After(time.Second):
+		t.Fatal("no pong received")
+	}
+
+	// Graceful close
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("client didn't stop")
+	}
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("server connection didn't close")
+	}
+
+	// Server shutdown
+	sctx, scancel := context.WithCancel(context.Background())
+	s2 := httptest.NewServer(astihttp.WebSocketHandler(sctx, websocket.Upgrader{}, astihttp.WebSocketOptions{}, func(ws *astihttp.WebSocket) {}))
+	defer s2.Close()
+	var connected = make(chan bool)
+	ws = astihttp.NewWebSocket(astihttp.WebSocketOptions{})
+	ws.AddListener(astihttp.WebSocketEventNameConnected, func(ws *astihttp.WebSocket, eventName string, payload json.RawMessage) error {
+		close(connected)
+		return nil
+	})
+	var errDial = make(chan error)
+	go func() { errDial <- ws.Dial(context.Background(), "ws"+strings.TrimPrefix(s2.URL, "http"), nil) }()
+	<-connected
+	scancel()
+	select {
+	case err := <-errDial:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("server didn't close the connection")
+	}
+}
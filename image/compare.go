@@ -0,0 +1,80 @@
+package astiimage
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/pkg/errors"
+)
+
+// CompareOptions represents compare options
+type CompareOptions struct {
+	// Maximum difference, between 0 and 255, of each component for 2 pixels to be considered identical
+	Threshold uint8
+}
+
+// ImageDiff represents the difference between 2 images
+type ImageDiff struct {
+	// Image where different pixels are red and identical pixels are a faded gray version of the first image
+	Diff *image.RGBA
+	// Number of pixels whose difference exceeds the threshold
+	DifferentPixels int
+	// Similarity between 0 (completely different) and 1 (identical), based on the mean difference of all components
+	Similarity float64
+}
+
+// CompareImages compares 2 images of the same dimensions pixel by pixel
+func CompareImages(a, b image.Image, o CompareOptions) (d ImageDiff, err error) {
+	// Check dimensions
+	if a.Bounds().Dx() != b.Bounds().Dx() || a.Bounds().Dy() != b.Bounds().Dy() {
+		err = errors.Errorf("astiimage: dimensions %dx%d and %dx%d differ", a.Bounds().Dx(), a.Bounds().Dy(), b.Bounds().Dx(), b.Bounds().Dy())
+		return
+	}
+
+	// Convert
+	var ra, rb = toRGBA(a), toRGBA(b)
+	d.Diff = image.NewRGBA(ra.Bounds())
+
+	// Loop through pixels
+	var total float64
+	for i := 0; i < len(ra.Pix); i += 4 {
+		// Compute difference
+		var different bool
+		for c := 0; c < 4; c++ {
+			var v = absDiff(ra.Pix[i+c], rb.Pix[i+c])
+			total += float64(v)
+			if v > o.Threshold {
+				different = true
+			}
+		}
+
+		// Draw difference
+		if different {
+			d.DifferentPixels++
+			copy(d.Diff.Pix[i:i+4], []uint8{0xff, 0, 0, 0xff})
+		} else {
+			var g = 0xc0 + gray(ra.Pix[i], ra.Pix[i+1], ra.Pix[i+2])/4
+			copy(d.Diff.Pix[i:i+4], []uint8{g, g, g, 0xff})
+		}
+	}
+
+	// Similarity
+	d.Similarity = 1
+	if len(ra.Pix) > 0 {
+		d.Similarity = 1 - total/float64(len(ra.Pix)*0xff)
+	}
+	return
+}
+
+// absDiff returns the absolute difference of 2 components
+func absDiff(a, b uint8) uint8 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// gray returns the luma of a color
+func gray(r, g, b uint8) uint8 {
+	return color.GrayModel.Convert(color.RGBA{R: r, G: g, B: b, A: 0xff}).(color.Gray).Y
+}
@@ -0,0 +1,86 @@
+package astiimage_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/asticode/go-astitools/image"
+	"github.com/stretchr/testify/assert"
+)
+
+// gradient creates a gradient image, with an optional brightness offset
+func gradient(w, h int, offset uint8) *image.RGBA {
+	var img = image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var v = uint8((x*255/w+y*128/h)/2) + offset
+			img.SetRGBA(x, y, color.RGBA{R: v, G: v, B: uint8(x * 255 / w), A: 255})
+		}
+	}
+	return img
+}
+
+func TestCompareImages(t *testing.T) {
+	// Identical
+	var a = gradient(16, 16, 0)
+	d, err := astiimage.CompareImages(a, a, astiimage.CompareOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, d.DifferentPixels)
+	assert.Equal(t, 1.0, d.Similarity)
+
+	// Different
+	var b = gradient(16, 16, 0)
+	b.SetRGBA(3, 4, color.RGBA{A: 255})
+	b.SetRGBA(5, 6, color.RGBA{R: b.RGBAAt(5, 6).R + 2, G: b.RGBAAt(5, 6).G, B: b.RGBAAt(5, 6).B, A: 255})
+	d, err = astiimage.CompareImages(a, b, astiimage.CompareOptions{Threshold: 5})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, d.DifferentPixels)
+	assert.Equal(t, color.RGBA{R: 255, A: 255}, d.Diff.RGBAAt(3, 4))
+	assert.NotEqual(t, color.RGBA{R: 255, A: 255}, d.Diff.RGBAAt(5, 6))
+	assert.True(t, d.Similarity < 1 && d.Similarity > 0.99)
+
+	// Dimensions mismatch
+	_, err = astiimage.CompareImages(a, gradient(8, 8, 0), astiimage.CompareOptions{})
+	assert.Error(t, err)
+}
+
+// shapes creates an image containing a few shapes, with an optional brightness offset
+func shapes(size int, offset uint8) *image.RGBA {
+	var img = image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := 0; y < size; y++ {
+		for x := 0; x < size; x++ {
+			var fx, fy = float64(x) / float64(size), float64(y) / float64(size)
+			var v uint8 = 40
+			if (fx-0.3)*(fx-0.3)+(fy-0.3)*(fy-0.3) < 0.04 {
+				v = 200
+			} else if fx > 0.55 && fx < 0.9 && fy > 0.5 && fy < 0.8 {
+				v = 150
+			} else if fy > 0.85 {
+				v = 100
+			}
+			img.SetRGBA(x, y, color.RGBA{R: v + offset, G: v + offset, B: v + offset, A: 255})
+		}
+	}
+	return img
+}
+
+func TestHashes(t *testing.T) {
+	var a, b = shapes(64, 0), shapes(128, 20)
+	var c = image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			if (x/8+y/8)%2 == 0 {
+				c.SetRGBA(x, y, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+			} else {
+				c.SetRGBA(x, y, color.RGBA{A: 255})
+			}
+		}
+	}
+	for _, fn := range []func(image.Image) uint64{astiimage.AverageHash, astiimage.PerceptualHash} {
+		assert.True(t, astiimage.HashDistance(fn(a), fn(b)) <= 10)
+		assert.True(t, astiimage.HashDistance(fn(a), fn(c)) > 10)
+		assert.Equal(t, uint64(0), fn(image.NewRGBA(image.Rect(0, 0, 0, 0))))
+	}
+	assert.Equal(t, 2, astiimage.HashDistance(0x5, 0x0))
+}
@@ -0,0 +1,275 @@
+package astiimage
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"io/ioutil"
+	"math"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+// roundedCornerSamples is the number of samples per axis used to antialias rounded corners
+const roundedCornerSamples = 4
+
+// DrawLine draws a line between 2 points
+// Colors with transparency are blended with the destination.
+func DrawLine(dst draw.Image, p0, p1 image.Point, c color.Color, thickness int) {
+	// Default values
+	if thickness <= 0 {
+		thickness = 1
+	}
+
+	// Create mask
+	var r = image.Rectangle{Min: p0, Max: p1}.Canon()
+	r.Max = r.Max.Add(image.Pt(1, 1))
+	r = r.Inset(-thickness / 2).Intersect(dst.Bounds())
+	if r.Empty() {
+		return
+	}
+	var m = image.NewAlpha(r)
+
+	// Loop through points using Bresenham's algorithm
+	var dx, dy = abs(p1.X - p0.X), -abs(p1.Y - p0.Y)
+	var sx, sy = sign(p1.X - p0.X), sign(p1.Y - p0.Y)
+	var e = dx + dy
+	for x, y := p0.X, p0.Y; ; {
+		// Draw brush
+		var b = image.Rect(x-thickness/2, y-thickness/2, x-thickness/2+thickness, y-thickness/2+thickness)
+		draw.Draw(m, b, image.Opaque, image.Point{}, draw.Src)
+
+		// Next point
+		if x == p1.X && y == p1.Y {
+			break
+		}
+		var e2 = 2 * e
+		if e2 >= dy {
+			e += dy
+			x += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y += sy
+		}
+	}
+
+	// Draw
+	draw.DrawMask(dst, r, image.NewUniform(c), image.Point{}, m, r.Min, draw.Over)
+}
+
+// DrawRectangle draws the outline of a rectangle, inside its bounds
+// Colors with transparency are blended with the destination.
+func DrawRectangle(dst draw.Image, r image.Rectangle, c color.Color, thickness int) {
+	// Default values
+	if thickness <= 0 {
+		thickness = 1
+	}
+
+	// Rectangle is too small to have an inside
+	r = r.Canon()
+	if 2*thickness >= r.Dx() || 2*thickness >= r.Dy() {
+		FillRectangle(dst, r, c)
+		return
+	}
+
+	// Draw sides
+	FillRectangle(dst, image.Rect(r.Min.X, r.Min.Y, r.Max.X, r.Min.Y+thickness), c)
+	FillRectangle(dst, image.Rect(r.Min.X, r.Max.Y-thickness, r.Max.X, r.Max.Y), c)
+	FillRectangle(dst, image.Rect(r.Min.X, r.Min.Y+thickness, r.Min.X+thickness, r.Max.Y-thickness), c)
+	FillRectangle(dst, image.Rect(r.Max.X-thickness, r.Min.Y+thickness, r.Max.X, r.Max.Y-thickness), c)
+}
+
+// FillRectangle fills a rectangle
+// Colors with transparency are blended with the destination.
+func FillRectangle(dst draw.Image, r image.Rectangle, c color.Color) {
+	draw.Draw(dst, r.Canon(), image.NewUniform(c), image.Point{}, draw.Over)
+}
+
+// FillRoundedRectangle fills a rectangle whose corners are rounded with the provided radius
+// Corners are antialiased and colors with transparency are blended with the destination.
+func FillRoundedRectangle(dst draw.Image, r image.Rectangle, radius int, c color.Color) {
+	// Clamp radius
+	r = r.Canon()
+	if l := int(math.Min(float64(r.Dx()), float64(r.Dy())) / 2); radius > l {
+		radius = l
+	}
+	if radius <= 0 {
+		FillRectangle(dst, r, c)
+		return
+	}
+
+	// Create mask
+	var b = r.Intersect(dst.Bounds())
+	if b.Empty() {
+		return
+	}
+	var m = image.NewAlpha(b)
+	var rf = float64(radius)
+	for y := b.Min.Y; y < b.Max.Y; y++ {
+		for x := b.Min.X; x < b.Max.X; x++ {
+			// Get corner center
+			var cx, cy float64
+			var inX, inY bool
+			if x < r.Min.X+radius {
+				cx, inX = float64(r.Min.X)+rf, true
+			} else if x >= r.Max.X-radius {
+				cx, inX = float64(r.Max.X)-rf, true
+			}
+			if y < r.Min.Y+radius {
+				cy, inY = float64(r.Min.Y)+rf, true
+			} else if y >= r.Max.Y-radius {
+				cy, inY = float64(r.Max.Y)-rf, true
+			}
+
+			// Pixel is not in a corner
+			if !inX || !inY {
+				m.SetAlpha(x, y, color.Alpha{A: 0xff})
+				continue
+			}
+
+			// Compute coverage
+			var n int
+			for sy := 0; sy < roundedCornerSamples; sy++ {
+				for sx := 0; sx < roundedCornerSamples; sx++ {
+					var px = float64(x) + (float64(sx)+0.5)/roundedCornerSamples - cx
+					var py = float64(y) + (float64(sy)+0.5)/roundedCornerSamples - cy
+					if px*px+py*py <= rf*rf {
+						n++
+					}
+				}
+			}
+			m.SetAlpha(x, y, color.Alpha{A: uint8(n * 0xff / (roundedCornerSamples * roundedCornerSamples))})
+		}
+	}
+
+	// Draw
+	draw.DrawMask(dst, b, image.NewUniform(c), image.Point{}, m, b.Min, draw.Over)
+}
+
+// TextOptions represents text options
+type TextOptions struct {
+	// Color of the box drawn behind the text. No box is drawn if nil.
+	Background color.Color
+	// Defaults to white
+	Color color.Color
+	// Defaults to a basic 7x13 face. Use LoadFontFace to provide a TrueType or OpenType font.
+	Face font.Face
+	// Space in pixels between the text and the edges of its background box
+	Padding int
+}
+
+// DrawText draws a text whose top left corner, background box included, is at the provided point, and returns the
+// bounds of what has been drawn
+// Lines are separated by "\n".
+func DrawText(dst draw.Image, s string, p image.Point, o TextOptions) (r image.Rectangle) {
+	// Default options values
+	if o.Color == nil {
+		o.Color = color.White
+	}
+	if o.Face == nil {
+		o.Face = basicfont.Face7x13
+	}
+
+	// Get bounds
+	var size = MeasureText(s, o.Face)
+	r = image.Rectangle{Min: p, Max: p.Add(size).Add(image.Pt(2*o.Padding, 2*o.Padding))}
+
+	// Draw background
+	if o.Background != nil {
+		FillRectangle(dst, r, o.Background)
+	}
+
+	// Draw lines
+	var m = o.Face.Metrics()
+	var d = &font.Drawer{
+		Dst:  dst,
+		Face: o.Face,
+		Src:  image.NewUniform(o.Color),
+	}
+	for i, l := range strings.Split(s, "\n") {
+		d.Dot = fixed.Point26_6{
+			X: fixed.I(p.X + o.Padding),
+			Y: fixed.I(p.Y+o.Padding) + m.Ascent + fixed.Int26_6(i)*m.Height,
+		}
+		d.DrawString(l)
+	}
+	return
+}
+
+// MeasureText returns the dimensions of a text drawn with the provided face
+// Lines are separated by "\n".
+func MeasureText(s string, f font.Face) image.Point {
+	// Default values
+	if f == nil {
+		f = basicfont.Face7x13
+	}
+
+	// Loop through lines
+	var m = f.Metrics()
+	var ls = strings.Split(s, "\n")
+	var w fixed.Int26_6
+	for _, l := range ls {
+		if a := font.MeasureString(f, l); a > w {
+			w = a
+		}
+	}
+	return image.Pt(w.Ceil(), (m.Ascent + m.Descent + fixed.Int26_6(len(ls)-1)*m.Height).Ceil())
+}
+
+// LoadFontFace loads a TrueType or OpenType font face from a file
+// size is in points, at 72 DPI, which makes it equal to the size in pixels.
+func LoadFontFace(path string, size float64) (f font.Face, err error) {
+	// Read file
+	var b []byte
+	if b, err = ioutil.ReadFile(path); err != nil {
+		err = errors.Wrapf(err, "astiimage: reading %s failed", path)
+		return
+	}
+	return ParseFontFace(b, size)
+}
+
+// ParseFontFace parses a TrueType or OpenType font face
+// size is in points, at 72 DPI, which makes it equal to the size in pixels.
+func ParseFontFace(b []byte, size float64) (f font.Face, err error) {
+	// Parse
+	var ft *opentype.Font
+	if ft, err = opentype.Parse(b); err != nil {
+		err = errors.Wrap(err, "astiimage: parsing font failed")
+		return
+	}
+
+	// Create face
+	if f, err = opentype.NewFace(ft, &opentype.FaceOptions{
+		DPI:     72,
+		Hinting: font.HintingFull,
+		Size:    size,
+	}); err != nil {
+		err = errors.Wrap(err, "astiimage: creating font face failed")
+		return
+	}
+	return
+}
+
+// abs returns the absolute value of an int
+func abs(i int) int {
+	if i < 0 {
+		return -i
+	}
+	return i
+}
+
+// sign returns the sign of an int
+func sign(i int) int {
+	if i < 0 {
+		return -1
+	} else if i > 0 {
+		return 1
+	}
+	return 0
+}
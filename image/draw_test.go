@@ -0,0 +1,93 @@
+package astiimage_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/asticode/go-astitools/image"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestDraw(t *testing.T) {
+	var red = color.RGBA{R: 255, A: 255}
+	var black = color.RGBA{A: 255}
+	var newImage = func() *image.RGBA {
+		img := image.NewRGBA(image.Rect(0, 0, 20, 20))
+		astiimage.FillRectangle(img, img.Bounds(), black)
+		return img
+	}
+
+	// Line
+	img := newImage()
+	astiimage.DrawLine(img, image.Pt(0, 0), image.Pt(19, 19), red, 1)
+	for i := 0; i < 20; i++ {
+		assert.Equal(t, red, img.RGBAAt(i, i))
+	}
+	assert.Equal(t, black, img.RGBAAt(1, 0))
+	img = newImage()
+	astiimage.DrawLine(img, image.Pt(2, 10), image.Pt(17, 10), red, 3)
+	assert.Equal(t, red, img.RGBAAt(10, 9))
+	assert.Equal(t, red, img.RGBAAt(10, 11))
+	assert.Equal(t, black, img.RGBAAt(10, 12))
+	assert.Equal(t, black, img.RGBAAt(0, 10))
+
+	// Rectangle
+	img = newImage()
+	astiimage.DrawRectangle(img, image.Rect(2, 2, 18, 18), red, 2)
+	assert.Equal(t, red, img.RGBAAt(2, 2))
+	assert.Equal(t, red, img.RGBAAt(3, 10))
+	assert.Equal(t, red, img.RGBAAt(17, 17))
+	assert.Equal(t, black, img.RGBAAt(4, 4))
+	assert.Equal(t, black, img.RGBAAt(1, 1))
+
+	// Semi transparent fill
+	img = newImage()
+	astiimage.FillRectangle(img, image.Rect(0, 0, 10, 10), color.NRGBA{R: 255, A: 128})
+	assert.Equal(t, color.RGBA{R: 128, A: 255}, img.RGBAAt(5, 5))
+
+	// Rounded rectangle
+	img = newImage()
+	astiimage.FillRoundedRectangle(img, img.Bounds(), 6, red)
+	assert.Equal(t, black, img.RGBAAt(0, 0))
+	assert.Equal(t, black, img.RGBAAt(19, 19))
+	assert.Equal(t, red, img.RGBAAt(10, 0))
+	assert.Equal(t, red, img.RGBAAt(0, 10))
+	assert.Equal(t, red, img.RGBAAt(3, 3))
+	c := img.RGBAAt(1, 2)
+	assert.True(t, c.R > 0 && c.R < 255)
+}
+
+func TestDrawText(t *testing.T) {
+	// Measure
+	assert.Equal(t, image.Pt(14, 13), astiimage.MeasureText("ab", nil))
+	assert.Equal(t, image.Pt(21, 26), astiimage.MeasureText("ab\nabc", basicfont.Face7x13))
+
+	// Draw
+	img := image.NewRGBA(image.Rect(0, 0, 40, 40))
+	r := astiimage.DrawText(img, "ab", image.Pt(5, 5), astiimage.TextOptions{
+		Background: color.RGBA{B: 255, A: 255},
+		Padding:    2,
+	})
+	assert.Equal(t, image.Rect(5, 5, 23, 22), r)
+	assert.Equal(t, color.RGBA{B: 255, A: 255}, img.RGBAAt(5, 5))
+	assert.Equal(t, color.RGBA{}, img.RGBAAt(30, 30))
+	var white int
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			if img.RGBAAt(x, y) == (color.RGBA{R: 255, G: 255, B: 255, A: 255}) {
+				white++
+			}
+		}
+	}
+	assert.True(t, white > 0)
+
+	// Font
+	_, err := astiimage.ParseFontFace([]byte("invalid"), 12)
+	assert.Error(t, err)
+	f, err := astiimage.ParseFontFace(goregular.TTF, 12)
+	assert.NoError(t, err)
+	assert.True(t, astiimage.MeasureText("ab", f).X > 0)
+}
@@ -0,0 +1,71 @@
+package astiimage
+
+import (
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// Formats
+const (
+	FormatJPEG Format = "jpeg"
+	FormatPNG  Format = "png"
+	FormatWebP Format = "webp"
+)
+
+// Format represents an image format
+type Format string
+
+// DefaultJPEGQuality represents the default JPEG quality
+const DefaultJPEGQuality = 85
+
+// EncodeOptions represents encode options
+type EncodeOptions struct {
+	// PNG compression level
+	PNGCompression png.CompressionLevel
+	// JPEG quality, between 1 and 100. Defaults to DefaultJPEGQuality
+	Quality int
+}
+
+// Encode encodes an image in the provided format
+// WebP images are encoded losslessly, which means Quality doesn't apply.
+func Encode(w io.Writer, img image.Image, f Format, o EncodeOptions) (err error) {
+	switch f {
+	case FormatJPEG:
+		err = EncodeJPEG(w, img, o.Quality)
+	case FormatPNG:
+		err = EncodePNG(w, img, o.PNGCompression)
+	case FormatWebP:
+		err = EncodeWebP(w, img)
+	default:
+		err = errors.Errorf("astiimage: unsupported format %s", f)
+	}
+	return
+}
+
+// EncodeJPEG encodes an image as JPEG, quality defaulting to DefaultJPEGQuality if 0
+func EncodeJPEG(w io.Writer, img image.Image, quality int) (err error) {
+	if quality <= 0 {
+		quality = DefaultJPEGQuality
+	} else if quality > 100 {
+		quality = 100
+	}
+	if err = jpeg.Encode(w, img, &jpeg.Options{Quality: quality}); err != nil {
+		err = errors.Wrap(err, "astiimage: encoding jpeg failed")
+		return
+	}
+	return
+}
+
+// EncodePNG encodes an image as PNG
+func EncodePNG(w io.Writer, img image.Image, c png.CompressionLevel) (err error) {
+	var e = &png.Encoder{CompressionLevel: c}
+	if err = e.Encode(w, img); err != nil {
+		err = errors.Wrap(err, "astiimage: encoding png failed")
+		return
+	}
+	return
+}
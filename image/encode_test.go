@@ -0,0 +1,40 @@
+package astiimage_test
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/asticode/go-astitools/image"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncode(t *testing.T) {
+	// Create image
+	var img = image.NewNRGBA(image.Rect(0, 0, 5, 3))
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 5; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 50), G: uint8(y * 100), B: uint8(x * y * 10), A: uint8(255 - x*20)})
+		}
+	}
+
+	// Loop through formats
+	for _, f := range []astiimage.Format{astiimage.FormatJPEG, astiimage.FormatPNG, astiimage.FormatWebP} {
+		var buf = &bytes.Buffer{}
+		assert.NoError(t, astiimage.Encode(buf, img, f, astiimage.EncodeOptions{Quality: 90}))
+		d, format, err := astiimage.Decode(buf)
+		assert.NoError(t, err)
+		assert.Equal(t, string(f), format)
+		assert.Equal(t, img.Bounds(), d.Bounds())
+		if f != astiimage.FormatJPEG {
+			// Lossless formats
+			for y := 0; y < 3; y++ {
+				for x := 0; x < 5; x++ {
+					assert.Equal(t, img.NRGBAAt(x, y), color.NRGBAModel.Convert(d.At(x, y)), "format %s at %d,%d", f, x, y)
+				}
+			}
+		}
+	}
+	assert.Error(t, astiimage.Encode(&bytes.Buffer{}, img, "bmp", astiimage.EncodeOptions{}))
+}
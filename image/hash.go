@@ -0,0 +1,122 @@
+package astiimage
+
+import (
+	"image"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+// AverageHash computes the average hash of an image
+// The image is shrunk to 8x8 grayscale pixels and every bit tells whether a pixel is brighter than the mean. Empty
+// images have a 0 hash.
+func AverageHash(img image.Image) (h uint64) {
+	// Empty image
+	if img.Bounds().Empty() {
+		return
+	}
+
+	// Compute mean
+	var ps = grayPixels(img, 8)
+	var mean float64
+	for _, p := range ps {
+		mean += p
+	}
+	mean /= float64(len(ps))
+
+	// Compute hash
+	for i, p := range ps {
+		if p > mean {
+			h |= 1 << uint(i)
+		}
+	}
+	return
+}
+
+// PerceptualHash computes the perceptual hash of an image
+// The image is shrunk to 32x32 grayscale pixels whose discrete cosine transform is computed, and every bit tells
+// whether one of the 8x8 lowest frequencies is above their median. The DC coefficient only depends on the mean
+// brightness and is skipped, so that the lowest bit is never set. It's more robust than the average hash to gamma and
+// color changes. Empty images have a 0 hash.
+func PerceptualHash(img image.Image) (h uint64) {
+	// Empty image
+	if img.Bounds().Empty() {
+		return
+	}
+
+	// Compute DCT
+	const size = 32
+	var ps = grayPixels(img, size)
+	var d = dct2D(ps, size)
+
+	// Get low frequencies and their median, the DC coefficient being excluded
+	var fs []float64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			fs = append(fs, d[y*size+x])
+		}
+	}
+	var sorted = append([]float64{}, fs[1:]...)
+	sort.Float64s(sorted)
+	var median = sorted[len(sorted)/2]
+
+	// Compute hash
+	for i := 1; i < len(fs); i++ {
+		if fs[i] > median {
+			h |= 1 << uint(i)
+		}
+	}
+	return
+}
+
+// HashDistance returns the number of different bits between 2 hashes
+// Images whose hashes are at most ~10 bits apart are usually near duplicates.
+func HashDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// grayPixels shrinks an image to size x size grayscale pixels
+func grayPixels(img image.Image, size int) (ps []float64) {
+	var r = Resize(img, size, size, InterpolationBilinear)
+	ps = make([]float64, size*size)
+	for i := range ps {
+		ps[i] = float64(gray(r.Pix[i*4], r.Pix[i*4+1], r.Pix[i*4+2]))
+	}
+	return
+}
+
+// dct2D computes the 2D discrete cosine transform (type II) of a square matrix
+func dct2D(ps []float64, size int) (o []float64) {
+	// Precompute cosines
+	var cs = make([]float64, size*size)
+	for k := 0; k < size; k++ {
+		for n := 0; n < size; n++ {
+			cs[k*size+n] = math.Cos(math.Pi / float64(size) * (float64(n) + 0.5) * float64(k))
+		}
+	}
+
+	// Rows
+	var tmp = make([]float64, size*size)
+	for y := 0; y < size; y++ {
+		for k := 0; k < size; k++ {
+			var s float64
+			for n := 0; n < size; n++ {
+				s += ps[y*size+n] * cs[k*size+n]
+			}
+			tmp[y*size+k] = s
+		}
+	}
+
+	// Columns
+	o = make([]float64, size*size)
+	for x := 0; x < size; x++ {
+		for k := 0; k < size; k++ {
+			var s float64
+			for n := 0; n < size; n++ {
+				s += tmp[n*size+x] * cs[k*size+n]
+			}
+			o[k*size+x] = s
+		}
+	}
+	return
+}
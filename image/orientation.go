@@ -0,0 +1,176 @@
+package astiimage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"io"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+
+	// Register decoders
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+
+	_ "golang.org/x/image/webp"
+)
+
+// EXIF orientations
+const (
+	OrientationNormal     Orientation = 1
+	OrientationFlipH      Orientation = 2
+	OrientationRotate180  Orientation = 3
+	OrientationFlipV      Orientation = 4
+	OrientationTranspose  Orientation = 5
+	OrientationRotate90   Orientation = 6
+	OrientationTransverse Orientation = 7
+	OrientationRotate270  Orientation = 8
+)
+
+// exifOrientationTag is the EXIF tag of the orientation
+const exifOrientationTag uint16 = 0x0112
+
+// Orientation represents an EXIF orientation, rotations being clockwise
+type Orientation int
+
+// Decode decodes an image and, if it's a JPEG, corrects its orientation based on its EXIF metadata
+func Decode(r io.Reader) (img image.Image, format string, err error) {
+	// Read
+	var b []byte
+	if b, err = ioutil.ReadAll(r); err != nil {
+		err = errors.Wrap(err, "astiimage: reading failed")
+		return
+	}
+
+	// Decode
+	if img, format, err = image.Decode(bytes.NewReader(b)); err != nil {
+		err = errors.Wrap(err, "astiimage: decoding failed")
+		return
+	}
+
+	// Orient
+	if format == "jpeg" {
+		img = Orient(img, ExifOrientation(b))
+	}
+	return
+}
+
+// ExifOrientation returns the EXIF orientation of a JPEG, or OrientationNormal if it can't be found
+func ExifOrientation(b []byte) Orientation {
+	// Check SOI
+	if len(b) < 2 || b[0] != 0xff || b[1] != 0xd8 {
+		return OrientationNormal
+	}
+
+	// Loop through segments
+	for i := 2; i+4 <= len(b); {
+		// Invalid marker
+		if b[i] != 0xff {
+			return OrientationNormal
+		}
+
+		// Start of scan or end of image
+		var marker = b[i+1]
+		if marker == 0xda || marker == 0xd9 {
+			return OrientationNormal
+		}
+
+		// Get segment
+		var size = int(binary.BigEndian.Uint16(b[i+2:]))
+		if size < 2 || i+2+size > len(b) {
+			return OrientationNormal
+		}
+		var s = b[i+4 : i+2+size]
+
+		// APP1 containing EXIF
+		if marker == 0xe1 && len(s) >= 6 && string(s[:6]) == "Exif\x00\x00" {
+			return tiffOrientation(s[6:])
+		}
+		i += 2 + size
+	}
+	return OrientationNormal
+}
+
+// tiffOrientation returns the orientation stored in the first IFD of a TIFF header
+func tiffOrientation(b []byte) Orientation {
+	// Get byte order
+	if len(b) < 8 {
+		return OrientationNormal
+	}
+	var o binary.ByteOrder
+	switch string(b[:2]) {
+	case "II":
+		o = binary.LittleEndian
+	case "MM":
+		o = binary.BigEndian
+	default:
+		return OrientationNormal
+	}
+
+	// Loop through IFD entries
+	var offset = int(o.Uint32(b[4:]))
+	if offset < 8 || offset+2 > len(b) {
+		return OrientationNormal
+	}
+	var count = int(o.Uint16(b[offset:]))
+	for i := 0; i < count; i++ {
+		var e = offset + 2 + i*12
+		if e+12 > len(b) {
+			return OrientationNormal
+		}
+		if o.Uint16(b[e:]) == exifOrientationTag {
+			if v := Orientation(o.Uint16(b[e+8:])); v >= OrientationNormal && v <= OrientationRotate270 {
+				return v
+			}
+			return OrientationNormal
+		}
+	}
+	return OrientationNormal
+}
+
+// Orient transforms an image so that it's displayed upright according to its EXIF orientation
+func Orient(src image.Image, o Orientation) image.Image {
+	// Nothing to do
+	if o <= OrientationNormal || o > OrientationRotate270 {
+		return src
+	}
+
+	// Create destination
+	var s = toRGBA(src)
+	var w, h = s.Bounds().Dx(), s.Bounds().Dy()
+	var dw, dh = w, h
+	if o >= OrientationTranspose {
+		dw, dh = h, w
+	}
+	var dst = image.NewRGBA(image.Rect(0, 0, dw, dh))
+
+	// Loop through destination pixels
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			// Get source pixel
+			var sx, sy int
+			switch o {
+			case OrientationFlipH:
+				sx, sy = w-1-x, y
+			case OrientationRotate180:
+				sx, sy = w-1-x, h-1-y
+			case OrientationFlipV:
+				sx, sy = x, h-1-y
+			case OrientationTranspose:
+				sx, sy = y, x
+			case OrientationRotate90:
+				sx, sy = y, h-1-x
+			case OrientationTransverse:
+				sx, sy = w-1-y, h-1-x
+			case OrientationRotate270:
+				sx, sy = w-1-y, x
+			}
+
+			// Copy
+			copy(dst.Pix[y*dst.Stride+x*4:y*dst.Stride+x*4+4], s.Pix[sy*s.Stride+sx*4:sy*s.Stride+sx*4+4])
+		}
+	}
+	return dst
+}
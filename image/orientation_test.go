@@ -0,0 +1,78 @@
+package astiimage_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+
+	"github.com/asticode/go-astitools/image"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOrient(t *testing.T) {
+	// Create image: 3x2 with distinct pixels
+	var img = image.NewRGBA(image.Rect(0, 0, 3, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 3; x++ {
+			img.SetRGBA(x, y, color.RGBA{R: uint8(y*3 + x), A: 255})
+		}
+	}
+	var pixels = func(i image.Image) (o [][]uint8) {
+		var r = i.(*image.RGBA)
+		for y := 0; y < r.Bounds().Dy(); y++ {
+			var row []uint8
+			for x := 0; x < r.Bounds().Dx(); x++ {
+				row = append(row, r.RGBAAt(x, y).R)
+			}
+			o = append(o, row)
+		}
+		return
+	}
+
+	// Loop through orientations
+	for o, e := range map[astiimage.Orientation][][]uint8{
+		astiimage.OrientationFlipH:      {{2, 1, 0}, {5, 4, 3}},
+		astiimage.OrientationRotate180:  {{5, 4, 3}, {2, 1, 0}},
+		astiimage.OrientationFlipV:      {{3, 4, 5}, {0, 1, 2}},
+		astiimage.OrientationTranspose:  {{0, 3}, {1, 4}, {2, 5}},
+		astiimage.OrientationRotate90:   {{3, 0}, {4, 1}, {5, 2}},
+		astiimage.OrientationTransverse: {{5, 2}, {4, 1}, {3, 0}},
+		astiimage.OrientationRotate270:  {{2, 5}, {1, 4}, {0, 3}},
+	} {
+		assert.Equal(t, e, pixels(astiimage.Orient(img, o)), "orientation %d", o)
+	}
+	assert.Equal(t, img, astiimage.Orient(img, astiimage.OrientationNormal))
+}
+
+func TestDecode(t *testing.T) {
+	// Encode JPEG
+	var buf = &bytes.Buffer{}
+	assert.NoError(t, jpeg.Encode(buf, image.NewRGBA(image.Rect(0, 0, 4, 2)), nil))
+	var b = buf.Bytes()
+
+	// Build EXIF segment with orientation 6
+	var tiff = &bytes.Buffer{}
+	tiff.WriteString("MM")
+	binary.Write(tiff, binary.BigEndian, uint16(42))
+	binary.Write(tiff, binary.BigEndian, uint32(8))
+	binary.Write(tiff, binary.BigEndian, uint16(1))
+	binary.Write(tiff, binary.BigEndian, []uint16{0x0112, 3})
+	binary.Write(tiff, binary.BigEndian, uint32(1))
+	binary.Write(tiff, binary.BigEndian, []uint16{6, 0})
+	binary.Write(tiff, binary.BigEndian, uint32(0))
+	var app1 = append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	var seg = []byte{0xff, 0xe1, 0, 0}
+	binary.BigEndian.PutUint16(seg[2:], uint16(len(app1)+2))
+	var withExif = append(append(append([]byte{}, b[:2]...), append(seg, app1...)...), b[2:]...)
+	assert.Equal(t, astiimage.OrientationRotate90, astiimage.ExifOrientation(withExif))
+	assert.Equal(t, astiimage.OrientationNormal, astiimage.ExifOrientation(b))
+
+	// Decode
+	img, format, err := astiimage.Decode(bytes.NewReader(withExif))
+	assert.NoError(t, err)
+	assert.Equal(t, "jpeg", format)
+	assert.Equal(t, image.Rect(0, 0, 2, 4), img.Bounds())
+}
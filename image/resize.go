@@ -0,0 +1,213 @@
+package astiimage
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+// Interpolations
+const (
+	InterpolationNearest Interpolation = iota
+	InterpolationBilinear
+	InterpolationLanczos
+)
+
+// Interpolation represents an interpolation used when resizing
+type Interpolation int
+
+// filter represents a resampling filter
+type filter struct {
+	kernel  func(x float64) float64
+	support float64
+}
+
+// filter returns the filter of an interpolation
+func (i Interpolation) filter() filter {
+	switch i {
+	case InterpolationBilinear:
+		return filter{
+			kernel: func(x float64) float64 {
+				if x = math.Abs(x); x < 1 {
+					return 1 - x
+				}
+				return 0
+			},
+			support: 1,
+		}
+	case InterpolationLanczos:
+		return filter{
+			kernel: func(x float64) float64 {
+				x = math.Abs(x)
+				if x == 0 {
+					return 1
+				} else if x >= 3 {
+					return 0
+				}
+				return 3 * math.Sin(math.Pi*x) * math.Sin(math.Pi*x/3) / (math.Pi * math.Pi * x * x)
+			},
+			support: 3,
+		}
+	}
+	return filter{}
+}
+
+// Resize resizes an image
+// If either width or height is 0, it's computed so that the aspect ratio is preserved.
+func Resize(src image.Image, width, height int, i Interpolation) *image.RGBA {
+	// Get dimensions
+	var b = src.Bounds()
+	if width == 0 && height == 0 {
+		width, height = b.Dx(), b.Dy()
+	} else if width == 0 && b.Dy() > 0 {
+		width = int(math.Max(1, math.Round(float64(height)*float64(b.Dx())/float64(b.Dy()))))
+	} else if height == 0 && b.Dx() > 0 {
+		height = int(math.Max(1, math.Round(float64(width)*float64(b.Dy())/float64(b.Dx()))))
+	}
+	if width <= 0 || height <= 0 || b.Empty() {
+		return image.NewRGBA(image.Rect(0, 0, 0, 0))
+	}
+
+	// Resample
+	var s = toRGBA(src)
+	var f = i.filter()
+	var h = resample(pixels(s), b.Dx(), b.Dy(), width, f, true)
+	return toImage(resample(h, width, b.Dy(), height, f, false), width, height)
+}
+
+// Fit resizes an image so that it fits in the provided box while preserving its aspect ratio
+// Images that already fit are not upscaled.
+func Fit(src image.Image, maxWidth, maxHeight int, i Interpolation) *image.RGBA {
+	var b = src.Bounds()
+	if b.Dx() <= maxWidth && b.Dy() <= maxHeight {
+		return toRGBA(src)
+	}
+	var r = math.Min(float64(maxWidth)/float64(b.Dx()), float64(maxHeight)/float64(b.Dy()))
+	return Resize(src, int(math.Max(1, math.Round(float64(b.Dx())*r))), int(math.Max(1, math.Round(float64(b.Dy())*r))), i)
+}
+
+// Fill resizes an image so that it covers the provided box while preserving its aspect ratio, and crops what exceeds
+// the box around the center
+func Fill(src image.Image, width, height int, i Interpolation) *image.RGBA {
+	// Resize
+	var b = src.Bounds()
+	if width <= 0 || height <= 0 || b.Empty() {
+		return image.NewRGBA(image.Rect(0, 0, 0, 0))
+	}
+	var r = math.Max(float64(width)/float64(b.Dx()), float64(height)/float64(b.Dy()))
+	var rw, rh = int(math.Max(float64(width), math.Round(float64(b.Dx())*r))), int(math.Max(float64(height), math.Round(float64(b.Dy())*r)))
+	var resized = Resize(src, rw, rh, i)
+
+	// Crop
+	var dst = image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), resized, image.Pt((rw-width)/2, (rh-height)/2), draw.Src)
+	return dst
+}
+
+// Thumbnail creates a thumbnail of exactly the provided dimensions using Lanczos interpolation
+func Thumbnail(src image.Image, width, height int) *image.RGBA {
+	return Fill(src, width, height, InterpolationLanczos)
+}
+
+// toRGBA converts an image to an *image.RGBA whose bounds start at (0, 0)
+func toRGBA(src image.Image) *image.RGBA {
+	var b = src.Bounds()
+	var dst = image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Src)
+	return dst
+}
+
+// pixels returns the premultiplied components of an image as floats
+func pixels(s *image.RGBA) (ps []float64) {
+	var w, h = s.Bounds().Dx(), s.Bounds().Dy()
+	ps = make([]float64, w*h*4)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w*4; x++ {
+			ps[y*w*4+x] = float64(s.Pix[y*s.Stride+x])
+		}
+	}
+	return
+}
+
+// toImage converts premultiplied components to an image
+func toImage(ps []float64, w, h int) (dst *image.RGBA) {
+	dst = image.NewRGBA(image.Rect(0, 0, w, h))
+	for i, p := range ps {
+		dst.Pix[i] = uint8(math.Max(0, math.Min(255, math.Round(p))))
+	}
+	// Premultiplied components can't exceed alpha
+	for i := 0; i < len(dst.Pix); i += 4 {
+		for j := 0; j < 3; j++ {
+			if dst.Pix[i+j] > dst.Pix[i+3] {
+				dst.Pix[i+j] = dst.Pix[i+3]
+			}
+		}
+	}
+	return
+}
+
+// resample resamples components along one axis
+func resample(src []float64, w, h, size int, f filter, horizontal bool) (dst []float64) {
+	// Get dimensions
+	var srcSize, otherSize = w, h
+	var dw, dh = size, h
+	if !horizontal {
+		srcSize, otherSize = h, w
+		dw, dh = w, size
+	}
+	dst = make([]float64, dw*dh*4)
+
+	// Offset of a pixel
+	var offset = func(i, o, width int) int {
+		if horizontal {
+			return (o*width + i) * 4
+		}
+		return (i*width + o) * 4
+	}
+
+	// Loop through destination pixels
+	var scale = float64(srcSize) / float64(size)
+	var fscale = math.Max(scale, 1)
+	for i := 0; i < size; i++ {
+		// Nearest
+		if f.kernel == nil {
+			var si = int(math.Min(float64(srcSize-1), math.Floor((float64(i)+0.5)*scale)))
+			for o := 0; o < otherSize; o++ {
+				copy(dst[offset(i, o, dw):offset(i, o, dw)+4], src[offset(si, o, w):offset(si, o, w)+4])
+			}
+			continue
+		}
+
+		// Compute weights
+		var center = (float64(i)+0.5)*scale - 0.5
+		var support = f.support * fscale
+		var start, end = int(math.Ceil(center - support)), int(math.Floor(center + support))
+		var idx []int
+		var ws []float64
+		var total float64
+		for j := start; j <= end; j++ {
+			var wt = f.kernel((float64(j) - center) / fscale)
+			if wt == 0 {
+				continue
+			}
+			idx = append(idx, int(math.Max(0, math.Min(float64(srcSize-1), float64(j)))))
+			ws = append(ws, wt)
+			total += wt
+		}
+		if total == 0 {
+			idx, ws, total = []int{int(math.Max(0, math.Min(float64(srcSize-1), math.Round(center))))}, []float64{1}, 1
+		}
+
+		// Apply weights
+		for o := 0; o < otherSize; o++ {
+			var d = offset(i, o, dw)
+			for k, si := range idx {
+				var s = offset(si, o, w)
+				for c := 0; c < 4; c++ {
+					dst[d+c] += src[s+c] * ws[k] / total
+				}
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,56 @@
+package astiimage_test
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/asticode/go-astitools/image"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResize(t *testing.T) {
+	// Create image: left half black, right half white
+	var img = image.NewRGBA(image.Rect(0, 0, 8, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 8; x++ {
+			var c = color.RGBA{A: 255}
+			if x >= 4 {
+				c = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+
+	// Loop through interpolations
+	for _, i := range []astiimage.Interpolation{astiimage.InterpolationNearest, astiimage.InterpolationBilinear, astiimage.InterpolationLanczos} {
+		// Downscale
+		var r = astiimage.Resize(img, 4, 0, i)
+		assert.Equal(t, image.Rect(0, 0, 4, 2), r.Bounds())
+		assert.Equal(t, color.RGBA{A: 255}, r.RGBAAt(0, 0))
+		assert.Equal(t, color.RGBA{R: 255, G: 255, B: 255, A: 255}, r.RGBAAt(3, 1))
+
+		// Upscale
+		r = astiimage.Resize(img, 0, 8, i)
+		assert.Equal(t, image.Rect(0, 0, 16, 8), r.Bounds())
+		assert.Equal(t, color.RGBA{A: 255}, r.RGBAAt(0, 0))
+		assert.Equal(t, color.RGBA{R: 255, G: 255, B: 255, A: 255}, r.RGBAAt(15, 7))
+	}
+
+	// Bilinear blends at the edge
+	var r = astiimage.Resize(img, 2, 1, astiimage.InterpolationBilinear)
+	assert.True(t, r.RGBAAt(0, 0).R < 64)
+	r = astiimage.Resize(img, 3, 1, astiimage.InterpolationBilinear)
+	assert.Equal(t, uint8(128), r.RGBAAt(1, 0).R)
+
+	// Fit
+	assert.Equal(t, image.Rect(0, 0, 4, 2), astiimage.Fit(img, 4, 4, astiimage.InterpolationBilinear).Bounds())
+	assert.Equal(t, image.Rect(0, 0, 8, 4), astiimage.Fit(img, 10, 10, astiimage.InterpolationBilinear).Bounds())
+
+	// Fill
+	r = astiimage.Fill(img, 2, 2, astiimage.InterpolationNearest)
+	assert.Equal(t, image.Rect(0, 0, 2, 2), r.Bounds())
+	assert.Equal(t, color.RGBA{A: 255}, r.RGBAAt(0, 0))
+	assert.Equal(t, color.RGBA{R: 255, G: 255, B: 255, A: 255}, r.RGBAAt(1, 0))
+	assert.Equal(t, image.Rect(0, 0, 3, 3), astiimage.Thumbnail(img, 3, 3).Bounds())
+}
@@ -0,0 +1,150 @@
+package astiimage
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/draw"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// webpMaxDimension is the maximum width or height of a lossless WebP image
+const webpMaxDimension = 1 << 14
+
+// EncodeWebP encodes an image as a lossless WebP (VP8L) image
+// It neither uses transforms nor backward references, which makes encoding fast but files bigger than with libwebp.
+func EncodeWebP(w io.Writer, img image.Image) (err error) {
+	// Check dimensions
+	var b = img.Bounds()
+	if b.Dx() <= 0 || b.Dy() <= 0 || b.Dx() > webpMaxDimension || b.Dy() > webpMaxDimension {
+		err = errors.Errorf("astiimage: invalid webp dimensions %dx%d", b.Dx(), b.Dy())
+		return
+	}
+
+	// Convert to non-premultiplied components
+	var n = image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(n, n.Bounds(), img, b.Min, draw.Src)
+
+	// Header
+	var bw = &webpBitWriter{}
+	bw.write(0x2f, 8)
+	bw.write(uint32(b.Dx()-1), 14)
+	bw.write(uint32(b.Dy()-1), 14)
+	var alpha uint32
+	for i := 3; i < len(n.Pix); i += 4 {
+		if n.Pix[i] != 0xff {
+			alpha = 1
+			break
+		}
+	}
+	bw.write(alpha, 1)
+	bw.write(0, 3)
+
+	// No transform, no color cache, no meta prefix codes
+	bw.write(0, 1)
+	bw.write(0, 1)
+	bw.write(0, 1)
+
+	// Prefix codes: green (and lengths), red, blue and alpha use 8-bit codes for all 256 literals while distance uses
+	// a single zero-length symbol
+	for _, size := range []int{256 + 24, 256, 256, 256} {
+		bw.writeLiteralCode(size)
+	}
+	bw.write(1, 1)
+	bw.write(0, 1)
+	bw.write(0, 1)
+	bw.write(0, 1)
+
+	// Pixels
+	for i := 0; i < len(n.Pix); i += 4 {
+		bw.writeCode(n.Pix[i+1])
+		bw.writeCode(n.Pix[i])
+		bw.writeCode(n.Pix[i+2])
+		bw.writeCode(n.Pix[i+3])
+	}
+	var data = bw.bytes()
+
+	// RIFF container
+	var buf = &bytes.Buffer{}
+	var pad = len(data) % 2
+	buf.WriteString("RIFF")
+	binary.Write(buf, binary.LittleEndian, uint32(4+8+len(data)+pad))
+	buf.WriteString("WEBPVP8L")
+	binary.Write(buf, binary.LittleEndian, uint32(len(data)))
+	buf.Write(data)
+	if pad > 0 {
+		buf.WriteByte(0)
+	}
+
+	// Write
+	if _, err = w.Write(buf.Bytes()); err != nil {
+		err = errors.Wrap(err, "astiimage: writing webp failed")
+		return
+	}
+	return
+}
+
+// webpBitWriter writes bits LSB first
+type webpBitWriter struct {
+	b     []byte
+	bits  uint64
+	nbits uint
+}
+
+// write writes the n least significant bits of v
+func (w *webpBitWriter) write(v uint32, n uint) {
+	w.bits |= uint64(v) << w.nbits
+	w.nbits += n
+	for w.nbits >= 8 {
+		w.b = append(w.b, byte(w.bits))
+		w.bits >>= 8
+		w.nbits -= 8
+	}
+}
+
+// writeCode writes the 8-bit canonical prefix code of a literal, whose bits are read MSB first
+func (w *webpBitWriter) writeCode(v uint8) {
+	var r uint8
+	for i := 0; i < 8; i++ {
+		r |= ((v >> uint(i)) & 1) << uint(7-i)
+	}
+	w.write(uint32(r), 8)
+}
+
+// writeLiteralCode writes a normal prefix code giving an 8-bit length to the first 256 symbols of an alphabet and a
+// zero length to the others
+// The code length code only uses symbols 0 and 8, both with a 1-bit code: 0 for symbol 0 and 1 for symbol 8.
+func (w *webpBitWriter) writeLiteralCode(size int) {
+	// Normal code
+	w.write(0, 1)
+
+	// Code length code lengths, in the kCodeLengthCodeOrder order (17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8) up to
+	// symbol 8
+	w.write(12-4, 4)
+	for _, l := range []uint32{0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0, 1} {
+		w.write(l, 3)
+	}
+
+	// Don't use max symbol
+	w.write(0, 1)
+
+	// Code lengths
+	for i := 0; i < size; i++ {
+		if i < 256 {
+			w.write(1, 1)
+		} else {
+			w.write(0, 1)
+		}
+	}
+}
+
+// bytes flushes and returns the written bytes
+func (w *webpBitWriter) bytes() []byte {
+	if w.nbits > 0 {
+		w.b = append(w.b, byte(w.bits))
+		w.bits, w.nbits = 0, 0
+	}
+	return w.b
+}
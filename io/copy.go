@@ -2,10 +2,100 @@ package astiio
 
 import (
 	"context"
+	"hash"
 	"io"
+	"time"
 )
 
 // Copy represents a cancellable copy
 func Copy(ctx context.Context, src io.Reader, dst io.Writer) (int64, error) {
 	return io.Copy(dst, NewReader(ctx, src))
 }
+
+// CopyOptions represents copy options
+type CopyOptions struct {
+	// Maximum number of bytes copied per second. 0 means no maximum
+	BandwidthLimit int64
+	// Hash is written with the copied content so that its sum can be retrieved once the copy is over
+	Hash hash.Hash
+	// Progress is called every time content is written
+	Progress func(p CopyProgress)
+}
+
+// CopyProgress represents a copy progress
+type CopyProgress struct {
+	// Number of bytes copied
+	Copied int64
+	// Amount of time elapsed since the copy has started
+	Elapsed time.Duration
+	// Average number of bytes copied per second since the copy has started
+	Throughput float64
+}
+
+// CopyWithOptions represents a cancellable copy with progress, bandwidth throttling and on the fly hashing
+func CopyWithOptions(ctx context.Context, src io.Reader, dst io.Writer, o CopyOptions) (int64, error) {
+	// Add hash
+	if o.Hash != nil {
+		dst = io.MultiWriter(dst, o.Hash)
+	}
+
+	// Create writer
+	var w = &copyWriter{
+		ctx:   ctx,
+		o:     o,
+		start: time.Now(),
+		w:     dst,
+	}
+
+	// Make sure throttling is not too coarse
+	var b = make([]byte, 32*1024)
+	if o.BandwidthLimit > 0 && o.BandwidthLimit < int64(len(b)) {
+		b = b[:o.BandwidthLimit]
+	}
+
+	// Copy
+	return io.CopyBuffer(w, NewReader(ctx, src), b)
+}
+
+// copyWriter represents a writer that reports progress and throttles bandwidth
+type copyWriter struct {
+	copied int64
+	ctx    context.Context
+	o      CopyOptions
+	start  time.Time
+	w      io.Writer
+}
+
+// Write implements the io.Writer interface
+func (w *copyWriter) Write(p []byte) (n int, err error) {
+	// Write
+	n, err = w.w.Write(p)
+	w.copied += int64(n)
+
+	// Report progress
+	var elapsed = time.Since(w.start)
+	if w.o.Progress != nil {
+		var cp = CopyProgress{Copied: w.copied, Elapsed: elapsed}
+		if elapsed > 0 {
+			cp.Throughput = float64(w.copied) / elapsed.Seconds()
+		}
+		w.o.Progress(cp)
+	}
+	if err != nil {
+		return
+	}
+
+	// Throttle
+	if w.o.BandwidthLimit > 0 {
+		if d := time.Duration(float64(w.copied)/float64(w.o.BandwidthLimit)*float64(time.Second)) - elapsed; d > 0 {
+			var t = time.NewTimer(d)
+			defer t.Stop()
+			select {
+			case <-t.C:
+			case <-w.ctx.Done():
+				err = w.ctx.Err()
+			}
+		}
+	}
+	return
+}
@@ -2,8 +2,11 @@ package astiio_test
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/asticode/go-astitools/io"
 	"github.com/stretchr/testify/assert"
@@ -55,4 +58,27 @@ func TestCopy(t *testing.T) {
 	nw, err = astiio.Copy(ctx, r2, w)
 	assert.NoError(t, err)
 	assert.Equal(t, "testiocopy", w.String())
+	assert.Equal(t, int64(10), nw)
+}
+
+func TestCopyWithOptions(t *testing.T) {
+	// Init
+	var w = &bytes.Buffer{}
+	var ps []astiio.CopyProgress
+	var h = sha256.New()
+
+	// Copy
+	n := time.Now()
+	c, err := astiio.CopyWithOptions(context.Background(), bytes.NewReader(bytes.Repeat([]byte("a"), 100)), w, astiio.CopyOptions{
+		BandwidthLimit: 1000,
+		Hash:           h,
+		Progress:       func(p astiio.CopyProgress) { ps = append(ps, p) },
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(100), c)
+	assert.True(t, time.Since(n) >= 90*time.Millisecond)
+	assert.Equal(t, bytes.Repeat([]byte("a"), 100), w.Bytes())
+	assert.Equal(t, "2816597888e4a0d3a36b82b83316ab32680eb8f00f8cd3b904d681246d285a0e", hex.EncodeToString(h.Sum(nil)))
+	assert.Len(t, ps, 1)
+	assert.Equal(t, int64(100), ps[0].Copied)
 }
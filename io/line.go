@@ -0,0 +1,165 @@
+package astiio
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// DefaultLineMaxSize represents the default max line size
+const DefaultLineMaxSize = 64 * 1024
+
+// LineTooLongError is returned when a line exceeds the max line size
+// The rest of the line is discarded and reading resumes with the next line.
+type LineTooLongError struct {
+	MaxSize int
+}
+
+// Error implements the error interface
+func (e LineTooLongError) Error() string {
+	return fmt.Sprintf("astiio: line exceeds max size of %d bytes", e.MaxSize)
+}
+
+// LineReaderOptions represents line reader options
+type LineReaderOptions struct {
+	// Amount of time after which a partial line is returned if no new data has been read. 0 disables flushes.
+	FlushTimeout time.Duration
+	// Defaults to DefaultLineMaxSize
+	MaxSize int
+	// Defaults to 4096
+	ReadSize int
+}
+
+// LineReader reads lines from an io.Reader while honoring a context, a max line size and partial lines flushes,
+// which is useful when tailing the output of processes whose prompts are not terminated by a newline
+// Since reads can't be interrupted, the underlying reader is read in a goroutine that exits once it returns an error:
+// closing it is up to the caller.
+type LineReader struct {
+	buf     []byte
+	ch      chan lineRead
+	ctx     context.Context
+	discard bool
+	err     error
+	o       LineReaderOptions
+	oStart  sync.Once
+	r       io.Reader
+}
+
+type lineRead struct {
+	b   []byte
+	err error
+}
+
+// NewLineReader creates a new line reader
+func NewLineReader(ctx context.Context, r io.Reader, o LineReaderOptions) *LineReader {
+	// Default options values
+	if o.MaxSize <= 0 {
+		o.MaxSize = DefaultLineMaxSize
+	}
+	if o.ReadSize <= 0 {
+		o.ReadSize = 4096
+	}
+
+	// Create line reader
+	return &LineReader{
+		ch:  make(chan lineRead),
+		ctx: ctx,
+		o:   o,
+		r:   r,
+	}
+}
+
+// read reads the underlying reader until it returns an error
+func (r *LineReader) read() {
+	for {
+		var b = make([]byte, r.o.ReadSize)
+		n, err := r.r.Read(b)
+		select {
+		case r.ch <- lineRead{b: b[:n], err: err}:
+		case <-r.ctx.Done():
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// ReadLine returns the next line without its trailing "\n" or "\r\n"
+// partial is true when the line has been flushed because no new data has been read during the flush timeout: the
+// rest of the line, if any, is returned by the next call. The last line is returned even if it's not terminated by a
+// newline, after which io.EOF is returned.
+func (r *LineReader) ReadLine() (line []byte, partial bool, err error) {
+	// Start reading
+	r.oStart.Do(func() { go r.read() })
+
+	for {
+		// Line is available
+		if i := bytes.IndexByte(r.buf, '\n'); i >= 0 {
+			line, r.buf = r.buf[:i], r.buf[i+1:]
+			if r.discard {
+				r.discard = false
+				continue
+			}
+			line = bytes.TrimSuffix(line, []byte("\r"))
+
+			// A single read may have buffered a whole line that is too long
+			if len(line) > r.o.MaxSize {
+				line, err = nil, LineTooLongError{MaxSize: r.o.MaxSize}
+			}
+			return
+		}
+
+		// Line is too long
+		if len(r.buf) > r.o.MaxSize {
+			r.buf = nil
+			if !r.discard {
+				r.discard = true
+				err = LineTooLongError{MaxSize: r.o.MaxSize}
+				return
+			}
+		} else if r.discard {
+			r.buf = nil
+		}
+
+		// Process read error
+		if r.err != nil {
+			if len(r.buf) > 0 {
+				line, r.buf = r.buf, nil
+				return
+			}
+			err = r.err
+			return
+		}
+
+		// Create flush timer
+		var t *time.Timer
+		var flush <-chan time.Time
+		if r.o.FlushTimeout > 0 && len(r.buf) > 0 {
+			t = time.NewTimer(r.o.FlushTimeout)
+			flush = t.C
+		}
+
+		// Wait
+		select {
+		case <-r.ctx.Done():
+			err = r.ctx.Err()
+		case <-flush:
+			line, r.buf, partial = r.buf, nil, true
+		case lr := <-r.ch:
+			r.buf = append(r.buf, lr.b...)
+			r.err = lr.err
+		}
+
+		// Stop flush timer
+		if t != nil {
+			t.Stop()
+		}
+		if err != nil || partial {
+			return
+		}
+	}
+}
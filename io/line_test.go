@@ -0,0 +1,62 @@
+package astiio_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/io"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLineReader(t *testing.T) {
+	// Lines
+	r := astiio.NewLineReader(context.Background(), strings.NewReader("1\r\n"+strings.Repeat("a", 20)+"\n2\n3"), astiio.LineReaderOptions{MaxSize: 10, ReadSize: 4})
+	l, p, err := r.ReadLine()
+	assert.NoError(t, err)
+	assert.False(t, p)
+	assert.Equal(t, "1", string(l))
+	_, _, err = r.ReadLine()
+	assert.Equal(t, astiio.LineTooLongError{MaxSize: 10}, err)
+	l, _, err = r.ReadLine()
+	assert.NoError(t, err)
+	assert.Equal(t, "2", string(l))
+	l, _, err = r.ReadLine()
+	assert.NoError(t, err)
+	assert.Equal(t, "3", string(l))
+	_, _, err = r.ReadLine()
+	assert.Equal(t, io.EOF, err)
+
+	// Line too long within a single read
+	r = astiio.NewLineReader(context.Background(), strings.NewReader(strings.Repeat("a", 20)+"\n2\n"), astiio.LineReaderOptions{MaxSize: 10, ReadSize: 64})
+	_, _, err = r.ReadLine()
+	assert.Equal(t, astiio.LineTooLongError{MaxSize: 10}, err)
+	l, _, err = r.ReadLine()
+	assert.NoError(t, err)
+	assert.Equal(t, "2", string(l))
+
+	// Partial line flush
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	r = astiio.NewLineReader(context.Background(), pr, astiio.LineReaderOptions{FlushTimeout: 10 * time.Millisecond})
+	go pw.Write([]byte("password: "))
+	l, p, err = r.ReadLine()
+	assert.NoError(t, err)
+	assert.True(t, p)
+	assert.Equal(t, "password: ", string(l))
+	go pw.Write([]byte("secret\n"))
+	l, p, err = r.ReadLine()
+	assert.NoError(t, err)
+	assert.False(t, p)
+	assert.Equal(t, "secret", string(l))
+
+	// Context
+	ctx, cancel := context.WithCancel(context.Background())
+	r = astiio.NewLineReader(ctx, pr, astiio.LineReaderOptions{})
+	cancel()
+	_, _, err = r.ReadLine()
+	assert.True(t, errors.Is(err, context.Canceled))
+}
@@ -0,0 +1,115 @@
+package astiio
+
+import (
+	"context"
+	"io"
+)
+
+// MultiWriter represents a writer that duplicates its writes to several writers with a context
+// Writes are executed in parallel and Write returns ctx.Err() as soon as the context is cancelled, even if a writer is
+// still blocked.
+type MultiWriter struct {
+	ctx     context.Context
+	writers []io.Writer
+}
+
+// NewMultiWriter creates a new MultiWriter
+func NewMultiWriter(ctx context.Context, ws ...io.Writer) *MultiWriter {
+	return &MultiWriter{
+		ctx:     ctx,
+		writers: ws,
+	}
+}
+
+// Write allows MultiWriter to implement the io.Writer interface
+func (w *MultiWriter) Write(p []byte) (n int, err error) {
+	// Check context
+	if err = w.ctx.Err(); err != nil {
+		return
+	}
+
+	// Copy p since writers may still be using it after we've returned
+	var b = make([]byte, len(p))
+	copy(b, p)
+
+	// Write
+	var ch = make(chan writeResult, len(w.writers))
+	for _, wr := range w.writers {
+		go func(wr io.Writer) { ch <- write(wr, b) }(wr)
+	}
+
+	// Wait for writes
+	for range w.writers {
+		select {
+		case r := <-ch:
+			if r.err != nil {
+				return 0, r.err
+			}
+			if r.n != len(b) {
+				return 0, io.ErrShortWrite
+			}
+		case <-w.ctx.Done():
+			return 0, w.ctx.Err()
+		}
+	}
+	return len(p), nil
+}
+
+// TeeReader represents a reader that writes to a writer what it reads with a context
+// Read returns ctx.Err() as soon as the context is cancelled, even if the writer is still blocked.
+type TeeReader struct {
+	ctx    context.Context
+	reader io.Reader
+	writer io.Writer
+}
+
+// NewTeeReader creates a new TeeReader
+func NewTeeReader(ctx context.Context, r io.Reader, w io.Writer) *TeeReader {
+	return &TeeReader{
+		ctx:    ctx,
+		reader: r,
+		writer: w,
+	}
+}
+
+// Read allows TeeReader to implement the io.Reader interface
+func (r *TeeReader) Read(p []byte) (n int, err error) {
+	// Check context
+	if err = r.ctx.Err(); err != nil {
+		return
+	}
+
+	// Read
+	if n, err = r.reader.Read(p); n <= 0 {
+		return
+	}
+
+	// Copy p since the writer may still be using it after we've returned
+	var b = make([]byte, n)
+	copy(b, p[:n])
+
+	// Write
+	var ch = make(chan writeResult, 1)
+	go func() { ch <- write(r.writer, b) }()
+	select {
+	case wr := <-ch:
+		if wr.err != nil {
+			return n, wr.err
+		}
+	case <-r.ctx.Done():
+		return n, r.ctx.Err()
+	}
+	return
+}
+
+// writeResult represents the result of a write
+type writeResult struct {
+	err error
+	n   int
+}
+
+// write writes b to w
+func write(w io.Writer, b []byte) writeResult {
+	n, err := w.Write(b)
+	return writeResult{err: err, n: n}
+}
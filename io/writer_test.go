@@ -0,0 +1,57 @@
+package astiio_test
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+
+	"github.com/asticode/go-astitools/io"
+	"github.com/stretchr/testify/assert"
+)
+
+// blockingWriter is a writer that blocks until its channel is closed
+type blockingWriter chan bool
+
+// Write implements the io.Writer interface
+func (w blockingWriter) Write(p []byte) (int, error) {
+	<-w
+	return len(p), nil
+}
+
+func TestMultiWriter(t *testing.T) {
+	// Success
+	var w1, w2 = &bytes.Buffer{}, &bytes.Buffer{}
+	n, err := astiio.NewMultiWriter(context.Background(), w1, w2).Write([]byte("test"))
+	assert.NoError(t, err)
+	assert.Equal(t, 4, n)
+	assert.Equal(t, "test", w1.String())
+	assert.Equal(t, "test", w2.String())
+
+	// Cancel
+	ctx, cancel := context.WithCancel(context.Background())
+	bw := make(blockingWriter)
+	defer close(bw)
+	w := astiio.NewMultiWriter(ctx, &bytes.Buffer{}, bw)
+	go cancel()
+	_, err = w.Write([]byte("test"))
+	assert.EqualError(t, err, "context canceled")
+}
+
+func TestTeeReader(t *testing.T) {
+	// Success
+	var w = &bytes.Buffer{}
+	b, err := ioutil.ReadAll(astiio.NewTeeReader(context.Background(), bytes.NewReader([]byte("test")), w))
+	assert.NoError(t, err)
+	assert.Equal(t, "test", string(b))
+	assert.Equal(t, "test", w.String())
+
+	// Cancel
+	ctx, cancel := context.WithCancel(context.Background())
+	bw := make(blockingWriter)
+	defer close(bw)
+	r := astiio.NewTeeReader(ctx, bytes.NewReader([]byte("test")), bw)
+	go cancel()
+	_, err = r.Read(make([]byte, 4))
+	assert.EqualError(t, err, "context canceled")
+}
@@ -0,0 +1,102 @@
+package astilimiter
+
+import (
+	"sync"
+	"time"
+
+	"github.com/asticode/go-astitools/time"
+)
+
+// Circuit breaker states
+const (
+	CircuitBreakerStateClosed   CircuitBreakerState = "closed"
+	CircuitBreakerStateHalfOpen CircuitBreakerState = "half-open"
+	CircuitBreakerStateOpen     CircuitBreakerState = "open"
+)
+
+// CircuitBreakerState represents a circuit breaker state
+type CircuitBreakerState string
+
+// CircuitBreaker represents a circuit breaker
+// It opens after maxFailures consecutive failures and half-opens after cooldown, letting a single trial through: if it
+// succeeds the circuit breaker closes, otherwise it opens again.
+type CircuitBreaker struct {
+	c           astitime.Clock
+	cooldown    time.Duration
+	failures    int
+	m           sync.Mutex // Locks attributes
+	maxFailures int
+	openedAt    time.Time
+	state       CircuitBreakerState
+	trial       bool
+}
+
+// NewCircuitBreaker creates a new circuit breaker
+func NewCircuitBreaker(maxFailures int, cooldown time.Duration) *CircuitBreaker {
+	return newCircuitBreaker(maxFailures, cooldown, astitime.RealClock{})
+}
+
+// newCircuitBreaker creates a new circuit breaker with a clock
+func newCircuitBreaker(maxFailures int, cooldown time.Duration, c astitime.Clock) *CircuitBreaker {
+	return &CircuitBreaker{
+		c:           c,
+		cooldown:    cooldown,
+		maxFailures: maxFailures,
+		state:       CircuitBreakerStateClosed,
+	}
+}
+
+// Allow checks whether an action is allowed
+// When it returns true, either Success or Failure must be called once the action is over
+func (b *CircuitBreaker) Allow() bool {
+	b.m.Lock()
+	defer b.m.Unlock()
+	switch b.state {
+	case CircuitBreakerStateOpen:
+		// Cooldown is not over
+		if b.c.Now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+
+		// Half-open
+		b.state = CircuitBreakerStateHalfOpen
+		b.trial = true
+		return true
+	case CircuitBreakerStateHalfOpen:
+		// Only one trial at a time
+		if b.trial {
+			return false
+		}
+		b.trial = true
+		return true
+	}
+	return true
+}
+
+// Failure records a failure
+func (b *CircuitBreaker) Failure() {
+	b.m.Lock()
+	defer b.m.Unlock()
+	b.failures++
+	b.trial = false
+	if b.state == CircuitBreakerStateHalfOpen || b.failures >= b.maxFailures {
+		b.state = CircuitBreakerStateOpen
+		b.openedAt = b.c.Now()
+	}
+}
+
+// State returns the circuit breaker state
+func (b *CircuitBreaker) State() CircuitBreakerState {
+	b.m.Lock()
+	defer b.m.Unlock()
+	return b.state
+}
+
+// Success records a success
+func (b *CircuitBreaker) Success() {
+	b.m.Lock()
+	defer b.m.Unlock()
+	b.failures = 0
+	b.state = CircuitBreakerStateClosed
+	b.trial = false
+}
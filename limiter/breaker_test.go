@@ -0,0 +1,45 @@
+package astilimiter_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/limiter"
+	"github.com/asticode/go-astitools/time"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	b := astilimiter.NewCircuitBreaker(2, 10*time.Millisecond)
+	assert.True(t, b.Allow())
+	b.Failure()
+	assert.Equal(t, astilimiter.CircuitBreakerStateClosed, b.State())
+	assert.True(t, b.Allow())
+	b.Failure()
+	assert.Equal(t, astilimiter.CircuitBreakerStateOpen, b.State())
+	assert.False(t, b.Allow())
+	time.Sleep(15 * time.Millisecond)
+	assert.True(t, b.Allow())
+	assert.Equal(t, astilimiter.CircuitBreakerStateHalfOpen, b.State())
+	assert.False(t, b.Allow())
+	b.Failure()
+	assert.Equal(t, astilimiter.CircuitBreakerStateOpen, b.State())
+	time.Sleep(15 * time.Millisecond)
+	assert.True(t, b.Allow())
+	b.Success()
+	assert.Equal(t, astilimiter.CircuitBreakerStateClosed, b.State())
+	assert.True(t, b.Allow())
+}
+
+func TestCircuitBreaker_Clock(t *testing.T) {
+	var c = astitime.NewFakeClock(time.Now())
+	var b = astilimiter.NewWithOptions(astilimiter.LimiterOptions{Clock: c}).NewCircuitBreaker(1, time.Minute)
+	assert.True(t, b.Allow())
+	b.Failure()
+	assert.Equal(t, astilimiter.CircuitBreakerStateOpen, b.State())
+	c.Add(59 * time.Second)
+	assert.False(t, b.Allow())
+	c.Add(time.Second)
+	assert.True(t, b.Allow())
+	assert.Equal(t, astilimiter.CircuitBreakerStateHalfOpen, b.State())
+}
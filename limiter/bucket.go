@@ -1,24 +1,34 @@
 package astilimiter
 
 import (
+	"context"
+	"sync"
 	"time"
+
+	"github.com/asticode/go-astitools/time"
 )
 
-// Bucket represents a bucket
+// Bucket represents a fixed-window bucket
 type Bucket struct {
+	c           astitime.Clock
 	cap         int
 	channelQuit chan bool
+	closed      bool
 	count       int
+	m           sync.Mutex // Locks closed, count and resetAt
 	period      time.Duration
+	resetAt     time.Time
 }
 
 // newBucket creates a new bucket
-func newBucket(cap int, period time.Duration) (b *Bucket) {
+func newBucket(cap int, period time.Duration, c astitime.Clock) (b *Bucket) {
 	b = &Bucket{
+		c:           c,
 		cap:         cap,
 		channelQuit: make(chan bool),
 		count:       0,
 		period:      period,
+		resetAt:     c.Now().Add(period),
 	}
 	go b.tick()
 	return
@@ -26,21 +36,45 @@ func newBucket(cap int, period time.Duration) (b *Bucket) {
 
 // Inc increments the bucket count
 func (b *Bucket) Inc() bool {
+	_, ok := b.inc()
+	return ok
+}
+
+// inc increments the bucket count and returns the time left before the next reset if it can't
+func (b *Bucket) inc() (time.Duration, bool) {
+	b.m.Lock()
+	defer b.m.Unlock()
 	if b.count >= b.cap {
-		return false
+		return b.resetAt.Sub(b.c.Now()), false
 	}
 	b.count++
-	return true
+	return 0, true
+}
+
+// Wait blocks until the bucket count can be incremented
+func (b *Bucket) Wait(ctx context.Context) (err error) {
+	for {
+		d, ok := b.inc()
+		if ok {
+			return
+		}
+		if err = b.c.Sleep(ctx, waitDuration(d)); err != nil {
+			return
+		}
+	}
 }
 
 // tick runs a ticker to purge the bucket
 func (b *Bucket) tick() {
-	var t = time.NewTicker(b.period)
+	var t = b.c.NewTicker(b.period)
 	defer t.Stop()
 	for {
 		select {
-		case <-t.C:
+		case <-t.C():
+			b.m.Lock()
 			b.count = 0
+			b.resetAt = b.c.Now().Add(b.period)
+			b.m.Unlock()
 		case <-b.channelQuit:
 			return
 		}
@@ -49,8 +83,18 @@ func (b *Bucket) tick() {
 
 // close closes the bucket properly
 func (b *Bucket) close() {
-	if b.channelQuit != nil {
+	b.m.Lock()
+	defer b.m.Unlock()
+	if !b.closed {
 		close(b.channelQuit)
-		b.channelQuit = nil
+		b.closed = true
+	}
+}
+
+// waitDuration makes sure waiting loops don't spin
+func waitDuration(d time.Duration) time.Duration {
+	if d < time.Millisecond {
+		return time.Millisecond
 	}
+	return d
 }
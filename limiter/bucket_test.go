@@ -1,6 +1,7 @@
 package astilimiter_test
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -15,3 +16,25 @@ func TestBucket(t *testing.T) {
 	assert.True(t, b.Inc())
 	assert.False(t, b.Inc())
 }
+
+func TestBucket_Wait(t *testing.T) {
+	var l = astilimiter.New()
+	defer l.Close()
+	var b = l.Add("test", 1, 50*time.Millisecond)
+	assert.True(t, b.Inc())
+	assert.NoError(t, b.Wait(context.Background()))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, b.Wait(ctx))
+}
+
+func TestLimiter_StrategyConflict(t *testing.T) {
+	l := astilimiter.New()
+	defer l.Close()
+	b := l.Add("b", 1, time.Second)
+	assert.Equal(t, b, l.Add("b", 2, time.Second))
+	assert.PanicsWithValue(t, "astilimiter: adding token bucket b failed: a fixed-window bucket already exists with the same name", func() { l.AddTokenBucket("b", 1, time.Second) })
+	assert.PanicsWithValue(t, "astilimiter: adding store bucket b failed: a fixed-window bucket already exists with the same name", func() { l.AddStoreBucket("b", astilimiter.NewMemoryStore(), 1, time.Second) })
+	l.AddSlidingWindow("w", 1, time.Second)
+	assert.PanicsWithValue(t, "astilimiter: adding fixed-window bucket w failed: a sliding window already exists with the same name", func() { l.Add("w", 1, time.Second) })
+}
@@ -1,38 +1,165 @@
 package astilimiter
 
 import (
+	"context"
+	"fmt"
 	"sync"
 	"time"
+
+	"github.com/asticode/go-astitools/time"
 )
 
+// RateLimiter represents a rate limiting strategy
+// Inc rejects the event when the rate limit is reached whereas Wait blocks until the event is allowed or the context
+// is cancelled.
+type RateLimiter interface {
+	Inc() bool
+	Wait(ctx context.Context) error
+}
+
+// closer represents a rate limiter that needs to be closed
+type closer interface {
+	close()
+}
+
 // Limiter represents a limiter
 type Limiter struct {
-	buckets map[string]*Bucket
+	buckets map[string]RateLimiter
+	c       astitime.Clock
 	m       *sync.Mutex // Locks buckets
 }
 
+// LimiterOptions represents limiter options
+type LimiterOptions struct {
+	// Clock used by the limiter buckets. Defaults to the real clock.
+	Clock astitime.Clock
+}
+
 // New creates a new limiter
 func New() *Limiter {
+	return NewWithOptions(LimiterOptions{})
+}
+
+// NewWithOptions creates a new limiter with options
+func NewWithOptions(o LimiterOptions) *Limiter {
+	// Default options values
+	if o.Clock == nil {
+		o.Clock = astitime.RealClock{}
+	}
+
+	// Create limiter
 	return &Limiter{
-		buckets: make(map[string]*Bucket),
+		buckets: make(map[string]RateLimiter),
+		c:       o.Clock,
 		m:       &sync.Mutex{},
 	}
 }
 
-// Add adds a new bucket
+// Add adds a new fixed-window bucket
+// It panics if a rate limiter with a different strategy already exists with the same name.
 func (l *Limiter) Add(name string, cap int, period time.Duration) *Bucket {
+	r := l.add(name, func() RateLimiter { return newBucket(cap, period, l.c) })
+	b, ok := r.(*Bucket)
+	if !ok {
+		panic(strategyConflict(name, r, "fixed-window bucket"))
+	}
+	return b
+}
+
+// AddSlidingWindow adds a new sliding-window bucket allowing cap events over any period
+// It panics if a rate limiter with a different strategy already exists with the same name.
+func (l *Limiter) AddSlidingWindow(name string, cap int, period time.Duration) *SlidingWindow {
+	r := l.add(name, func() RateLimiter { return newSlidingWindow(cap, period, l.c) })
+	w, ok := r.(*SlidingWindow)
+	if !ok {
+		panic(strategyConflict(name, r, "sliding window"))
+	}
+	return w
+}
+
+// AddStoreBucket adds a new fixed-window bucket whose state lives in a store, the name being used as store key
+// It panics if a rate limiter with a different strategy already exists with the same name.
+func (l *Limiter) AddStoreBucket(name string, s Store, cap int, period time.Duration) *StoreBucket {
+	r := l.add(name, func() RateLimiter { return newStoreBucket(s, name, cap, period, l.c) })
+	b, ok := r.(*StoreBucket)
+	if !ok {
+		panic(strategyConflict(name, r, "store bucket"))
+	}
+	return b
+}
+
+// AddTokenBucket adds a new token bucket refilled with cap tokens every period
+// It panics if a rate limiter with a different strategy already exists with the same name, or if cap or period is
+// not positive.
+func (l *Limiter) AddTokenBucket(name string, cap int, period time.Duration) *TokenBucket {
+	r := l.add(name, func() RateLimiter { return newTokenBucket(cap, period, l.c) })
+	b, ok := r.(*TokenBucket)
+	if !ok {
+		panic(strategyConflict(name, r, "token bucket"))
+	}
+	return b
+}
+
+// NewCircuitBreaker creates a new circuit breaker using the limiter's clock
+// Unlike rate limiters, circuit breakers are not stored in the limiter.
+func (l *Limiter) NewCircuitBreaker(maxFailures int, cooldown time.Duration) *CircuitBreaker {
+	return newCircuitBreaker(maxFailures, cooldown, l.c)
+}
+
+// strategyConflict returns the message of the panic occurring when adding a rate limiter whose name is already used
+// by a rate limiter with a different strategy
+func strategyConflict(name string, r RateLimiter, strategy string) string {
+	var existing string
+	switch r.(type) {
+	case *Bucket:
+		existing = "fixed-window bucket"
+	case *SlidingWindow:
+		existing = "sliding window"
+	case *StoreBucket:
+		existing = "store bucket"
+	case *TokenBucket:
+		existing = "token bucket"
+	default:
+		existing = fmt.Sprintf("%T", r)
+	}
+	return fmt.Sprintf("astilimiter: adding %s %s failed: a %s already exists with the same name", strategy, name,
+		existing)
+}
+
+// add adds a new rate limiter if none exists with the same name
+func (l *Limiter) add(name string, fn func() RateLimiter) RateLimiter {
 	l.m.Lock()
 	defer l.m.Unlock()
 	if _, ok := l.buckets[name]; !ok {
-		l.buckets[name] = newBucket(cap, period)
+		l.buckets[name] = fn()
 	}
 	return l.buckets[name]
 }
 
-// Bucket retrieves a bucket from the limiter
+// Bucket retrieves a fixed-window bucket from the limiter
 func (l *Limiter) Bucket(name string) (b *Bucket, ok bool) {
 	l.m.Lock()
 	defer l.m.Unlock()
-	b, ok = l.buckets[name]
+	b, ok = l.buckets[name].(*Bucket)
 	return
 }
+
+// RateLimiter retrieves a rate limiter from the limiter, whatever its strategy
+func (l *Limiter) RateLimiter(name string) (r RateLimiter, ok bool) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	r, ok = l.buckets[name]
+	return
+}
+
+// Close closes the limiter properly
+func (l *Limiter) Close() {
+	l.m.Lock()
+	defer l.m.Unlock()
+	for k, b := range l.buckets {
+		if c, ok := b.(closer); ok {
+			c.close()
+		}
+		delete(l.buckets, k)
+	}
+}
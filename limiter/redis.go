@@ -0,0 +1,75 @@
+package astilimiter
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// redisIncrScript atomically increments a key, sets its expiration when it's created and returns its count and ttl
+const redisIncrScript = `local c = redis.call('INCR', KEYS[1])
+if c == 1 then redis.call('PEXPIRE', KEYS[1], ARGV[1]) end
+local t = redis.call('PTTL', KEYS[1])
+if t < 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+	t = tonumber(ARGV[1])
+end
+return {c, t}`
+
+// RedisClient represents a Redis client
+// It only needs to run Lua scripts, which makes it easy to adapt from any Redis library. For instance with go-redis:
+// func(ctx, script, keys, args) (interface{}, error) { return c.Eval(ctx, script, keys, args...).Result() }
+type RedisClient interface {
+	Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error)
+}
+
+// RedisClientFunc is a RedisClient adapter for functions
+type RedisClientFunc func(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error)
+
+// Eval implements the RedisClient interface
+func (f RedisClientFunc) Eval(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+	return f(ctx, script, keys, args...)
+}
+
+// RedisStore represents a Redis store
+type RedisStore struct {
+	c      RedisClient
+	prefix string
+}
+
+// NewRedisStore creates a new Redis store, prefix being prepended to every key
+func NewRedisStore(c RedisClient, prefix string) *RedisStore {
+	return &RedisStore{
+		c:      c,
+		prefix: prefix,
+	}
+}
+
+// Incr implements the Store interface
+func (s *RedisStore) Incr(ctx context.Context, key string, window time.Duration) (count int64, ttl time.Duration, err error) {
+	// Eval script
+	var r interface{}
+	if r, err = s.c.Eval(ctx, redisIncrScript, []string{s.prefix + key}, window.Milliseconds()); err != nil {
+		err = errors.Wrapf(err, "astilimiter: evaluating redis script for key %s failed", s.prefix+key)
+		return
+	}
+
+	// Parse reply
+	vs, ok := r.([]interface{})
+	if !ok || len(vs) != 2 {
+		err = errors.Errorf("astilimiter: invalid redis reply %#v", r)
+		return
+	}
+	if count, ok = vs[0].(int64); !ok {
+		err = errors.Errorf("astilimiter: invalid redis count %#v", vs[0])
+		return
+	}
+	var ms int64
+	if ms, ok = vs[1].(int64); !ok {
+		err = errors.Errorf("astilimiter: invalid redis ttl %#v", vs[1])
+		return
+	}
+	ttl = time.Duration(ms) * time.Millisecond
+	return
+}
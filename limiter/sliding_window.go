@@ -0,0 +1,80 @@
+package astilimiter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/asticode/go-astitools/time"
+)
+
+// SlidingWindow represents a sliding-window log
+// It allows at most cap events over any period, the log keeping the time of every event of the last period.
+type SlidingWindow struct {
+	c      astitime.Clock
+	cap    int
+	events []time.Time
+	m      sync.Mutex // Locks events
+	period time.Duration
+}
+
+// NewSlidingWindow creates a new sliding window
+func NewSlidingWindow(cap int, period time.Duration) *SlidingWindow {
+	return newSlidingWindow(cap, period, astitime.RealClock{})
+}
+
+// newSlidingWindow creates a new sliding window with a clock
+func newSlidingWindow(cap int, period time.Duration, c astitime.Clock) *SlidingWindow {
+	return &SlidingWindow{
+		c:      c,
+		cap:    cap,
+		events: make([]time.Time, 0, cap),
+		period: period,
+	}
+}
+
+// Inc logs an event
+func (w *SlidingWindow) Inc() bool {
+	_, ok := w.inc()
+	return ok
+}
+
+// inc logs an event and returns the time left before the oldest event leaves the window if it can't
+func (w *SlidingWindow) inc() (time.Duration, bool) {
+	// Lock
+	w.m.Lock()
+	defer w.m.Unlock()
+
+	// Purge events that have left the window
+	var n = w.c.Now()
+	var i int
+	for i < len(w.events) && n.Sub(w.events[i]) >= w.period {
+		i++
+	}
+	w.events = append(w.events[:0], w.events[i:]...)
+
+	// Window is full
+	if len(w.events) >= w.cap {
+		if len(w.events) == 0 {
+			return w.period, false
+		}
+		return w.events[0].Add(w.period).Sub(n), false
+	}
+
+	// Log event
+	w.events = append(w.events, n)
+	return 0, true
+}
+
+// Wait blocks until an event can be logged
+func (w *SlidingWindow) Wait(ctx context.Context) (err error) {
+	for {
+		d, ok := w.inc()
+		if ok {
+			return
+		}
+		if err = w.c.Sleep(ctx, waitDuration(d)); err != nil {
+			return
+		}
+	}
+}
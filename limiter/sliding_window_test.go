@@ -0,0 +1,24 @@
+package astilimiter_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/limiter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlidingWindow(t *testing.T) {
+	var w = astilimiter.NewSlidingWindow(2, 100*time.Millisecond)
+	assert.True(t, w.Inc())
+	time.Sleep(50 * time.Millisecond)
+	assert.True(t, w.Inc())
+	assert.False(t, w.Inc())
+
+	// Wait for the first event to leave the window
+	var n = time.Now()
+	assert.NoError(t, w.Wait(context.Background()))
+	assert.True(t, time.Since(n) >= 40*time.Millisecond)
+	assert.False(t, w.Inc())
+}
@@ -0,0 +1,151 @@
+package astilimiter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/asticode/go-astitools/time"
+)
+
+// Store represents a place where bucket state lives
+// Using a shared store such as Redis makes rate limits consistent across several instances of a service.
+type Store interface {
+	// Incr increments the count of key in its current window, starting a new window of the provided duration if none
+	// exists, and returns the new count as well as the time left before the window ends
+	Incr(ctx context.Context, key string, window time.Duration) (count int64, ttl time.Duration, err error)
+}
+
+// MemoryStore represents an in-memory store
+// Windows that have ended are purged at most once per window duration, so that increments don't go through all
+// windows.
+type MemoryStore struct {
+	c       astitime.Clock
+	m       sync.Mutex // Locks purgeAt and windows
+	purgeAt time.Time
+	windows map[string]*memoryWindow
+}
+
+// memoryWindow represents an in-memory window
+type memoryWindow struct {
+	count int64
+	endAt time.Time
+}
+
+// MemoryStoreOptions represents in-memory store options
+type MemoryStoreOptions struct {
+	// Clock used to expire windows. Defaults to the real clock.
+	Clock astitime.Clock
+}
+
+// NewMemoryStore creates a new in-memory store
+func NewMemoryStore() *MemoryStore {
+	return NewMemoryStoreWithOptions(MemoryStoreOptions{})
+}
+
+// NewMemoryStoreWithOptions creates a new in-memory store with options
+func NewMemoryStoreWithOptions(o MemoryStoreOptions) *MemoryStore {
+	// Default options values
+	if o.Clock == nil {
+		o.Clock = astitime.RealClock{}
+	}
+
+	// Create store
+	return &MemoryStore{
+		c:       o.Clock,
+		windows: make(map[string]*memoryWindow),
+	}
+}
+
+// Incr implements the Store interface
+func (s *MemoryStore) Incr(ctx context.Context, key string, window time.Duration) (count int64, ttl time.Duration, err error) {
+	// Lock
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	// Purge windows that have ended
+	var n = s.c.Now()
+	if !n.Before(s.purgeAt) {
+		for k, w := range s.windows {
+			if !n.Before(w.endAt) {
+				delete(s.windows, k)
+			}
+		}
+		s.purgeAt = n.Add(window)
+	}
+
+	// Get or create window
+	w, ok := s.windows[key]
+	if !ok || !n.Before(w.endAt) {
+		w = &memoryWindow{endAt: n.Add(window)}
+		s.windows[key] = w
+	}
+
+	// Increment
+	w.count++
+	count = w.count
+	ttl = w.endAt.Sub(n)
+	return
+}
+
+// StoreBucket represents a fixed-window bucket whose state lives in a store
+type StoreBucket struct {
+	c      astitime.Clock
+	cap    int
+	key    string
+	period time.Duration
+	s      Store
+}
+
+// NewStoreBucket creates a new store bucket
+func NewStoreBucket(s Store, key string, cap int, period time.Duration) *StoreBucket {
+	return newStoreBucket(s, key, cap, period, astitime.RealClock{})
+}
+
+// newStoreBucket creates a new store bucket with a clock
+func newStoreBucket(s Store, key string, cap int, period time.Duration, c astitime.Clock) *StoreBucket {
+	return &StoreBucket{
+		c:      c,
+		cap:    cap,
+		key:    key,
+		period: period,
+		s:      s,
+	}
+}
+
+// Allow increments the bucket count and checks whether it is still within the bucket capacity
+func (b *StoreBucket) Allow(ctx context.Context) (ok bool, err error) {
+	ok, _, err = b.allow(ctx)
+	return
+}
+
+// allow increments the bucket count and returns the time left before the next window if it is over capacity
+func (b *StoreBucket) allow(ctx context.Context) (ok bool, ttl time.Duration, err error) {
+	var count int64
+	if count, ttl, err = b.s.Incr(ctx, b.key, b.period); err != nil {
+		return
+	}
+	ok = count <= int64(b.cap)
+	return
+}
+
+// Inc increments the bucket count
+// Store errors are considered as the bucket being full.
+func (b *StoreBucket) Inc() bool {
+	ok, _ := b.Allow(context.Background())
+	return ok
+}
+
+// Wait blocks until the bucket count can be incremented
+func (b *StoreBucket) Wait(ctx context.Context) (err error) {
+	for {
+		var ok bool
+		var ttl time.Duration
+		if ok, ttl, err = b.allow(ctx); err != nil || ok {
+			return
+		}
+		if err = b.c.Sleep(ctx, waitDuration(ttl)); err != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package astilimiter_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/limiter"
+	"github.com/asticode/go-astitools/time"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoreBucket(t *testing.T) {
+	// Two buckets sharing the same store behave as a single bucket
+	var s = astilimiter.NewMemoryStore()
+	var l1, l2 = astilimiter.New(), astilimiter.New()
+	var b1 = l1.AddStoreBucket("test", s, 2, 50*time.Millisecond)
+	var b2 = l2.AddStoreBucket("test", s, 2, 50*time.Millisecond)
+	assert.True(t, b1.Inc())
+	assert.True(t, b2.Inc())
+	assert.False(t, b1.Inc())
+	assert.False(t, b2.Inc())
+
+	// Wait
+	assert.NoError(t, b1.Wait(context.Background()))
+	assert.True(t, b2.Inc())
+	assert.False(t, b2.Inc())
+}
+
+func TestRedisStore(t *testing.T) {
+	// Fake redis client running the script against an in-memory store
+	var m = astilimiter.NewMemoryStore()
+	var ks []string
+	var s = astilimiter.NewRedisStore(astilimiter.RedisClientFunc(func(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+		ks = append(ks, keys...)
+		c, ttl, err := m.Incr(ctx, keys[0], time.Duration(args[0].(int64))*time.Millisecond)
+		return []interface{}{c, ttl.Milliseconds()}, err
+	}), "prefix:")
+	c, ttl, err := s.Incr(context.Background(), "key", time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), c)
+	assert.True(t, ttl > 900*time.Millisecond)
+	c, _, err = s.Incr(context.Background(), "key", time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), c)
+	assert.Equal(t, []string{"prefix:key", "prefix:key"}, ks)
+
+	// Errors
+	s = astilimiter.NewRedisStore(astilimiter.RedisClientFunc(func(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+		return nil, errors.New("test")
+	}), "")
+	_, _, err = s.Incr(context.Background(), "key", time.Second)
+	assert.Error(t, err)
+	assert.False(t, astilimiter.NewStoreBucket(s, "key", 1, time.Second).Inc())
+	s = astilimiter.NewRedisStore(astilimiter.RedisClientFunc(func(ctx context.Context, script string, keys []string, args ...interface{}) (interface{}, error) {
+		return "invalid", nil
+	}), "")
+	_, _, err = s.Incr(context.Background(), "key", time.Second)
+	assert.Error(t, err)
+}
+
+func TestMemoryStore_Clock(t *testing.T) {
+	var c = astitime.NewFakeClock(time.Now())
+	var s = astilimiter.NewMemoryStoreWithOptions(astilimiter.MemoryStoreOptions{Clock: c})
+	n, ttl, err := s.Incr(context.Background(), "key", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	assert.Equal(t, time.Minute, ttl)
+	c.Add(time.Minute)
+	n, _, err = s.Incr(context.Background(), "key", time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+}
@@ -0,0 +1,88 @@
+package astilimiter
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/asticode/go-astitools/time"
+)
+
+// TokenBucket represents a token bucket
+// It holds up to cap tokens and is refilled continuously at a rate of cap tokens per period, which smooths bursts out
+// instead of allowing them at window boundaries.
+type TokenBucket struct {
+	c         astitime.Clock
+	cap       float64
+	m         sync.Mutex // Locks tokens and updatedAt
+	rate      float64    // Tokens per nanosecond
+	tokens    float64
+	updatedAt time.Time
+}
+
+// NewTokenBucket creates a new token bucket
+// It panics if cap or period is not positive since the refill rate would be either null or infinite.
+func NewTokenBucket(cap int, period time.Duration) *TokenBucket {
+	return newTokenBucket(cap, period, astitime.RealClock{})
+}
+
+// newTokenBucket creates a new token bucket with a clock
+func newTokenBucket(cap int, period time.Duration, c astitime.Clock) *TokenBucket {
+	if cap <= 0 {
+		panic(fmt.Sprintf("astilimiter: token bucket cap %d is not positive", cap))
+	}
+	if period <= 0 {
+		panic(fmt.Sprintf("astilimiter: token bucket period %s is not positive", period))
+	}
+	return &TokenBucket{
+		c:         c,
+		cap:       float64(cap),
+		rate:      float64(cap) / float64(period),
+		tokens:    float64(cap),
+		updatedAt: c.Now(),
+	}
+}
+
+// Inc takes a token from the bucket
+func (b *TokenBucket) Inc() bool {
+	_, ok := b.take()
+	return ok
+}
+
+// take takes a token from the bucket and returns the time left before the next token if it can't
+func (b *TokenBucket) take() (time.Duration, bool) {
+	// Lock
+	b.m.Lock()
+	defer b.m.Unlock()
+
+	// Refill
+	var n = b.c.Now()
+	b.tokens += float64(n.Sub(b.updatedAt)) * b.rate
+	if b.tokens > b.cap {
+		b.tokens = b.cap
+	}
+	b.updatedAt = n
+
+	// No token left
+	if b.tokens < 1 {
+		return time.Duration((1 - b.tokens) / b.rate), false
+	}
+
+	// Take token
+	b.tokens--
+	return 0, true
+}
+
+// Wait blocks until a token can be taken from the bucket
+func (b *TokenBucket) Wait(ctx context.Context) (err error) {
+	for {
+		d, ok := b.take()
+		if ok {
+			return
+		}
+		if err = b.c.Sleep(ctx, waitDuration(d)); err != nil {
+			return
+		}
+	}
+}
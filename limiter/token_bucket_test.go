@@ -0,0 +1,64 @@
+package astilimiter_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/limiter"
+	"github.com/asticode/go-astitools/time"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucket(t *testing.T) {
+	var l = astilimiter.New()
+	defer l.Close()
+	var b = l.AddTokenBucket("test", 2, 100*time.Millisecond)
+	assert.True(t, b.Inc())
+	assert.True(t, b.Inc())
+	assert.False(t, b.Inc())
+
+	// Wait
+	var n = time.Now()
+	assert.NoError(t, b.Wait(context.Background()))
+	assert.True(t, time.Since(n) >= 40*time.Millisecond)
+
+	// Cancel
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, b.Wait(ctx))
+
+	// Interface
+	r, ok := l.RateLimiter("test")
+	assert.True(t, ok)
+	assert.Equal(t, b, r)
+	_, ok = l.Bucket("test")
+	assert.False(t, ok)
+}
+
+func TestTokenBucket_Clock(t *testing.T) {
+	var c = astitime.NewFakeClock(time.Now())
+	var l = astilimiter.NewWithOptions(astilimiter.LimiterOptions{Clock: c})
+	defer l.Close()
+	var b = l.AddTokenBucket("test", 2, time.Minute)
+	assert.True(t, b.Inc())
+	assert.True(t, b.Inc())
+	assert.False(t, b.Inc())
+	c.Add(30 * time.Second)
+	assert.True(t, b.Inc())
+	assert.False(t, b.Inc())
+
+	// Wait
+	var done = make(chan error)
+	go func() { done <- b.Wait(context.Background()) }()
+	c.BlockUntil(1)
+	c.Add(30 * time.Second)
+	assert.NoError(t, <-done)
+}
+
+func TestTokenBucket_InvalidArgs(t *testing.T) {
+	assert.Panics(t, func() { astilimiter.NewTokenBucket(0, time.Second) })
+	assert.Panics(t, func() { astilimiter.NewTokenBucket(-1, time.Second) })
+	assert.Panics(t, func() { astilimiter.NewTokenBucket(1, 0) })
+	assert.Panics(t, func() { astilimiter.New().AddTokenBucket("test", 1, -time.Second) })
+}
@@ -0,0 +1,97 @@
+package astilogger
+
+import (
+	"log"
+
+	"github.com/asticode/go-astilog"
+	"github.com/sirupsen/logrus"
+)
+
+// astilogLogger represents a go-astilog adapter
+type astilogLogger struct {
+	l astilog.Logger
+}
+
+// NewAstilogLogger creates a go-astilog adapter, key-value pairs being appended to messages as " k=v"
+// If l is nil, messages are forwarded to the global go-astilog logger at the time they're logged.
+func NewAstilogLogger(l astilog.Logger) Logger {
+	return astilogLogger{l: l}
+}
+
+func (l astilogLogger) get() astilog.Logger {
+	if l.l != nil {
+		return l.l
+	}
+	return astilog.GetLogger()
+}
+
+func (l astilogLogger) Debug(msg string, kvs ...interface{}) { l.get().Debug(format(msg, kvs)) }
+func (l astilogLogger) Info(msg string, kvs ...interface{})  { l.get().Info(format(msg, kvs)) }
+func (l astilogLogger) Warn(msg string, kvs ...interface{})  { l.get().Warn(format(msg, kvs)) }
+func (l astilogLogger) Error(msg string, kvs ...interface{}) { l.get().Error(format(msg, kvs)) }
+
+// stdLogger represents a standard library logger adapter
+type stdLogger struct {
+	l   *log.Logger
+	min Level
+}
+
+// NewStdLogger creates a standard library logger adapter discarding messages below min
+// Messages are written as "LEVEL msg k1=v1 k2=v2". If l is nil, the standard logger is used.
+func NewStdLogger(l *log.Logger, min Level) Logger {
+	if l == nil {
+		l = log.Default()
+	}
+	return stdLogger{l: l, min: min}
+}
+
+func (l stdLogger) log(lv Level, msg string, kvs []interface{}) {
+	if lv < l.min {
+		return
+	}
+	l.l.Print(lv.String() + " " + format(msg, kvs))
+}
+
+func (l stdLogger) Debug(msg string, kvs ...interface{}) { l.log(LevelDebug, msg, kvs) }
+func (l stdLogger) Info(msg string, kvs ...interface{})  { l.log(LevelInfo, msg, kvs) }
+func (l stdLogger) Warn(msg string, kvs ...interface{})  { l.log(LevelWarn, msg, kvs) }
+func (l stdLogger) Error(msg string, kvs ...interface{}) { l.log(LevelError, msg, kvs) }
+
+// logrusLogger represents a logrus adapter
+type logrusLogger struct {
+	l logrus.FieldLogger
+}
+
+// NewLogrusLogger creates a logrus adapter, key-value pairs being converted to logrus fields
+func NewLogrusLogger(l logrus.FieldLogger) Logger {
+	return logrusLogger{l: l}
+}
+
+func (l logrusLogger) Debug(msg string, kvs ...interface{}) { l.l.WithFields(fields(kvs)).Debug(msg) }
+func (l logrusLogger) Info(msg string, kvs ...interface{})  { l.l.WithFields(fields(kvs)).Info(msg) }
+func (l logrusLogger) Warn(msg string, kvs ...interface{})  { l.l.WithFields(fields(kvs)).Warn(msg) }
+func (l logrusLogger) Error(msg string, kvs ...interface{}) { l.l.WithFields(fields(kvs)).Error(msg) }
+
+// ZapSugaredLogger represents the methods of zap's *SugaredLogger used by its adapter, which allows using zap
+// without depending on it
+type ZapSugaredLogger interface {
+	Debugw(msg string, keysAndValues ...interface{})
+	Infow(msg string, keysAndValues ...interface{})
+	Warnw(msg string, keysAndValues ...interface{})
+	Errorw(msg string, keysAndValues ...interface{})
+}
+
+// zapLogger represents a zap adapter
+type zapLogger struct {
+	l ZapSugaredLogger
+}
+
+// NewZapLogger creates a zap adapter, e.g. NewZapLogger(zapLogger.Sugar())
+func NewZapLogger(l ZapSugaredLogger) Logger {
+	return zapLogger{l: l}
+}
+
+func (l zapLogger) Debug(msg string, kvs ...interface{}) { l.l.Debugw(msg, kvs...) }
+func (l zapLogger) Info(msg string, kvs ...interface{})  { l.l.Infow(msg, kvs...) }
+func (l zapLogger) Warn(msg string, kvs ...interface{})  { l.l.Warnw(msg, kvs...) }
+func (l zapLogger) Error(msg string, kvs ...interface{}) { l.l.Errorw(msg, kvs...) }
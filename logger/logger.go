@@ -0,0 +1,194 @@
+package astilogger
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Keys
+const (
+	KeyError     = "error"
+	KeyJobID     = "job_id"
+	KeyRequestID = "request_id"
+	KeyTask      = "task"
+	KeyTaskID    = "task_id"
+)
+
+// Logger represents a leveled logger whose messages are followed by key-value pairs, e.g.
+// l.Info("astiworker: task stopped", "task", "my-task", "duration", d)
+// Keys must be strings. A missing value is replaced with "!MISSING".
+type Logger interface {
+	Debug(msg string, kvs ...interface{})
+	Info(msg string, kvs ...interface{})
+	Warn(msg string, kvs ...interface{})
+	Error(msg string, kvs ...interface{})
+}
+
+// Level represents a log level
+type Level int
+
+// Levels
+const (
+	LevelDebug Level = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+// String implements the fmt.Stringer interface
+func (l Level) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	}
+	return "Level(" + strconv.Itoa(int(l)) + ")"
+}
+
+var (
+	gb  Logger       = NewAstilogLogger(nil)
+	mgb sync.RWMutex // Locks gb
+)
+
+// SetDefault sets the logger returned by Default
+// It's used by packages whose options don't provide a logger, and defaults to the global go-astilog logger.
+func SetDefault(l Logger) {
+	mgb.Lock()
+	defer mgb.Unlock()
+	gb = l
+}
+
+// Default returns a logger forwarding to the logger set with SetDefault at the time messages are logged
+func Default() Logger {
+	return defaultLogger{}
+}
+
+type defaultLogger struct{}
+
+func (defaultLogger) get() Logger {
+	mgb.RLock()
+	defer mgb.RUnlock()
+	return gb
+}
+
+func (l defaultLogger) Debug(msg string, kvs ...interface{}) { l.get().Debug(msg, kvs...) }
+func (l defaultLogger) Info(msg string, kvs ...interface{})  { l.get().Info(msg, kvs...) }
+func (l defaultLogger) Warn(msg string, kvs ...interface{})  { l.get().Warn(msg, kvs...) }
+func (l defaultLogger) Error(msg string, kvs ...interface{}) { l.get().Error(msg, kvs...) }
+
+// NopLogger returns a logger discarding messages
+func NopLogger() Logger {
+	return nop{}
+}
+
+type nop struct{}
+
+func (nop) Debug(msg string, kvs ...interface{}) {}
+func (nop) Info(msg string, kvs ...interface{})  {}
+func (nop) Warn(msg string, kvs ...interface{})  {}
+func (nop) Error(msg string, kvs ...interface{}) {}
+
+// fieldsLogger represents a logger adding key-value pairs to every message
+type fieldsLogger struct {
+	kvs []interface{}
+	l   Logger
+}
+
+// With returns a logger adding key-value pairs before the ones of every message
+func With(l Logger, kvs ...interface{}) Logger {
+	// Nothing to add
+	if len(kvs) == 0 {
+		return l
+	}
+
+	// Merge fields so that loggers are not nested
+	if fl, ok := l.(fieldsLogger); ok {
+		return fieldsLogger{kvs: concat(fl.kvs, kvs), l: fl.l}
+	}
+	return fieldsLogger{kvs: concat(nil, kvs), l: l}
+}
+
+func (l fieldsLogger) Debug(msg string, kvs ...interface{}) { l.l.Debug(msg, concat(l.kvs, kvs)...) }
+func (l fieldsLogger) Info(msg string, kvs ...interface{})  { l.l.Info(msg, concat(l.kvs, kvs)...) }
+func (l fieldsLogger) Warn(msg string, kvs ...interface{})  { l.l.Warn(msg, concat(l.kvs, kvs)...) }
+func (l fieldsLogger) Error(msg string, kvs ...interface{}) { l.l.Error(msg, concat(l.kvs, kvs)...) }
+
+// concat concatenates key-value pairs without modifying a
+func concat(a, b []interface{}) []interface{} {
+	var o = make([]interface{}, 0, len(a)+len(b))
+	return append(append(o, a...), b...)
+}
+
+type ctxKeyFields struct{}
+
+// ContextWithFields returns a context holding key-value pairs added to the ones of its parent
+// They are added to messages logged by loggers created with WithContext, which is how IDs such as KeyTaskID or
+// KeyRequestID are correlated across packages.
+func ContextWithFields(ctx context.Context, kvs ...interface{}) context.Context {
+	if len(kvs) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, ctxKeyFields{}, concat(Fields(ctx), kvs))
+}
+
+// Fields returns the key-value pairs held by a context
+func Fields(ctx context.Context) []interface{} {
+	kvs, _ := ctx.Value(ctxKeyFields{}).([]interface{})
+	return kvs
+}
+
+// Field returns the last value held by a context for a key
+func Field(ctx context.Context, k string) (v interface{}, ok bool) {
+	var kvs = Fields(ctx)
+	for i := len(kvs) - 2; i >= 0; i -= 2 {
+		if kvs[i] == k {
+			return kvs[i+1], true
+		}
+	}
+	return
+}
+
+// WithContext returns a logger adding the key-value pairs held by a context to every message
+func WithContext(l Logger, ctx context.Context) Logger {
+	return With(l, Fields(ctx)...)
+}
+
+// format appends key-value pairs to a message as " k1=v1 k2=v2"
+// Values containing spaces, quotes or "=" are quoted.
+func format(msg string, kvs []interface{}) string {
+	var b strings.Builder
+	b.WriteString(msg)
+	for i := 0; i < len(kvs); i += 2 {
+		var v interface{} = "!MISSING"
+		if i+1 < len(kvs) {
+			v = kvs[i+1]
+		}
+		var s = fmt.Sprint(v)
+		if s == "" || strings.ContainsAny(s, " \t\n\"=") {
+			s = strconv.Quote(s)
+		}
+		b.WriteString(" " + fmt.Sprint(kvs[i]) + "=" + s)
+	}
+	return b.String()
+}
+
+// fields converts key-value pairs to a map
+func fields(kvs []interface{}) map[string]interface{} {
+	var m = make(map[string]interface{}, len(kvs)/2)
+	for i := 0; i < len(kvs); i += 2 {
+		var v interface{} = "!MISSING"
+		if i+1 < len(kvs) {
+			v = kvs[i+1]
+		}
+		m[fmt.Sprint(kvs[i])] = v
+	}
+	return m
+}
@@ -0,0 +1,99 @@
+package astilogger_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"testing"
+
+	"github.com/asticode/go-astilog"
+	"github.com/asticode/go-astitools/logger"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStdLogger(t *testing.T) {
+	var buf = &bytes.Buffer{}
+	l := astilogger.NewStdLogger(log.New(buf, "", 0), astilogger.LevelInfo)
+	l.Debug("debug")
+	l.Info("info", "k1", 1, "k2", "with space", "k3", errors.New("e=1"), "k4")
+	l.Error("error")
+	assert.Equal(t, "INFO info k1=1 k2=\"with space\" k3=\"e=1\" k4=!MISSING\nERROR error\n", buf.String())
+}
+
+func TestWith(t *testing.T) {
+	var buf = &bytes.Buffer{}
+	l := astilogger.NewStdLogger(log.New(buf, "", 0), astilogger.LevelDebug)
+	l1 := astilogger.With(l, "k1", "v1")
+	l2 := astilogger.With(l1, "k2", "v2")
+	l1.Warn("1", "k3", "v3")
+	l2.Warn("2")
+	assert.Equal(t, "WARN 1 k1=v1 k3=v3\nWARN 2 k1=v1 k2=v2\n", buf.String())
+}
+
+func TestContext(t *testing.T) {
+	// Fields
+	ctx := astilogger.ContextWithFields(context.Background(), astilogger.KeyTaskID, 1)
+	ctx = astilogger.ContextWithFields(ctx, astilogger.KeyRequestID, "r1", astilogger.KeyTaskID, 2)
+	assert.Equal(t, []interface{}{astilogger.KeyTaskID, 1, astilogger.KeyRequestID, "r1", astilogger.KeyTaskID, 2}, astilogger.Fields(ctx))
+	v, ok := astilogger.Field(ctx, astilogger.KeyTaskID)
+	assert.True(t, ok)
+	assert.Equal(t, 2, v)
+	_, ok = astilogger.Field(ctx, "invalid")
+	assert.False(t, ok)
+
+	// Logger
+	var buf = &bytes.Buffer{}
+	astilogger.WithContext(astilogger.NewStdLogger(log.New(buf, "", 0), astilogger.LevelDebug), ctx).Debug("msg")
+	assert.Equal(t, "DEBUG msg task_id=1 request_id=r1 task_id=2\n", buf.String())
+}
+
+func TestDefault(t *testing.T) {
+	var buf = &bytes.Buffer{}
+	l := astilogger.Default()
+	astilogger.SetDefault(astilogger.NewStdLogger(log.New(buf, "", 0), astilogger.LevelDebug))
+	defer astilogger.SetDefault(astilogger.NewAstilogLogger(nil))
+	l.Info("msg", "k", "v")
+	assert.Equal(t, "INFO msg k=v\n", buf.String())
+}
+
+type mockedAstilogLogger struct {
+	astilog.Logger
+	msgs []string
+}
+
+func (l *mockedAstilogLogger) Warn(v ...interface{}) { l.msgs = append(l.msgs, fmt.Sprint(v...)) }
+
+func TestAstilogLogger(t *testing.T) {
+	m := &mockedAstilogLogger{}
+	astilogger.NewAstilogLogger(m).Warn("msg", "k", "v")
+	assert.Equal(t, []string{"msg k=v"}, m.msgs)
+}
+
+func TestLogrusLogger(t *testing.T) {
+	var buf = &bytes.Buffer{}
+	l := logrus.New()
+	l.Out = buf
+	l.Formatter = &logrus.JSONFormatter{DisableTimestamp: true}
+	astilogger.NewLogrusLogger(l).Error("msg", "k", 1)
+	assert.Equal(t, "{\"k\":1,\"level\":\"error\",\"msg\":\"msg\"}\n", buf.String())
+}
+
+type mockedZapLogger struct {
+	calls []string
+}
+
+func (l *mockedZapLogger) Debugw(msg string, kvs ...interface{}) {}
+func (l *mockedZapLogger) Infow(msg string, kvs ...interface{}) {
+	l.calls = append(l.calls, fmt.Sprint(append([]interface{}{msg}, kvs...)...))
+}
+func (l *mockedZapLogger) Warnw(msg string, kvs ...interface{})  {}
+func (l *mockedZapLogger) Errorw(msg string, kvs ...interface{}) {}
+
+func TestZapLogger(t *testing.T) {
+	m := &mockedZapLogger{}
+	astilogger.NewZapLogger(m).Info("msg", "k", "v")
+	assert.Equal(t, []string{"msgkv"}, m.calls)
+}
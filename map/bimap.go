@@ -0,0 +1,297 @@
+package astimap
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"github.com/asticode/go-astitools/os"
+	"github.com/pkg/errors"
+)
+
+// BiMap represents a typed bi-directional map whose pairs are kept in insertion order
+// Contrary to Map, it's safe for concurrent use and can be (de)serialized and persisted.
+type BiMap[A, B comparable] struct {
+	atob  map[A]B
+	btoa  map[B]A
+	m     sync.Mutex // Locks atob, btoa and order
+	ms    sync.Mutex // Locks saves
+	o     BiMapOptions
+	order []A
+}
+
+// BiMapOptions represents bi-map options
+type BiMapOptions struct {
+	// If true, the map is saved every time it's modified. Path must be provided.
+	AutoSave bool
+	// Path of the file the map is loaded from and saved to. The format is deduced from its extension: .json or .toml
+	Path string
+}
+
+// BiMapPair represents a bi-map pair
+type BiMapPair[A, B comparable] struct {
+	A A `json:"a" toml:"a"`
+	B B `json:"b" toml:"b"`
+}
+
+// bimapTOML represents the TOML representation of a bi-map, whose root must be a table
+type bimapTOML[A, B comparable] struct {
+	Pairs []BiMapPair[A, B] `toml:"pairs"`
+}
+
+// NewBiMap creates a new bi-map
+func NewBiMap[A, B comparable]() *BiMap[A, B] {
+	return &BiMap[A, B]{
+		atob: make(map[A]B),
+		btoa: make(map[B]A),
+	}
+}
+
+// NewBiMapWithOptions creates a new bi-map with options and loads its file if it exists
+func NewBiMapWithOptions[A, B comparable](o BiMapOptions) (m *BiMap[A, B], err error) {
+	// Create map
+	m = NewBiMap[A, B]()
+	m.o = o
+
+	// No path
+	if o.Path == "" {
+		if o.AutoSave {
+			err = errors.New("astimap: auto save requires a path")
+		}
+		return
+	}
+
+	// Read file
+	var b []byte
+	if b, err = ioutil.ReadFile(o.Path); err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+			return
+		}
+		err = errors.Wrapf(err, "astimap: reading %s failed", o.Path)
+		return
+	}
+
+	// Decode
+	switch ext := strings.ToLower(filepath.Ext(o.Path)); ext {
+	case ".json":
+		err = m.UnmarshalJSON(b)
+	case ".toml":
+		err = m.DecodeTOML(bytes.NewReader(b))
+	default:
+		err = errors.Errorf("astimap: unknown extension %s", ext)
+	}
+	if err != nil {
+		err = errors.Wrapf(err, "astimap: decoding %s failed", o.Path)
+		return
+	}
+	return
+}
+
+// A retrieves a based on b
+func (m *BiMap[A, B]) A(b B) (a A, ok bool) {
+	m.m.Lock()
+	defer m.m.Unlock()
+	a, ok = m.btoa[b]
+	return
+}
+
+// B retrieves b based on a
+func (m *BiMap[A, B]) B(a A) (b B, ok bool) {
+	m.m.Lock()
+	defer m.m.Unlock()
+	b, ok = m.atob[a]
+	return
+}
+
+// Len returns the number of pairs
+func (m *BiMap[A, B]) Len() int {
+	m.m.Lock()
+	defer m.m.Unlock()
+	return len(m.order)
+}
+
+// Set sets a pair
+// Pairs previously containing either a or b are removed so that the map remains bi-directional.
+func (m *BiMap[A, B]) Set(a A, b B) error {
+	// Lock
+	m.m.Lock()
+
+	// Remove previous pairs
+	m.deleteA(a)
+	if pa, ok := m.btoa[b]; ok {
+		m.deleteA(pa)
+	}
+
+	// Set
+	m.atob[a] = b
+	m.btoa[b] = a
+	m.order = append(m.order, a)
+	m.m.Unlock()
+	return m.autoSave()
+}
+
+// DeleteA deletes the pair containing a
+func (m *BiMap[A, B]) DeleteA(a A) error {
+	m.m.Lock()
+	m.deleteA(a)
+	m.m.Unlock()
+	return m.autoSave()
+}
+
+// DeleteB deletes the pair containing b
+func (m *BiMap[A, B]) DeleteB(b B) error {
+	m.m.Lock()
+	if a, ok := m.btoa[b]; ok {
+		m.deleteA(a)
+	}
+	m.m.Unlock()
+	return m.autoSave()
+}
+
+// deleteA deletes the pair containing a, assuming the map is locked
+func (m *BiMap[A, B]) deleteA(a A) {
+	// Pair doesn't exist
+	b, ok := m.atob[a]
+	if !ok {
+		return
+	}
+
+	// Delete
+	delete(m.atob, a)
+	delete(m.btoa, b)
+	for i, v := range m.order {
+		if v == a {
+			m.order = append(m.order[:i], m.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// Pairs returns the pairs in insertion order
+func (m *BiMap[A, B]) Pairs() (ps []BiMapPair[A, B]) {
+	m.m.Lock()
+	defer m.m.Unlock()
+	ps = make([]BiMapPair[A, B], 0, len(m.order))
+	for _, a := range m.order {
+		ps = append(ps, BiMapPair[A, B]{A: a, B: m.atob[a]})
+	}
+	return
+}
+
+// Range executes fn on every pair in insertion order until it returns false
+// fn is executed on a snapshot of the pairs and can therefore modify the map.
+func (m *BiMap[A, B]) Range(fn func(a A, b B) bool) {
+	for _, p := range m.Pairs() {
+		if !fn(p.A, p.B) {
+			return
+		}
+	}
+}
+
+// setPairs replaces the pairs
+func (m *BiMap[A, B]) setPairs(ps []BiMapPair[A, B]) {
+	m.m.Lock()
+	defer m.m.Unlock()
+	m.atob = make(map[A]B)
+	m.btoa = make(map[B]A)
+	m.order = nil
+	for _, p := range ps {
+		m.deleteA(p.A)
+		if pa, ok := m.btoa[p.B]; ok {
+			m.deleteA(pa)
+		}
+		m.atob[p.A] = p.B
+		m.btoa[p.B] = p.A
+		m.order = append(m.order, p.A)
+	}
+}
+
+// MarshalJSON implements the json.Marshaler interface
+// Pairs are marshaled as an array, in insertion order.
+func (m *BiMap[A, B]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.Pairs())
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface
+func (m *BiMap[A, B]) UnmarshalJSON(b []byte) (err error) {
+	var ps []BiMapPair[A, B]
+	if err = json.Unmarshal(b, &ps); err != nil {
+		return
+	}
+	m.setPairs(ps)
+	return
+}
+
+// EncodeTOML encodes pairs as a TOML array of tables named "pairs", in insertion order
+func (m *BiMap[A, B]) EncodeTOML(w io.Writer) (err error) {
+	if err = toml.NewEncoder(w).Encode(bimapTOML[A, B]{Pairs: m.Pairs()}); err != nil {
+		err = errors.Wrap(err, "astimap: toml encoding failed")
+		return
+	}
+	return
+}
+
+// DecodeTOML decodes pairs encoded by EncodeTOML
+func (m *BiMap[A, B]) DecodeTOML(r io.Reader) (err error) {
+	var v bimapTOML[A, B]
+	if _, err = toml.NewDecoder(r).Decode(&v); err != nil {
+		err = errors.Wrap(err, "astimap: toml decoding failed")
+		return
+	}
+	m.setPairs(v.Pairs)
+	return
+}
+
+// autoSave saves the map if auto save is enabled
+func (m *BiMap[A, B]) autoSave() error {
+	if !m.o.AutoSave {
+		return nil
+	}
+	return m.Save()
+}
+
+// Save writes the map to its file atomically
+func (m *BiMap[A, B]) Save() (err error) {
+	// No path
+	if m.o.Path == "" {
+		err = errors.New("astimap: no path provided")
+		return
+	}
+
+	// Lock so that a save can't overwrite a more recent one
+	m.ms.Lock()
+	defer m.ms.Unlock()
+
+	// Encode
+	var buf = &bytes.Buffer{}
+	switch ext := strings.ToLower(filepath.Ext(m.o.Path)); ext {
+	case ".json":
+		var b []byte
+		if b, err = json.MarshalIndent(m.Pairs(), "", "  "); err != nil {
+			err = errors.Wrap(err, "astimap: marshaling failed")
+			return
+		}
+		buf.Write(b)
+	case ".toml":
+		err = m.EncodeTOML(buf)
+	default:
+		err = errors.Errorf("astimap: unknown extension %s", ext)
+	}
+	if err != nil {
+		return
+	}
+
+	// Write
+	if err = astios.WriteFileAtomic(m.o.Path, buf.Bytes(), 0644); err != nil {
+		err = errors.Wrapf(err, "astimap: writing %s failed", m.o.Path)
+		return
+	}
+	return
+}
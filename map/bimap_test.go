@@ -0,0 +1,78 @@
+package astimap_test
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/asticode/go-astitools/map"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBiMap(t *testing.T) {
+	// Set
+	m := astimap.NewBiMap[int, string]()
+	assert.NoError(t, m.Set(1, "one"))
+	assert.NoError(t, m.Set(2, "two"))
+	assert.NoError(t, m.Set(3, "three"))
+	b, ok := m.B(2)
+	assert.True(t, ok)
+	assert.Equal(t, "two", b)
+	a, ok := m.A("three")
+	assert.True(t, ok)
+	assert.Equal(t, 3, a)
+
+	// Bi-directionality is preserved
+	assert.NoError(t, m.Set(4, "two"))
+	_, ok = m.B(2)
+	assert.False(t, ok)
+	a, _ = m.A("two")
+	assert.Equal(t, 4, a)
+	assert.Equal(t, 3, m.Len())
+
+	// Range
+	var as []int
+	m.Range(func(a int, b string) bool {
+		as = append(as, a)
+		return true
+	})
+	assert.Equal(t, []int{1, 3, 4}, as)
+
+	// Delete
+	assert.NoError(t, m.DeleteB("one"))
+	assert.NoError(t, m.DeleteA(3))
+	assert.Equal(t, []astimap.BiMapPair[int, string]{{A: 4, B: "two"}}, m.Pairs())
+
+	// JSON
+	bs, err := json.Marshal(m)
+	assert.NoError(t, err)
+	assert.Equal(t, `[{"a":4,"b":"two"}]`, string(bs))
+	m2 := astimap.NewBiMap[int, string]()
+	assert.NoError(t, json.Unmarshal(bs, m2))
+	assert.Equal(t, m.Pairs(), m2.Pairs())
+}
+
+func TestBiMapPersistence(t *testing.T) {
+	for _, ext := range []string{".json", ".toml"} {
+		// Auto save
+		p := filepath.Join(t.TempDir(), "map"+ext)
+		m, err := astimap.NewBiMapWithOptions[string, int](astimap.BiMapOptions{AutoSave: true, Path: p})
+		assert.NoError(t, err)
+		assert.Equal(t, 0, m.Len())
+		assert.NoError(t, m.Set("b", 2))
+		assert.NoError(t, m.Set("a", 1))
+
+		// Load
+		m, err = astimap.NewBiMapWithOptions[string, int](astimap.BiMapOptions{Path: p})
+		assert.NoError(t, err)
+		assert.Equal(t, []astimap.BiMapPair[string, int]{{A: "b", B: 2}, {A: "a", B: 1}}, m.Pairs())
+	}
+
+	// Invalid options
+	_, err := astimap.NewBiMapWithOptions[string, int](astimap.BiMapOptions{AutoSave: true})
+	assert.Error(t, err)
+	_, err = astimap.NewBiMapWithOptions[string, int](astimap.BiMapOptions{Path: filepath.Join(t.TempDir(), "map.yaml")})
+	assert.NoError(t, err)
+	m := astimap.NewBiMap[string, int]()
+	assert.Error(t, m.Save())
+}
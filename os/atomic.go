@@ -0,0 +1,68 @@
+package astios
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// WriteFileAtomic writes data to a file atomically: either the file has its previous content or it has the new one,
+// even if the process crashes while writing
+// Data is written to a temp file in the same directory, synced to the disk and renamed.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) (err error) {
+	// Create temp file
+	var f *os.File
+	if f, err = ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp"); err != nil {
+		return errors.Wrapf(err, "astios: creating temp file for %s failed", path)
+	}
+	var tmp = f.Name()
+
+	// Remove temp file if something goes wrong
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(tmp)
+		}
+	}()
+
+	// Write
+	if _, err = f.Write(data); err != nil {
+		return errors.Wrapf(err, "astios: writing to %s failed", tmp)
+	}
+
+	// Set permissions
+	if err = f.Chmod(perm); err != nil {
+		return errors.Wrapf(err, "astios: chmoding %s failed", tmp)
+	}
+
+	// Sync
+	if err = f.Sync(); err != nil {
+		return errors.Wrapf(err, "astios: syncing %s failed", tmp)
+	}
+
+	// Close
+	if err = f.Close(); err != nil {
+		return errors.Wrapf(err, "astios: closing %s failed", tmp)
+	}
+
+	// Replace
+	if err = ReplaceFile(tmp, path); err != nil {
+		return
+	}
+	return
+}
+
+// ReplaceFile replaces dst with src atomically
+// src and dst must be on the same partition. On Windows, it fails if dst is opened by another process.
+func ReplaceFile(src, dst string) (err error) {
+	// Rename
+	if err = replaceFile(src, dst); err != nil {
+		return errors.Wrapf(err, "astios: renaming %s into %s failed", src, dst)
+	}
+
+	// Sync dir so that the rename is persisted
+	syncDir(filepath.Dir(dst))
+	return
+}
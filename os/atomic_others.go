@@ -0,0 +1,19 @@
+//go:build !windows
+// +build !windows
+
+package astios
+
+import "os"
+
+// replaceFile renames src into dst, replacing it if it exists
+func replaceFile(src, dst string) error {
+	return os.Rename(src, dst)
+}
+
+// syncDir syncs a directory, which is best effort
+func syncDir(path string) {
+	if d, err := os.Open(path); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
@@ -0,0 +1,41 @@
+package astios_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/asticode/go-astitools/os"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	// Init
+	dir, err := ioutil.TempDir("", "astios")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	p := filepath.Join(dir, "f")
+
+	// Create
+	err = astios.WriteFileAtomic(p, []byte("content1"), 0600)
+	assert.NoError(t, err)
+	b, err := ioutil.ReadFile(p)
+	assert.NoError(t, err)
+	assert.Equal(t, "content1", string(b))
+	fi, err := os.Stat(p)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+
+	// Replace
+	err = astios.WriteFileAtomic(p, []byte("content2"), 0644)
+	assert.NoError(t, err)
+	b, err = ioutil.ReadFile(p)
+	assert.NoError(t, err)
+	assert.Equal(t, "content2", string(b))
+
+	// No temp file left
+	fs, err := ioutil.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, fs, 1)
+}
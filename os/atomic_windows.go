@@ -0,0 +1,37 @@
+//go:build windows
+// +build windows
+
+package astios
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// Windows file moving
+const (
+	movefileReplaceExisting = 0x1
+	movefileWriteThrough    = 0x8
+)
+
+var procMoveFileExW = kernel32.NewProc("MoveFileExW")
+
+// replaceFile renames src into dst, replacing it atomically if it exists
+// MOVEFILE_WRITE_THROUGH makes sure the rename is persisted before returning.
+func replaceFile(src, dst string) (err error) {
+	var s, d *uint16
+	if s, err = syscall.UTF16PtrFromString(src); err != nil {
+		return
+	}
+	if d, err = syscall.UTF16PtrFromString(dst); err != nil {
+		return
+	}
+	var flags uintptr = movefileReplaceExisting | movefileWriteThrough
+	if r, _, e := procMoveFileExW.Call(uintptr(unsafe.Pointer(s)), uintptr(unsafe.Pointer(d)), flags); r == 0 {
+		err = e
+	}
+	return
+}
+
+// syncDir syncs a directory, which is not supported on Windows
+func syncDir(path string) {}
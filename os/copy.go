@@ -2,11 +2,13 @@ package astios
 
 import (
 	"context"
+	"io/ioutil"
 	"os"
-
+	"path"
 	"path/filepath"
 
 	"github.com/asticode/go-astitools/io"
+	"github.com/pkg/errors"
 )
 
 // Copy is a cross partitions cancellable copy
@@ -54,3 +56,203 @@ func Copy(ctx context.Context, src, dst string) (err error) {
 	_, err = astiio.Copy(ctx, srcFile, dstFile)
 	return
 }
+
+// Symlink policies
+const (
+	// Symlinks are recreated in the destination
+	SymlinkPolicyCopy SymlinkPolicy = "copy"
+	// Symlinks targets are copied in place of the symlinks
+	SymlinkPolicyFollow SymlinkPolicy = "follow"
+	// Symlinks are ignored
+	SymlinkPolicySkip SymlinkPolicy = "skip"
+)
+
+// SymlinkPolicy represents the way symlinks are handled when copying a directory
+type SymlinkPolicy string
+
+// CopyDirOptions represents copy dir options
+type CopyDirOptions struct {
+	// Glob patterns (see path.Match) of slash separated paths, relative to src, that must not be copied. A pattern
+	// matching a directory matches its content as well
+	Exclude []string
+	// Glob patterns (see path.Match) of slash separated paths, relative to src, that must be copied. If empty, all
+	// paths are copied. A pattern matching a directory matches its content as well
+	Include []string
+	// Whether files and directories permissions are preserved
+	PreservePermissions bool
+	// Progress is called every time a file has been copied
+	Progress func(p CopyDirProgress)
+	// Defaults to SymlinkPolicyCopy
+	SymlinkPolicy SymlinkPolicy
+}
+
+// CopyDirProgress represents a copy dir progress
+type CopyDirProgress struct {
+	// Number of bytes copied so far
+	Bytes int64
+	// Number of files copied so far
+	Files int
+	// Path of the last copied file, relative to src
+	Path string
+}
+
+// CopyDir is a cross partitions cancellable recursive copy of a directory
+func CopyDir(ctx context.Context, src, dst string, o CopyDirOptions) (err error) {
+	// Default options values
+	if o.SymlinkPolicy == "" {
+		o.SymlinkPolicy = SymlinkPolicyCopy
+	}
+
+	// Copy
+	var p CopyDirProgress
+	return copyDir(ctx, src, dst, "", o, &p, map[string]bool{}, nil)
+}
+
+// copyDir copies the content of src in dst, rel being the path of src relative to the root of the copy and
+// mkdirParent creating the parent of dst if it hasn't been created yet
+func copyDir(ctx context.Context, src, dst, rel string, o CopyDirOptions, p *CopyDirProgress, visited map[string]bool,
+	mkdirParent func() error) (err error) {
+	// Make sure we don't loop in symlinks
+	var abs string
+	if abs, err = filepath.EvalSymlinks(src); err != nil {
+		return errors.Wrapf(err, "astios: evaluating symlinks of %s failed", src)
+	}
+	if visited[abs] {
+		return
+	}
+	visited[abs] = true
+	defer delete(visited, abs)
+
+	// Stat src
+	var fi os.FileInfo
+	if fi, err = os.Stat(src); err != nil {
+		return errors.Wrapf(err, "astios: stating %s failed", src)
+	}
+
+	// Create dst
+	// When there are include patterns that dst doesn't match, it's only created once its first entry is copied so
+	// that directories without matching content are not created
+	var created bool
+	var mkdir = func() (err error) {
+		if created {
+			return
+		}
+		if mkdirParent != nil {
+			if err = mkdirParent(); err != nil {
+				return
+			}
+		}
+		if err = os.MkdirAll(dst, 0755); err != nil {
+			return errors.Wrapf(err, "astios: mkdirall %s failed", dst)
+		}
+		created = true
+		return
+	}
+	if mkdirParent == nil || len(o.Include) == 0 || MatchPatterns(o.Include, rel) {
+		if err = mkdir(); err != nil {
+			return
+		}
+	}
+
+	// Read dir
+	var fis []os.FileInfo
+	if fis, err = ioutil.ReadDir(src); err != nil {
+		return errors.Wrapf(err, "astios: reading dir %s failed", src)
+	}
+
+	// Loop through entries
+	for _, fi := range fis {
+		// Check context
+		if err = ctx.Err(); err != nil {
+			return
+		}
+
+		// Follow symlinks so that they're filtered according to their target
+		var r = path.Join(rel, fi.Name())
+		var s, d = filepath.Join(src, fi.Name()), filepath.Join(dst, fi.Name())
+		if fi.Mode()&os.ModeSymlink != 0 && o.SymlinkPolicy == SymlinkPolicyFollow && !MatchPatterns(o.Exclude, r) {
+			if fi, err = os.Stat(s); err != nil {
+				return errors.Wrapf(err, "astios: stating %s failed", s)
+			}
+		}
+
+		// Filter
+		if !o.match(r, fi.IsDir()) {
+			continue
+		}
+
+		// Handle symlinks that are not followed
+		if fi.Mode()&os.ModeSymlink != 0 {
+			switch o.SymlinkPolicy {
+			case SymlinkPolicySkip:
+				continue
+			default:
+				var l string
+				if l, err = os.Readlink(s); err != nil {
+					return errors.Wrapf(err, "astios: reading link %s failed", s)
+				}
+				if err = mkdir(); err != nil {
+					return
+				}
+				if err = os.Symlink(l, d); err != nil {
+					return errors.Wrapf(err, "astios: creating symlink from %s to %s failed", l, d)
+				}
+				continue
+			}
+		}
+
+		// Dir
+		if fi.IsDir() {
+			if err = copyDir(ctx, s, d, r, o, p, visited, mkdir); err != nil {
+				return
+			}
+			continue
+		}
+
+		// Only regular files are copied
+		if !fi.Mode().IsRegular() {
+			continue
+		}
+
+		// Copy file
+		if err = mkdir(); err != nil {
+			return
+		}
+		if err = Copy(ctx, s, d); err != nil {
+			return errors.Wrapf(err, "astios: copying %s to %s failed", s, d)
+		}
+
+		// Preserve permissions
+		if o.PreservePermissions {
+			if err = os.Chmod(d, fi.Mode().Perm()); err != nil {
+				return errors.Wrapf(err, "astios: chmoding %s failed", d)
+			}
+		}
+
+		// Progress
+		p.Bytes += fi.Size()
+		p.Files++
+		p.Path = r
+		if o.Progress != nil {
+			o.Progress(*p)
+		}
+	}
+
+	// Preserve permissions once the content has been copied so that read-only dirs can be populated
+	if o.PreservePermissions && created {
+		if err = os.Chmod(dst, fi.Mode().Perm()); err != nil {
+			return errors.Wrapf(err, "astios: chmoding %s failed", dst)
+		}
+	}
+	return
+}
+
+// match checks whether a path relative to the root of the copy matches the include and exclude patterns
+// Directories are always traversed when there are include patterns since their content may match, but they're
+// only created if it does
+func (o CopyDirOptions) match(rel string, isDir bool) bool {
+	if MatchPatterns(o.Exclude, rel) {
+		return false
+	}
+	return len(o.Include) == 0 || isDir || MatchPatterns(o.Include, rel)
+}
@@ -0,0 +1,109 @@
+package astios_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/asticode/go-astitools/os"
+	"github.com/stretchr/testify/assert"
+)
+
+func listDir(dir string) (o []string) {
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && path != dir {
+			o = append(o, filepath.ToSlash(strings.TrimPrefix(path, dir+string(os.PathSeparator))))
+		}
+		return nil
+	})
+	sort.Strings(o)
+	return
+}
+
+func TestCopyDir(t *testing.T) {
+	// Init
+	dir, err := ioutil.TempDir("", "astios")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "src")
+	os.MkdirAll(filepath.Join(src, "d1", "d2"), 0755)
+	os.MkdirAll(filepath.Join(src, "d3"), 0755)
+	ioutil.WriteFile(filepath.Join(src, "f1.txt"), []byte("f1"), 0600)
+	ioutil.WriteFile(filepath.Join(src, "f2.log"), []byte("f2"), 0644)
+	ioutil.WriteFile(filepath.Join(src, "d1", "d2", "f3.txt"), []byte("f3"), 0644)
+	ioutil.WriteFile(filepath.Join(src, "d3", "f4.txt"), []byte("f4"), 0644)
+	os.Symlink("f1.txt", filepath.Join(src, "l1"))
+	os.Symlink("d1", filepath.Join(src, "l2"))
+
+	// Copy symlinks and preserve permissions
+	var ps []astios.CopyDirProgress
+	dst := filepath.Join(dir, "dst1")
+	err = astios.CopyDir(context.Background(), src, dst, astios.CopyDirOptions{
+		Exclude:             []string{"d3"},
+		PreservePermissions: true,
+		Progress:            func(p astios.CopyDirProgress) { ps = append(ps, p) },
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"d1", "d1/d2", "d1/d2/f3.txt", "f1.txt", "f2.log", "l1", "l2"}, listDir(dst))
+	l, err := os.Readlink(filepath.Join(dst, "l1"))
+	assert.NoError(t, err)
+	assert.Equal(t, "f1.txt", l)
+	fi, err := os.Stat(filepath.Join(dst, "f1.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), fi.Mode().Perm())
+	assert.Len(t, ps, 3)
+	assert.Equal(t, astios.CopyDirProgress{Bytes: 6, Files: 3, Path: "f2.log"}, ps[2])
+
+	// Follow symlinks and include
+	dst = filepath.Join(dir, "dst2")
+	err = astios.CopyDir(context.Background(), src, dst, astios.CopyDirOptions{
+		Include:       []string{"*.txt", "d1", "l*"},
+		SymlinkPolicy: astios.SymlinkPolicyFollow,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"d1", "d1/d2", "d1/d2/f3.txt", "f1.txt", "l1", "l2", "l2/d2", "l2/d2/f3.txt"}, listDir(dst))
+	fi, err = os.Lstat(filepath.Join(dst, "l1"))
+	assert.NoError(t, err)
+	assert.True(t, fi.Mode().IsRegular())
+
+	// Followed symlinks are filtered according to their target
+	dst = filepath.Join(dir, "dst6")
+	err = astios.CopyDir(context.Background(), src, dst, astios.CopyDirOptions{
+		Include:       []string{"*.txt", "*/d2/*.txt"},
+		SymlinkPolicy: astios.SymlinkPolicyFollow,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"d1", "d1/d2", "d1/d2/f3.txt", "f1.txt", "l2", "l2/d2", "l2/d2/f3.txt"}, listDir(dst))
+
+	// Skip symlinks
+	dst = filepath.Join(dir, "dst3")
+	err = astios.CopyDir(context.Background(), src, dst, astios.CopyDirOptions{
+		Exclude:       []string{"d*"},
+		SymlinkPolicy: astios.SymlinkPolicySkip,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"f1.txt", "f2.log"}, listDir(dst))
+
+	// Read-only dir
+	os.Chmod(filepath.Join(src, "d3"), 0555)
+	defer os.Chmod(filepath.Join(src, "d3"), 0755)
+	dst = filepath.Join(dir, "dst4")
+	err = astios.CopyDir(context.Background(), src, dst, astios.CopyDirOptions{PreservePermissions: true})
+	assert.NoError(t, err)
+	defer os.Chmod(filepath.Join(dst, "d3"), 0755)
+	fi, err = os.Stat(filepath.Join(dst, "d3"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0555), fi.Mode().Perm())
+	_, err = os.Stat(filepath.Join(dst, "d3", "f4.txt"))
+	assert.NoError(t, err)
+
+	// Directories without included content are not created
+	dst = filepath.Join(dir, "dst5")
+	err = astios.CopyDir(context.Background(), src, dst, astios.CopyDirOptions{Include: []string{"*.log", "d1/d2/*.txt"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"d1", "d1/d2", "d1/d2/f3.txt", "f2.log"}, listDir(dst))
+}
@@ -0,0 +1,104 @@
+package astios
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/asticode/go-astitools/time"
+	"github.com/pkg/errors"
+)
+
+// FileLockRetryPeriod represents the period at which Lock tries to acquire the lock
+var FileLockRetryPeriod = 50 * time.Millisecond
+
+// FileLock represents an exclusive advisory lock on a file shared between processes
+// It relies on flock on Unix and on LockFileEx on Windows. The lock file is created if it doesn't exist and is never
+// removed.
+type FileLock struct {
+	f    *os.File
+	m    sync.Mutex // Locks f
+	path string
+}
+
+// NewFileLock creates a new file lock
+func NewFileLock(path string) *FileLock {
+	return &FileLock{path: path}
+}
+
+// Path returns the path of the lock file
+func (l *FileLock) Path() string {
+	return l.path
+}
+
+// TryLock tries to acquire the lock without blocking and returns whether it has been acquired
+func (l *FileLock) TryLock() (ok bool, err error) {
+	// Lock
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	// Lock is already held
+	if l.f != nil {
+		return false, nil
+	}
+
+	// Open file
+	var f *os.File
+	if f, err = os.OpenFile(l.path, os.O_RDWR|os.O_CREATE, 0644); err != nil {
+		err = errors.Wrapf(err, "astios: opening %s failed", l.path)
+		return
+	}
+
+	// Lock file
+	if ok, err = lockFile(f); err != nil || !ok {
+		f.Close()
+		if err != nil {
+			err = errors.Wrapf(err, "astios: locking %s failed", l.path)
+		}
+		return
+	}
+	l.f = f
+	return
+}
+
+// Lock acquires the lock, blocking until it's available or the context is cancelled
+func (l *FileLock) Lock(ctx context.Context) (err error) {
+	for {
+		// Try to lock
+		var ok bool
+		if ok, err = l.TryLock(); err != nil || ok {
+			return
+		}
+
+		// Sleep
+		if err = astitime.Sleep(ctx, FileLockRetryPeriod); err != nil {
+			return
+		}
+	}
+}
+
+// Unlock releases the lock
+func (l *FileLock) Unlock() (err error) {
+	// Lock
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	// Lock is not held
+	if l.f == nil {
+		return errors.Errorf("astios: %s is not locked", l.path)
+	}
+
+	// Unlock file
+	defer func() { l.f = nil }()
+	if err = unlockFile(l.f); err != nil {
+		l.f.Close()
+		return errors.Wrapf(err, "astios: unlocking %s failed", l.path)
+	}
+
+	// Close file
+	if err = l.f.Close(); err != nil {
+		return errors.Wrapf(err, "astios: closing %s failed", l.path)
+	}
+	return
+}
@@ -0,0 +1,22 @@
+//go:build !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !windows
+// +build !darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!windows
+
+package astios
+
+import (
+	"errors"
+	"os"
+)
+
+// errFileLockNotSupported is returned when file locking is not supported on the current platform
+var errFileLockNotSupported = errors.New("file locking is not supported on this platform")
+
+// lockFile tries to acquire an exclusive lock on a file without blocking
+func lockFile(f *os.File) (bool, error) {
+	return false, errFileLockNotSupported
+}
+
+// unlockFile releases the lock on a file
+func unlockFile(f *os.File) error {
+	return errFileLockNotSupported
+}
@@ -0,0 +1,48 @@
+package astios_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/os"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileLock(t *testing.T) {
+	// Init
+	dir, err := ioutil.TempDir("", "astios")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	p := filepath.Join(dir, "lock")
+	l1 := astios.NewFileLock(p)
+	l2 := astios.NewFileLock(p)
+
+	// Try lock
+	ok, err := l1.TryLock()
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = l1.TryLock()
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	ok, err = l2.TryLock()
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	// Lock is cancelled
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, l2.Lock(ctx))
+
+	// Lock is acquired once released
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		l1.Unlock()
+	}()
+	assert.NoError(t, l2.Lock(context.Background()))
+	assert.NoError(t, l2.Unlock())
+	assert.Error(t, l2.Unlock())
+}
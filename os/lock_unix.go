@@ -0,0 +1,25 @@
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package astios
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile tries to acquire an exclusive lock on a file without blocking
+func lockFile(f *os.File) (ok bool, err error) {
+	if err = syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		if err == syscall.EWOULDBLOCK {
+			err = nil
+		}
+		return
+	}
+	return true, nil
+}
+
+// unlockFile releases the lock on a file
+func unlockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+}
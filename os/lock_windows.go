@@ -0,0 +1,44 @@
+//go:build windows
+// +build windows
+
+package astios
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// Windows file locking
+const (
+	errorLockViolation      syscall.Errno = 33
+	lockfileExclusiveLock                 = 0x2
+	lockfileFailImmediately               = 0x1
+)
+
+var (
+	kernel32         = syscall.NewLazyDLL("kernel32.dll")
+	procLockFileEx   = kernel32.NewProc("LockFileEx")
+	procUnlockFileEx = kernel32.NewProc("UnlockFileEx")
+)
+
+// lockFile tries to acquire an exclusive lock on a file without blocking
+func lockFile(f *os.File) (ok bool, err error) {
+	var o syscall.Overlapped
+	if r, _, e := procLockFileEx.Call(f.Fd(), lockfileExclusiveLock|lockfileFailImmediately, 0, 1, 0, uintptr(unsafe.Pointer(&o))); r == 0 {
+		if e != errorLockViolation {
+			err = e
+		}
+		return
+	}
+	return true, nil
+}
+
+// unlockFile releases the lock on a file
+func unlockFile(f *os.File) (err error) {
+	var o syscall.Overlapped
+	if r, _, e := procUnlockFileEx.Call(f.Fd(), 0, 1, 0, uintptr(unsafe.Pointer(&o))); r == 0 {
+		err = e
+	}
+	return
+}
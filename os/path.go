@@ -0,0 +1,69 @@
+package astios
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// MatchPatterns checks whether a slash-separated path or one of its parent directories matches one of the glob
+// patterns (see path.Match). Trailing slashes are ignored.
+func MatchPatterns(patterns []string, p string) bool {
+	for p = strings.TrimSuffix(p, "/"); p != "." && p != "/" && p != ""; p = path.Dir(p) {
+		for _, pattern := range patterns {
+			if ok, _ := path.Match(strings.TrimSuffix(pattern, "/"), p); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CheckSymlink makes sure the target of a symlink located at p doesn't escape dst
+// It's used when extracting archives, together with CheckParents.
+func CheckSymlink(dst, p, linkname string) (err error) {
+	var t = linkname
+	if !filepath.IsAbs(t) {
+		t = filepath.Join(filepath.Dir(p), t)
+	}
+	if t = filepath.Clean(t); t != filepath.Clean(dst) && !strings.HasPrefix(t, filepath.Clean(dst)+string(os.PathSeparator)) {
+		err = errors.Errorf("astios: symlink %s targets %s which escapes destination %s", p, linkname, dst)
+	}
+	return
+}
+
+// CheckParents makes sure none of the existing parents of p located in dst is a symlink, so that nothing can be
+// written through a symlink
+// Since CheckSymlink checks each symlink on its own, it prevents chained symlinks (e.g. s1 -> . and s1/s2 -> ..)
+// from escaping dst when they're created in order.
+func CheckParents(dst, p string) (err error) {
+	// Get relative path
+	var rel string
+	if rel, err = filepath.Rel(dst, filepath.Dir(p)); err != nil {
+		return errors.Wrapf(err, "astios: getting relative path of %s failed", p)
+	}
+	if rel == "." {
+		return
+	}
+
+	// Loop through parents
+	var c = filepath.Clean(dst)
+	for _, n := range strings.Split(rel, string(os.PathSeparator)) {
+		c = filepath.Join(c, n)
+		var fi os.FileInfo
+		if fi, err = os.Lstat(c); err != nil {
+			if os.IsNotExist(err) {
+				err = nil
+				return
+			}
+			return errors.Wrapf(err, "astios: lstating %s failed", c)
+		}
+		if fi.Mode()&os.ModeSymlink != 0 {
+			return errors.Errorf("astios: parent %s of %s is a symlink", c, p)
+		}
+	}
+	return
+}
@@ -0,0 +1,129 @@
+package astios
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/asticode/go-astilog"
+	"github.com/asticode/go-astitools/defer"
+	"github.com/pkg/errors"
+)
+
+// TempManagerOptions represents temp manager options
+type TempManagerOptions struct {
+	// If set, the manager's cleanup is registered in the closer
+	Closer *astidefer.Closer
+	// Directory temp files and dirs are created in. Defaults to os.TempDir().
+	Dir string
+	// Keeps temp files and dirs on cleanup, which is useful when debugging
+	Keep bool
+	// Prefix prepended to every temp file and dir name
+	Prefix string
+}
+
+// TempManager allocates temp files and dirs, tracks them and removes them on cleanup
+// When registered in a closer whose Close is deferred, cleanup happens on shutdown even after a panic.
+type TempManager struct {
+	m     *sync.Mutex // Locks paths
+	o     TempManagerOptions
+	paths []string
+}
+
+// NewTempManager creates a new temp manager
+func NewTempManager(o TempManagerOptions) (m *TempManager) {
+	// Default options values
+	if o.Dir == "" {
+		o.Dir = os.TempDir()
+	}
+
+	// Create manager
+	m = &TempManager{
+		m: &sync.Mutex{},
+		o: o,
+	}
+
+	// Register cleanup
+	if o.Closer != nil {
+		o.Closer.AddNamed("temp manager", m.Close)
+	}
+	return
+}
+
+// pattern returns the pattern of temp names
+func (m *TempManager) pattern(prefix string) string {
+	return m.o.Prefix + prefix + "*"
+}
+
+// track tracks a path
+func (m *TempManager) track(p string) {
+	m.m.Lock()
+	defer m.m.Unlock()
+	m.paths = append(m.paths, p)
+}
+
+// Dir creates a new temp dir
+func (m *TempManager) Dir(prefix string) (path string, err error) {
+	// Create dir
+	if path, err = ioutil.TempDir(m.o.Dir, m.pattern(prefix)); err != nil {
+		err = errors.Wrap(err, "astios: creating temp dir failed")
+		return
+	}
+
+	// Track
+	m.track(path)
+	return
+}
+
+// File creates a new temp file, which must be closed by the caller
+func (m *TempManager) File(prefix string) (f *os.File, err error) {
+	// Create file
+	if f, err = ioutil.TempFile(m.o.Dir, m.pattern(prefix)); err != nil {
+		err = errors.Wrap(err, "astios: creating temp file failed")
+		return
+	}
+
+	// Track
+	m.track(f.Name())
+	return
+}
+
+// Paths returns the paths of tracked temp files and dirs in the order they were created
+func (m *TempManager) Paths() []string {
+	m.m.Lock()
+	defer m.m.Unlock()
+	return append([]string{}, m.paths...)
+}
+
+// Close implements the io.Closer interface and removes tracked temp files and dirs in the reverse order they were
+// created, unless they should be kept
+func (m *TempManager) Close() (err error) {
+	// Get paths
+	m.m.Lock()
+	var ps = m.paths
+	m.paths = []string{}
+	m.m.Unlock()
+
+	// Keep
+	if m.o.Keep {
+		if len(ps) > 0 {
+			astilog.Infof("astios: keeping temp paths %s", strings.Join(ps, ", "))
+		}
+		return
+	}
+
+	// Remove
+	var failed []string
+	for i := len(ps) - 1; i >= 0; i-- {
+		if errR := os.RemoveAll(ps[i]); errR != nil {
+			astilog.Error(errors.Wrapf(errR, "astios: removing %s failed", ps[i]))
+			failed = append(failed, ps[i])
+		}
+	}
+	if len(failed) > 0 {
+		err = errors.Errorf("astios: removing temp paths %s failed", strings.Join(failed, ", "))
+		return
+	}
+	return
+}
@@ -0,0 +1,45 @@
+package astios_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/asticode/go-astitools/defer"
+	"github.com/asticode/go-astitools/os"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTempManager(t *testing.T) {
+	// Create
+	var c = astidefer.NewCloser()
+	var dir = t.TempDir()
+	m := astios.NewTempManager(astios.TempManagerOptions{Closer: c, Dir: dir, Prefix: "test-"})
+	d, err := m.Dir("dir-")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(filepath.Base(d), "test-dir-"))
+	f, err := m.File("file-")
+	assert.NoError(t, err)
+	f.Close()
+	assert.Equal(t, []string{d, f.Name()}, m.Paths())
+
+	// Cleanup happens even after a panic
+	func() {
+		defer func() { recover() }()
+		defer c.Close()
+		panic("test")
+	}()
+	_, err = os.Stat(d)
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(f.Name())
+	assert.True(t, os.IsNotExist(err))
+
+	// Keep
+	m = astios.NewTempManager(astios.TempManagerOptions{Dir: dir, Keep: true})
+	d, err = m.Dir("")
+	assert.NoError(t, err)
+	assert.NoError(t, m.Close())
+	_, err = os.Stat(d)
+	assert.NoError(t, err)
+}
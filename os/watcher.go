@@ -0,0 +1,208 @@
+package astios
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/asticode/go-astilog"
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+)
+
+// WatchEvent represents a watch event
+// Op is the union of all the operations that happened on the path during the debounce window.
+type WatchEvent struct {
+	Name string
+	Op   fsnotify.Op
+}
+
+// Watcher represents an object capable of watching directories and delivering debounced events
+type Watcher struct {
+	events  chan WatchEvent
+	m       sync.Mutex // Locks pending
+	o       WatcherOptions
+	pending map[string]fsnotify.Op
+	w       *fsnotify.Watcher
+}
+
+// WatcherOptions represents watcher options
+type WatcherOptions struct {
+	// Window during which events are coalesced. An event is delivered once no event has happened during the window.
+	// Defaults to 100ms
+	Debounce time.Duration
+	// Glob patterns (see path.Match) of base names whose events must not be delivered
+	Exclude []string
+	// Glob patterns (see path.Match) of base names whose events must be delivered. If empty, all events are delivered
+	Include []string
+	// Whether sub directories, including the ones created after the watch has started, are watched as well
+	Recursive bool
+}
+
+// NewWatcher creates a new watcher
+// Events are delivered on the Events channel until the context is cancelled, at which point the channel is closed.
+func NewWatcher(ctx context.Context, o WatcherOptions) (w *Watcher, err error) {
+	// Default options values
+	if o.Debounce <= 0 {
+		o.Debounce = 100 * time.Millisecond
+	}
+
+	// Create watcher
+	w = &Watcher{
+		events:  make(chan WatchEvent),
+		o:       o,
+		pending: make(map[string]fsnotify.Op),
+	}
+	if w.w, err = fsnotify.NewWatcher(); err != nil {
+		err = errors.Wrap(err, "astios: creating fsnotify watcher failed")
+		return
+	}
+
+	// Watch
+	go w.watch(ctx)
+	return
+}
+
+// Events returns the channel on which events are delivered
+func (w *Watcher) Events() <-chan WatchEvent {
+	return w.events
+}
+
+// Add adds a directory to the watcher
+func (w *Watcher) Add(dir string) (err error) {
+	// Not recursive
+	if !w.o.Recursive {
+		if err = w.w.Add(dir); err != nil {
+			return errors.Wrapf(err, "astios: adding %s to watcher failed", dir)
+		}
+		return
+	}
+
+	// Walk
+	if err = filepath.Walk(dir, func(p string, fi os.FileInfo, e error) (err error) {
+		// Check error
+		if e != nil {
+			return e
+		}
+
+		// Only directories are added
+		if !fi.IsDir() {
+			return
+		}
+
+		// Add
+		if err = w.w.Add(p); err != nil {
+			return errors.Wrapf(err, "astios: adding %s to watcher failed", p)
+		}
+		return
+	}); err != nil {
+		return errors.Wrapf(err, "astios: walking through %s failed", dir)
+	}
+	return
+}
+
+// watch reads fsnotify events until the context is cancelled
+func (w *Watcher) watch(ctx context.Context) {
+	// Create timer
+	var t = time.NewTimer(w.o.Debounce)
+	t.Stop()
+
+	// Clean up
+	defer func() {
+		t.Stop()
+		w.w.Close()
+		close(w.events)
+	}()
+
+	// Loop
+	for {
+		select {
+		case e, ok := <-w.w.Events:
+			if !ok {
+				return
+			}
+			if w.handleEvent(e) {
+				t.Reset(w.o.Debounce)
+			}
+		case err, ok := <-w.w.Errors:
+			if !ok {
+				return
+			}
+			astilog.Error(errors.Wrap(err, "astios: watching failed"))
+		case <-t.C:
+			if !w.flush(ctx) {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// handleEvent handles an fsnotify event and returns whether it's pending
+func (w *Watcher) handleEvent(e fsnotify.Event) bool {
+	// Watch new directories
+	if w.o.Recursive && e.Op&fsnotify.Create != 0 {
+		if fi, err := os.Stat(e.Name); err == nil && fi.IsDir() {
+			if err = w.Add(e.Name); err != nil {
+				astilog.Error(errors.Wrapf(err, "astios: adding %s to watcher failed", e.Name))
+			}
+		}
+	}
+
+	// Filter
+	if !w.match(filepath.Base(e.Name)) {
+		return false
+	}
+
+	// Add to pending events
+	w.m.Lock()
+	w.pending[e.Name] |= e.Op
+	w.m.Unlock()
+	return true
+}
+
+// match checks whether a base name matches the include and exclude patterns
+func (w *Watcher) match(name string) bool {
+	for _, p := range w.o.Exclude {
+		if ok, _ := path.Match(p, name); ok {
+			return false
+		}
+	}
+	if len(w.o.Include) == 0 {
+		return true
+	}
+	for _, p := range w.o.Include {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// flush delivers pending events sorted by name and returns false if the context has been cancelled
+func (w *Watcher) flush(ctx context.Context) bool {
+	// Get pending events
+	w.m.Lock()
+	var es []WatchEvent
+	for n, op := range w.pending {
+		es = append(es, WatchEvent{Name: n, Op: op})
+	}
+	w.pending = make(map[string]fsnotify.Op)
+	w.m.Unlock()
+	sort.Slice(es, func(i, j int) bool { return es[i].Name < es[j].Name })
+
+	// Deliver
+	for _, e := range es {
+		select {
+		case w.events <- e:
+		case <-ctx.Done():
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,64 @@
+package astios_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/os"
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatcher(t *testing.T) {
+	// Init
+	dir, err := ioutil.TempDir("", "astios")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	w, err := astios.NewWatcher(ctx, astios.WatcherOptions{
+		Debounce:  50 * time.Millisecond,
+		Exclude:   []string{"*.log"},
+		Include:   []string{"*.txt", "*.log"},
+		Recursive: true,
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, w.Add(dir))
+
+	// Sub directory created after the watch has started
+	sub := filepath.Join(dir, "sub")
+	assert.NoError(t, os.Mkdir(sub, 0755))
+	time.Sleep(20 * time.Millisecond)
+
+	// Bursts are coalesced
+	p := filepath.Join(sub, "f.txt")
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, ioutil.WriteFile(p, []byte("test"), 0644))
+	}
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(sub, "f.log"), []byte("test"), 0644))
+	select {
+	case e := <-w.Events():
+		assert.Equal(t, p, e.Name)
+		assert.True(t, e.Op&fsnotify.Write != 0)
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+	}
+	select {
+	case e := <-w.Events():
+		t.Fatalf("unexpected event %+v", e)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// Channel is closed once the context is cancelled
+	cancel()
+	select {
+	case _, ok := <-w.Events():
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("channel not closed")
+	}
+}
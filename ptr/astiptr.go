@@ -1,36 +1,73 @@
 package astiptr
 
+// Ptr transforms a value into a pointer
+func Ptr[T any](v T) *T {
+	return &v
+}
+
+// Deref returns the value a pointer points to, or the default value if the pointer is nil
+func Deref[T any](p *T, def T) T {
+	if p == nil {
+		return def
+	}
+	return *p
+}
+
+// Ptrs transforms a slice of values into a slice of pointers to copies of these values
+func Ptrs[T any](vs []T) []*T {
+	if vs == nil {
+		return nil
+	}
+	var ps = make([]*T, len(vs))
+	for i := range vs {
+		ps[i] = Ptr(vs[i])
+	}
+	return ps
+}
+
+// Derefs transforms a slice of pointers into a slice of values, nil pointers being replaced by the default value
+func Derefs[T any](ps []*T, def T) []T {
+	if ps == nil {
+		return nil
+	}
+	var vs = make([]T, len(ps))
+	for i, p := range ps {
+		vs[i] = Deref(p, def)
+	}
+	return vs
+}
+
 // Bool transforms a bool into a *bool
 func Bool(i bool) *bool {
-	return &i
+	return Ptr(i)
 }
 
 // Float transforms a float64 into a *float64
 func Float(i float64) *float64 {
-	return &i
+	return Ptr(i)
 }
 
 // Int transforms an int into an *int
 func Int(i int) *int {
-	return &i
+	return Ptr(i)
 }
 
 // Int64 transforms an int64 into an *int64
 func Int64(i int64) *int64 {
-	return &i
+	return Ptr(i)
 }
 
 // Str transforms a string into a *string
 func Str(i string) *string {
-	return &i
+	return Ptr(i)
 }
 
 // UInt8 transforms a uint8 into a *uint8
 func UInt8(i uint8) *uint8 {
-	return &i
+	return Ptr(i)
 }
 
 // UInt32 transforms a uint32 into a *uint32
 func UInt32(i uint32) *uint32 {
-	return &i
+	return Ptr(i)
 }
@@ -0,0 +1,29 @@
+package astiptr_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/ptr"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPtr(t *testing.T) {
+	// Ptr
+	p := astiptr.Ptr(time.Second)
+	assert.Equal(t, time.Second, *p)
+	assert.Equal(t, uint64(1), *astiptr.Ptr[uint64](1))
+	assert.Equal(t, "a", *astiptr.Str("a"))
+
+	// Deref
+	assert.Equal(t, time.Second, astiptr.Deref(p, time.Minute))
+	assert.Equal(t, time.Minute, astiptr.Deref(nil, time.Minute))
+
+	// Slices
+	ps := astiptr.Ptrs([]int{1, 2})
+	assert.Len(t, ps, 2)
+	*ps[0] = 3
+	assert.Equal(t, []int{3, 2, -1}, astiptr.Derefs(append(ps, nil), -1))
+	assert.Nil(t, astiptr.Ptrs[int](nil))
+	assert.Nil(t, astiptr.Derefs[int](nil, 0))
+}
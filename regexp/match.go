@@ -0,0 +1,110 @@
+package astiregexp
+
+import (
+	"context"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// contextCheckPeriod is the number of runes read between 2 context checks
+const contextCheckPeriod = 1024
+
+// NamedGroups returns the named capture groups of the first match, indexed by name
+// Groups that didn't participate in the match are set to an empty string.
+func NamedGroups(rgx *regexp.Regexp, s string) (m map[string]string, ok bool) {
+	var idx = rgx.FindStringSubmatchIndex(s)
+	if idx == nil {
+		return
+	}
+	return namedGroups(rgx, s, idx), true
+}
+
+// NamedGroupsAll returns the named capture groups of at most n successive matches, or all of them if n < 0
+func NamedGroupsAll(rgx *regexp.Regexp, s string, n int) (ms []map[string]string) {
+	for _, idx := range rgx.FindAllStringSubmatchIndex(s, n) {
+		ms = append(ms, namedGroups(rgx, s, idx))
+	}
+	return
+}
+
+// namedGroups builds named capture groups out of submatch indexes
+func namedGroups(rgx *regexp.Regexp, s string, idx []int) (m map[string]string) {
+	m = make(map[string]string)
+	for i, n := range rgx.SubexpNames() {
+		if n == "" {
+			continue
+		}
+		if idx[2*i] >= 0 {
+			m[n] = s[idx[2*i]:idx[2*i+1]]
+		} else if _, ok := m[n]; !ok {
+			m[n] = ""
+		}
+	}
+	return
+}
+
+// MatchContext checks whether s contains a match, giving up once the context is done
+// Use a context with a timeout to bound the time spent on large inputs. The context's error is returned if it's done
+// before the end of the matching.
+func MatchContext(ctx context.Context, rgx *regexp.Regexp, s string) (ok bool, err error) {
+	// Check context
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	// Match
+	var r = newContextRuneReader(ctx, s)
+	ok = rgx.MatchReader(r)
+	if err = r.err; err != nil {
+		ok = false
+	}
+	return
+}
+
+// NamedGroupsContext is like NamedGroups but gives up once the context is done
+// The context's error is returned if it's done before the end of the matching.
+func NamedGroupsContext(ctx context.Context, rgx *regexp.Regexp, s string) (m map[string]string, ok bool, err error) {
+	// Check context
+	if err = ctx.Err(); err != nil {
+		return
+	}
+
+	// Find
+	var r = newContextRuneReader(ctx, s)
+	var idx = rgx.FindReaderSubmatchIndex(r)
+	if err = r.err; err != nil || idx == nil {
+		return
+	}
+	return namedGroups(rgx, s, idx), true, nil
+}
+
+// contextRuneReader represents a rune reader ending the input once its context is done
+type contextRuneReader struct {
+	ctx context.Context
+	err error
+	n   int
+	r   *strings.Reader
+}
+
+// newContextRuneReader creates a new context rune reader
+func newContextRuneReader(ctx context.Context, s string) *contextRuneReader {
+	return &contextRuneReader{
+		ctx: ctx,
+		r:   strings.NewReader(s),
+	}
+}
+
+// ReadRune implements the io.RuneReader interface
+func (r *contextRuneReader) ReadRune() (c rune, size int, err error) {
+	// Check context
+	if r.err != nil {
+		return 0, 0, io.EOF
+	}
+	if r.n++; r.n%contextCheckPeriod == 0 {
+		if r.err = r.ctx.Err(); r.err != nil {
+			return 0, 0, io.EOF
+		}
+	}
+	return r.r.ReadRune()
+}
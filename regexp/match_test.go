@@ -0,0 +1,66 @@
+package astiregexp_test
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/asticode/go-astitools/regexp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamedGroups(t *testing.T) {
+	rgx := regexp.MustCompile(`(?P<key>[a-z]+)=(?P<value>[0-9]+)(?P<unit>ms)?`)
+	m, ok := astiregexp.NamedGroups(rgx, "a=1 b=2ms")
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{"key": "a", "unit": "", "value": "1"}, m)
+	_, ok = astiregexp.NamedGroups(rgx, "invalid")
+	assert.False(t, ok)
+	assert.Equal(t, []map[string]string{
+		{"key": "a", "unit": "", "value": "1"},
+		{"key": "b", "unit": "ms", "value": "2"},
+	}, astiregexp.NamedGroupsAll(rgx, "a=1 b=2ms", -1))
+
+	// Context
+	m, ok, err := astiregexp.NamedGroupsContext(context.Background(), rgx, "x b=2ms")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, map[string]string{"key": "b", "unit": "ms", "value": "2"}, m)
+}
+
+// expiringContext is a context whose error is set after a number of checks
+type expiringContext struct {
+	context.Context
+	checks int
+}
+
+func (ctx *expiringContext) Err() error {
+	if ctx.checks--; ctx.checks < 0 {
+		return context.DeadlineExceeded
+	}
+	return nil
+}
+
+func TestMatchContext(t *testing.T) {
+	rgx := regexp.MustCompile(`b$`)
+	s := strings.Repeat("a", 10000) + "b"
+	ok, err := astiregexp.MatchContext(context.Background(), rgx, s)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	// Cancelled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ok, err = astiregexp.MatchContext(ctx, rgx, s)
+	assert.Equal(t, context.Canceled, err)
+	assert.False(t, ok)
+	_, ok, err = astiregexp.NamedGroupsContext(ctx, rgx, s)
+	assert.Equal(t, context.Canceled, err)
+	assert.False(t, ok)
+
+	// Expired while matching
+	ok, err = astiregexp.MatchContext(&expiringContext{Context: context.Background(), checks: 2}, rgx, s)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	assert.False(t, ok)
+}
@@ -0,0 +1,79 @@
+package astiregexp
+
+import (
+	"regexp"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultRegistry represents the registry used by Compile and MustCompile
+var DefaultRegistry = NewRegistry()
+
+// Registry represents a concurrent-safe cache of compiled patterns
+// Patterns are compiled once and retrieved by their string afterwards.
+type Registry struct {
+	m  sync.RWMutex // Locks rs
+	rs map[string]*regexp.Regexp
+}
+
+// NewRegistry creates a new registry
+func NewRegistry() *Registry {
+	return &Registry{rs: make(map[string]*regexp.Regexp)}
+}
+
+// Compile returns the compiled pattern, compiling and caching it if needed
+// Patterns that fail to compile are not cached.
+func (r *Registry) Compile(pattern string) (rgx *regexp.Regexp, err error) {
+	// Pattern is cached
+	r.m.RLock()
+	rgx, ok := r.rs[pattern]
+	r.m.RUnlock()
+	if ok {
+		return
+	}
+
+	// Compile
+	if rgx, err = regexp.Compile(pattern); err != nil {
+		err = errors.Wrapf(err, "astiregexp: compiling %s failed", pattern)
+		return
+	}
+
+	// Cache
+	// If another call has compiled the pattern in the meantime, its result is used so that the same *regexp.Regexp is
+	// always returned.
+	r.m.Lock()
+	defer r.m.Unlock()
+	if c, ok := r.rs[pattern]; ok {
+		rgx = c
+		return
+	}
+	r.rs[pattern] = rgx
+	return
+}
+
+// MustCompile is like Compile but panics if the pattern fails to compile
+func (r *Registry) MustCompile(pattern string) *regexp.Regexp {
+	rgx, err := r.Compile(pattern)
+	if err != nil {
+		panic(err)
+	}
+	return rgx
+}
+
+// Len returns the number of cached patterns
+func (r *Registry) Len() int {
+	r.m.RLock()
+	defer r.m.RUnlock()
+	return len(r.rs)
+}
+
+// Compile returns the compiled pattern from the default registry
+func Compile(pattern string) (*regexp.Regexp, error) {
+	return DefaultRegistry.Compile(pattern)
+}
+
+// MustCompile returns the compiled pattern from the default registry and panics if it fails to compile
+func MustCompile(pattern string) *regexp.Regexp {
+	return DefaultRegistry.MustCompile(pattern)
+}
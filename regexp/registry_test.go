@@ -0,0 +1,30 @@
+package astiregexp_test
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/asticode/go-astitools/regexp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	r := astiregexp.NewRegistry()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := r.Compile("^a+$")
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, r.Len())
+	assert.Same(t, r.MustCompile("^a+$"), r.MustCompile("^a+$"))
+	_, err := r.Compile("(")
+	assert.Error(t, err)
+	assert.Equal(t, 1, r.Len())
+	assert.Panics(t, func() { r.MustCompile("(") })
+	assert.Same(t, astiregexp.MustCompile("^b$"), astiregexp.MustCompile("^b$"))
+}
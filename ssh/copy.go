@@ -0,0 +1,389 @@
+package astissh
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/asticode/go-astitools/io"
+	"github.com/pkg/errors"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// Copy transports
+const (
+	CopyTransportSCP  CopyTransport = "scp"
+	CopyTransportSFTP CopyTransport = "sftp"
+)
+
+// CopyTransport represents a copy transport
+type CopyTransport string
+
+// CopyOptions represents copy options
+type CopyOptions struct {
+	// Progress is called every time a file has been copied
+	Progress func(p CopyProgress)
+	// Defaults to CopyTransportSCP
+	Transport CopyTransport
+}
+
+// CopyProgress represents a copy progress
+type CopyProgress struct {
+	// Number of bytes copied so far
+	Bytes int64
+	// Number of files copied so far
+	Files int
+	// Remote path of the last copied file
+	Path string
+}
+
+// Copy is a cancellable copy of a local file or directory to a remote path
+// Directories are copied recursively and modes are preserved. When src is a directory, dst is the remote directory
+// its content is copied into, and symlinks it contains are skipped.
+func (m *Manager) Copy(ctx context.Context, src, dst string, o CopyOptions) (err error) {
+	// Stat src
+	var fi os.FileInfo
+	if fi, err = os.Stat(src); err != nil {
+		return errors.Wrapf(err, "astissh: stating %s failed", src)
+	}
+
+	// Copy
+	var c = &copier{o: o}
+	switch o.Transport {
+	case CopyTransportSFTP:
+		err = c.sftp(ctx, m, src, dst, fi)
+	case CopyTransportSCP, "":
+		err = c.scp(ctx, m, src, dst, fi)
+	default:
+		err = fmt.Errorf("astissh: unknown copy transport %s", o.Transport)
+	}
+	return
+}
+
+// copier represents an object capable of copying files and reporting progress
+type copier struct {
+	o CopyOptions
+	p CopyProgress
+}
+
+// progress updates the progress once a file has been copied
+func (c *copier) progress(path string, size int64) {
+	c.p.Bytes += size
+	c.p.Files++
+	c.p.Path = path
+	if c.o.Progress != nil {
+		c.o.Progress(c.p)
+	}
+}
+
+// sessionContext closes the session once the context is done and returns a func that must be called once the session
+// is over
+func sessionContext(ctx context.Context, s *ssh.Session) func() {
+	var done = make(chan bool)
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.Close()
+		case <-done:
+		}
+	}()
+	return func() { close(done) }
+}
+
+// scp copies using the scp protocol
+func (c *copier) scp(ctx context.Context, m *Manager, src, dst string, fi os.FileInfo) (err error) {
+	// Create session
+	var s *ssh.Session
+	var fn func()
+	if s, fn, err = m.NewSession(ctx); err != nil {
+		return
+	}
+	defer fn()
+	defer sessionContext(ctx, s)()
+
+	// Create pipes
+	var stdin io.WriteCloser
+	if stdin, err = s.StdinPipe(); err != nil {
+		return errors.Wrap(err, "astissh: creating stdin pipe failed")
+	}
+	var stdout io.Reader
+	if stdout, err = s.StdoutPipe(); err != nil {
+		return errors.Wrap(err, "astissh: creating stdout pipe failed")
+	}
+
+	// Start scp
+	var cmd = "scp -qt " + quote(path.Dir(dst))
+	if fi.IsDir() {
+		cmd = "scp -rqt " + quote(path.Dir(dst))
+	}
+	if err = s.Start(cmd); err != nil {
+		return errors.Wrapf(err, "astissh: starting %s failed", cmd)
+	}
+
+	// Copy
+	var w = &scpWriter{r: bufio.NewReader(stdout), w: stdin}
+	if err = w.ack(); err != nil {
+		err = errors.Wrapf(err, "astissh: starting %s failed", cmd)
+	} else if fi.IsDir() {
+		err = c.scpDir(ctx, w, src, dst, fi)
+	} else {
+		err = c.scpFile(ctx, w, src, dst, fi)
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	} else if err != nil {
+		return
+	}
+
+	// Wait
+	stdin.Close()
+	if err = s.Wait(); err != nil {
+		return errors.Wrapf(err, "astissh: waiting for %s failed", cmd)
+	}
+	return
+}
+
+// scpDir copies a directory recursively using the scp protocol
+func (c *copier) scpDir(ctx context.Context, w *scpWriter, src, dst string, fi os.FileInfo) (err error) {
+	// Start directory
+	if err = w.send(fmt.Sprintf("D%04o 0 %s\n", fi.Mode().Perm(), path.Base(dst))); err != nil {
+		return errors.Wrapf(err, "astissh: creating dir %s failed", dst)
+	}
+
+	// Read dir
+	var fis []os.FileInfo
+	if fis, err = ioutil.ReadDir(src); err != nil {
+		return errors.Wrapf(err, "astissh: reading dir %s failed", src)
+	}
+
+	// Loop through entries
+	for _, fi := range fis {
+		// Check context
+		if err = ctx.Err(); err != nil {
+			return
+		}
+
+		// Copy
+		// Entries are lstated by ioutil.ReadDir, so that symlinks are neither regular files nor dirs and are skipped,
+		// which prevents symlink loops from being followed forever
+		var s, d = filepath.Join(src, fi.Name()), path.Join(dst, fi.Name())
+		if fi.IsDir() {
+			err = c.scpDir(ctx, w, s, d, fi)
+		} else if fi.Mode().IsRegular() {
+			err = c.scpFile(ctx, w, s, d, fi)
+		}
+		if err != nil {
+			return
+		}
+	}
+
+	// End directory
+	if err = w.send("E\n"); err != nil {
+		return errors.Wrapf(err, "astissh: ending dir %s failed", dst)
+	}
+	return
+}
+
+// scpFile copies a file using the scp protocol
+func (c *copier) scpFile(ctx context.Context, w *scpWriter, src, dst string, fi os.FileInfo) (err error) {
+	// Open file
+	var f *os.File
+	if f, err = os.Open(src); err != nil {
+		return errors.Wrapf(err, "astissh: opening %s failed", src)
+	}
+	defer f.Close()
+
+	// Send header
+	if err = w.send(fmt.Sprintf("C%04o %d %s\n", fi.Mode().Perm(), fi.Size(), path.Base(dst))); err != nil {
+		return errors.Wrapf(err, "astissh: copying %s to %s failed", src, dst)
+	}
+
+	// Copy content
+	if _, err = astiio.Copy(ctx, f, w.w); err != nil {
+		return errors.Wrapf(err, "astissh: copying %s to %s failed", src, dst)
+	}
+	if err = w.send("\x00"); err != nil {
+		return errors.Wrapf(err, "astissh: copying %s to %s failed", src, dst)
+	}
+
+	// Progress
+	c.progress(dst, fi.Size())
+	return
+}
+
+// scpWriter represents an object capable of writing scp protocol messages and reading their acknowledgments
+type scpWriter struct {
+	r *bufio.Reader
+	w io.Writer
+}
+
+// send writes a message and reads its acknowledgment
+func (w *scpWriter) send(msg string) (err error) {
+	if _, err = io.WriteString(w.w, msg); err != nil {
+		return errors.Wrap(err, "astissh: writing scp message failed")
+	}
+	return w.ack()
+}
+
+// ack reads an acknowledgment
+func (w *scpWriter) ack() (err error) {
+	// Read code
+	var b byte
+	if b, err = w.r.ReadByte(); err != nil {
+		return errors.Wrap(err, "astissh: reading scp acknowledgment failed")
+	}
+
+	// Success
+	if b == 0 {
+		return
+	}
+
+	// Read message
+	var msg string
+	if msg, err = w.r.ReadString('\n'); err != nil {
+		return errors.Wrap(err, "astissh: reading scp error message failed")
+	}
+	return fmt.Errorf("astissh: scp failed: %s", strings.TrimSpace(msg))
+}
+
+// sftp copies using the sftp protocol
+func (c *copier) sftp(ctx context.Context, m *Manager, src, dst string, fi os.FileInfo) (err error) {
+	// Create session
+	var s *ssh.Session
+	var fn func()
+	if s, fn, err = m.NewSession(ctx); err != nil {
+		return
+	}
+	defer fn()
+	defer sessionContext(ctx, s)()
+
+	// Create client
+	var cl *sftp.Client
+	if cl, err = newSFTPClient(s); err != nil {
+		return
+	}
+	defer cl.Close()
+
+	// Copy
+	if fi.IsDir() {
+		err = c.sftpDir(ctx, cl, src, dst, fi)
+	} else {
+		err = c.sftpFile(ctx, cl, src, dst, fi)
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return
+}
+
+// newSFTPClient creates a new sftp client on a session
+func newSFTPClient(s *ssh.Session) (c *sftp.Client, err error) {
+	// Create pipes
+	var stdin io.WriteCloser
+	if stdin, err = s.StdinPipe(); err != nil {
+		err = errors.Wrap(err, "astissh: creating stdin pipe failed")
+		return
+	}
+	var stdout io.Reader
+	if stdout, err = s.StdoutPipe(); err != nil {
+		err = errors.Wrap(err, "astissh: creating stdout pipe failed")
+		return
+	}
+
+	// Request subsystem
+	if err = s.RequestSubsystem("sftp"); err != nil {
+		err = errors.Wrap(err, "astissh: requesting sftp subsystem failed")
+		return
+	}
+
+	// Create client
+	if c, err = sftp.NewClientPipe(stdout, stdin); err != nil {
+		err = errors.Wrap(err, "astissh: creating sftp client failed")
+		return
+	}
+	return
+}
+
+// sftpDir copies a directory recursively using the sftp protocol
+func (c *copier) sftpDir(ctx context.Context, cl *sftp.Client, src, dst string, fi os.FileInfo) (err error) {
+	// Create directory
+	if err = cl.MkdirAll(dst); err != nil {
+		return errors.Wrapf(err, "astissh: creating dir %s failed", dst)
+	}
+
+	// Read dir
+	var fis []os.FileInfo
+	if fis, err = ioutil.ReadDir(src); err != nil {
+		return errors.Wrapf(err, "astissh: reading dir %s failed", src)
+	}
+
+	// Loop through entries
+	for _, fi := range fis {
+		// Check context
+		if err = ctx.Err(); err != nil {
+			return
+		}
+
+		// Copy
+		// Entries are lstated by ioutil.ReadDir, so that symlinks are neither regular files nor dirs and are skipped,
+		// which prevents symlink loops from being followed forever
+		var s, d = filepath.Join(src, fi.Name()), path.Join(dst, fi.Name())
+		if fi.IsDir() {
+			err = c.sftpDir(ctx, cl, s, d, fi)
+		} else if fi.Mode().IsRegular() {
+			err = c.sftpFile(ctx, cl, s, d, fi)
+		}
+		if err != nil {
+			return
+		}
+	}
+
+	// Preserve mode once the content has been copied so that read-only dirs can be populated
+	if err = cl.Chmod(dst, fi.Mode().Perm()); err != nil {
+		return errors.Wrapf(err, "astissh: chmoding %s failed", dst)
+	}
+	return
+}
+
+// sftpFile copies a file using the sftp protocol
+func (c *copier) sftpFile(ctx context.Context, cl *sftp.Client, src, dst string, fi os.FileInfo) (err error) {
+	// Open src
+	var f *os.File
+	if f, err = os.Open(src); err != nil {
+		return errors.Wrapf(err, "astissh: opening %s failed", src)
+	}
+	defer f.Close()
+
+	// Create dst
+	var df *sftp.File
+	if df, err = cl.Create(dst); err != nil {
+		return errors.Wrapf(err, "astissh: creating %s failed", dst)
+	}
+	defer df.Close()
+
+	// Copy
+	if _, err = astiio.Copy(ctx, f, df); err != nil {
+		return errors.Wrapf(err, "astissh: copying %s to %s failed", src, dst)
+	}
+
+	// Preserve mode
+	if err = df.Chmod(fi.Mode().Perm()); err != nil {
+		return errors.Wrapf(err, "astissh: chmoding %s failed", dst)
+	}
+
+	// Progress
+	c.progress(dst, fi.Size())
+	return
+}
+
+// quote quotes a string so that it can be used in a remote shell command
+func quote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
@@ -0,0 +1,68 @@
+package astissh_test
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/asticode/go-astitools/ssh"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestManager_Copy(t *testing.T) {
+	// Init
+	s := newServer(t)
+	defer s.close()
+	m := astissh.NewManager(s.addr(), astissh.ManagerOptions{Config: &ssh.ClientConfig{HostKeyCallback: ssh.InsecureIgnoreHostKey()}})
+	defer m.Close()
+	dir, err := ioutil.TempDir("", "astissh")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "src")
+	os.MkdirAll(filepath.Join(src, "d"), 0700)
+	ioutil.WriteFile(filepath.Join(src, "f1"), []byte("f1"), 0600)
+	ioutil.WriteFile(filepath.Join(src, "d", "f2"), []byte("f2"), 0755)
+	os.Symlink("..", filepath.Join(src, "d", "loop"))
+	os.Chmod(filepath.Join(src, "d"), 0500)
+	defer os.Chmod(filepath.Join(src, "d"), 0700)
+
+	for _, tr := range []astissh.CopyTransport{astissh.CopyTransportSCP, astissh.CopyTransportSFTP} {
+		// File
+		dst := filepath.Join(dir, string(tr)+"-file")
+		assert.NoError(t, m.Copy(context.Background(), filepath.Join(src, "f1"), dst, astissh.CopyOptions{Transport: tr}))
+		b, err := ioutil.ReadFile(dst)
+		assert.NoError(t, err)
+		assert.Equal(t, "f1", string(b))
+
+		// Dir
+		var ps []astissh.CopyProgress
+		dst = filepath.Join(dir, string(tr)+"-dir")
+		assert.NoError(t, m.Copy(context.Background(), src, dst, astissh.CopyOptions{
+			Progress:  func(p astissh.CopyProgress) { ps = append(ps, p) },
+			Transport: tr,
+		}))
+		b, err = ioutil.ReadFile(filepath.Join(dst, "d", "f2"))
+		assert.NoError(t, err)
+		assert.Equal(t, "f2", string(b))
+		fi, err := os.Stat(filepath.Join(dst, "d", "f2"))
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0755), fi.Mode().Perm())
+		fi, err = os.Stat(filepath.Join(dst, "d"))
+		assert.NoError(t, err)
+		assert.Equal(t, os.FileMode(0500), fi.Mode().Perm())
+		_, err = os.Lstat(filepath.Join(dst, "d", "loop"))
+		assert.True(t, os.IsNotExist(err))
+		os.Chmod(filepath.Join(dst, "d"), 0700)
+		assert.Equal(t, []astissh.CopyProgress{
+			{Bytes: 2, Files: 1, Path: filepath.Join(dst, "d", "f2")},
+			{Bytes: 4, Files: 2, Path: filepath.Join(dst, "f1")},
+		}, ps)
+	}
+
+	// Error context is kept
+	err = m.Copy(context.Background(), filepath.Join(src, "f1"), "/nonexistent/dir/file", astissh.CopyOptions{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,194 @@
+package astissh
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/asticode/go-astitools/exec"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+)
+
+// DefaultSudoPasswordPrompt represents the password prompt used when executing commands with sudo
+const DefaultSudoPasswordPrompt = "[astissh] password: "
+
+// ExitError represents an error returned when a remote command exits with a non-zero code
+type ExitError struct {
+	Command  string
+	ExitCode int
+}
+
+// Error implements the error interface
+func (e ExitError) Error() string {
+	return fmt.Sprintf("astissh: %s exited with code %d", e.Command, e.ExitCode)
+}
+
+// ExecOptions represents exec options
+type ExecOptions struct {
+	// Password written to stdin the first time PasswordPrompt is found in the output, after which stdin is closed
+	Password string
+	// Defaults to DefaultSudoPasswordPrompt when Sudo is true
+	PasswordPrompt string
+	// Whether a PTY is requested. With a PTY, stderr is merged into stdout
+	PTY bool
+	// Stderr is called for every line written on stderr, without its EOL
+	Stderr func(line []byte)
+	// Stdout is called for every line written on stdout, without its EOL
+	Stdout func(line []byte)
+	// Whether the command is executed with sudo
+	Sudo bool
+	// Amount of time after which the command is aborted. 0 means no timeout
+	Timeout time.Duration
+}
+
+// Exec executes a remote command and streams its output
+// If the command exits with a non-zero code, an ExitError is returned. If the context is done or the timeout is
+// reached, the session is closed and the error cause is the context error.
+func (m *Manager) Exec(ctx context.Context, cmd string, o ExecOptions) (err error) {
+	// Default options values
+	if o.Sudo && o.PasswordPrompt == "" {
+		o.PasswordPrompt = DefaultSudoPasswordPrompt
+	}
+
+	// Handle timeout
+	if o.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.Timeout)
+		defer cancel()
+	}
+
+	// Create session
+	var s *ssh.Session
+	var fn func()
+	if s, fn, err = m.NewSession(ctx); err != nil {
+		return
+	}
+	defer fn()
+	defer sessionContext(ctx, s)()
+
+	// Request PTY
+	if o.PTY {
+		if err = s.RequestPty("xterm", 40, 80, ssh.TerminalModes{ssh.ECHO: 0}); err != nil {
+			return errors.Wrap(err, "astissh: requesting pty failed")
+		}
+	}
+
+	// Create writers
+	var stdin io.WriteCloser
+	if stdin, err = s.StdinPipe(); err != nil {
+		return errors.Wrap(err, "astissh: creating stdin pipe failed")
+	}
+	var pw = &passwordWriter{password: []byte(o.Password + "\n"), prompt: []byte(o.PasswordPrompt), stdin: stdin}
+	var stdout, stderr = newExecWriter(o.Stdout, pw), newExecWriter(o.Stderr, pw)
+	s.Stdout = stdout
+	s.Stderr = stderr
+
+	// Build command
+	var c = cmd
+	if o.Sudo {
+		c = "sudo -p " + quote(o.PasswordPrompt)
+		if !o.PTY {
+			c += " -S"
+		}
+		c += " -- sh -c " + quote(cmd)
+	}
+
+	// Start
+	if err = s.Start(c); err != nil {
+		return errors.Wrapf(err, "astissh: starting %s failed", cmd)
+	}
+
+	// Without password, stdin is not needed and is closed so that the command doesn't wait for input
+	if len(pw.prompt) == 0 {
+		stdin.Close()
+	}
+
+	// Wait
+	err = s.Wait()
+	stdout.close()
+	stderr.close()
+
+	// Process error
+	if ctx.Err() != nil {
+		return errors.Wrapf(ctx.Err(), "astissh: executing %s failed", cmd)
+	} else if err != nil {
+		if e, ok := err.(*ssh.ExitError); ok {
+			return ExitError{Command: cmd, ExitCode: e.ExitStatus()}
+		}
+		return errors.Wrapf(err, "astissh: executing %s failed", cmd)
+	}
+	return
+}
+
+// passwordWriter represents an object capable of writing a password to stdin when a prompt is detected
+type passwordWriter struct {
+	answered bool
+	m        sync.Mutex // Locks answered and stdin
+	password []byte
+	prompt   []byte
+	stdin    io.WriteCloser
+}
+
+// write writes the password once and closes stdin since it's not needed afterwards
+func (w *passwordWriter) write() {
+	w.m.Lock()
+	defer w.m.Unlock()
+	if w.answered {
+		return
+	}
+	w.answered = true
+	w.stdin.Write(w.password)
+	w.stdin.Close()
+}
+
+// execWriter represents a writer streaming lines to a callback and detecting password prompts
+type execWriter struct {
+	buf []byte
+	pw  *passwordWriter
+	sw  *astiexec.StdWriter
+}
+
+// newExecWriter creates a new exec writer
+func newExecWriter(fn func(line []byte), pw *passwordWriter) (w *execWriter) {
+	w = &execWriter{pw: pw}
+	if fn != nil {
+		w.sw = astiexec.NewStdWriter(fn)
+	}
+	return
+}
+
+// Write implements the io.Writer interface
+func (w *execWriter) Write(i []byte) (n int, err error) {
+	// Stream lines
+	if w.sw != nil {
+		w.sw.Write(i)
+	}
+
+	// Detect prompts, keeping enough bytes to detect prompts split across writes
+	if len(w.pw.prompt) > 0 {
+		w.buf = append(w.buf, i...)
+		for {
+			idx := bytes.Index(w.buf, w.pw.prompt)
+			if idx < 0 {
+				break
+			}
+			w.buf = w.buf[idx+len(w.pw.prompt):]
+			w.pw.write()
+		}
+		if len(w.buf) > len(w.pw.prompt) {
+			w.buf = append([]byte{}, w.buf[len(w.buf)-len(w.pw.prompt):]...)
+		}
+	}
+	return len(i), nil
+}
+
+// close streams the last line if it has no EOL
+func (w *execWriter) close() {
+	if w.sw != nil {
+		w.sw.Close()
+	}
+}
@@ -0,0 +1,51 @@
+package astissh_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/ssh"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestManager_Exec(t *testing.T) {
+	// Init
+	s := newServer(t)
+	defer s.close()
+	m := astissh.NewManager(s.addr(), astissh.ManagerOptions{Config: &ssh.ClientConfig{HostKeyCallback: ssh.InsecureIgnoreHostKey()}})
+	defer m.Close()
+
+	// Streaming output
+	var stdout, stderr []string
+	err := m.Exec(context.Background(), "echo 1; echo 2 >&2; echo 3", astissh.ExecOptions{
+		Stderr: func(line []byte) { stderr = append(stderr, string(line)) },
+		Stdout: func(line []byte) { stdout = append(stdout, string(line)) },
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "3"}, stdout)
+	assert.Equal(t, []string{"2"}, stderr)
+
+	// Password prompt
+	stdout = []string{}
+	err = m.Exec(context.Background(), "printf 'pwd: '; read p; echo \"got $p\"", astissh.ExecOptions{
+		Password:       "secret",
+		PasswordPrompt: "pwd: ",
+		PTY:            true,
+		Stdout:         func(line []byte) { stdout = append(stdout, string(line)) },
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"pwd: got secret"}, stdout)
+
+	// Exit code
+	err = m.Exec(context.Background(), "exit 3", astissh.ExecOptions{})
+	assert.Equal(t, astissh.ExitError{Command: "exit 3", ExitCode: 3}, err)
+
+	// Timeout
+	n := time.Now()
+	err = m.Exec(context.Background(), "sleep 5", astissh.ExecOptions{Timeout: 50 * time.Millisecond})
+	assert.Equal(t, context.DeadlineExceeded, errors.Cause(err))
+	assert.True(t, time.Since(n) < time.Second)
+}
@@ -0,0 +1,270 @@
+package astissh
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/asticode/go-astilog"
+	"github.com/asticode/go-astitools/time"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+)
+
+// ForwardOptions represents port forwarding options
+type ForwardOptions struct {
+	// Delay before listening again on the remote host once the connection has been lost. Defaults to 1s
+	ReconnectDelay time.Duration
+}
+
+// Forward represents a running port forwarding
+type Forward struct {
+	addr net.Addr
+	done chan bool
+	m    sync.Mutex // Locks addr
+}
+
+// Addr returns the address the forwarding listens on
+// For remote forwardings, the port may change after a reconnection if an ephemeral port has been requested.
+func (f *Forward) Addr() net.Addr {
+	f.m.Lock()
+	defer f.m.Unlock()
+	return f.addr
+}
+
+// Done returns a channel closed once the forwarding is over
+func (f *Forward) Done() <-chan bool {
+	return f.done
+}
+
+// LocalForward listens on a local address and tunnels connections to a remote address, as seen from the SSH server,
+// until the context is done
+// Each connection is tunneled through the shared connection, which is reopened if it has been lost in the meantime.
+func (m *Manager) LocalForward(ctx context.Context, localAddr, remoteAddr string) (f *Forward, err error) {
+	// Listen
+	var l net.Listener
+	if l, err = net.Listen("tcp", localAddr); err != nil {
+		err = errors.Wrapf(err, "astissh: listening on %s failed", localAddr)
+		return
+	}
+
+	// Create forward
+	f = &Forward{
+		addr: l.Addr(),
+		done: make(chan bool),
+	}
+
+	// Close listener once the context is done
+	go func() {
+		<-ctx.Done()
+		l.Close()
+	}()
+
+	// Accept connections
+	go func() {
+		var wg sync.WaitGroup
+		defer close(f.done)
+		defer wg.Wait()
+		for {
+			// Accept
+			conn, err := l.Accept()
+			if err != nil {
+				if ctx.Err() == nil {
+					astilog.Error(errors.Wrapf(err, "astissh: accepting on %s failed", localAddr))
+				}
+				return
+			}
+
+			// Tunnel
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if err := m.tunnelLocal(ctx, conn, remoteAddr); err != nil {
+					astilog.Error(err)
+				}
+			}()
+		}
+	}()
+	return
+}
+
+// tunnelLocal tunnels a local connection to a remote address
+func (m *Manager) tunnelLocal(ctx context.Context, conn net.Conn, remoteAddr string) (err error) {
+	// Make sure the local connection is closed
+	defer conn.Close()
+
+	// Get client
+	var c *ssh.Client
+	if c, err = m.Client(ctx); err != nil {
+		return
+	}
+
+	// Dial remote address
+	var rconn net.Conn
+	if rconn, err = c.Dial("tcp", remoteAddr); err != nil {
+		// Connection may have been lost, reset it and try again once
+		m.reset(c)
+		if c, err = m.Client(ctx); err != nil {
+			return
+		}
+		if rconn, err = c.Dial("tcp", remoteAddr); err != nil {
+			err = errors.Wrapf(err, "astissh: dialing %s through %s failed", remoteAddr, m.addr)
+			return
+		}
+	}
+
+	// Pipe
+	defer m.use()()
+	pipe(ctx, conn, rconn)
+	return
+}
+
+// RemoteForward asks the SSH server to listen on a remote address and tunnels connections to a local address until
+// the context is done
+// If the connection is lost, the manager reconnects and the SSH server is asked to listen again.
+func (m *Manager) RemoteForward(ctx context.Context, remoteAddr, localAddr string, o ForwardOptions) (f *Forward, err error) {
+	// Default options values
+	if o.ReconnectDelay <= 0 {
+		o.ReconnectDelay = time.Second
+	}
+
+	// Listen
+	var l net.Listener
+	if l, err = m.listenRemote(ctx, remoteAddr); err != nil {
+		return
+	}
+
+	// Create forward
+	f = &Forward{
+		addr: l.Addr(),
+		done: make(chan bool),
+	}
+
+	// Make sure the connection is not considered idle
+	var release = m.use()
+
+	// Accept connections
+	go func() {
+		var wg sync.WaitGroup
+		defer close(f.done)
+		defer release()
+		defer wg.Wait()
+		for {
+			// Serve
+			m.serveRemote(ctx, l, localAddr, &wg)
+
+			// Reconnect
+			for ctx.Err() == nil {
+				astilog.Debugf("astissh: listening on %s through %s again in %s", remoteAddr, m.addr, o.ReconnectDelay)
+				if astitime.Sleep(ctx, o.ReconnectDelay) != nil {
+					return
+				}
+				var err error
+				if l, err = m.listenRemote(ctx, remoteAddr); err != nil {
+					astilog.Error(err)
+					continue
+				}
+				f.m.Lock()
+				f.addr = l.Addr()
+				f.m.Unlock()
+				break
+			}
+			if ctx.Err() != nil {
+				return
+			}
+		}
+	}()
+	return
+}
+
+// listenRemote asks the SSH server to listen on a remote address
+func (m *Manager) listenRemote(ctx context.Context, remoteAddr string) (l net.Listener, err error) {
+	// Get client
+	var c *ssh.Client
+	if c, err = m.Client(ctx); err != nil {
+		return
+	}
+
+	// Listen
+	if l, err = c.Listen("tcp", remoteAddr); err != nil {
+		// Connection may have been lost, reset it and try again once
+		m.reset(c)
+		if c, err = m.Client(ctx); err != nil {
+			return
+		}
+		if l, err = c.Listen("tcp", remoteAddr); err != nil {
+			err = errors.Wrapf(err, "astissh: listening on %s through %s failed", remoteAddr, m.addr)
+			return
+		}
+	}
+	return
+}
+
+// serveRemote tunnels connections accepted by the remote listener until it's closed or the context is done
+func (m *Manager) serveRemote(ctx context.Context, l net.Listener, localAddr string, wg *sync.WaitGroup) {
+	// Close listener once the context is done
+	var done = make(chan bool)
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		l.Close()
+	}()
+
+	// Loop
+	for {
+		// Accept
+		rconn, err := l.Accept()
+		if err != nil {
+			if ctx.Err() == nil {
+				astilog.Error(errors.Wrapf(err, "astissh: accepting on %s through %s failed", l.Addr(), m.addr))
+			}
+			return
+		}
+
+		// Tunnel
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer rconn.Close()
+			var d net.Dialer
+			conn, err := d.DialContext(ctx, "tcp", localAddr)
+			if err != nil {
+				astilog.Error(errors.Wrapf(err, "astissh: dialing %s failed", localAddr))
+				return
+			}
+			pipe(ctx, conn, rconn)
+		}()
+	}
+}
+
+// pipe copies data in both directions until one of them is over or the context is done, and closes both connections
+func pipe(ctx context.Context, a, b net.Conn) {
+	// Close connections once the context is done
+	var done = make(chan bool)
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-done:
+		}
+		a.Close()
+		b.Close()
+	}()
+
+	// Copy
+	var ch = make(chan bool, 2)
+	go func() {
+		io.Copy(a, b)
+		ch <- true
+	}()
+	go func() {
+		io.Copy(b, a)
+		ch <- true
+	}()
+	<-ch
+}
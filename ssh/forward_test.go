@@ -0,0 +1,85 @@
+package astissh_test
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/ssh"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+func newEchoServer(t *testing.T) net.Listener {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				io.Copy(conn, conn)
+			}()
+		}
+	}()
+	return l
+}
+
+func echo(t *testing.T, addr string) {
+	conn, err := net.Dial("tcp", addr)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer conn.Close()
+	_, err = conn.Write([]byte("test\n"))
+	assert.NoError(t, err)
+	l, err := bufio.NewReader(conn).ReadString('\n')
+	assert.NoError(t, err)
+	assert.Equal(t, "test\n", l)
+}
+
+func TestManager_Forward(t *testing.T) {
+	// Init
+	s := newServer(t)
+	defer s.close()
+	e := newEchoServer(t)
+	defer e.Close()
+	m := astissh.NewManager(s.addr(), astissh.ManagerOptions{
+		Config:         &ssh.ClientConfig{HostKeyCallback: ssh.InsecureIgnoreHostKey()},
+		DialRetryDelay: time.Millisecond,
+	})
+	defer m.Close()
+
+	// Local
+	ctx, cancel := context.WithCancel(context.Background())
+	f, err := m.LocalForward(ctx, "127.0.0.1:0", e.Addr().String())
+	assert.NoError(t, err)
+	echo(t, f.Addr().String())
+	s.closeConnections()
+	echo(t, f.Addr().String())
+	cancel()
+	<-f.Done()
+	_, err = net.Dial("tcp", f.Addr().String())
+	assert.Error(t, err)
+
+	// Remote
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	f, err = m.RemoteForward(ctx, "127.0.0.1:0", e.Addr().String(), astissh.ForwardOptions{ReconnectDelay: time.Millisecond})
+	assert.NoError(t, err)
+	addr := f.Addr().String()
+	echo(t, addr)
+	s.closeConnections()
+	assert.Eventually(t, func() bool { return f.Addr().String() != addr }, time.Second, time.Millisecond)
+	echo(t, f.Addr().String())
+	cancel()
+	<-f.Done()
+}
@@ -0,0 +1,376 @@
+package astissh
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/asticode/go-astilog"
+	"github.com/asticode/go-astitools/time"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+)
+
+// ErrManagerClosed is returned when using a closed manager
+var ErrManagerClosed = errors.New("astissh: manager is closed")
+
+// Manager represents an object capable of sharing a single SSH connection between multiple concurrent sessions
+// The connection is opened lazily, kept alive, reopened when it's lost and closed when it has been idle for too long.
+type Manager struct {
+	addr     string
+	c        *ssh.Client
+	cancel   context.CancelFunc
+	closed   bool
+	ctx      context.Context
+	dialErr  error
+	dialing  chan bool
+	lastUsed time.Time
+	m        sync.Mutex // Locks c, closed, dialErr, dialing, lastUsed and sessions
+	o        ManagerOptions
+	sessions int
+}
+
+// ManagerOptions represents manager options
+type ManagerOptions struct {
+	Config *ssh.ClientConfig
+	// Maximum number of attempts made to connect. Defaults to 3
+	DialMaxAttempts int
+	// Delay before reconnecting. It doubles after each failed attempt. Defaults to 1s
+	DialRetryDelay time.Duration
+	// Amount of time after which a connection without sessions is closed. 0 means connections are never closed
+	IdleTimeout time.Duration
+	// Period at which keepalive requests are sent. Defaults to 30s
+	KeepAlivePeriod time.Duration
+	// Amount of time after which a keepalive request without reply is considered failed, in which case the connection
+	// is closed and reopened on next use. Defaults to 15s
+	KeepAliveTimeout time.Duration
+}
+
+// NewManager creates a new manager
+func NewManager(addr string, o ManagerOptions) (m *Manager) {
+	// Default options values
+	if o.Config == nil {
+		o.Config = &ssh.ClientConfig{}
+	}
+	if o.DialMaxAttempts <= 0 {
+		o.DialMaxAttempts = 3
+	}
+	if o.DialRetryDelay <= 0 {
+		o.DialRetryDelay = time.Second
+	}
+	if o.KeepAlivePeriod <= 0 {
+		o.KeepAlivePeriod = 30 * time.Second
+	}
+	if o.KeepAliveTimeout <= 0 {
+		o.KeepAliveTimeout = 15 * time.Second
+	}
+
+	// Create manager
+	m = &Manager{
+		addr: addr,
+		o:    o,
+	}
+	m.ctx, m.cancel = context.WithCancel(context.Background())
+	return
+}
+
+// Client returns the shared client, connecting if needed
+// Only one connection attempt is made at a time: concurrent calls wait for its outcome.
+func (m *Manager) Client(ctx context.Context) (c *ssh.Client, err error) {
+	// Lock
+	m.m.Lock()
+
+	// Manager is closed
+	if m.closed {
+		m.m.Unlock()
+		err = ErrManagerClosed
+		return
+	}
+
+	// Client is already connected
+	if m.c != nil {
+		c = m.c
+		m.m.Unlock()
+		return
+	}
+
+	// Another call is already dialing
+	if m.dialing != nil {
+		ch := m.dialing
+		m.m.Unlock()
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			err = ctx.Err()
+			return
+		}
+		m.m.Lock()
+		defer m.m.Unlock()
+		if m.c != nil {
+			return m.c, nil
+		}
+		return nil, m.dialErr
+	}
+
+	// Dial
+	ch := make(chan bool)
+	m.dialing = ch
+	m.m.Unlock()
+	c, err = m.dialWithRetries(ctx)
+
+	// Lock
+	m.m.Lock()
+	defer m.m.Unlock()
+
+	// Store outcome
+	m.dialErr = err
+	m.dialing = nil
+	close(ch)
+	if err != nil {
+		return
+	}
+
+	// Manager has been closed in the meantime
+	if m.closed {
+		c.Close()
+		c, err = nil, ErrManagerClosed
+		m.dialErr = err
+		return
+	}
+
+	// Store client
+	m.c = c
+	m.lastUsed = time.Now()
+
+	// Keep alive
+	go m.keepAlive(c)
+	return
+}
+
+// dialWithRetries dials with a backoff until it succeeds, the max attempts are reached, the context is cancelled or
+// the manager is closed
+func (m *Manager) dialWithRetries(ctx context.Context) (c *ssh.Client, err error) {
+	// Create context
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-m.ctx.Done():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	// Loop
+	for attempt := 0; attempt < m.o.DialMaxAttempts; attempt++ {
+		// Sleep
+		if attempt > 0 {
+			var d = m.o.DialRetryDelay << uint(attempt-1)
+			astilog.Error(errors.Wrapf(err, "astissh: dialing %s failed, retrying in %s", m.addr, d))
+			if err = astitime.Sleep(ctx, d); err != nil {
+				if m.ctx.Err() != nil {
+					err = ErrManagerClosed
+				}
+				return
+			}
+		}
+
+		// Dial
+		if c, err = m.dial(ctx); err == nil {
+			return
+		}
+	}
+	return
+}
+
+// NewSession opens a new session on the shared connection
+// fn must be called once the session is over so that it's closed and the connection can be considered idle.
+func (m *Manager) NewSession(ctx context.Context) (s *ssh.Session, fn func(), err error) {
+	// Make sure the connection is not closed as idle while the session is being created
+	var release = m.use()
+	defer func() {
+		if err != nil {
+			release()
+		}
+	}()
+
+	// Get client
+	var c *ssh.Client
+	if c, err = m.Client(ctx); err != nil {
+		return
+	}
+
+	// Create session
+	if s, err = c.NewSession(); err != nil {
+		// Connection may have been lost, reset it and try again once
+		m.reset(c)
+		if c, err = m.Client(ctx); err != nil {
+			return
+		}
+		if s, err = c.NewSession(); err != nil {
+			err = errors.Wrapf(err, "astissh: creating session on %s failed", m.addr)
+			return
+		}
+	}
+
+	// Create func
+	fn = func() {
+		s.Close()
+		release()
+	}
+	return
+}
+
+// use makes sure the connection is not considered idle until the returned func is called
+func (m *Manager) use() func() {
+	m.m.Lock()
+	m.sessions++
+	m.m.Unlock()
+	var o sync.Once
+	return func() {
+		o.Do(func() {
+			m.m.Lock()
+			m.sessions--
+			m.lastUsed = time.Now()
+			m.m.Unlock()
+		})
+	}
+}
+
+// Close closes the manager and its connection
+func (m *Manager) Close() (err error) {
+	// Lock
+	m.m.Lock()
+	defer m.m.Unlock()
+
+	// Update state
+	m.closed = true
+	m.cancel()
+	if m.c == nil {
+		return
+	}
+
+	// Close client
+	if err = m.c.Close(); err != nil {
+		err = errors.Wrapf(err, "astissh: closing connection to %s failed", m.addr)
+	}
+	m.c = nil
+	return
+}
+
+// dial opens a new connection
+func (m *Manager) dial(ctx context.Context) (c *ssh.Client, err error) {
+	// Dial
+	var d = net.Dialer{Timeout: m.o.Config.Timeout}
+	var conn net.Conn
+	if conn, err = d.DialContext(ctx, "tcp", m.addr); err != nil {
+		err = errors.Wrapf(err, "astissh: dialing %s failed", m.addr)
+		return
+	}
+
+	// Handshake
+	var cc ssh.Conn
+	var chans <-chan ssh.NewChannel
+	var reqs <-chan *ssh.Request
+	if cc, chans, reqs, err = ssh.NewClientConn(conn, m.addr, m.o.Config); err != nil {
+		conn.Close()
+		err = errors.Wrapf(err, "astissh: handshaking with %s failed", m.addr)
+		return
+	}
+	c = ssh.NewClient(cc, chans, reqs)
+	return
+}
+
+// keepAlive sends keepalive requests and closes idle connections until the connection is closed
+func (m *Manager) keepAlive(c *ssh.Client) {
+	// Create ticker
+	var p = m.o.KeepAlivePeriod
+	if m.o.IdleTimeout > 0 && m.o.IdleTimeout < p {
+		p = m.o.IdleTimeout
+	}
+	var t = time.NewTicker(p)
+	defer t.Stop()
+
+	// Wait for the connection to be closed
+	var chanDone = make(chan error, 1)
+	go func() { chanDone <- c.Wait() }()
+
+	// Loop
+	var lastKeepAlive = time.Now()
+	for {
+		select {
+		case <-t.C:
+			// Close idle connection
+			if m.closeIdle(c) {
+				return
+			}
+
+			// Send keepalive
+			if time.Since(lastKeepAlive) < m.o.KeepAlivePeriod {
+				continue
+			}
+			lastKeepAlive = time.Now()
+			if err := m.sendKeepAlive(c); err != nil {
+				astilog.Error(err)
+				m.reset(c)
+				return
+			}
+		case <-chanDone:
+			m.reset(c)
+			return
+		}
+	}
+}
+
+// sendKeepAlive sends a keepalive request and waits for its reply until the keepalive timeout is reached
+func (m *Manager) sendKeepAlive(c *ssh.Client) (err error) {
+	// Send
+	var chanErr = make(chan error, 1)
+	go func() {
+		_, _, err := c.SendRequest("keepalive@openssh.com", true, nil)
+		chanErr <- err
+	}()
+
+	// Wait
+	var t = time.NewTimer(m.o.KeepAliveTimeout)
+	defer t.Stop()
+	select {
+	case err = <-chanErr:
+		if err != nil {
+			err = errors.Wrapf(err, "astissh: sending keepalive to %s failed", m.addr)
+		}
+	case <-t.C:
+		err = fmt.Errorf("astissh: keepalive to %s timed out after %s", m.addr, m.o.KeepAliveTimeout)
+	}
+	return
+}
+
+// closeIdle closes the connection if it has been idle for too long
+func (m *Manager) closeIdle(c *ssh.Client) bool {
+	// Lock
+	m.m.Lock()
+	defer m.m.Unlock()
+
+	// Connection is not idle
+	if m.o.IdleTimeout <= 0 || m.c != c || m.sessions > 0 || time.Since(m.lastUsed) < m.o.IdleTimeout {
+		return false
+	}
+
+	// Close
+	astilog.Debugf("astissh: closing idle connection to %s", m.addr)
+	c.Close()
+	m.c = nil
+	return true
+}
+
+// reset closes a connection and makes sure the next call reconnects
+func (m *Manager) reset(c *ssh.Client) {
+	m.m.Lock()
+	defer m.m.Unlock()
+	c.Close()
+	if m.c == c {
+		m.c = nil
+	}
+}
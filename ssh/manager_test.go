@@ -0,0 +1,110 @@
+package astissh_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/ssh"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestManager(t *testing.T) {
+	// Init
+	s := newServer(t)
+	defer s.close()
+	m := astissh.NewManager(s.addr(), astissh.ManagerOptions{
+		Config:      &ssh.ClientConfig{HostKeyCallback: ssh.InsecureIgnoreHostKey()},
+		IdleTimeout: 50 * time.Millisecond,
+	})
+	defer m.Close()
+
+	// Concurrent sessions share the same connection
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ss, fn, err := m.NewSession(context.Background())
+			assert.NoError(t, err)
+			defer fn()
+			b, err := ss.Output("echo test")
+			assert.NoError(t, err)
+			assert.Equal(t, "test\n", string(b))
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, s.connections())
+
+	// Idle connection is closed and reopened
+	time.Sleep(150 * time.Millisecond)
+	ss, fn, err := m.NewSession(context.Background())
+	assert.NoError(t, err)
+	assert.NoError(t, ss.Run("true"))
+	fn()
+	assert.Equal(t, 2, s.connections())
+
+	// Closed manager
+	assert.NoError(t, m.Close())
+	_, _, err = m.NewSession(context.Background())
+	assert.Equal(t, astissh.ErrManagerClosed, err)
+}
+
+func TestManager_Reconnect(t *testing.T) {
+	// Init
+	s := newServer(t)
+	defer s.close()
+	m := astissh.NewManager(s.addr(), astissh.ManagerOptions{
+		Config:           &ssh.ClientConfig{HostKeyCallback: ssh.InsecureIgnoreHostKey()},
+		KeepAlivePeriod:  20 * time.Millisecond,
+		KeepAliveTimeout: 20 * time.Millisecond,
+	})
+	defer m.Close()
+	exec := func() {
+		ss, fn, err := m.NewSession(context.Background())
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer fn()
+		assert.NoError(t, ss.Run("true"))
+	}
+	exec()
+	assert.Equal(t, 1, s.connections())
+
+	// Connection is lost
+	s.closeConnections()
+	time.Sleep(20 * time.Millisecond)
+	exec()
+	assert.Equal(t, 2, s.connections())
+
+	// Keepalive times out
+	s.setBlackhole(true)
+	time.Sleep(100 * time.Millisecond)
+	s.setBlackhole(false)
+	exec()
+	assert.Equal(t, 3, s.connections())
+}
+
+func TestManager_CloseWhileDialing(t *testing.T) {
+	// Init
+	s := newServer(t)
+	addr := s.addr()
+	s.close()
+	m := astissh.NewManager(addr, astissh.ManagerOptions{
+		Config:          &ssh.ClientConfig{HostKeyCallback: ssh.InsecureIgnoreHostKey()},
+		DialMaxAttempts: 5,
+		DialRetryDelay:  time.Second,
+	})
+
+	// Close interrupts dialing
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		m.Close()
+	}()
+	n := time.Now()
+	_, err := m.Client(context.Background())
+	assert.Equal(t, astissh.ErrManagerClosed, err)
+	assert.True(t, time.Since(n) < 500*time.Millisecond)
+}
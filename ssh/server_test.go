@@ -0,0 +1,251 @@
+package astissh_test
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"net"
+	"os/exec"
+	"strconv"
+	"sync"
+	"syscall"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// server represents a minimal SSH server executing commands with sh
+type server struct {
+	blackhole bool
+	conns     int
+	l         net.Listener
+	m         sync.Mutex // Locks blackhole, conns and open
+	open      []net.Conn
+}
+
+func newServer(t *testing.T) (s *server) {
+	// Create config
+	_, k, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sg, err := ssh.NewSignerFromKey(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &ssh.ServerConfig{NoClientAuth: true}
+	c.AddHostKey(sg)
+
+	// Listen
+	s = &server{}
+	if s.l, err = net.Listen("tcp", "127.0.0.1:0"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Accept
+	go func() {
+		for {
+			conn, err := s.l.Accept()
+			if err != nil {
+				return
+			}
+			s.m.Lock()
+			s.conns++
+			s.open = append(s.open, conn)
+			s.m.Unlock()
+			go s.handleConn(conn, c)
+		}
+	}()
+	return
+}
+
+func (s *server) addr() string {
+	return s.l.Addr().String()
+}
+
+func (s *server) close() {
+	s.l.Close()
+}
+
+// closeConnections closes all open connections
+func (s *server) closeConnections() {
+	s.m.Lock()
+	defer s.m.Unlock()
+	for _, c := range s.open {
+		c.Close()
+	}
+	s.open = nil
+}
+
+// setBlackhole sets whether global requests are left without reply
+func (s *server) setBlackhole(v bool) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.blackhole = v
+}
+
+func (s *server) connections() int {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.conns
+}
+
+func (s *server) handleConn(conn net.Conn, c *ssh.ServerConfig) {
+	sc, chans, reqs, err := ssh.NewServerConn(conn, c)
+	if err != nil {
+		return
+	}
+	defer sc.Close()
+	go func() {
+		for r := range reqs {
+			s.m.Lock()
+			bh := s.blackhole
+			s.m.Unlock()
+			if r.Type == "tcpip-forward" {
+				s.handleTCPIPForward(sc, r)
+				continue
+			}
+			if r.WantReply && !bh {
+				r.Reply(true, nil)
+			}
+		}
+	}()
+	for nc := range chans {
+		switch nc.ChannelType() {
+		case "direct-tcpip":
+			go s.handleDirectTCPIP(nc)
+		case "session":
+			ch, reqs, err := nc.Accept()
+			if err != nil {
+				continue
+			}
+			go s.handleSession(ch, reqs)
+		default:
+			nc.Reject(ssh.UnknownChannelType, "unknown channel type")
+		}
+	}
+}
+
+// handleDirectTCPIP dials the requested address and pipes it with the channel
+func (s *server) handleDirectTCPIP(nc ssh.NewChannel) {
+	var p struct {
+		Addr       string
+		Port       uint32
+		OriginAddr string
+		OriginPort uint32
+	}
+	ssh.Unmarshal(nc.ExtraData(), &p)
+	conn, err := net.Dial("tcp", net.JoinHostPort(p.Addr, strconv.Itoa(int(p.Port))))
+	if err != nil {
+		nc.Reject(ssh.ConnectionFailed, err.Error())
+		return
+	}
+	ch, reqs, err := nc.Accept()
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	go func() {
+		io.Copy(ch, conn)
+		ch.Close()
+	}()
+	io.Copy(conn, ch)
+	conn.Close()
+}
+
+// handleTCPIPForward listens on the requested address and opens a channel for each accepted connection until the
+// connection is closed
+func (s *server) handleTCPIPForward(sc *ssh.ServerConn, r *ssh.Request) {
+	var p struct {
+		Addr string
+		Port uint32
+	}
+	ssh.Unmarshal(r.Payload, &p)
+	l, err := net.Listen("tcp", net.JoinHostPort(p.Addr, strconv.Itoa(int(p.Port))))
+	if err != nil {
+		r.Reply(false, nil)
+		return
+	}
+	port := uint32(l.Addr().(*net.TCPAddr).Port)
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, port)
+	r.Reply(true, b)
+	go func() {
+		sc.Wait()
+		l.Close()
+	}()
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				ch, reqs, err := sc.OpenChannel("forwarded-tcpip", ssh.Marshal(struct {
+					Addr       string
+					Port       uint32
+					OriginAddr string
+					OriginPort uint32
+				}{p.Addr, port, "127.0.0.1", uint32(conn.RemoteAddr().(*net.TCPAddr).Port)}))
+				if err != nil {
+					return
+				}
+				go ssh.DiscardRequests(reqs)
+				go func() {
+					io.Copy(ch, conn)
+					ch.Close()
+				}()
+				io.Copy(conn, ch)
+			}()
+		}
+	}()
+}
+
+func (s *server) handleSession(ch ssh.Channel, reqs <-chan *ssh.Request) {
+	defer ch.Close()
+	for r := range reqs {
+		switch r.Type {
+		case "exec":
+			var p struct{ Command string }
+			ssh.Unmarshal(r.Payload, &p)
+			r.Reply(true, nil)
+			cmd := exec.Command("sh", "-c", p.Command)
+			cmd.Stdin = ch
+			cmd.Stdout = ch
+			cmd.Stderr = ch.Stderr()
+			var code uint32
+			if err := cmd.Run(); err != nil {
+				code = 1
+				if e, ok := err.(*exec.ExitError); ok {
+					code = uint32(e.Sys().(syscall.WaitStatus).ExitStatus())
+				}
+			}
+			b := make([]byte, 4)
+			binary.BigEndian.PutUint32(b, code)
+			ch.SendRequest("exit-status", false, b)
+			return
+		case "subsystem":
+			var p struct{ Name string }
+			ssh.Unmarshal(r.Payload, &p)
+			if p.Name != "sftp" {
+				r.Reply(false, nil)
+				continue
+			}
+			r.Reply(true, nil)
+			sv, err := sftp.NewServer(ch)
+			if err != nil {
+				return
+			}
+			sv.Serve()
+			return
+		default:
+			if r.WantReply {
+				r.Reply(r.Type == "env" || r.Type == "pty-req", nil)
+			}
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package astistat
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/asticode/go-astilog"
+	"github.com/asticode/go-astitools/time"
+	"github.com/pkg/errors"
+)
+
+// File formats
+const (
+	FileFormatCSV   = "csv"
+	FileFormatJSONL = "jsonl"
+)
+
+// fileCSVHeader is the header of CSV files
+var fileCSVHeader = []string{"time", "label", "labels", "unit", "value"}
+
+// FileExporterOptions represents file exporter options
+type FileExporterOptions struct {
+	// Defaults to astitime.RealClock
+	Clock astitime.Clock
+	// Defaults to FileFormatCSV if the path has a .csv extension, FileFormatJSONL otherwise
+	Format string
+	// Age after which the file is rotated. 0 means the file is never rotated based on its age.
+	MaxAge time.Duration
+	// Size in bytes after which the file is rotated. 0 means the file is never rotated based on its size.
+	MaxSize int64
+	// Opens the file in append mode, creating it if needed. Defaults to os.OpenFile.
+	OpenFile func(path string) (ExporterFile, error)
+	Path     string
+}
+
+// ExporterFile represents the file a file exporter writes to
+type ExporterFile interface {
+	io.WriteCloser
+	Stat() (os.FileInfo, error)
+}
+
+// openExporterFile opens a file in append mode, creating it if needed
+func openExporterFile(path string) (ExporterFile, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// FileExporter appends stats to a CSV or JSON-lines file, one row per stat and per period
+// Rotated files are renamed with the UTC time of the rotation inserted before their extension, e.g.
+// stats.20060102T150405.csv. Use HandleStats as the stater's handle func.
+type FileExporter struct {
+	c        astitime.Clock
+	closed   bool
+	f        ExporterFile
+	m        sync.Mutex // Locks closed, f, openedAt and size
+	o        FileExporterOptions
+	openedAt time.Time
+	size     int64
+}
+
+// NewFileExporter creates a new file exporter and opens its file
+func NewFileExporter(o FileExporterOptions) (e *FileExporter, err error) {
+	// Default options values
+	if o.Clock == nil {
+		o.Clock = astitime.RealClock{}
+	}
+	if o.Format == "" {
+		o.Format = FileFormatJSONL
+		if strings.ToLower(filepath.Ext(o.Path)) == ".csv" {
+			o.Format = FileFormatCSV
+		}
+	}
+	if o.Format != FileFormatCSV && o.Format != FileFormatJSONL {
+		err = errors.Errorf("astistat: unknown file format %s", o.Format)
+		return
+	}
+	if o.OpenFile == nil {
+		o.OpenFile = openExporterFile
+	}
+
+	// Create exporter
+	e = &FileExporter{
+		c: o.Clock,
+		o: o,
+	}
+
+	// Open file
+	if err = e.open(); err != nil {
+		return
+	}
+	return
+}
+
+// open opens the file in append mode, writing the CSV header if it's empty
+func (e *FileExporter) open() (err error) {
+	// Open
+	var f ExporterFile
+	if f, err = e.o.OpenFile(e.o.Path); err != nil {
+		err = errors.Wrapf(err, "astistat: opening %s failed", e.o.Path)
+		return
+	}
+
+	// Stat
+	var fi os.FileInfo
+	if fi, err = f.Stat(); err != nil {
+		f.Close()
+		err = errors.Wrapf(err, "astistat: stating %s failed", e.o.Path)
+		return
+	}
+	e.f = f
+	e.openedAt = e.c.Now()
+	e.size = fi.Size()
+
+	// Write CSV header
+	if e.o.Format == FileFormatCSV && e.size == 0 {
+		var buf = &bytes.Buffer{}
+		var w = csv.NewWriter(buf)
+		w.Write(fileCSVHeader)
+		w.Flush()
+		if err = e.write(buf.Bytes()); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// write writes to the file and updates its size
+func (e *FileExporter) write(b []byte) (err error) {
+	var n int
+	n, err = e.f.Write(b)
+	e.size += int64(n)
+	if err != nil {
+		err = errors.Wrapf(err, "astistat: writing to %s failed", e.o.Path)
+		return
+	}
+	return
+}
+
+// HandleStats writes stats and logs errors
+// It implements the StatsHandleFunc signature.
+func (e *FileExporter) HandleStats(stats []Stat) {
+	if err := e.Write(stats); err != nil {
+		astilog.Error(err)
+	}
+}
+
+// Write appends stats to the file, rotating it first if needed
+// Labeled stats are written as one row per set of labels.
+func (e *FileExporter) Write(stats []Stat) (err error) {
+	// Lock
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	// Exporter is closed
+	if e.closed {
+		err = errors.Errorf("astistat: file exporter of %s is closed", e.o.Path)
+		return
+	}
+
+	// Reopen the file if it couldn't be reopened after a failed rotation
+	if e.f == nil {
+		if err = e.open(); err != nil {
+			return
+		}
+	}
+
+	// Rotate
+	var now = e.c.Now()
+	if (e.o.MaxAge > 0 && now.Sub(e.openedAt) >= e.o.MaxAge) || (e.o.MaxSize > 0 && e.size >= e.o.MaxSize) {
+		if err = e.rotate(now); err != nil {
+			return
+		}
+	}
+
+	// Encode
+	var b []byte
+	if e.o.Format == FileFormatCSV {
+		b = fileCSVRows(ExpandStats(stats), now)
+	} else if b, err = fileJSONLines(ExpandStats(stats), now); err != nil {
+		return
+	}
+
+	// Write
+	if err = e.write(b); err != nil {
+		return
+	}
+	return
+}
+
+// rotate renames the current file and opens a new one
+func (e *FileExporter) rotate(now time.Time) (err error) {
+	// Close
+	// The file can't be used anymore even if closing it fails, in which case it's reopened on the next write
+	var f = e.f
+	e.f = nil
+	if err = f.Close(); err != nil {
+		err = errors.Wrapf(err, "astistat: closing %s failed", e.o.Path)
+		return
+	}
+
+	// Rename
+	// A counter is added if a file has already been rotated within the same second
+	var ext = filepath.Ext(e.o.Path)
+	var prefix = strings.TrimSuffix(e.o.Path, ext) + "." + now.UTC().Format("20060102T150405")
+	var dst = prefix + ext
+	for i := 1; ; i++ {
+		if _, err = os.Stat(dst); err != nil {
+			break
+		}
+		dst = fmt.Sprintf("%s-%d%s", prefix, i, ext)
+	}
+	if err = os.Rename(e.o.Path, dst); err != nil {
+		err = errors.Wrapf(err, "astistat: renaming %s into %s failed", e.o.Path, dst)
+		e.reopen()
+		return
+	}
+
+	// Open
+	if err = e.open(); err != nil {
+		// Move the rotated file back so that stats keep being appended to it
+		if errRename := os.Rename(dst, e.o.Path); errRename != nil {
+			astilog.Error(errors.Wrapf(errRename, "astistat: renaming %s into %s failed", dst, e.o.Path))
+		}
+		e.reopen()
+		return
+	}
+	return
+}
+
+// reopen reopens the original file after a failed rotation and logs errors
+// The file is nil if it can't be reopened, in which case it's reopened on the next write.
+func (e *FileExporter) reopen() {
+	if err := e.open(); err != nil {
+		e.f = nil
+		astilog.Error(err)
+	}
+}
+
+// Close closes the file
+// Writes fail once the exporter is closed, even if closing the file fails.
+func (e *FileExporter) Close() (err error) {
+	e.m.Lock()
+	defer e.m.Unlock()
+	e.closed = true
+	if e.f == nil {
+		return
+	}
+	var f = e.f
+	e.f = nil
+	if err = f.Close(); err != nil {
+		err = errors.Wrapf(err, "astistat: closing %s failed", e.o.Path)
+		return
+	}
+	return
+}
+
+// fileCSVRows encodes stats as CSV rows
+// Histogram values are written as one row per field, the field name being appended to the label.
+func fileCSVRows(stats []Stat, now time.Time) []byte {
+	var buf = &bytes.Buffer{}
+	var w = csv.NewWriter(buf)
+	var t = now.UTC().Format(time.RFC3339Nano)
+	for _, s := range stats {
+		var labels = fileCSVLabels(s.Labels)
+		if h, ok := s.Value.(HistogramValue); ok {
+			for _, f := range []struct {
+				n string
+				v interface{}
+			}{
+				{n: "count", v: h.Count},
+				{n: "max", v: h.Max},
+				{n: "mean", v: h.Mean},
+				{n: "min", v: h.Min},
+				{n: "p50", v: h.P50},
+				{n: "p90", v: h.P90},
+				{n: "p99", v: h.P99},
+			} {
+				w.Write([]string{t, s.Label + "." + f.n, labels, s.Unit, fmt.Sprint(f.v)})
+			}
+			continue
+		}
+		w.Write([]string{t, s.Label, labels, s.Unit, fmt.Sprint(s.Value)})
+	}
+	w.Flush()
+	return buf.Bytes()
+}
+
+// fileCSVLabels encodes labels as sorted k=v pairs separated by ";"
+func fileCSVLabels(labels map[string]string) string {
+	var ps []string
+	for k, v := range labels {
+		ps = append(ps, k+"="+v)
+	}
+	sort.Strings(ps)
+	return strings.Join(ps, ";")
+}
+
+// fileJSONLine represents a JSON line
+type fileJSONLine struct {
+	Label  string            `json:"label"`
+	Labels map[string]string `json:"labels,omitempty"`
+	Time   time.Time         `json:"time"`
+	Unit   string            `json:"unit,omitempty"`
+	Value  interface{}       `json:"value"`
+}
+
+// fileJSONLines encodes stats as JSON lines
+func fileJSONLines(stats []Stat, now time.Time) (b []byte, err error) {
+	var buf = &bytes.Buffer{}
+	var enc = json.NewEncoder(buf)
+	for _, s := range stats {
+		if err = enc.Encode(fileJSONLine{
+			Label:  s.Label,
+			Labels: s.Labels,
+			Time:   now.UTC(),
+			Unit:   s.Unit,
+			Value:  s.Value,
+		}); err != nil {
+			err = errors.Wrapf(err, "astistat: encoding stat %s failed", s.Label)
+			return
+		}
+	}
+	b = buf.Bytes()
+	return
+}
@@ -0,0 +1,173 @@
+package astistat_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/stat"
+	"github.com/asticode/go-astitools/time"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileExporter(t *testing.T) {
+	// Init
+	dir := t.TempDir()
+	now := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	c := astitime.NewFakeClock(now)
+	l := astistat.NewLabeledStat(astistat.NewIncrementStat)
+	l.WithLabels(map[string]string{"code": "200"})
+	stats := []astistat.Stat{
+		{StatMetadata: astistat.StatMetadata{Label: "throughput", Unit: "B/s"}, Value: 1.5},
+		{StatMetadata: astistat.StatMetadata{Label: "latency", Unit: "ms"}, Value: astistat.HistogramValue{Count: 2, P50: 3}},
+		{StatMetadata: astistat.StatMetadata{Label: "requests"}, Value: l.Value(time.Second)},
+	}
+
+	// CSV
+	p := filepath.Join(dir, "stats.csv")
+	e, err := astistat.NewFileExporter(astistat.FileExporterOptions{Clock: c, MaxAge: time.Minute, Path: p})
+	assert.NoError(t, err)
+	assert.NoError(t, e.Write(stats[:1]))
+	c.Add(time.Minute)
+	assert.NoError(t, e.Write(stats))
+	assert.NoError(t, e.Close())
+	b, err := ioutil.ReadFile(filepath.Join(dir, "stats.20200102T030505.csv"))
+	assert.NoError(t, err)
+	assert.Equal(t, "time,label,labels,unit,value\n2020-01-02T03:04:05Z,throughput,,B/s,1.5\n", string(b))
+	b, err = ioutil.ReadFile(p)
+	assert.NoError(t, err)
+	assert.Equal(t, `time,label,labels,unit,value
+2020-01-02T03:05:05Z,throughput,,B/s,1.5
+2020-01-02T03:05:05Z,latency.count,,ms,2
+2020-01-02T03:05:05Z,latency.max,,ms,0
+2020-01-02T03:05:05Z,latency.mean,,ms,0
+2020-01-02T03:05:05Z,latency.min,,ms,0
+2020-01-02T03:05:05Z,latency.p50,,ms,3
+2020-01-02T03:05:05Z,latency.p90,,ms,0
+2020-01-02T03:05:05Z,latency.p99,,ms,0
+2020-01-02T03:05:05Z,requests,code=200,,0
+`, string(b))
+
+	// JSON lines
+	p = filepath.Join(dir, "stats.jsonl")
+	e, err = astistat.NewFileExporter(astistat.FileExporterOptions{Clock: c, MaxSize: 1, Path: p})
+	assert.NoError(t, err)
+	assert.NoError(t, e.Write(stats[:1]))
+	assert.NoError(t, e.Write(stats[2:]))
+	assert.NoError(t, e.Write(stats[2:]))
+	assert.NoError(t, e.Close())
+	b, err = ioutil.ReadFile(p)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"label":"requests","labels":{"code":"200"},"time":"2020-01-02T03:05:05Z","value":0}`+"\n", string(b))
+	b, err = ioutil.ReadFile(filepath.Join(dir, "stats.20200102T030505.jsonl"))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"label":"throughput","time":"2020-01-02T03:05:05Z","unit":"B/s","value":1.5}`+"\n", string(b))
+	fs, _ := filepath.Glob(filepath.Join(dir, "stats.*.jsonl"))
+	assert.Len(t, fs, 2)
+
+	// Failed rotation
+	d := filepath.Join(dir, "rotation")
+	assert.NoError(t, os.Mkdir(d, 0755))
+	p = filepath.Join(d, "stats.jsonl")
+	e, err = astistat.NewFileExporter(astistat.FileExporterOptions{Clock: c, MaxSize: 1, Path: p})
+	assert.NoError(t, err)
+	assert.NoError(t, e.Write(stats[:1]))
+	assert.NoError(t, os.RemoveAll(d))
+	assert.Error(t, e.Write(stats[:1]))
+	assert.NoError(t, os.Mkdir(d, 0755))
+	assert.NoError(t, e.Write(stats[:1]))
+	assert.NoError(t, e.Close())
+	b, err = ioutil.ReadFile(p)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"label":"throughput","time":"2020-01-02T03:05:05Z","unit":"B/s","value":1.5}`+"\n", string(b))
+
+	// Closed
+	assert.Error(t, e.Write(stats[:1]))
+	assert.NoError(t, e.Close())
+
+	// Invalid format
+	_, err = astistat.NewFileExporter(astistat.FileExporterOptions{Format: "xml", Path: filepath.Join(dir, "stats.xml")})
+	assert.Error(t, err)
+}
+
+type mockedExporterFile struct {
+	*os.File
+	closeErr error
+	closed   bool
+	statErr  error
+}
+
+func (f *mockedExporterFile) Close() error {
+	f.closed = true
+	f.File.Close()
+	err := f.closeErr
+	f.closeErr = nil
+	return err
+}
+
+func (f *mockedExporterFile) Stat() (os.FileInfo, error) {
+	if f.statErr != nil {
+		return nil, f.statErr
+	}
+	return f.File.Stat()
+}
+
+func TestFileExporter_FailedClose(t *testing.T) {
+	// Init
+	p := filepath.Join(t.TempDir(), "stats.jsonl")
+	c := astitime.NewFakeClock(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC))
+	var files []*mockedExporterFile
+	e, err := astistat.NewFileExporter(astistat.FileExporterOptions{
+		Clock:   c,
+		MaxSize: 1,
+		OpenFile: func(path string) (astistat.ExporterFile, error) {
+			f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+			if err != nil {
+				return nil, err
+			}
+			mf := &mockedExporterFile{File: f}
+			if len(files) == 0 {
+				mf.closeErr = errors.New("test")
+			}
+			files = append(files, mf)
+			return mf, nil
+		},
+		Path: p,
+	})
+	assert.NoError(t, err)
+	defer e.Close()
+	stats := []astistat.Stat{{StatMetadata: astistat.StatMetadata{Label: "throughput"}, Value: 1.5}}
+	assert.NoError(t, e.Write(stats))
+
+	// Closing the file fails during the rotation
+	assert.Error(t, e.Write(stats))
+
+	// The file is reopened and rotated on the next write
+	assert.NoError(t, e.Write(stats))
+	b, err := ioutil.ReadFile(p)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"label":"throughput","time":"2020-01-02T03:04:05Z","value":1.5}`+"\n", string(b))
+	b, err = ioutil.ReadFile(filepath.Join(filepath.Dir(p), "stats.20200102T030405.jsonl"))
+	assert.NoError(t, err)
+	assert.Equal(t, `{"label":"throughput","time":"2020-01-02T03:04:05Z","value":1.5}`+"\n", string(b))
+}
+
+func TestFileExporter_FailedStat(t *testing.T) {
+	var f *mockedExporterFile
+	_, err := astistat.NewFileExporter(astistat.FileExporterOptions{
+		OpenFile: func(path string) (astistat.ExporterFile, error) {
+			o, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+			if err != nil {
+				return nil, err
+			}
+			f = &mockedExporterFile{File: o, statErr: errors.New("test")}
+			return f, nil
+		},
+		Path: filepath.Join(t.TempDir(), "stats.jsonl"),
+	})
+	assert.Error(t, err)
+	assert.True(t, f.closed)
+}
@@ -0,0 +1,180 @@
+package astistat
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultHistogramRelativeAccuracy represents the default relative accuracy of histogram quantiles
+const DefaultHistogramRelativeAccuracy = 0.01
+
+// HistogramStat represents a stat computing percentiles of the values added during the period
+// Values are counted in logarithmic buckets so that memory is bounded by the range of values rather than by their
+// number, and so that quantiles are computed with a relative accuracy. Only positive values are bucketed: other
+// values are counted as 0.
+type HistogramStat struct {
+	buckets  map[int]uint64
+	count    uint64
+	gamma    float64
+	logGamma float64
+	m        sync.Mutex // Locks buckets, count, max, min, sum, totalCount, totalSum and zeros
+	max      float64
+	min      float64
+	sum      float64
+	// Totals are not reset at the end of a period
+	totalCount uint64
+	totalSum   float64
+	zeros      uint64
+}
+
+// HistogramValue represents the value of a histogram stat
+// Count, Max, Mean, Min and percentiles describe the values added during the period whereas TotalCount and TotalSum
+// are cumulative.
+type HistogramValue struct {
+	Count      uint64
+	Max        float64
+	Mean       float64
+	Min        float64
+	P50        float64
+	P90        float64
+	P99        float64
+	TotalCount uint64
+	TotalSum   float64
+}
+
+// NewHistogramStat creates a new histogram stat
+// relativeAccuracy is the maximum relative error of quantiles, 0 meaning DefaultHistogramRelativeAccuracy.
+func NewHistogramStat(relativeAccuracy float64) (s *HistogramStat) {
+	if relativeAccuracy <= 0 || relativeAccuracy >= 1 {
+		relativeAccuracy = DefaultHistogramRelativeAccuracy
+	}
+	s = &HistogramStat{
+		buckets: make(map[int]uint64),
+		gamma:   (1 + relativeAccuracy) / (1 - relativeAccuracy),
+	}
+	s.logGamma = math.Log(s.gamma)
+	return
+}
+
+// Add adds a value
+func (s *HistogramStat) Add(v float64) {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	// Update summary
+	if s.count == 0 || v < s.min {
+		s.min = v
+	}
+	if s.count == 0 || v > s.max {
+		s.max = v
+	}
+	s.count++
+	s.sum += v
+	s.totalCount++
+	s.totalSum += v
+
+	// Update buckets
+	if v <= 0 {
+		s.zeros++
+		return
+	}
+	s.buckets[int(math.Ceil(math.Log(v)/s.logGamma))]++
+}
+
+// AddDuration adds a duration in seconds
+func (s *HistogramStat) AddDuration(d time.Duration) {
+	s.Add(d.Seconds())
+}
+
+// Quantile returns the estimated value of quantile q, q being between 0 and 1
+func (s *HistogramStat) Quantile(q float64) float64 {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.quantile(q, s.sortedIndexes())
+}
+
+// Start implements the StatHandler interface
+func (s *HistogramStat) Start() {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.reset()
+}
+
+// Stop implements the StatHandler interface
+func (s *HistogramStat) Stop() {}
+
+// Value implements the StatHandler interface
+// It returns a HistogramValue and resets the histogram.
+func (s *HistogramStat) Value(delta time.Duration) interface{} {
+	s.m.Lock()
+	defer s.m.Unlock()
+	var v = HistogramValue{
+		Count:      s.count,
+		Max:        s.max,
+		Min:        s.min,
+		TotalCount: s.totalCount,
+		TotalSum:   s.totalSum,
+	}
+	if s.count > 0 {
+		var idxs = s.sortedIndexes()
+		v.Mean = s.sum / float64(s.count)
+		v.P50 = s.quantile(0.5, idxs)
+		v.P90 = s.quantile(0.9, idxs)
+		v.P99 = s.quantile(0.99, idxs)
+	}
+	s.reset()
+	return v
+}
+
+// reset resets the histogram
+func (s *HistogramStat) reset() {
+	s.buckets = make(map[int]uint64)
+	s.count, s.zeros = 0, 0
+	s.max, s.min, s.sum = 0, 0, 0
+}
+
+// sortedIndexes returns the indexes of non-empty buckets in ascending order
+func (s *HistogramStat) sortedIndexes() (idxs []int) {
+	for idx := range s.buckets {
+		idxs = append(idxs, idx)
+	}
+	sort.Ints(idxs)
+	return
+}
+
+// quantile returns the estimated value of quantile q
+func (s *HistogramStat) quantile(q float64, idxs []int) float64 {
+	// No values
+	if s.count == 0 {
+		return 0
+	}
+
+	// Extremes are known
+	if q <= 0 {
+		return s.min
+	} else if q >= 1 {
+		return s.max
+	}
+
+	// Get rank
+	var rank = uint64(q * float64(s.count-1))
+
+	// Rank is in the zero bucket
+	if rank < s.zeros {
+		return 0
+	}
+
+	// Loop through buckets
+	var n = s.zeros
+	for _, idx := range idxs {
+		n += s.buckets[idx]
+		if n > rank {
+			// Estimate is the middle of the bucket, relatively, and is clamped by the extremes
+			var v = 2 * math.Pow(s.gamma, float64(idx)) / (s.gamma + 1)
+			return math.Max(s.min, math.Min(s.max, v))
+		}
+	}
+	return s.max
+}
@@ -0,0 +1,45 @@
+package astistat_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/stat"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistogramStat(t *testing.T) {
+	// Init
+	s := astistat.NewHistogramStat(0.01)
+	for i := 1; i <= 1000; i++ {
+		s.Add(float64(i))
+	}
+
+	// Quantiles are within the relative accuracy
+	for _, v := range []struct {
+		e float64
+		q float64
+	}{{e: 500, q: 0.5}, {e: 900, q: 0.9}, {e: 990, q: 0.99}} {
+		assert.True(t, math.Abs(s.Quantile(v.q)-v.e)/v.e <= 0.02, "quantile %v is %v", v.q, s.Quantile(v.q))
+	}
+
+	// Value
+	v := s.Value(time.Second).(astistat.HistogramValue)
+	assert.Equal(t, uint64(1000), v.Count)
+	assert.Equal(t, 1.0, v.Min)
+	assert.Equal(t, 1000.0, v.Max)
+	assert.Equal(t, 500.5, v.Mean)
+	assert.Equal(t, uint64(1000), v.TotalCount)
+	assert.Equal(t, 500500.0, v.TotalSum)
+	assert.True(t, math.Abs(v.P99-990)/990 <= 0.02)
+
+	// Histogram is reset but totals are kept
+	assert.Equal(t, astistat.HistogramValue{TotalCount: 1000, TotalSum: 500500}, s.Value(time.Second))
+
+	// Zeros
+	s.Add(0)
+	s.AddDuration(time.Second)
+	assert.Equal(t, 0.0, s.Quantile(0))
+	assert.Equal(t, 1.0, s.Quantile(1))
+}
@@ -0,0 +1,209 @@
+package astistat
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LabeledStat represents a stat split by labels, e.g. requests by status code
+// A handler is created for every new set of labels, and the stat value is the list of the values of all handlers.
+type LabeledStat[T StatHandler] struct {
+	fn      func() T
+	hs      map[string]*labeledHandler[T]
+	m       sync.Mutex // Locks hs and started
+	started bool
+}
+
+// labeledHandler represents a handler and its labels
+type labeledHandler[T StatHandler] struct {
+	h      T
+	labels map[string]string
+}
+
+// LabeledValue represents the value of a stat for a set of labels
+type LabeledValue struct {
+	Labels map[string]string
+	Value  interface{}
+}
+
+// LabeledValues represents the value of a labeled stat
+type LabeledValues []LabeledValue
+
+// NewLabeledStat creates a new labeled stat, fn being used to create the handler of every new set of labels
+func NewLabeledStat[T StatHandler](fn func() T) *LabeledStat[T] {
+	return &LabeledStat[T]{
+		fn: fn,
+		hs: make(map[string]*labeledHandler[T]),
+	}
+}
+
+// WithLabels returns the handler of a set of labels, creating it if needed
+func (s *LabeledStat[T]) WithLabels(labels map[string]string) T {
+	// Lock
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	// Handler exists
+	var k = labelsKey(labels)
+	if h, ok := s.hs[k]; ok {
+		return h.h
+	}
+
+	// Create handler
+	var ls = make(map[string]string, len(labels))
+	for k, v := range labels {
+		ls[k] = v
+	}
+	var h = &labeledHandler[T]{h: s.fn(), labels: ls}
+	if s.started {
+		h.h.Start()
+	}
+	s.hs[k] = h
+	return h.h
+}
+
+// Start implements the StatHandler interface
+func (s *LabeledStat[T]) Start() {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.started = true
+	for _, h := range s.hs {
+		h.h.Start()
+	}
+}
+
+// Stop implements the StatHandler interface
+func (s *LabeledStat[T]) Stop() {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.started = false
+	for _, h := range s.hs {
+		h.h.Stop()
+	}
+}
+
+// Value implements the StatHandler interface
+// It returns LabeledValues sorted by labels.
+func (s *LabeledStat[T]) Value(delta time.Duration) interface{} {
+	// Lock
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	// Get keys
+	var ks []string
+	for k := range s.hs {
+		ks = append(ks, k)
+	}
+	sort.Strings(ks)
+
+	// Get values
+	var vs = make(LabeledValues, 0, len(ks))
+	for _, k := range ks {
+		vs = append(vs, LabeledValue{Labels: s.hs[k].labels, Value: s.hs[k].h.Value(delta)})
+	}
+	return vs
+}
+
+// labelsKey returns a canonical key for a set of labels
+func labelsKey(labels map[string]string) string {
+	var ps []string
+	for k, v := range labels {
+		ps = append(ps, k+"\x00"+v)
+	}
+	sort.Strings(ps)
+	return strings.Join(ps, "\x01")
+}
+
+// ExpandStats replaces labeled stats by one stat per set of labels, merging the labels with the metadata labels
+func ExpandStats(stats []Stat) (o []Stat) {
+	for _, s := range stats {
+		vs, ok := s.Value.(LabeledValues)
+		if !ok {
+			o = append(o, s)
+			continue
+		}
+		for _, v := range vs {
+			var m = s.StatMetadata
+			m.Labels = make(map[string]string, len(s.Labels)+len(v.Labels))
+			for k, l := range s.Labels {
+				m.Labels[k] = l
+			}
+			for k, l := range v.Labels {
+				m.Labels[k] = l
+			}
+			o = append(o, Stat{StatMetadata: m, Value: v.Value})
+		}
+	}
+	return
+}
+
+// AggregateStats replaces labeled stats by a single stat whose value is the sum of the values of all sets of labels
+// Histogram values are merged: counts, means and totals are exact, and percentiles are the highest of all sets of labels,
+// which makes them upper bounds. Values that are neither numbers nor histograms are skipped.
+func AggregateStats(stats []Stat) (o []Stat) {
+	for _, s := range stats {
+		vs, ok := s.Value.(LabeledValues)
+		if !ok {
+			o = append(o, s)
+			continue
+		}
+		var h HistogramValue
+		var sum float64
+		var isHistogram bool
+		for _, v := range vs {
+			if hv, ok := v.Value.(HistogramValue); ok {
+				isHistogram = true
+				h = mergeHistogramValues(h, hv)
+			} else if f, ok := prometheusValue(v.Value); ok {
+				sum += f
+			}
+		}
+		if isHistogram {
+			o = append(o, Stat{StatMetadata: s.StatMetadata, Value: h})
+		} else {
+			o = append(o, Stat{StatMetadata: s.StatMetadata, Value: sum})
+		}
+	}
+	return
+}
+
+// mergeHistogramValues merges two histogram values
+func mergeHistogramValues(a, b HistogramValue) (o HistogramValue) {
+	// Merge values of the period
+	if a.Count == 0 {
+		o = b
+	} else if b.Count == 0 {
+		o = a
+	} else {
+		var c = a.Count + b.Count
+		o = HistogramValue{
+			Count: c,
+			Max:   maxFloat(a.Max, b.Max),
+			Mean:  (a.Mean*float64(a.Count) + b.Mean*float64(b.Count)) / float64(c),
+			Min:   minFloat(a.Min, b.Min),
+			P50:   maxFloat(a.P50, b.P50),
+			P90:   maxFloat(a.P90, b.P90),
+			P99:   maxFloat(a.P99, b.P99),
+		}
+	}
+
+	// Merge totals
+	o.TotalCount, o.TotalSum = a.TotalCount+b.TotalCount, a.TotalSum+b.TotalSum
+	return
+}
+
+func maxFloat(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minFloat(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,36 @@
+package astistat_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/stat"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabeledStat(t *testing.T) {
+	// Init
+	s := astistat.NewLabeledStat(astistat.NewCounterStat)
+	s.Start()
+	s.WithLabels(map[string]string{"code": "500"}).Add(1)
+	s.WithLabels(map[string]string{"code": "200"}).Add(2)
+	s.WithLabels(map[string]string{"code": "200"}).Add(3)
+	m := astistat.StatMetadata{Label: "requests", Labels: map[string]string{"app": "test"}}
+	stats := []astistat.Stat{{StatMetadata: m, Value: s.Value(time.Second)}}
+
+	// Value
+	assert.Equal(t, astistat.LabeledValues{
+		{Labels: map[string]string{"code": "200"}, Value: 5.0},
+		{Labels: map[string]string{"code": "500"}, Value: 1.0},
+	}, stats[0].Value)
+
+	// Expand
+	assert.Equal(t, []astistat.Stat{
+		{StatMetadata: astistat.StatMetadata{Label: "requests", Labels: map[string]string{"app": "test", "code": "200"}}, Value: 5.0},
+		{StatMetadata: astistat.StatMetadata{Label: "requests", Labels: map[string]string{"app": "test", "code": "500"}}, Value: 1.0},
+	}, astistat.ExpandStats(stats))
+
+	// Aggregate
+	assert.Equal(t, []astistat.Stat{{StatMetadata: m, Value: 6.0}}, astistat.AggregateStats(stats))
+	assert.Equal(t, "# TYPE requests gauge\nrequests{app=\"test\",code=\"200\"} 5\nrequests{app=\"test\",code=\"500\"} 1\n", string(astistat.PrometheusText(stats, "")))
+}
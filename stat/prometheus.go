@@ -0,0 +1,165 @@
+package astistat
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/asticode/go-astilog"
+	"github.com/pkg/errors"
+)
+
+// Vars
+var (
+	prometheusInvalidChars = regexp.MustCompile("[^a-zA-Z0-9_]")
+	prometheusLabelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+// PrometheusHandler returns a handler exposing the last stats computed by the stater in the Prometheus text format
+// Metric names are built from the namespace, the label and the unit. Histogram values are exposed as summaries whose
+// quantiles describe the period and whose sum and count are cumulative, and other values as gauges unless their
+// metadata specifies otherwise. Values that are not numbers are skipped.
+func PrometheusHandler(s *Stater, namespace string) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		if _, err := rw.Write(PrometheusText(s.Stats(), namespace)); err != nil {
+			astilog.Error(errors.Wrap(err, "astistat: writing prometheus metrics failed"))
+		}
+	})
+}
+
+// PrometheusText returns stats in the Prometheus text format
+// Labeled stats are exposed as one series per set of labels.
+func PrometheusText(stats []Stat, namespace string) []byte {
+	// Expand labeled stats
+	stats = ExpandStats(stats)
+
+	// Group stats by metric name since a metric can only be described once
+	var names []string
+	var groups = make(map[string][]Stat)
+	for _, s := range stats {
+		// Skip values that are not numbers
+		if _, ok := s.Value.(HistogramValue); !ok {
+			if _, ok = prometheusValue(s.Value); !ok {
+				continue
+			}
+		}
+
+		// Add to group
+		n := prometheusName(namespace, s.Label, s.Unit)
+		if _, ok := groups[n]; !ok {
+			names = append(names, n)
+		}
+		groups[n] = append(groups[n], s)
+	}
+	sort.Strings(names)
+
+	// Loop through metrics
+	var buf = &bytes.Buffer{}
+	for _, n := range names {
+		// Get type
+		var ss = groups[n]
+		var t = ss[0].Type
+		if t == "" {
+			t = StatTypeGauge
+			if _, ok := ss[0].Value.(HistogramValue); ok {
+				t = StatTypeSummary
+			}
+		}
+
+		// Write description
+		if ss[0].Description != "" {
+			fmt.Fprintf(buf, "# HELP %s %s\n", n, strings.Replace(ss[0].Description, "\n", " ", -1))
+		}
+		fmt.Fprintf(buf, "# TYPE %s %s\n", n, t)
+
+		// Write values
+		for _, s := range ss {
+			if h, ok := s.Value.(HistogramValue); ok {
+				for _, q := range []struct {
+					q string
+					v float64
+				}{{q: "0.5", v: h.P50}, {q: "0.9", v: h.P90}, {q: "0.99", v: h.P99}} {
+					fmt.Fprintf(buf, "%s%s %s\n", n, prometheusLabels(s.Labels, "quantile", q.q), prometheusFloat(q.v))
+				}
+				fmt.Fprintf(buf, "%s_sum%s %s\n", n, prometheusLabels(s.Labels), prometheusFloat(h.TotalSum))
+				fmt.Fprintf(buf, "%s_count%s %d\n", n, prometheusLabels(s.Labels), h.TotalCount)
+			} else {
+				v, _ := prometheusValue(s.Value)
+				fmt.Fprintf(buf, "%s%s %s\n", n, prometheusLabels(s.Labels), prometheusFloat(v))
+			}
+		}
+	}
+	return buf.Bytes()
+}
+
+// prometheusName builds a valid metric name
+func prometheusName(namespace, label, unit string) string {
+	var ps []string
+	for _, p := range []string{namespace, label, unit} {
+		if p = strings.Trim(prometheusInvalidChars.ReplaceAllString(strings.ToLower(p), "_"), "_"); p != "" {
+			ps = append(ps, p)
+		}
+	}
+	var n = strings.Join(ps, "_")
+	if n == "" || (n[0] >= '0' && n[0] <= '9') {
+		n = "_" + n
+	}
+	return n
+}
+
+// prometheusLabels builds the labels part of a metric line, extra being additional key/value pairs
+func prometheusLabels(labels map[string]string, extra ...string) string {
+	// Get keys
+	var ks []string
+	for k := range labels {
+		ks = append(ks, k)
+	}
+	sort.Strings(ks)
+
+	// Build pairs
+	var ps []string
+	for _, k := range ks {
+		ps = append(ps, fmt.Sprintf(`%s="%s"`, prometheusInvalidChars.ReplaceAllString(k, "_"), prometheusLabelEscaper.Replace(labels[k])))
+	}
+	for idx := 0; idx+1 < len(extra); idx += 2 {
+		ps = append(ps, fmt.Sprintf(`%s="%s"`, extra[idx], prometheusLabelEscaper.Replace(extra[idx+1])))
+	}
+	if len(ps) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(ps, ",") + "}"
+}
+
+// prometheusValue converts a stat value to a float
+func prometheusValue(i interface{}) (v float64, ok bool) {
+	ok = true
+	switch t := i.(type) {
+	case float64:
+		v = t
+	case float32:
+		v = float64(t)
+	case int:
+		v = float64(t)
+	case int64:
+		v = float64(t)
+	case uint64:
+		v = float64(t)
+	case bool:
+		if t {
+			v = 1
+		}
+	default:
+		ok = false
+	}
+	return
+}
+
+// prometheusFloat formats a float
+func prometheusFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
@@ -0,0 +1,48 @@
+package astistat_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/stat"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrometheusText(t *testing.T) {
+	assert.Equal(t, `# HELP app_latency_seconds Request "latency"
+# TYPE app_latency_seconds summary
+app_latency_seconds{route="/a",quantile="0.5"} 1
+app_latency_seconds{route="/a",quantile="0.9"} 2
+app_latency_seconds{route="/a",quantile="0.99"} 3
+app_latency_seconds_sum{route="/a"} 8
+app_latency_seconds_count{route="/a"} 4
+# TYPE app_requests counter
+app_requests{code="200"} 10
+app_requests{code="500"} 2
+# TYPE app_sound_level gauge
+app_sound_level 0.5
+`, string(astistat.PrometheusText([]astistat.Stat{
+		{StatMetadata: astistat.StatMetadata{Label: "Sound level"}, Value: 0.5},
+		{StatMetadata: astistat.StatMetadata{Label: "requests", Labels: map[string]string{"code": "200"}, Type: astistat.StatTypeCounter}, Value: 10.0},
+		{StatMetadata: astistat.StatMetadata{Label: "requests", Labels: map[string]string{"code": "500"}, Type: astistat.StatTypeCounter}, Value: int64(2)},
+		{StatMetadata: astistat.StatMetadata{Description: `Request "latency"`, Label: "latency", Labels: map[string]string{"route": "/a"}, Unit: "seconds"}, Value: astistat.HistogramValue{Count: 2, Mean: 2, P50: 1, P90: 2, P99: 3, TotalCount: 4, TotalSum: 8}},
+		{StatMetadata: astistat.StatMetadata{Label: "invalid"}, Value: "invalid"},
+	}, "app")))
+}
+
+func TestPrometheusHandler(t *testing.T) {
+	s := astistat.NewStater(10*time.Millisecond, nil)
+	c := astistat.NewCounterStat()
+	c.Add(3)
+	s.AddStat(astistat.StatMetadata{Label: "total", Type: astistat.StatTypeCounter}, c)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Start(ctx)
+	time.Sleep(50 * time.Millisecond)
+	rec := httptest.NewRecorder()
+	astistat.PrometheusHandler(s, "").ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, "# TYPE total counter\ntotal 3\n", rec.Body.String())
+}
@@ -0,0 +1,170 @@
+package astistat
+
+import (
+	"runtime"
+	"sync"
+	"time"
+)
+
+// AddRuntimeStats adds stats sampling the Go runtime (goroutines, memory and GC) to a stater, prefix being prepended
+// to their labels
+// runtime.MemStats is read once per period and shared by all runtime stats.
+func AddRuntimeStats(s *Stater, prefix string) {
+	var r = &runtimeSampler{m: &sync.Mutex{}}
+	s.AddStat(StatMetadata{
+		Description: "Number of bytes allocated per second",
+		Label:       prefix + "alloc_rate",
+		Type:        StatTypeGauge,
+		Unit:        "bytes",
+	}, runtimeStat{r: r, fn: r.allocRate})
+	s.AddStat(StatMetadata{
+		Description: "Number of completed GC cycles",
+		Label:       prefix + "gc_count",
+		Type:        StatTypeCounter,
+	}, runtimeStat{r: r, fn: r.gcCount})
+	s.AddStat(StatMetadata{
+		Description: "GC pauses during the period",
+		Label:       prefix + "gc_pause",
+		Type:        StatTypeSummary,
+		Unit:        "seconds",
+	}, runtimeStat{r: r, fn: r.gcPauses})
+	s.AddStat(StatMetadata{
+		Description: "Cumulative GC pause time",
+		Label:       prefix + "gc_pause_total",
+		Type:        StatTypeCounter,
+		Unit:        "seconds",
+	}, runtimeStat{r: r, fn: r.gcPauseTotal})
+	s.AddStat(StatMetadata{
+		Description: "Number of goroutines",
+		Label:       prefix + "goroutines",
+		Type:        StatTypeGauge,
+	}, runtimeStat{r: r, fn: r.goroutines})
+	s.AddStat(StatMetadata{
+		Description: "Number of bytes of allocated heap objects",
+		Label:       prefix + "heap_alloc",
+		Type:        StatTypeGauge,
+		Unit:        "bytes",
+	}, runtimeStat{r: r, fn: r.heapAlloc})
+	s.AddStat(StatMetadata{
+		Description: "Number of bytes in in-use heap spans",
+		Label:       prefix + "heap_inuse",
+		Type:        StatTypeGauge,
+		Unit:        "bytes",
+	}, runtimeStat{r: r, fn: r.heapInuse})
+	s.AddStat(StatMetadata{
+		Description: "Number of allocated heap objects",
+		Label:       prefix + "heap_objects",
+		Type:        StatTypeGauge,
+	}, runtimeStat{r: r, fn: r.heapObjects})
+	s.AddStat(StatMetadata{
+		Description: "Number of bytes obtained from the OS",
+		Label:       prefix + "sys",
+		Type:        StatTypeGauge,
+		Unit:        "bytes",
+	}, runtimeStat{r: r, fn: r.sys})
+}
+
+// runtimeSampler samples runtime.MemStats once per period
+type runtimeSampler struct {
+	delta     time.Duration
+	last      runtime.MemStats
+	m         *sync.Mutex // Locks all attributes
+	ms        runtime.MemStats
+	sampled   bool
+	sampledAt time.Time
+}
+
+// sample reads runtime.MemStats unless it has already been read during this period, which is assumed when the last
+// sample is more recent than half the period
+func (r *runtimeSampler) sample(delta time.Duration) {
+	var now = time.Now()
+	if r.sampled && now.Sub(r.sampledAt) < delta/2 {
+		return
+	}
+	r.last = r.ms
+	runtime.ReadMemStats(&r.ms)
+	r.delta = delta
+	if !r.sampled {
+		r.last = r.ms
+	}
+	r.sampled, r.sampledAt = true, now
+}
+
+// allocRate returns the number of bytes allocated per second since the previous sample
+func (r *runtimeSampler) allocRate(m runtime.MemStats) interface{} {
+	if r.delta <= 0 {
+		return float64(0)
+	}
+	return float64(m.TotalAlloc-r.last.TotalAlloc) / r.delta.Seconds()
+}
+
+// gcPauses returns GC pauses since the previous sample
+// Only the 256 most recent pauses are available in runtime.MemStats, whereas totals are the ones of all GC cycles.
+func (r *runtimeSampler) gcPauses(m runtime.MemStats) interface{} {
+	var h = NewHistogramStat(0)
+	var n = m.NumGC - r.last.NumGC
+	if n > uint32(len(m.PauseNs)) {
+		n = uint32(len(m.PauseNs))
+	}
+	for i := uint32(0); i < n; i++ {
+		h.AddDuration(time.Duration(m.PauseNs[(m.NumGC-i+255)%256]))
+	}
+	var v = h.Value(r.delta).(HistogramValue)
+	v.TotalCount, v.TotalSum = uint64(m.NumGC), time.Duration(m.PauseTotalNs).Seconds()
+	return v
+}
+
+// gcCount returns the number of completed GC cycles
+func (r *runtimeSampler) gcCount(m runtime.MemStats) interface{} {
+	return float64(m.NumGC)
+}
+
+// gcPauseTotal returns the cumulative GC pause time in seconds
+func (r *runtimeSampler) gcPauseTotal(m runtime.MemStats) interface{} {
+	return time.Duration(m.PauseTotalNs).Seconds()
+}
+
+// goroutines returns the number of goroutines
+func (r *runtimeSampler) goroutines(runtime.MemStats) interface{} {
+	return float64(runtime.NumGoroutine())
+}
+
+// heapAlloc returns the number of bytes of allocated heap objects
+func (r *runtimeSampler) heapAlloc(m runtime.MemStats) interface{} {
+	return float64(m.HeapAlloc)
+}
+
+// heapInuse returns the number of bytes in in-use heap spans
+func (r *runtimeSampler) heapInuse(m runtime.MemStats) interface{} {
+	return float64(m.HeapInuse)
+}
+
+// heapObjects returns the number of allocated heap objects
+func (r *runtimeSampler) heapObjects(m runtime.MemStats) interface{} {
+	return float64(m.HeapObjects)
+}
+
+// sys returns the number of bytes obtained from the OS
+func (r *runtimeSampler) sys(m runtime.MemStats) interface{} {
+	return float64(m.Sys)
+}
+
+// runtimeStat represents a runtime stat
+type runtimeStat struct {
+	fn func(m runtime.MemStats) interface{}
+	r  *runtimeSampler
+}
+
+// Start implements the StatHandler interface
+func (s runtimeStat) Start() {}
+
+// Stop implements the StatHandler interface
+func (s runtimeStat) Stop() {}
+
+// Value implements the StatHandler interface
+func (s runtimeStat) Value(delta time.Duration) interface{} {
+	s.r.m.Lock()
+	defer s.r.m.Unlock()
+	s.r.sample(delta)
+	return s.fn(s.r.ms)
+}
@@ -0,0 +1,39 @@
+package astistat_test
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/stat"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddRuntimeStats(t *testing.T) {
+	// Run stater
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var n int
+	var last []astistat.Stat
+	s := astistat.NewStater(10*time.Millisecond, func(ss []astistat.Stat) {
+		runtime.GC()
+		if n++; n == 3 {
+			last = ss
+			cancel()
+		}
+	})
+	astistat.AddRuntimeStats(s, "runtime_")
+	s.Start(ctx)
+
+	// Check stats
+	var vs = make(map[string]interface{})
+	for _, st := range last {
+		vs[st.Label] = st.Value
+	}
+	assert.Len(t, vs, 9)
+	assert.Greater(t, vs["runtime_goroutines"].(float64), float64(0))
+	assert.Greater(t, vs["runtime_heap_alloc"].(float64), float64(0))
+	assert.GreaterOrEqual(t, vs["runtime_gc_count"].(float64), float64(2))
+	assert.GreaterOrEqual(t, vs["runtime_gc_pause"].(astistat.HistogramValue).Count, uint64(1))
+}
@@ -0,0 +1,158 @@
+package astistat
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Stater represents an object capable of computing stats periodically and handling them
+type Stater struct {
+	cancel context.CancelFunc
+	fn     StatsHandleFunc
+	last   []Stat
+	m      sync.Mutex // Locks last and stats
+	period time.Duration
+	stats  []stat
+}
+
+// Stat represents a stat
+type Stat struct {
+	StatMetadata
+	Value interface{}
+}
+
+// StatsHandleFunc represents a func capable of handling stats
+type StatsHandleFunc func(stats []Stat)
+
+// Stat types
+const (
+	StatTypeCounter StatType = "counter"
+	StatTypeGauge   StatType = "gauge"
+	StatTypeSummary StatType = "summary"
+)
+
+// StatType represents a stat type, used by exporters. If empty, it's inferred from the value
+type StatType string
+
+// StatMetadata represents a stat metadata
+type StatMetadata struct {
+	Description string
+	Label       string
+	// Labels attached to the stat, e.g. by exporters
+	Labels map[string]string
+	Type   StatType
+	Unit   string
+}
+
+// StatHandler represents a stat handler
+type StatHandler interface {
+	Start()
+	Stop()
+	// Value returns the value of the stat for the period that has just ended, delta being its duration
+	Value(delta time.Duration) interface{}
+}
+
+// stat represents a registered stat
+type stat struct {
+	h StatHandler
+	m StatMetadata
+}
+
+// NewStater creates a new stater
+func NewStater(period time.Duration, fn StatsHandleFunc) *Stater {
+	return &Stater{
+		fn:     fn,
+		period: period,
+	}
+}
+
+// AddStat adds a stat
+func (s *Stater) AddStat(m StatMetadata, h StatHandler) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.stats = append(s.stats, stat{h: h, m: m})
+}
+
+// DelStats deletes stats
+func (s *Stater) DelStats(hs ...StatHandler) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	for _, h := range hs {
+		for idx := 0; idx < len(s.stats); idx++ {
+			if s.stats[idx].h == h {
+				s.stats = append(s.stats[:idx], s.stats[idx+1:]...)
+				idx--
+			}
+		}
+	}
+}
+
+// Start starts the stater
+// This is a blocking pattern that computes and handles stats every period until the context is cancelled or the
+// stater is stopped.
+func (s *Stater) Start(ctx context.Context) {
+	// Create context
+	s.m.Lock()
+	ctx, s.cancel = context.WithCancel(ctx)
+	s.m.Unlock()
+
+	// Start stats
+	s.m.Lock()
+	for _, v := range s.stats {
+		v.h.Start()
+	}
+	s.m.Unlock()
+
+	// Stop stats
+	defer func() {
+		s.m.Lock()
+		defer s.m.Unlock()
+		for _, v := range s.stats {
+			v.h.Stop()
+		}
+	}()
+
+	// Loop
+	var t = time.NewTicker(s.period)
+	defer t.Stop()
+	var last = time.Now()
+	for {
+		select {
+		case n := <-t.C:
+			// Compute stats
+			var delta = n.Sub(last)
+			last = n
+			s.m.Lock()
+			var ss = make([]Stat, 0, len(s.stats))
+			for _, v := range s.stats {
+				ss = append(ss, Stat{StatMetadata: v.m, Value: v.h.Value(delta)})
+			}
+			s.last = ss
+			s.m.Unlock()
+
+			// Handle stats
+			if s.fn != nil {
+				s.fn(ss)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Stats returns the stats computed at the end of the last period
+func (s *Stater) Stats() []Stat {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return append([]Stat{}, s.last...)
+}
+
+// Stop stops the stater
+func (s *Stater) Stop() {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.cancel != nil {
+		s.cancel()
+	}
+}
@@ -0,0 +1,41 @@
+package astistat_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/stat"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStater(t *testing.T) {
+	// Init
+	var chanStats = make(chan []astistat.Stat, 1)
+	s := astistat.NewStater(50*time.Millisecond, func(stats []astistat.Stat) {
+		select {
+		case chanStats <- stats:
+		default:
+		}
+	})
+	a := astistat.NewAverageStat()
+	i := astistat.NewIncrementStat()
+	s.AddStat(astistat.StatMetadata{Label: "average"}, a)
+	s.AddStat(astistat.StatMetadata{Label: "increment"}, i)
+	s.DelStats(i)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go s.Start(ctx)
+	time.Sleep(10 * time.Millisecond)
+	a.Add(1)
+	a.Add(3)
+
+	// Handle stats
+	select {
+	case stats := <-chanStats:
+		assert.Equal(t, []astistat.Stat{{StatMetadata: astistat.StatMetadata{Label: "average"}, Value: 2.0}}, stats)
+	case <-time.After(time.Second):
+		t.Fatal("no stats received")
+	}
+	s.Stop()
+}
@@ -0,0 +1,116 @@
+package astistat
+
+import (
+	"sync"
+	"time"
+)
+
+// IncrementStat represents a stat computing the number of increments per second
+type IncrementStat struct {
+	c int64
+	m sync.Mutex // Locks c
+}
+
+// NewIncrementStat creates a new increment stat
+func NewIncrementStat() *IncrementStat {
+	return &IncrementStat{}
+}
+
+// Add increments the stat
+func (s *IncrementStat) Add(delta int64) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.c += delta
+}
+
+// Start implements the StatHandler interface
+func (s *IncrementStat) Start() {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.c = 0
+}
+
+// Stop implements the StatHandler interface
+func (s *IncrementStat) Stop() {}
+
+// Value implements the StatHandler interface
+func (s *IncrementStat) Value(delta time.Duration) interface{} {
+	s.m.Lock()
+	defer s.m.Unlock()
+	var v = float64(s.c) / delta.Seconds()
+	s.c = 0
+	return v
+}
+
+// AverageStat represents a stat computing the average of the values added during the period
+type AverageStat struct {
+	c int64
+	m sync.Mutex // Locks c and t
+	t float64
+}
+
+// NewAverageStat creates a new average stat
+func NewAverageStat() *AverageStat {
+	return &AverageStat{}
+}
+
+// Add adds a value
+func (s *AverageStat) Add(v float64) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.c++
+	s.t += v
+}
+
+// Start implements the StatHandler interface
+func (s *AverageStat) Start() {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.c, s.t = 0, 0
+}
+
+// Stop implements the StatHandler interface
+func (s *AverageStat) Stop() {}
+
+// Value implements the StatHandler interface
+func (s *AverageStat) Value(delta time.Duration) interface{} {
+	s.m.Lock()
+	defer s.m.Unlock()
+	var v float64
+	if s.c > 0 {
+		v = s.t / float64(s.c)
+	}
+	s.c, s.t = 0, 0
+	return v
+}
+
+// CounterStat represents a stat computing a total that never resets, such as a number of requests since startup
+type CounterStat struct {
+	c float64
+	m sync.Mutex // Locks c
+}
+
+// NewCounterStat creates a new counter stat
+func NewCounterStat() *CounterStat {
+	return &CounterStat{}
+}
+
+// Add increments the counter
+func (s *CounterStat) Add(delta float64) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.c += delta
+}
+
+// Start implements the StatHandler interface
+func (s *CounterStat) Start() {}
+
+// Stop implements the StatHandler interface
+func (s *CounterStat) Stop() {}
+
+// Value implements the StatHandler interface
+func (s *CounterStat) Value(delta time.Duration) interface{} {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.c
+}
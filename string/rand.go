@@ -1,8 +1,13 @@
 package astistring
 
 import (
-	"math/rand"
+	"crypto/rand"
+	"io"
+	mathrand "math/rand"
+	"sync"
 	"time"
+
+	"github.com/asticode/go-astitools/time"
 )
 
 const (
@@ -12,16 +17,85 @@ const (
 	letterIdxMax  = 63 / letterIdxBits   // # of letter indices fitting in 63 bits
 )
 
-var src = rand.NewSource(time.Now().UnixNano())
+var (
+	defaultRand  = NewRand(RandOptions{})
+	mDefaultRand = &sync.Mutex{} // Locks defaultRand
+)
+
+// RandOptions represents rand options
+type RandOptions struct {
+	// Clock is used by time-ordered UUIDs. Default is the real clock.
+	Clock astitime.Clock
+	// Source makes the rand deterministic, which is useful for reproducible tests. Default is a time seeded
+	// source for random strings and crypto/rand for UUIDs.
+	Source mathrand.Source
+}
+
+// Rand generates random strings and UUIDs
+type Rand struct {
+	c      astitime.Clock
+	lastMs int64
+	m      *sync.Mutex // Locks all attributes
+	r      io.Reader
+	seq    uint16
+	src    mathrand.Source
+}
+
+// NewRand creates a new rand
+func NewRand(o RandOptions) (r *Rand) {
+	// Create rand
+	r = &Rand{
+		c:   o.Clock,
+		m:   &sync.Mutex{},
+		r:   rand.Reader,
+		src: o.Source,
+	}
+
+	// Default options values
+	if r.c == nil {
+		r.c = astitime.RealClock{}
+	}
+	if r.src == nil {
+		r.src = mathrand.NewSource(time.Now().UnixNano())
+	} else {
+		r.r = mathrand.New(r.src)
+	}
+	return
+}
+
+// SetRandSource sets the source of the default rand used by package level funcs
+// A nil source restores the default behavior.
+func SetRandSource(s mathrand.Source) {
+	mDefaultRand.Lock()
+	defer mDefaultRand.Unlock()
+	defaultRand = NewRand(RandOptions{Source: s})
+}
+
+// getDefaultRand returns the default rand
+func getDefaultRand() *Rand {
+	mDefaultRand.Lock()
+	defer mDefaultRand.Unlock()
+	return defaultRand
+}
+
+// RandomString generates a random string using the default rand
+// It uses math/rand and must not be used for secrets, see SecureRandomString.
+func RandomString(n int) string {
+	return getDefaultRand().RandomString(n)
+}
 
 // RandomString generates a random string
 // https://stackoverflow.com/questions/22892120/how-to-generate-a-random-string-of-a-fixed-length-in-golang
-func RandomString(n int) string {
+func (r *Rand) RandomString(n int) string {
+	// Lock
+	r.m.Lock()
+	defer r.m.Unlock()
+
 	b := make([]byte, n)
 	// A src.Int63() generates 63 random bits, enough for letterIdxMax characters!
-	for i, cache, remain := n-1, src.Int63(), letterIdxMax; i >= 0; {
+	for i, cache, remain := n-1, r.src.Int63(), letterIdxMax; i >= 0; {
 		if remain == 0 {
-			cache, remain = src.Int63(), letterIdxMax
+			cache, remain = r.src.Int63(), letterIdxMax
 		}
 		if idx := int(cache & letterIdxMask); idx < len(letterBytes) {
 			b[i] = letterBytes[idx]
@@ -0,0 +1,41 @@
+package astistring
+
+import (
+	"crypto/rand"
+	"math/big"
+
+	"github.com/pkg/errors"
+)
+
+// Alphabets
+const (
+	AlphabetAlphanumeric = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	AlphabetHex          = "0123456789abcdef"
+	AlphabetURLSafe      = AlphabetAlphanumeric + "-_"
+)
+
+// SecureRandomString generates a random string of n characters using crypto/rand, which makes it suitable for
+// secrets such as tokens
+// Characters are picked uniformly from the alphabet, which defaults to AlphabetAlphanumeric and may contain any
+// unicode character.
+func SecureRandomString(n int, alphabet string) (s string, err error) {
+	// Default alphabet
+	if alphabet == "" {
+		alphabet = AlphabetAlphanumeric
+	}
+	var rs = []rune(alphabet)
+	var max = big.NewInt(int64(len(rs)))
+
+	// Pick characters
+	var o = make([]rune, n)
+	for i := range o {
+		var idx *big.Int
+		if idx, err = rand.Int(rand.Reader, max); err != nil {
+			err = errors.Wrap(err, "astistring: generating random int failed")
+			return
+		}
+		o[i] = rs[idx.Int64()]
+	}
+	s = string(o)
+	return
+}
@@ -0,0 +1,78 @@
+package astistring
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// transliterations represents characters that don't decompose into an ASCII base character
+var transliterations = map[rune]string{
+	'æ': "ae", 'Æ': "ae", 'ð': "d", 'Ð': "d", 'đ': "d", 'Đ': "d", 'ł': "l", 'Ł': "l", 'œ': "oe", 'Œ': "oe",
+	'ø': "o", 'Ø': "o", 'ß': "ss", 'þ': "th", 'Þ': "th",
+}
+
+// Slugify converts a string to a lowercase ASCII slug made of letters, digits and dashes
+// Accented characters are transliterated to their base character, e.g. "Crème brûlée" becomes "creme-brulee".
+func Slugify(s string) string {
+	// Remove diacritics
+	if v, _, err := transform.String(transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC), s); err == nil {
+		s = v
+	}
+
+	// Loop through runes
+	var b strings.Builder
+	var dash bool
+	for _, r := range s {
+		// Transliterate
+		var v = string(unicode.ToLower(r))
+		if t, ok := transliterations[r]; ok {
+			v = t
+		}
+
+		// Letters and digits are kept, other characters are replaced by a single dash
+		for _, c := range v {
+			if c < utf8.RuneSelf && (unicode.IsLetter(c) || unicode.IsDigit(c)) {
+				if dash && b.Len() > 0 {
+					b.WriteByte('-')
+				}
+				dash = false
+				b.WriteRune(c)
+			} else {
+				dash = true
+			}
+		}
+	}
+	return b.String()
+}
+
+// Truncate truncates a string to at most max runes, ellipsis included, cutting at the last word break if any
+func Truncate(s string, max int, ellipsis string) string {
+	// Nothing to truncate
+	if utf8.RuneCountInString(s) <= max {
+		return s
+	}
+
+	// Ellipsis doesn't fit
+	var rs = []rune(s)
+	var el = []rune(ellipsis)
+	if max <= len(el) {
+		return string(el[:max])
+	}
+
+	// Cut at the last word break
+	var cut = rs[:max-len(el)]
+	if !unicode.IsSpace(rs[len(cut)]) {
+		for i := len(cut) - 1; i > 0; i-- {
+			if unicode.IsSpace(cut[i]) {
+				cut = cut[:i]
+				break
+			}
+		}
+	}
+	return strings.TrimRightFunc(string(cut), unicode.IsSpace) + ellipsis
+}
@@ -0,0 +1,34 @@
+package astistring_test
+
+import (
+	"testing"
+
+	"github.com/asticode/go-astitools/string"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlugify(t *testing.T) {
+	assert.Equal(t, "creme-brulee", astistring.Slugify("Crème brûlée"))
+	assert.Equal(t, "strasse-aeroskobing-lodz", astistring.Slugify("  Straße / Ærøskøbing -- Łódź!  "))
+	assert.Equal(t, "hello-world-2019", astistring.Slugify("Hello, World! 2019"))
+	assert.Equal(t, "", astistring.Slugify("日本"))
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "short", astistring.Truncate("short", 10, "…"))
+	assert.Equal(t, "hello…", astistring.Truncate("hello world", 8, "…"))
+	assert.Equal(t, "hello…", astistring.Truncate("hello world", 7, "…"))
+	assert.Equal(t, "helloworl…", astistring.Truncate("helloworldagain", 10, "…"))
+	assert.Equal(t, "héllo…", astistring.Truncate("héllo wörld", 9, "…"))
+	assert.Equal(t, "héllö…", astistring.Truncate("héllöwörld", 6, "…"))
+	assert.Equal(t, "..", astistring.Truncate("hello", 2, "..."))
+}
+
+func TestSecureRandomString(t *testing.T) {
+	s, err := astistring.SecureRandomString(32, "")
+	assert.NoError(t, err)
+	assert.Len(t, s, 32)
+	s, err = astistring.SecureRandomString(10, "é")
+	assert.NoError(t, err)
+	assert.Equal(t, "éééééééééé", s)
+}
@@ -0,0 +1,129 @@
+package astistring
+
+import (
+	"encoding/hex"
+	"io"
+
+	"github.com/pkg/errors"
+)
+
+// UUID represents an RFC 9562 UUID
+type UUID [16]byte
+
+// ParseUUID parses a UUID in its canonical "xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx" form
+func ParseUUID(s string) (u UUID, err error) {
+	// Check format
+	if len(s) != 36 || s[8] != '-' || s[13] != '-' || s[18] != '-' || s[23] != '-' {
+		err = errors.Errorf("astistring: invalid uuid %s", s)
+		return
+	}
+
+	// Decode
+	var b = []byte(s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:])
+	if _, err = hex.Decode(u[:], b); err != nil {
+		err = errors.Wrapf(err, "astistring: decoding uuid %s failed", s)
+		return
+	}
+	return
+}
+
+// String implements the fmt.Stringer interface
+func (u UUID) String() string {
+	var b = make([]byte, 36)
+	hex.Encode(b[0:8], u[0:4])
+	b[8] = '-'
+	hex.Encode(b[9:13], u[4:6])
+	b[13] = '-'
+	hex.Encode(b[14:18], u[6:8])
+	b[18] = '-'
+	hex.Encode(b[19:23], u[8:10])
+	b[23] = '-'
+	hex.Encode(b[24:], u[10:])
+	return string(b)
+}
+
+// Version returns the UUID version
+func (u UUID) Version() int {
+	return int(u[6] >> 4)
+}
+
+// MarshalText implements the TextMarshaler interface
+func (u UUID) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+// UnmarshalText implements the TextUnmarshaler interface
+func (u *UUID) UnmarshalText(text []byte) (err error) {
+	*u, err = ParseUUID(string(text))
+	return
+}
+
+// setVersion sets the version and the RFC 9562 variant
+func (u *UUID) setVersion(v byte) {
+	u[6] = u[6]&0x0f | v<<4
+	u[8] = u[8]&0x3f | 0x80
+}
+
+// UUIDv4 generates a random UUID using the default rand
+func UUIDv4() UUID {
+	return getDefaultRand().UUIDv4()
+}
+
+// UUIDv7 generates a time-ordered UUID using the default rand
+func UUIDv7() UUID {
+	return getDefaultRand().UUIDv7()
+}
+
+// UUIDv4 generates a random UUID
+func (r *Rand) UUIDv4() (u UUID) {
+	// Lock
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	// Generate
+	r.read(u[:])
+	u.setVersion(4)
+	return
+}
+
+// UUIDv7 generates a time-ordered UUID
+// UUIDs generated by the same rand are strictly increasing: within the same millisecond, the 12 bits following the
+// timestamp are used as a counter.
+func (r *Rand) UUIDv7() (u UUID) {
+	// Lock
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	// Random bits
+	r.read(u[6:])
+
+	// Get timestamp and sequence
+	var ms = r.c.Now().UnixMilli()
+	if ms > r.lastMs {
+		r.lastMs = ms
+		r.seq = (uint16(u[6])<<8 | uint16(u[7])) & 0x7ff
+	} else if r.seq++; r.seq > 0xfff {
+		r.lastMs++
+		r.seq = 0
+	}
+
+	// Set timestamp and sequence
+	u[0] = byte(r.lastMs >> 40)
+	u[1] = byte(r.lastMs >> 32)
+	u[2] = byte(r.lastMs >> 24)
+	u[3] = byte(r.lastMs >> 16)
+	u[4] = byte(r.lastMs >> 8)
+	u[5] = byte(r.lastMs)
+	u[6] = byte(r.seq >> 8)
+	u[7] = byte(r.seq)
+	u.setVersion(7)
+	return
+}
+
+// read fills b with random bytes
+func (r *Rand) read(b []byte) {
+	if _, err := io.ReadFull(r.r, b); err != nil {
+		// crypto/rand and math/rand readers never fail
+		panic(errors.Wrap(err, "astistring: reading random bytes failed"))
+	}
+}
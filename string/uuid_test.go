@@ -0,0 +1,49 @@
+package astistring_test
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/string"
+	"github.com/asticode/go-astitools/time"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUUID(t *testing.T) {
+	// Deterministic
+	var r1 = astistring.NewRand(astistring.RandOptions{Source: rand.NewSource(1)})
+	var r2 = astistring.NewRand(astistring.RandOptions{Source: rand.NewSource(1)})
+	assert.Equal(t, r1.RandomString(10), r2.RandomString(10))
+	var u = r1.UUIDv4()
+	assert.Equal(t, u, r2.UUIDv4())
+	assert.Equal(t, 4, u.Version())
+	assert.Equal(t, byte(0x80), u[8]&0xc0)
+
+	// Parse
+	p, err := astistring.ParseUUID(u.String())
+	assert.NoError(t, err)
+	assert.Equal(t, u, p)
+	_, err = astistring.ParseUUID("invalid")
+	assert.Error(t, err)
+
+	// Time ordered
+	var c = astitime.NewFakeClock(time.Unix(1700000000, 0))
+	var r = astistring.NewRand(astistring.RandOptions{Clock: c, Source: rand.NewSource(1)})
+	var prev string
+	for i := 0; i < 5000; i++ {
+		if i%1000 == 0 {
+			c.Add(time.Millisecond)
+		}
+		u = r.UUIDv7()
+		assert.Equal(t, 7, u.Version())
+		assert.Greater(t, u.String(), prev)
+		prev = u.String()
+	}
+	u = r.UUIDv7()
+	assert.Equal(t, "018bcfe5", u.String()[:8])
+
+	// Default rand
+	assert.NotEqual(t, astistring.UUIDv4(), astistring.UUIDv4())
+	assert.Equal(t, 7, astistring.UUIDv7().Version())
+}
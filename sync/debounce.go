@@ -0,0 +1,187 @@
+package astisync
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// EdgeOptions represents the edges a coalesced func is called on
+// When neither is set, the func is only called on the trailing edge.
+type EdgeOptions struct {
+	// Call the func on the first call of a burst
+	Leading bool
+	// Call the func once the burst is over if it has been called since the leading edge
+	Trailing bool
+}
+
+// normalize sets default options values
+func (o EdgeOptions) normalize() EdgeOptions {
+	if !o.Leading && !o.Trailing {
+		o.Trailing = true
+	}
+	return o
+}
+
+// Debouncer coalesces calls happening less than a delay apart into a single call of a func
+// The func is called either in the goroutine of the caller (leading edge) or in a timer goroutine (trailing edge).
+// Once the context is cancelled, pending calls are dropped.
+type Debouncer struct {
+	ctx      context.Context
+	deadline time.Time
+	delay    time.Duration
+	fn       func()
+	m        *sync.Mutex // Locks deadline, pending, running and t
+	o        EdgeOptions
+	pending  bool
+	running  bool
+	t        *time.Timer
+}
+
+// NewDebouncer creates a new debouncer
+func NewDebouncer(ctx context.Context, delay time.Duration, fn func(), o EdgeOptions) *Debouncer {
+	return &Debouncer{
+		ctx:   ctx,
+		delay: delay,
+		fn:    fn,
+		m:     &sync.Mutex{},
+		o:     o.normalize(),
+	}
+}
+
+// Call records a call which delays the end of the current burst
+func (d *Debouncer) Call() {
+	// Lock
+	d.m.Lock()
+
+	// Context is cancelled
+	if d.ctx.Err() != nil {
+		d.m.Unlock()
+		return
+	}
+
+	// Update state
+	var leading bool
+	if !d.running {
+		d.running = true
+		leading = d.o.Leading
+		d.pending = !leading
+	} else {
+		d.pending = d.o.Trailing
+	}
+
+	// Restart timer
+	// The same timer is reused for all calls
+	d.deadline = time.Now().Add(d.delay)
+	if d.t == nil {
+		d.t = time.AfterFunc(d.delay, d.fire)
+	} else {
+		d.t.Reset(d.delay)
+	}
+	d.m.Unlock()
+
+	// Leading edge
+	if leading {
+		d.fn()
+	}
+}
+
+// fire ends the burst if no call has happened since the timer has been started
+func (d *Debouncer) fire() {
+	// Lock
+	d.m.Lock()
+
+	// A call has happened since then, in which case the timer has been reset, or the burst is already over
+	if !d.running || time.Now().Before(d.deadline) {
+		d.m.Unlock()
+		return
+	}
+
+	// End burst
+	var call = d.pending && d.ctx.Err() == nil
+	d.pending = false
+	d.running = false
+	d.m.Unlock()
+
+	// Trailing edge
+	if call {
+		d.fn()
+	}
+}
+
+// Throttler makes sure a func is called at most once per period whatever the number of calls
+// The func is called either in the goroutine of the caller (leading edge) or in a timer goroutine (trailing edge).
+// Once the context is cancelled, pending calls are dropped.
+type Throttler struct {
+	ctx     context.Context
+	fn      func()
+	m       *sync.Mutex // Locks pending and running
+	o       EdgeOptions
+	pending bool
+	period  time.Duration
+	running bool
+}
+
+// NewThrottler creates a new throttler
+func NewThrottler(ctx context.Context, period time.Duration, fn func(), o EdgeOptions) *Throttler {
+	return &Throttler{
+		ctx:    ctx,
+		fn:     fn,
+		m:      &sync.Mutex{},
+		o:      o.normalize(),
+		period: period,
+	}
+}
+
+// Call records a call
+func (t *Throttler) Call() {
+	// Lock
+	t.m.Lock()
+
+	// Context is cancelled
+	if t.ctx.Err() != nil {
+		t.m.Unlock()
+		return
+	}
+
+	// A period is already running
+	if t.running {
+		t.pending = t.o.Trailing
+		t.m.Unlock()
+		return
+	}
+
+	// Start period
+	t.running = true
+	var leading = t.o.Leading
+	t.pending = !leading
+	time.AfterFunc(t.period, t.fire)
+	t.m.Unlock()
+
+	// Leading edge
+	if leading {
+		t.fn()
+	}
+}
+
+// fire ends the current period
+func (t *Throttler) fire() {
+	// Lock
+	t.m.Lock()
+
+	// Nothing is pending
+	if !t.pending || t.ctx.Err() != nil {
+		t.pending = false
+		t.running = false
+		t.m.Unlock()
+		return
+	}
+
+	// The trailing call starts a new period
+	t.pending = false
+	time.AfterFunc(t.period, t.fire)
+	t.m.Unlock()
+
+	// Trailing edge
+	t.fn()
+}
@@ -0,0 +1,73 @@
+package astisync_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/sync"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebouncer(t *testing.T) {
+	// Trailing
+	var count int32
+	var d = astisync.NewDebouncer(context.Background(), 20*time.Millisecond, func() { atomic.AddInt32(&count, 1) }, astisync.EdgeOptions{})
+	for i := 0; i < 5; i++ {
+		d.Call()
+		time.Sleep(5 * time.Millisecond)
+	}
+	assert.Equal(t, int32(0), atomic.LoadInt32(&count))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+
+	// Leading and trailing
+	count = 0
+	d = astisync.NewDebouncer(context.Background(), 20*time.Millisecond, func() { atomic.AddInt32(&count, 1) }, astisync.EdgeOptions{Leading: true, Trailing: true})
+	d.Call()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+	d.Call()
+	d.Call()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&count))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&count))
+
+	// Context
+	count = 0
+	ctx, cancel := context.WithCancel(context.Background())
+	d = astisync.NewDebouncer(ctx, 20*time.Millisecond, func() { atomic.AddInt32(&count, 1) }, astisync.EdgeOptions{})
+	d.Call()
+	cancel()
+	d.Call()
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&count))
+}
+
+func TestThrottler(t *testing.T) {
+	// Leading
+	var count int32
+	var th = astisync.NewThrottler(context.Background(), 30*time.Millisecond, func() { atomic.AddInt32(&count, 1) }, astisync.EdgeOptions{Leading: true})
+	for i := 0; i < 5; i++ {
+		th.Call()
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+	th.Call()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&count))
+
+	// Leading and trailing
+	count = 0
+	th = astisync.NewThrottler(context.Background(), 30*time.Millisecond, func() { atomic.AddInt32(&count, 1) }, astisync.EdgeOptions{Leading: true, Trailing: true})
+	for i := 0; i < 5; i++ {
+		th.Call()
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+	time.Sleep(45 * time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&count))
+	time.Sleep(45 * time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&count))
+}
@@ -0,0 +1,92 @@
+package astisync
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Future represents a value that will be available later, either resolved or rejected exactly once
+// It bridges callback based components to synchronous call sites.
+type Future[T any] struct {
+	done chan struct{}
+	err  error
+	o    sync.Once
+	v    T
+}
+
+// NewFuture creates a new pending future
+func NewFuture[T any]() *Future[T] {
+	return &Future[T]{done: make(chan struct{})}
+}
+
+// Go executes fn in a goroutine and returns a future settled with its result
+func Go[T any](ctx context.Context, fn func(ctx context.Context) (T, error)) (f *Future[T]) {
+	f = NewFuture[T]()
+	go func() {
+		v, err := fn(ctx)
+		f.settle(v, err)
+	}()
+	return
+}
+
+// Then returns a future settled with the result of fn once f is resolved, or with f's error if it's rejected
+func Then[T, U any](f *Future[T], fn func(v T) (U, error)) (n *Future[U]) {
+	n = NewFuture[U]()
+	go func() {
+		<-f.done
+		if f.err != nil {
+			n.Reject(f.err)
+			return
+		}
+		n.settle(fn(f.v))
+	}()
+	return
+}
+
+// settle settles the future and returns false if it was already settled
+func (f *Future[T]) settle(v T, err error) (ok bool) {
+	f.o.Do(func() {
+		f.v, f.err = v, err
+		close(f.done)
+		ok = true
+	})
+	return
+}
+
+// Resolve resolves the future with a value and returns false if it was already settled
+func (f *Future[T]) Resolve(v T) bool {
+	return f.settle(v, nil)
+}
+
+// Reject rejects the future with an error and returns false if it was already settled
+func (f *Future[T]) Reject(err error) bool {
+	var v T
+	return f.settle(v, err)
+}
+
+// Done returns a channel closed once the future is settled
+func (f *Future[T]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Get waits for the future to be settled and returns its value or error
+// If ctx is cancelled first, it returns the context error and the future is left pending.
+func (f *Future[T]) Get(ctx context.Context) (v T, err error) {
+	select {
+	case <-f.done:
+		return f.v, f.err
+	case <-ctx.Done():
+		err = errors.Wrap(ctx.Err(), "astisync: waiting for future failed")
+		return
+	}
+}
+
+// GetWithTimeout waits for the future to be settled at most during timeout
+func (f *Future[T]) GetWithTimeout(timeout time.Duration) (T, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return f.Get(ctx)
+}
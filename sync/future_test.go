@@ -0,0 +1,44 @@
+package astisync_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/sync"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFuture(t *testing.T) {
+	// Resolve
+	f := astisync.NewFuture[int]()
+	_, err := f.GetWithTimeout(time.Millisecond)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	go f.Resolve(1)
+	v, err := f.Get(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, v)
+	assert.False(t, f.Resolve(2))
+	assert.False(t, f.Reject(errors.New("test")))
+	v, _ = f.Get(context.Background())
+	assert.Equal(t, 1, v)
+
+	// Then
+	s, err := astisync.Then(f, func(v int) (string, error) { return strconv.Itoa(v + 1), nil }).Get(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "2", s)
+
+	// Reject
+	var errTest = errors.New("test")
+	r := astisync.Go(context.Background(), func(ctx context.Context) (int, error) { return 0, errTest })
+	var called bool
+	_, err = astisync.Then(r, func(v int) (int, error) {
+		called = true
+		return v, nil
+	}).Get(context.Background())
+	assert.Equal(t, errTest, err)
+	assert.False(t, called)
+	<-r.Done()
+}
@@ -0,0 +1,126 @@
+package astisync
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// GroupOptions represents group options
+type GroupOptions struct {
+	// Maximum number of funcs running at the same time. 0 means no limit.
+	MaxParallelism int
+}
+
+// Group runs funcs in parallel, with a bounded parallelism, and cancels its context as soon as one of them fails
+type Group struct {
+	cancel context.CancelFunc
+	ctx    context.Context
+	errs   []error
+	m      *sync.Mutex // Locks errs
+	sem    chan struct{}
+	wg     *sync.WaitGroup
+}
+
+// NewGroup creates a new group whose context is derived from the provided context
+func NewGroup(ctx context.Context, o GroupOptions) (g *Group) {
+	// Create group
+	g = &Group{
+		m:  &sync.Mutex{},
+		wg: &sync.WaitGroup{},
+	}
+
+	// Create context
+	g.ctx, g.cancel = context.WithCancel(ctx)
+
+	// Create semaphore
+	if o.MaxParallelism > 0 {
+		g.sem = make(chan struct{}, o.MaxParallelism)
+	}
+	return
+}
+
+// Context returns the group context, which is cancelled once a func has failed or once Wait returns
+func (g *Group) Context() context.Context {
+	return g.ctx
+}
+
+// Go runs a new func in a goroutine
+// It blocks while the maximum parallelism is reached. Funcs added once the group context is cancelled are not run.
+func (g *Group) Go(fn func(ctx context.Context) error) {
+	// Acquire slot
+	if g.sem != nil {
+		select {
+		case g.sem <- struct{}{}:
+		case <-g.ctx.Done():
+			return
+		}
+	}
+
+	// Group context is cancelled
+	if g.ctx.Err() != nil {
+		if g.sem != nil {
+			<-g.sem
+		}
+		return
+	}
+
+	// Run func
+	g.wg.Add(1)
+	go func() {
+		// Release slot
+		defer func() {
+			if g.sem != nil {
+				<-g.sem
+			}
+			g.wg.Done()
+		}()
+
+		// Execute func
+		if err := fn(g.ctx); err != nil {
+			g.m.Lock()
+			// Errors caused by the group cancellation are only noise
+			if len(g.errs) == 0 || err != context.Canceled {
+				g.errs = append(g.errs, err)
+			}
+			g.m.Unlock()
+			g.cancel()
+		}
+	}()
+}
+
+// Wait waits for all funcs to be done and returns a GroupError aggregating their errors if any
+func (g *Group) Wait() (err error) {
+	// Wait
+	g.wg.Wait()
+	g.cancel()
+
+	// Process errors
+	g.m.Lock()
+	defer g.m.Unlock()
+	if len(g.errs) > 0 {
+		err = GroupError{Errors: g.errs}
+	}
+	return
+}
+
+// GroupError represents an error aggregating errors returned by group funcs, the first one being the one that
+// cancelled the group
+type GroupError struct {
+	Errors []error
+}
+
+// Error implements the error interface
+func (e GroupError) Error() string {
+	var ss []string
+	for _, err := range e.Errors {
+		ss = append(ss, err.Error())
+	}
+	return fmt.Sprintf("astisync: group failed: %s", strings.Join(ss, ", "))
+}
+
+// Unwrap allows using errors.Is and errors.As on the aggregated errors
+func (e GroupError) Unwrap() []error {
+	return e.Errors
+}
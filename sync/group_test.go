@@ -0,0 +1,56 @@
+package astisync_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/sync"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGroup(t *testing.T) {
+	// Bounded parallelism
+	var g = astisync.NewGroup(context.Background(), astisync.GroupOptions{MaxParallelism: 2})
+	var running, max int32
+	for i := 0; i < 6; i++ {
+		g.Go(func(ctx context.Context) error {
+			r := atomic.AddInt32(&running, 1)
+			for {
+				m := atomic.LoadInt32(&max)
+				if r <= m || atomic.CompareAndSwapInt32(&max, m, r) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return nil
+		})
+	}
+	assert.NoError(t, g.Wait())
+	assert.Equal(t, int32(2), max)
+
+	// First error cancellation
+	g = astisync.NewGroup(context.Background(), astisync.GroupOptions{MaxParallelism: 2})
+	var errTest = errors.New("test")
+	var count int32
+	g.Go(func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	g.Go(func(ctx context.Context) error { return errTest })
+	for i := 0; i < 3; i++ {
+		g.Go(func(ctx context.Context) error {
+			atomic.AddInt32(&count, 1)
+			return nil
+		})
+	}
+	err := g.Wait()
+	assert.Error(t, err)
+	assert.Equal(t, astisync.GroupError{Errors: []error{errTest}}, err)
+	assert.True(t, errors.Is(err, errTest))
+	assert.Equal(t, int32(0), count)
+	assert.Error(t, g.Context().Err())
+}
@@ -0,0 +1,236 @@
+package astisync
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/asticode/go-astitools/stat"
+)
+
+// LRU eviction reasons
+const (
+	LRUEvictionReasonDeleted LRUEvictionReason = "deleted"
+	LRUEvictionReasonExpired LRUEvictionReason = "expired"
+	LRUEvictionReasonSize    LRUEvictionReason = "size"
+)
+
+// LRUEvictionReason represents the reason why an entry has been evicted
+type LRUEvictionReason string
+
+// LRUOptions represents LRU options
+type LRUOptions[K comparable, V any] struct {
+	// Maximum total size of the entries. 0 means no limit.
+	MaxSize int64
+	// Called, without the cache being locked, every time an entry is evicted
+	OnEvict func(k K, v V, r LRUEvictionReason)
+	// Returns the size of an entry. Defaults to 1 which makes MaxSize a maximum number of entries.
+	Size func(k K, v V) int64
+	// Duration after which entries expire. 0 means entries never expire.
+	TTL time.Duration
+}
+
+// LRU represents a least-recently-used cache with size limits and optional expiration
+type LRU[K comparable, V any] struct {
+	es        map[K]*list.Element
+	evictions *astistat.CounterStat
+	hits      *astistat.CounterStat
+	l         *list.List
+	m         *sync.Mutex // Locks es, l and size
+	misses    *astistat.CounterStat
+	o         LRUOptions[K, V]
+	size      int64
+}
+
+// lruEntry represents an LRU entry
+type lruEntry[K comparable, V any] struct {
+	expireAt time.Time
+	k        K
+	size     int64
+	v        V
+}
+
+// lruEviction represents an eviction to report once the cache is unlocked
+type lruEviction[K comparable, V any] struct {
+	e *lruEntry[K, V]
+	r LRUEvictionReason
+}
+
+// NewLRU creates a new LRU
+func NewLRU[K comparable, V any](o LRUOptions[K, V]) *LRU[K, V] {
+	// Default options values
+	if o.Size == nil {
+		o.Size = func(K, V) int64 { return 1 }
+	}
+
+	// Create LRU
+	return &LRU[K, V]{
+		es:        make(map[K]*list.Element),
+		evictions: astistat.NewCounterStat(),
+		hits:      astistat.NewCounterStat(),
+		l:         list.New(),
+		m:         &sync.Mutex{},
+		misses:    astistat.NewCounterStat(),
+		o:         o,
+	}
+}
+
+// AddStats adds the LRU stats to a stater, prefix being prepended to their labels
+func (c *LRU[K, V]) AddStats(s *astistat.Stater, prefix string) {
+	s.AddStat(astistat.StatMetadata{
+		Description: "Number of cache evictions",
+		Label:       prefix + "evictions",
+		Type:        astistat.StatTypeCounter,
+	}, c.evictions)
+	s.AddStat(astistat.StatMetadata{
+		Description: "Number of cache hits",
+		Label:       prefix + "hits",
+		Type:        astistat.StatTypeCounter,
+	}, c.hits)
+	s.AddStat(astistat.StatMetadata{
+		Description: "Number of cache misses",
+		Label:       prefix + "misses",
+		Type:        astistat.StatTypeCounter,
+	}, c.misses)
+	s.AddStat(astistat.StatMetadata{
+		Description: "Total size of the cache entries",
+		Label:       prefix + "size",
+		Type:        astistat.StatTypeGauge,
+	}, lruSizeStat[K, V]{c: c})
+}
+
+// Delete deletes a key
+func (c *LRU[K, V]) Delete(k K) {
+	c.m.Lock()
+	var evs []lruEviction[K, V]
+	if e, ok := c.es[k]; ok {
+		evs = append(evs, c.remove(e, LRUEvictionReasonDeleted))
+	}
+	c.m.Unlock()
+	c.evict(evs)
+}
+
+// Get retrieves the value of a key and marks it as the most recently used
+func (c *LRU[K, V]) Get(k K) (v V, ok bool) {
+	// Lock
+	c.m.Lock()
+
+	// Get entry
+	var evs []lruEviction[K, V]
+	var e *list.Element
+	if e, ok = c.es[k]; ok {
+		if le := e.Value.(*lruEntry[K, V]); c.expired(le, time.Now()) {
+			evs = append(evs, c.remove(e, LRUEvictionReasonExpired))
+			ok = false
+		} else {
+			c.l.MoveToFront(e)
+			v = le.v
+		}
+	}
+	c.m.Unlock()
+
+	// Stats
+	if ok {
+		c.hits.Add(1)
+	} else {
+		c.misses.Add(1)
+	}
+
+	// Evict
+	c.evict(evs)
+	return
+}
+
+// Len returns the number of entries, including expired entries that have not been evicted yet
+func (c *LRU[K, V]) Len() int {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.l.Len()
+}
+
+// Set sets the value of a key, marks it as the most recently used and evicts entries if the cache is too big
+func (c *LRU[K, V]) Set(k K, v V) {
+	// Lock
+	c.m.Lock()
+
+	// Remove previous entry
+	var evs []lruEviction[K, V]
+	if e, ok := c.es[k]; ok {
+		c.l.Remove(e)
+		c.size -= e.Value.(*lruEntry[K, V]).size
+		delete(c.es, k)
+	}
+
+	// Add entry
+	var n = time.Now()
+	var le = &lruEntry[K, V]{k: k, size: c.o.Size(k, v), v: v}
+	if c.o.TTL > 0 {
+		le.expireAt = n.Add(c.o.TTL)
+	}
+	c.es[k] = c.l.PushFront(le)
+	c.size += le.size
+
+	// Evict entries
+	if c.o.MaxSize > 0 && c.size > c.o.MaxSize {
+		// Expired entries first
+		for e := c.l.Back(); e != nil; {
+			p := e.Prev()
+			if c.expired(e.Value.(*lruEntry[K, V]), n) {
+				evs = append(evs, c.remove(e, LRUEvictionReasonExpired))
+			}
+			e = p
+		}
+
+		// Least recently used entries then, the new entry being kept even if it's bigger than the max size
+		for c.size > c.o.MaxSize && c.l.Len() > 1 {
+			evs = append(evs, c.remove(c.l.Back(), LRUEvictionReasonSize))
+		}
+	}
+	c.m.Unlock()
+
+	// Evict
+	c.evict(evs)
+}
+
+// expired checks whether an entry has expired
+func (c *LRU[K, V]) expired(e *lruEntry[K, V], n time.Time) bool {
+	return !e.expireAt.IsZero() && !n.Before(e.expireAt)
+}
+
+// remove removes an element
+// Assumes the cache is locked
+func (c *LRU[K, V]) remove(e *list.Element, r LRUEvictionReason) lruEviction[K, V] {
+	var le = e.Value.(*lruEntry[K, V])
+	c.l.Remove(e)
+	c.size -= le.size
+	delete(c.es, le.k)
+	return lruEviction[K, V]{e: le, r: r}
+}
+
+// evict reports evictions
+func (c *LRU[K, V]) evict(evs []lruEviction[K, V]) {
+	for _, ev := range evs {
+		c.evictions.Add(1)
+		if c.o.OnEvict != nil {
+			c.o.OnEvict(ev.e.k, ev.e.v, ev.r)
+		}
+	}
+}
+
+// lruSizeStat represents a stat returning the size of an LRU
+type lruSizeStat[K comparable, V any] struct {
+	c *LRU[K, V]
+}
+
+// Start implements the StatHandler interface
+func (s lruSizeStat[K, V]) Start() {}
+
+// Stop implements the StatHandler interface
+func (s lruSizeStat[K, V]) Stop() {}
+
+// Value implements the StatHandler interface
+func (s lruSizeStat[K, V]) Value(delta time.Duration) interface{} {
+	s.c.m.Lock()
+	defer s.c.m.Unlock()
+	return float64(s.c.size)
+}
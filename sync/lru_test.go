@@ -0,0 +1,62 @@
+package astisync_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/stat"
+	"github.com/asticode/go-astitools/sync"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRU(t *testing.T) {
+	// Size
+	var evs []string
+	var c = astisync.NewLRU(astisync.LRUOptions[string, int]{
+		MaxSize: 2,
+		OnEvict: func(k string, v int, r astisync.LRUEvictionReason) { evs = append(evs, k+":"+string(r)) },
+	})
+	c.Set("a", 1)
+	c.Set("b", 2)
+	_, ok := c.Get("a")
+	assert.True(t, ok)
+	c.Set("c", 3)
+	assert.Equal(t, []string{"b:size"}, evs)
+	_, ok = c.Get("b")
+	assert.False(t, ok)
+	c.Delete("a")
+	assert.Equal(t, []string{"b:size", "a:deleted"}, evs)
+	assert.Equal(t, 1, c.Len())
+
+	// Stats
+	var vs = make(map[string]interface{})
+	ctx, cancel := context.WithCancel(context.Background())
+	var s = astistat.NewStater(10*time.Millisecond, func(stats []astistat.Stat) {
+		for _, st := range stats {
+			vs[st.Label] = st.Value
+		}
+		cancel()
+	})
+	c.AddStats(s, "cache_")
+	s.Start(ctx)
+	assert.Equal(t, map[string]interface{}{"cache_evictions": 2.0, "cache_hits": 1.0, "cache_misses": 1.0, "cache_size": 1.0}, vs)
+
+	// TTL and custom size
+	evs = []string{}
+	c = astisync.NewLRU(astisync.LRUOptions[string, int]{
+		MaxSize: 10,
+		OnEvict: func(k string, v int, r astisync.LRUEvictionReason) { evs = append(evs, k+":"+string(r)) },
+		Size:    func(k string, v int) int64 { return int64(v) },
+		TTL:     20 * time.Millisecond,
+	})
+	c.Set("a", 4)
+	c.Set("b", 4)
+	time.Sleep(30 * time.Millisecond)
+	c.Set("c", 4)
+	assert.Equal(t, []string{"a:expired", "b:expired"}, evs)
+	time.Sleep(30 * time.Millisecond)
+	_, ok = c.Get("c")
+	assert.False(t, ok)
+	assert.Equal(t, []string{"a:expired", "b:expired", "c:expired"}, evs)
+}
@@ -0,0 +1,81 @@
+package astisync
+
+import "sync"
+
+// Map represents a type-safe concurrent map
+type Map[K comparable, V any] struct {
+	m  *sync.RWMutex // Locks vs
+	vs map[K]V
+}
+
+// NewMap creates a new map
+func NewMap[K comparable, V any]() *Map[K, V] {
+	return &Map[K, V]{
+		m:  &sync.RWMutex{},
+		vs: make(map[K]V),
+	}
+}
+
+// Delete deletes a key
+func (m *Map[K, V]) Delete(k K) {
+	m.m.Lock()
+	defer m.m.Unlock()
+	delete(m.vs, k)
+}
+
+// Get retrieves the value of a key
+func (m *Map[K, V]) Get(k K) (v V, ok bool) {
+	m.m.RLock()
+	defer m.m.RUnlock()
+	v, ok = m.vs[k]
+	return
+}
+
+// GetOrSet retrieves the value of a key if it exists, sets it otherwise, loaded indicating whether it existed
+func (m *Map[K, V]) GetOrSet(k K, v V) (actual V, loaded bool) {
+	m.m.Lock()
+	defer m.m.Unlock()
+	if actual, loaded = m.vs[k]; loaded {
+		return
+	}
+	m.vs[k] = v
+	actual = v
+	return
+}
+
+// Keys returns the keys, in no particular order
+func (m *Map[K, V]) Keys() (ks []K) {
+	m.m.RLock()
+	defer m.m.RUnlock()
+	ks = make([]K, 0, len(m.vs))
+	for k := range m.vs {
+		ks = append(ks, k)
+	}
+	return
+}
+
+// Len returns the number of keys
+func (m *Map[K, V]) Len() int {
+	m.m.RLock()
+	defer m.m.RUnlock()
+	return len(m.vs)
+}
+
+// Range calls fn for every key/value couple until it returns false
+// The map is read locked meanwhile which means fn must not modify it.
+func (m *Map[K, V]) Range(fn func(k K, v V) bool) {
+	m.m.RLock()
+	defer m.m.RUnlock()
+	for k, v := range m.vs {
+		if !fn(k, v) {
+			return
+		}
+	}
+}
+
+// Set sets the value of a key
+func (m *Map[K, V]) Set(k K, v V) {
+	m.m.Lock()
+	defer m.m.Unlock()
+	m.vs[k] = v
+}
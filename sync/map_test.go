@@ -0,0 +1,36 @@
+package astisync_test
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/asticode/go-astitools/sync"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMap(t *testing.T) {
+	var m = astisync.NewMap[string, int]()
+	m.Set("a", 1)
+	v, ok := m.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+	v, loaded := m.GetOrSet("a", 2)
+	assert.True(t, loaded)
+	assert.Equal(t, 1, v)
+	v, loaded = m.GetOrSet("b", 2)
+	assert.False(t, loaded)
+	assert.Equal(t, 2, v)
+	ks := m.Keys()
+	sort.Strings(ks)
+	assert.Equal(t, []string{"a", "b"}, ks)
+	var c int
+	m.Range(func(k string, v int) bool {
+		c++
+		return false
+	})
+	assert.Equal(t, 1, c)
+	m.Delete("a")
+	_, ok = m.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 1, m.Len())
+}
@@ -0,0 +1,154 @@
+package astisync
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+// SingleFlightOptions represents single flight options
+type SingleFlightOptions struct {
+	// Duration during which successful results are cached. 0 means results are not cached.
+	TTL time.Duration
+}
+
+// SingleFlight deduplicates concurrent calls sharing the same key: only the first one is executed and all callers get
+// its result
+type SingleFlight[K comparable, V any] struct {
+	cache map[K]singleFlightResult[V]
+	calls map[K]*singleFlightCall[V]
+	m     *sync.Mutex // Locks cache and calls
+	o     SingleFlightOptions
+}
+
+// PanicError represents the error returned to callers when the func of a call panics
+type PanicError struct {
+	Stack []byte
+	Value interface{}
+}
+
+// Error implements the error interface
+func (e PanicError) Error() string {
+	return fmt.Sprintf("astisync: call panicked: %v", e.Value)
+}
+
+// singleFlightCall represents an in-flight call
+type singleFlightCall[V any] struct {
+	cancel  context.CancelFunc
+	done    chan struct{}
+	r       singleFlightResult[V]
+	waiters int
+}
+
+// singleFlightResult represents a call result
+type singleFlightResult[V any] struct {
+	err      error
+	expireAt time.Time
+	v        V
+}
+
+// NewSingleFlight creates a new single flight
+func NewSingleFlight[K comparable, V any](o SingleFlightOptions) *SingleFlight[K, V] {
+	return &SingleFlight[K, V]{
+		cache: make(map[K]singleFlightResult[V]),
+		calls: make(map[K]*singleFlightCall[V]),
+		m:     &sync.Mutex{},
+		o:     o,
+	}
+}
+
+// Do executes fn unless a call with the same key is in flight, or its result is cached, and returns the result,
+// shared indicating whether it has been given to several callers.
+// When ctx is cancelled, Do returns ctx.Err() right away. The context provided to fn is only cancelled once all
+// callers waiting for it have been cancelled.
+func (s *SingleFlight[K, V]) Do(ctx context.Context, k K, fn func(ctx context.Context) (V, error)) (v V, shared bool, err error) {
+	// Lock
+	s.m.Lock()
+
+	// Result is cached
+	if r, ok := s.cache[k]; ok {
+		if time.Now().Before(r.expireAt) {
+			s.m.Unlock()
+			return r.v, true, nil
+		}
+		delete(s.cache, k)
+	}
+
+	// Join or start call
+	c, ok := s.calls[k]
+	if ok {
+		shared = true
+	} else {
+		c = &singleFlightCall[V]{done: make(chan struct{})}
+		var fnCtx context.Context
+		fnCtx, c.cancel = context.WithCancel(context.Background())
+		s.calls[k] = c
+		go s.call(fnCtx, k, c, fn)
+	}
+	c.waiters++
+	s.m.Unlock()
+
+	// Wait
+	select {
+	case <-c.done:
+		s.m.Lock()
+		shared = shared || c.waiters > 1
+		s.m.Unlock()
+		return c.r.v, shared, c.r.err
+	case <-ctx.Done():
+		// Cancel the call if nobody waits for it anymore, so that next callers start a new one
+		s.m.Lock()
+		c.waiters--
+		if c.waiters == 0 {
+			c.cancel()
+			if s.calls[k] == c {
+				delete(s.calls, k)
+			}
+		}
+		s.m.Unlock()
+		err = ctx.Err()
+		return
+	}
+}
+
+// Forget removes the cached result of a key
+func (s *SingleFlight[K, V]) Forget(k K) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	delete(s.cache, k)
+}
+
+// call executes fn and stores its result
+// A panic in fn is recovered and returned to callers as a PanicError, in which case the result is not cached.
+func (s *SingleFlight[K, V]) call(ctx context.Context, k K, c *singleFlightCall[V], fn func(ctx context.Context) (V, error)) {
+	// Store result once fn is over, even if it panics
+	defer func() {
+		// Recover
+		var panicked bool
+		if v := recover(); v != nil {
+			c.r.err = PanicError{
+				Stack: debug.Stack(),
+				Value: v,
+			}
+			panicked = true
+		}
+		c.cancel()
+
+		// Store result
+		s.m.Lock()
+		if s.calls[k] == c {
+			delete(s.calls, k)
+		}
+		if !panicked && c.r.err == nil && s.o.TTL > 0 {
+			c.r.expireAt = time.Now().Add(s.o.TTL)
+			s.cache[k] = c.r
+		}
+		s.m.Unlock()
+		close(c.done)
+	}()
+
+	// Execute
+	c.r.v, c.r.err = fn(ctx)
+}
@@ -0,0 +1,73 @@
+package astisync_test
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/sync"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSingleFlight(t *testing.T) {
+	// Deduplication
+	var s = astisync.NewSingleFlight[string, int](astisync.SingleFlightOptions{TTL: 30 * time.Millisecond})
+	var count int32
+	var fn = func(ctx context.Context) (int, error) {
+		time.Sleep(10 * time.Millisecond)
+		return int(atomic.AddInt32(&count, 1)), nil
+	}
+	var wg = &sync.WaitGroup{}
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, shared, err := s.Do(context.Background(), "key", fn)
+			assert.NoError(t, err)
+			assert.True(t, shared)
+			assert.Equal(t, 1, v)
+		}()
+	}
+	wg.Wait()
+
+	// Cache
+	v, shared, err := s.Do(context.Background(), "key", fn)
+	assert.NoError(t, err)
+	assert.True(t, shared)
+	assert.Equal(t, 1, v)
+	s.Forget("key")
+	v, shared, err = s.Do(context.Background(), "key", fn)
+	assert.NoError(t, err)
+	assert.False(t, shared)
+	assert.Equal(t, 2, v)
+
+	// Cancellation
+	var fnErr = make(chan error, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	_, _, err = s.Do(ctx, "cancel", func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		fnErr <- ctx.Err()
+		return 0, ctx.Err()
+	})
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, context.Canceled, <-fnErr)
+
+	// Panic
+	var panicFn = func(ctx context.Context) (int, error) { panic("test") }
+	for i := 0; i < 2; i++ {
+		_, _, err = s.Do(context.Background(), "panic", panicFn)
+		assert.Error(t, err)
+		p, ok := err.(astisync.PanicError)
+		assert.True(t, ok)
+		assert.Equal(t, "test", p.Value)
+	}
+	v, _, err = s.Do(context.Background(), "panic", fn)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, v)
+}
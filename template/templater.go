@@ -1,6 +1,8 @@
 package astitemplate
 
 import (
+	"context"
+	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -8,52 +10,119 @@ import (
 	"sync"
 	"text/template"
 
+	"github.com/asticode/go-astilog"
+	"github.com/asticode/go-astitools/os"
 	"github.com/pkg/errors"
 )
 
 // Templater represents an object capable of storing templates
+// Layouts are parsed once and shared by all templates: a template is a clone of the layouts in which its own content
+// is parsed, which means it can override any block defined in the layouts. Layouts can themselves define blocks used
+// by other layouts, which allows nested inheritance.
 type Templater struct {
-	layouts   []string
-	m         sync.Mutex
+	base      *template.Template
+	m         sync.Mutex // Locks base and templates
+	o         TemplaterOptions
 	templates map[string]*template.Template
 }
 
+// TemplaterOptions represents templater options
+type TemplaterOptions struct {
+	// Only files with this extension are loaded. If empty, all files are loaded
+	Ext string
+	// Funcs shared by all templates
+	FuncMap template.FuncMap
+	// Directory containing layouts
+	LayoutsPath string
+	// Directory containing templates
+	TemplatesPath string
+}
+
 // NewTemplater creates a new templater
 func NewTemplater(templatesPath, layoutsPath, ext string) (t *Templater, err error) {
+	return NewTemplaterWithOptions(TemplaterOptions{
+		Ext:           ext,
+		LayoutsPath:   layoutsPath,
+		TemplatesPath: templatesPath,
+	})
+}
+
+// NewTemplaterWithOptions creates a new templater with options
+func NewTemplaterWithOptions(o TemplaterOptions) (t *Templater, err error) {
 	// Create templater
-	t = &Templater{templates: make(map[string]*template.Template)}
+	t = &Templater{
+		o:         o,
+		templates: make(map[string]*template.Template),
+	}
+
+	// Load
+	if err = t.Reload(); err != nil {
+		err = errors.Wrap(err, "astitemplate: loading templater failed")
+		return
+	}
+	return
+}
 
+// Reload reloads layouts and templates from their directories
+// Templates added manually are dropped. If reloading fails, previous templates are kept.
+func (t *Templater) Reload() (err error) {
 	// Get layouts
-	if err = filepath.Walk(layoutsPath, func(path string, info os.FileInfo, e error) (err error) {
-		// Check input error
-		if e != nil {
-			err = errors.Wrapf(e, "astitemplate: walking layouts has an input error for path %s", path)
+	var layouts []string
+	if t.o.LayoutsPath != "" {
+		if err = t.walk(t.o.LayoutsPath, func(path string) error {
+			layouts = append(layouts, path)
+			return nil
+		}); err != nil {
+			err = errors.Wrapf(err, "astitemplate: walking layouts in %s failed", t.o.LayoutsPath)
 			return
 		}
+	}
 
-		// Only process files
-		if info.IsDir() {
-			return
-		}
+	// Parse layouts
+	var base *template.Template
+	if base, err = t.parseLayouts(layouts); err != nil {
+		return
+	}
 
-		// Check extension
-		if ext != "" && filepath.Ext(path) != ext {
+	// Loop through templates
+	var templates = make(map[string]*template.Template)
+	if t.o.TemplatesPath != "" {
+		if err = t.walk(t.o.TemplatesPath, func(path string) (err error) {
+			// Read file
+			var b []byte
+			if b, err = ioutil.ReadFile(path); err != nil {
+				err = errors.Wrapf(err, "astitemplate: reading template content of %s failed", path)
+				return
+			}
+
+			// Parse template
+			// We use ToSlash to homogenize Windows path
+			var p = filepath.ToSlash(strings.TrimPrefix(path, t.o.TemplatesPath))
+			if templates[p], err = parseTemplate(base, p, string(b)); err != nil {
+				err = errors.Wrap(err, "astitemplate: adding template failed")
+				return
+			}
+			return
+		}); err != nil {
+			err = errors.Wrapf(err, "astitemplate: walking templates in %s failed", t.o.TemplatesPath)
 			return
 		}
-
-		// Append
-		t.layouts = append(t.layouts, path)
-		return
-	}); err != nil {
-		err = errors.Wrapf(err, "astitemplate: walking layouts in %s failed", layoutsPath)
-		return
 	}
 
-	// Loop through templates
-	if err = filepath.Walk(templatesPath, func(path string, info os.FileInfo, e error) (err error) {
+	// Update
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.base = base
+	t.templates = templates
+	return
+}
+
+// walk calls fn for every file of a directory matching the extension
+func (t *Templater) walk(dir string, fn func(path string) error) error {
+	return filepath.Walk(dir, func(path string, info os.FileInfo, e error) (err error) {
 		// Check input error
 		if e != nil {
-			err = errors.Wrapf(e, "astitemplate: walking templates has an input error for path %s", path)
+			err = errors.Wrapf(e, "astitemplate: walking has an input error for path %s", path)
 			return
 		}
 
@@ -63,47 +132,51 @@ func NewTemplater(templatesPath, layoutsPath, ext string) (t *Templater, err err
 		}
 
 		// Check extension
-		if ext != "" && filepath.Ext(path) != ext {
-			return
-		}
-
-		// Read file
-		var b []byte
-		if b, err = ioutil.ReadFile(path); err != nil {
-			err = errors.Wrapf(err, "astitemplate: reading template content of %s failed", path)
+		if t.o.Ext != "" && filepath.Ext(path) != t.o.Ext {
 			return
 		}
+		return fn(path)
+	})
+}
 
-		// Add template
-		// We use ToSlash to homogenize Windows path
-		if err = t.Add(filepath.ToSlash(strings.TrimPrefix(path, templatesPath)), string(b)); err != nil {
-			err = errors.Wrap(err, "astitemplate: adding template failed")
-			return
-		}
+// parseLayouts parses layouts into a base template
+func (t *Templater) parseLayouts(layouts []string) (base *template.Template, err error) {
+	base = template.New("root").Funcs(t.o.FuncMap)
+	if len(layouts) == 0 {
 		return
-	}); err != nil {
-		err = errors.Wrapf(err, "astitemplate: walking templates in %s failed", templatesPath)
+	}
+	if base, err = base.ParseFiles(layouts...); err != nil {
+		err = errors.Wrapf(err, "astitemplate: parsing layouts %s failed", strings.Join(layouts, ", "))
 		return
 	}
 	return
 }
 
-// Add adds a new template
-func (t *Templater) Add(path, content string) (err error) {
-	// Lock
-	t.m.Lock()
-	defer t.m.Unlock()
+// parseTemplate parses a template content in a clone of the base template
+func parseTemplate(base *template.Template, path, content string) (tpl *template.Template, err error) {
+	// Clone base
+	if tpl, err = base.Clone(); err != nil {
+		err = errors.Wrapf(err, "astitemplate: cloning layouts for path %s failed", path)
+		return
+	}
 
 	// Parse content
-	var tpl = template.New("root")
 	if tpl, err = tpl.Parse(content); err != nil {
 		err = errors.Wrapf(err, "astitemplate: parsing template content for path %s failed", path)
 		return
 	}
+	return
+}
 
-	// Parse files
-	if tpl, err = tpl.ParseFiles(t.layouts...); err != nil {
-		err = errors.Wrapf(err, "astitemplate: parsing layouts %s for path %s failed", strings.Join(t.layouts, ", "), path)
+// Add adds a new template
+func (t *Templater) Add(path, content string) (err error) {
+	// Lock
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	// Parse template
+	var tpl *template.Template
+	if tpl, err = parseTemplate(t.base, path, content); err != nil {
 		return
 	}
 
@@ -119,6 +192,23 @@ func (t *Templater) Del(path string) {
 	delete(t.templates, path)
 }
 
+// Execute executes a template
+func (t *Templater) Execute(w io.Writer, path string, data interface{}) (err error) {
+	// Get template
+	tpl, ok := t.Template(path)
+	if !ok {
+		err = errors.Errorf("astitemplate: template %s doesn't exist", path)
+		return
+	}
+
+	// Execute
+	if err = tpl.Execute(w, data); err != nil {
+		err = errors.Wrapf(err, "astitemplate: executing template %s failed", path)
+		return
+	}
+	return
+}
+
 // Template retrieves a templates
 func (t *Templater) Template(path string) (tpl *template.Template, ok bool) {
 	t.m.Lock()
@@ -126,3 +216,37 @@ func (t *Templater) Template(path string) (tpl *template.Template, ok bool) {
 	tpl, ok = t.templates[path]
 	return
 }
+
+// Watch reloads the templater every time a file changes in its directories, until ctx is cancelled
+// It's mostly meant for development. Reload errors are logged and don't stop the watch.
+func (t *Templater) Watch(ctx context.Context) (err error) {
+	// Create watcher
+	var w *astios.Watcher
+	var o = astios.WatcherOptions{Recursive: true}
+	if t.o.Ext != "" {
+		o.Include = []string{"*" + t.o.Ext}
+	}
+	if w, err = astios.NewWatcher(ctx, o); err != nil {
+		err = errors.Wrap(err, "astitemplate: creating watcher failed")
+		return
+	}
+
+	// Add directories
+	for _, dir := range []string{t.o.LayoutsPath, t.o.TemplatesPath} {
+		if dir == "" {
+			continue
+		}
+		if err = w.Add(dir); err != nil {
+			err = errors.Wrapf(err, "astitemplate: watching %s failed", dir)
+			return
+		}
+	}
+
+	// Loop through events
+	for range w.Events() {
+		if errReload := t.Reload(); errReload != nil {
+			astilog.Error(errors.Wrap(errReload, "astitemplate: reloading templater failed"))
+		}
+	}
+	return
+}
@@ -0,0 +1,64 @@
+package astitemplate_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/asticode/go-astitools/template"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTemplater(t *testing.T) {
+	// Write files
+	var dir = t.TempDir()
+	var files = map[string]string{
+		"layouts/base.html":   `{{ define "base" }}<title>{{ block "title" . }}default{{ end }}</title>{{ template "body" . }}{{ end }}`,
+		"layouts/body.html":   `{{ define "body" }}<body>{{ block "content" . }}{{ end }}</body>{{ end }}`,
+		"templates/page.html": `{{ template "base" . }}{{ define "title" }}{{ upper . }}{{ end }}{{ define "content" }}page{{ end }}`,
+		"templates/skip.txt":  `skip`,
+	}
+	for p, c := range files {
+		assert.NoError(t, os.MkdirAll(filepath.Dir(filepath.Join(dir, p)), 0700))
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, p), []byte(c), 0600))
+	}
+
+	// Create templater
+	tr, err := astitemplate.NewTemplaterWithOptions(astitemplate.TemplaterOptions{
+		Ext:           ".html",
+		FuncMap:       template.FuncMap{"upper": strings.ToUpper},
+		LayoutsPath:   filepath.Join(dir, "layouts"),
+		TemplatesPath: filepath.Join(dir, "templates"),
+	})
+	assert.NoError(t, err)
+	_, ok := tr.Template("/skip.txt")
+	assert.False(t, ok)
+
+	// Execute
+	var buf = &bytes.Buffer{}
+	assert.NoError(t, tr.Execute(buf, "/page.html", "title"))
+	assert.Equal(t, "<title>TITLE</title><body>page</body>", buf.String())
+	assert.Error(t, tr.Execute(buf, "/unknown.html", nil))
+
+	// Add uses the default blocks
+	assert.NoError(t, tr.Add("/added.html", `{{ template "base" . }}`))
+	buf.Reset()
+	assert.NoError(t, tr.Execute(buf, "/added.html", nil))
+	assert.Equal(t, "<title>default</title><body></body>", buf.String())
+
+	// Watch
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go tr.Watch(ctx)
+	time.Sleep(50 * time.Millisecond)
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "templates/page.html"), []byte(`{{ template "base" . }}{{ define "content" }}reloaded{{ end }}`), 0600))
+	assert.Eventually(t, func() bool {
+		buf.Reset()
+		return tr.Execute(buf, "/page.html", nil) == nil && buf.String() == "<title>default</title><body>reloaded</body>"
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,62 @@
+package astitime
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff represents a retry backoff policy
+type Backoff interface {
+	// Duration returns the amount of time to wait before the nth retry, n starting at 1
+	Duration(n int) time.Duration
+}
+
+// ConstantBackoff represents a backoff that always waits the same amount of time
+type ConstantBackoff time.Duration
+
+// Duration implements the Backoff interface
+func (b ConstantBackoff) Duration(n int) time.Duration {
+	return time.Duration(b)
+}
+
+// ExponentialBackoff represents a capped exponential backoff with jitter
+type ExponentialBackoff struct {
+	// Amount of time waited before the first retry
+	Base time.Duration
+	// Defaults to 2
+	Factor float64
+	// Fraction, between 0 and 1, of the duration that is randomized
+	Jitter float64
+	// Maximum amount of time waited before a retry. 0 means no maximum
+	Max time.Duration
+}
+
+// Duration implements the Backoff interface
+func (b ExponentialBackoff) Duration(n int) (d time.Duration) {
+	// Get factor
+	var f = b.Factor
+	if f == 0 {
+		f = 2
+	}
+
+	// Compute duration
+	var v = float64(b.Base) * math.Pow(f, float64(n-1))
+	if math.IsNaN(v) {
+		// A 0 base multiplied by an infinite factor
+		v = 0
+	} else if b.Max > 0 && v > float64(b.Max) {
+		v = float64(b.Max)
+	}
+
+	// Add jitter
+	if b.Jitter > 0 {
+		v -= v * math.Min(b.Jitter, 1) * rand.Float64()
+	}
+
+	// The duration is clamped before being converted since it may overflow, or even be +Inf
+	if v >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(v)
+}
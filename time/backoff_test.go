@@ -0,0 +1,30 @@
+package astitime_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/time"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExponentialBackoff(t *testing.T) {
+	b := astitime.ExponentialBackoff{Base: time.Second, Max: 5 * time.Second}
+	assert.Equal(t, time.Second, b.Duration(1))
+	assert.Equal(t, 2*time.Second, b.Duration(2))
+	assert.Equal(t, 4*time.Second, b.Duration(3))
+	assert.Equal(t, 5*time.Second, b.Duration(4))
+	b.Jitter = 0.5
+	for i := 0; i < 10; i++ {
+		d := b.Duration(2)
+		assert.True(t, d > time.Second && d <= 2*time.Second)
+	}
+
+	// Overflows are clamped
+	b = astitime.ExponentialBackoff{Base: time.Second}
+	assert.Equal(t, time.Duration(math.MaxInt64), b.Duration(100))
+	assert.Equal(t, time.Duration(math.MaxInt64), b.Duration(10000))
+	b.Base = 0
+	assert.Equal(t, time.Duration(0), b.Duration(10000))
+}
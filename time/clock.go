@@ -0,0 +1,314 @@
+package astitime
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Clock represents a clock
+// Time-dependent objects accept a clock so that tests can replace the real clock with a fake one.
+type Clock interface {
+	After(d time.Duration) <-chan time.Time
+	NewTicker(d time.Duration) ClockTicker
+	NewTimer(d time.Duration) ClockTimer
+	Now() time.Time
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+// ClockTicker represents a ticker created by a clock
+type ClockTicker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// ClockTimer represents a timer created by a clock
+// Reset and Stop return whether the timer was active, as with time.Timer.
+type ClockTimer interface {
+	C() <-chan time.Time
+	Reset(d time.Duration) bool
+	Stop() bool
+}
+
+// RealClock represents the real clock
+type RealClock struct{}
+
+// After implements the Clock interface
+func (RealClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// NewTicker implements the Clock interface
+func (RealClock) NewTicker(d time.Duration) ClockTicker {
+	return realTicker{t: time.NewTicker(d)}
+}
+
+// NewTimer implements the Clock interface
+func (RealClock) NewTimer(d time.Duration) ClockTimer {
+	return realTimer{t: time.NewTimer(d)}
+}
+
+// Now implements the Clock interface
+func (RealClock) Now() time.Time {
+	return time.Now()
+}
+
+// Sleep implements the Clock interface
+func (RealClock) Sleep(ctx context.Context, d time.Duration) error {
+	return Sleep(ctx, d)
+}
+
+// realTicker represents a real ticker
+type realTicker struct {
+	t *time.Ticker
+}
+
+// C implements the ClockTicker interface
+func (t realTicker) C() <-chan time.Time {
+	return t.t.C
+}
+
+// Stop implements the ClockTicker interface
+func (t realTicker) Stop() {
+	t.t.Stop()
+}
+
+// realTimer represents a real timer
+type realTimer struct {
+	t *time.Timer
+}
+
+// C implements the ClockTimer interface
+func (t realTimer) C() <-chan time.Time {
+	return t.t.C
+}
+
+// Reset implements the ClockTimer interface
+func (t realTimer) Reset(d time.Duration) bool {
+	return t.t.Reset(d)
+}
+
+// Stop implements the ClockTimer interface
+func (t realTimer) Stop() bool {
+	return t.t.Stop()
+}
+
+// FakeClock represents a clock whose time only moves forward when told to
+type FakeClock struct {
+	cond    *sync.Cond
+	m       *sync.Mutex // Locks now and waiters
+	now     time.Time
+	waiters []*fakeWaiter
+}
+
+// fakeWaiter represents a timer or a ticker waiting for the fake clock to reach a time
+type fakeWaiter struct {
+	at     time.Time
+	c      chan time.Time
+	period time.Duration
+}
+
+// NewFakeClock creates a new fake clock set at now
+func NewFakeClock(now time.Time) (c *FakeClock) {
+	c = &FakeClock{
+		m:   &sync.Mutex{},
+		now: now,
+	}
+	c.cond = sync.NewCond(c.m)
+	return
+}
+
+// Add moves the fake clock forward and fires timers and tickers whose time has come
+// As with real tickers, ticks are dropped when nobody reads them.
+func (c *FakeClock) Add(d time.Duration) {
+	// Lock
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	// Update time
+	c.now = c.now.Add(d)
+
+	// Fire waiters in chronological order
+	sort.SliceStable(c.waiters, func(i, j int) bool { return c.waiters[i].at.Before(c.waiters[j].at) })
+	var ws []*fakeWaiter
+	for _, w := range c.waiters {
+		// Time has not come
+		if w.at.After(c.now) {
+			ws = append(ws, w)
+			continue
+		}
+
+		// Fire
+		select {
+		case w.c <- w.at:
+		default:
+		}
+
+		// Ticker
+		if w.period > 0 {
+			for !w.at.After(c.now) {
+				w.at = w.at.Add(w.period)
+			}
+			ws = append(ws, w)
+		}
+	}
+	c.waiters = ws
+}
+
+// After implements the Clock interface
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	c.m.Lock()
+	defer c.m.Unlock()
+	var w = &fakeWaiter{at: c.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		w.c <- c.now
+		return w.c
+	}
+	c.addWaiter(w)
+	return w.c
+}
+
+// BlockUntil blocks until at least n timers and tickers are waiting for the fake clock
+// It allows making sure a goroutine is sleeping before moving the clock forward.
+func (c *FakeClock) BlockUntil(n int) {
+	c.m.Lock()
+	defer c.m.Unlock()
+	for len(c.waiters) < n {
+		c.cond.Wait()
+	}
+}
+
+// NewTicker implements the Clock interface
+// As with time.NewTicker, it panics if d <= 0.
+func (c *FakeClock) NewTicker(d time.Duration) ClockTicker {
+	if d <= 0 {
+		panic("astitime: non-positive interval for FakeClock.NewTicker")
+	}
+	c.m.Lock()
+	defer c.m.Unlock()
+	var w = &fakeWaiter{at: c.now.Add(d), c: make(chan time.Time, 1), period: d}
+	c.addWaiter(w)
+	return &fakeTicker{c: c, w: w}
+}
+
+// NewTimer implements the Clock interface
+func (c *FakeClock) NewTimer(d time.Duration) ClockTimer {
+	c.m.Lock()
+	defer c.m.Unlock()
+	var t = &fakeTimer{c: c, w: &fakeWaiter{c: make(chan time.Time, 1)}}
+	c.startTimer(t.w, d)
+	return t
+}
+
+// Now implements the Clock interface
+func (c *FakeClock) Now() time.Time {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.now
+}
+
+// Sleep implements the Clock interface
+// The sleeper stops waiting for the fake clock once ctx is cancelled.
+func (c *FakeClock) Sleep(ctx context.Context, d time.Duration) (err error) {
+	var t = c.NewTimer(d)
+	select {
+	case <-t.C():
+	case <-ctx.Done():
+		t.Stop()
+		err = ctx.Err()
+	}
+	return
+}
+
+// addWaiter adds a waiter
+// Assumes the clock is locked
+func (c *FakeClock) addWaiter(w *fakeWaiter) {
+	c.waiters = append(c.waiters, w)
+	c.cond.Broadcast()
+}
+
+// startTimer makes a timer's waiter fire once d has elapsed
+// Assumes the clock is locked
+func (c *FakeClock) startTimer(w *fakeWaiter, d time.Duration) {
+	w.at = c.now.Add(d)
+	if d <= 0 {
+		w.c <- c.now
+		return
+	}
+	c.addWaiter(w)
+}
+
+// removeWaiter removes a waiter and returns whether it was waiting
+func (c *FakeClock) removeWaiter(w *fakeWaiter) bool {
+	c.m.Lock()
+	defer c.m.Unlock()
+	return c.deleteWaiter(w)
+}
+
+// deleteWaiter removes a waiter and returns whether it was waiting
+// Assumes the clock is locked
+func (c *FakeClock) deleteWaiter(w *fakeWaiter) bool {
+	for i, v := range c.waiters {
+		if v == w {
+			c.waiters = append(c.waiters[:i], c.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// fakeTicker represents a fake ticker
+type fakeTicker struct {
+	c *FakeClock
+	w *fakeWaiter
+}
+
+// C implements the ClockTicker interface
+func (t *fakeTicker) C() <-chan time.Time {
+	return t.w.c
+}
+
+// Stop implements the ClockTicker interface
+func (t *fakeTicker) Stop() {
+	t.c.removeWaiter(t.w)
+}
+
+// fakeTimer represents a fake timer
+// As with time.Timer since Go 1.23, no stale value is received after Reset or Stop returns.
+type fakeTimer struct {
+	c *FakeClock
+	w *fakeWaiter
+}
+
+// C implements the ClockTimer interface
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.w.c
+}
+
+// Reset implements the ClockTimer interface
+func (t *fakeTimer) Reset(d time.Duration) (active bool) {
+	t.c.m.Lock()
+	defer t.c.m.Unlock()
+	active = t.stop()
+	t.c.startTimer(t.w, d)
+	return
+}
+
+// Stop implements the ClockTimer interface
+func (t *fakeTimer) Stop() bool {
+	t.c.m.Lock()
+	defer t.c.m.Unlock()
+	return t.stop()
+}
+
+// stop removes the timer's waiter and drains its channel
+// Assumes the clock is locked
+func (t *fakeTimer) stop() (active bool) {
+	active = t.c.deleteWaiter(t.w)
+	select {
+	case <-t.w.c:
+	default:
+	}
+	return
+}
@@ -0,0 +1,90 @@
+package astitime_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/time"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeClock(t *testing.T) {
+	// Now
+	var n = time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC)
+	var c = astitime.NewFakeClock(n)
+	assert.Equal(t, n, c.Now())
+
+	// Sleep
+	var done = make(chan error)
+	go func() { done <- c.Sleep(context.Background(), time.Minute) }()
+	c.BlockUntil(1)
+	c.Add(30 * time.Second)
+	select {
+	case <-done:
+		t.Fatal("sleep should not be over")
+	default:
+	}
+	c.Add(30 * time.Second)
+	assert.NoError(t, <-done)
+	assert.Equal(t, n.Add(time.Minute), c.Now())
+
+	// Ticker
+	tk := c.NewTicker(time.Second)
+	c.Add(time.Second)
+	assert.Equal(t, n.Add(time.Minute+time.Second), <-tk.C())
+	c.Add(5 * time.Second)
+	assert.Equal(t, n.Add(time.Minute+2*time.Second), <-tk.C())
+	tk.Stop()
+	c.Add(time.Second)
+	select {
+	case <-tk.C():
+		t.Fatal("ticker should be stopped")
+	default:
+	}
+
+	// Timer
+	tm := c.NewTimer(time.Second)
+	c.Add(time.Second)
+	assert.Equal(t, n.Add(time.Minute+8*time.Second), <-tm.C())
+	assert.False(t, tm.Reset(time.Second))
+	assert.True(t, tm.Reset(2*time.Second))
+	c.Add(time.Second)
+	select {
+	case <-tm.C():
+		t.Fatal("timer should have been reset")
+	default:
+	}
+	c.Add(time.Second)
+	assert.Equal(t, n.Add(time.Minute+10*time.Second), <-tm.C())
+	tm.Reset(time.Second)
+	assert.True(t, tm.Stop())
+	c.Add(time.Second)
+	select {
+	case <-tm.C():
+		t.Fatal("timer should be stopped")
+	default:
+	}
+
+	// Immediate
+	<-c.After(0)
+
+	// Cancelled sleep
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() { done <- c.Sleep(ctx, time.Minute) }()
+	c.BlockUntil(1)
+	cancel()
+	assert.Equal(t, context.Canceled, <-done)
+	go func() { done <- c.Sleep(context.Background(), time.Minute) }()
+	c.BlockUntil(1)
+	c.Add(time.Minute)
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("sleep should be over")
+	}
+
+	// Invalid ticker
+	assert.Panics(t, func() { c.NewTicker(0) })
+}
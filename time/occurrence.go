@@ -0,0 +1,54 @@
+package astitime
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// TimeOfDay represents a wall clock time
+type TimeOfDay struct {
+	Hour   int
+	Minute int
+	Second int
+}
+
+// ParseTimeOfDay parses a wall clock time formatted as "15:04" or "15:04:05"
+func ParseTimeOfDay(s string) (t TimeOfDay, err error) {
+	// Parse
+	var p time.Time
+	if p, err = time.Parse("15:04:05", s); err != nil {
+		if p, err = time.Parse("15:04", s); err != nil {
+			err = errors.Wrapf(err, "astitime: parsing time of day %s failed", s)
+			return
+		}
+	}
+	t = TimeOfDay{Hour: p.Hour(), Minute: p.Minute(), Second: p.Second()}
+	return
+}
+
+// String implements the fmt.Stringer interface
+func (t TimeOfDay) String() string {
+	return fmt.Sprintf("%.2d:%.2d:%.2d", t.Hour, t.Minute, t.Second)
+}
+
+// UnmarshalText implements the TextUnmarshaler interface
+func (t *TimeOfDay) UnmarshalText(text []byte) (err error) {
+	*t, err = ParseTimeOfDay(string(text))
+	return
+}
+
+// MarshalText implements the TextMarshaler interface
+func (t TimeOfDay) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// NextOccurrenceOf returns the first time strictly after from whose wall clock, in from's location, is t
+func NextOccurrenceOf(t TimeOfDay, from time.Time) (n time.Time) {
+	n = time.Date(from.Year(), from.Month(), from.Day(), t.Hour, t.Minute, t.Second, 0, from.Location())
+	if !n.After(from) {
+		n = time.Date(from.Year(), from.Month(), from.Day()+1, t.Hour, t.Minute, t.Second, 0, from.Location())
+	}
+	return
+}
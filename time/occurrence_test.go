@@ -0,0 +1,24 @@
+package astitime_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/time"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNextOccurrenceOf(t *testing.T) {
+	tod, err := astitime.ParseTimeOfDay("08:30")
+	assert.NoError(t, err)
+	assert.Equal(t, astitime.TimeOfDay{Hour: 8, Minute: 30}, tod)
+	assert.Equal(t, "08:30:00", tod.String())
+	_, err = astitime.ParseTimeOfDay("invalid")
+	assert.Error(t, err)
+	var from = time.Date(2019, 3, 30, 7, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2019, 3, 30, 8, 30, 0, 0, time.UTC), astitime.NextOccurrenceOf(tod, from))
+	from = time.Date(2019, 3, 30, 8, 30, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2019, 3, 31, 8, 30, 0, 0, time.UTC), astitime.NextOccurrenceOf(tod, from))
+	from = time.Date(2019, 12, 31, 23, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2020, 1, 1, 8, 30, 0, 0, time.UTC), astitime.NextOccurrenceOf(tod, from))
+}
@@ -18,3 +18,18 @@ func Sleep(ctx context.Context, d time.Duration) (err error) {
 	}
 	return
 }
+
+// SleepUntil is a cancellable sleep lasting until t
+func SleepUntil(ctx context.Context, t time.Time) error {
+	return Sleep(ctx, time.Until(t))
+}
+
+// UntilDeadline returns the time left before the context deadline, ok being false if it has no deadline
+func UntilDeadline(ctx context.Context) (d time.Duration, ok bool) {
+	var t time.Time
+	if t, ok = ctx.Deadline(); !ok {
+		return
+	}
+	d = time.Until(t)
+	return
+}
@@ -23,3 +23,14 @@ func TestSleep(t *testing.T) {
 	wg.Wait()
 	assert.EqualError(t, err, "context canceled")
 }
+
+func TestUntilDeadline(t *testing.T) {
+	_, ok := astitime.UntilDeadline(context.Background())
+	assert.False(t, ok)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	d, ok := astitime.UntilDeadline(ctx)
+	assert.True(t, ok)
+	assert.True(t, d > 59*time.Second)
+	assert.NoError(t, astitime.SleepUntil(ctx, time.Now().Add(time.Millisecond)))
+}
@@ -0,0 +1,57 @@
+package astitime
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Ticker represents a context-aware ticker
+// Its channel is closed once the context is cancelled or the ticker is stopped, which allows ranging over it.
+type Ticker struct {
+	C      <-chan time.Time
+	cancel context.CancelFunc
+	o      sync.Once
+}
+
+// NewTicker creates a new ticker ticking every d until ctx is cancelled
+func NewTicker(ctx context.Context, d time.Duration) (t *Ticker) {
+	// Create ticker
+	var c = make(chan time.Time)
+	t = &Ticker{C: c}
+	ctx, t.cancel = context.WithCancel(ctx)
+
+	// Tick
+	go func() {
+		defer close(c)
+		var tk = time.NewTicker(d)
+		defer tk.Stop()
+		for {
+			select {
+			case n := <-tk.C:
+				select {
+				case c <- n:
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return
+}
+
+// Stop stops the ticker
+func (t *Ticker) Stop() {
+	t.o.Do(t.cancel)
+}
+
+// Tick calls fn every d until ctx is cancelled
+func Tick(ctx context.Context, d time.Duration, fn func(t time.Time)) {
+	var t = NewTicker(ctx, d)
+	defer t.Stop()
+	for n := range t.C {
+		fn(n)
+	}
+}
@@ -0,0 +1,29 @@
+package astitime_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/time"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTicker(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var count int
+	astitime.Tick(ctx, time.Millisecond, func(t time.Time) {
+		count++
+		if count == 3 {
+			cancel()
+		}
+	})
+	assert.Equal(t, 3, count)
+
+	// Stop
+	tk := astitime.NewTicker(context.Background(), time.Millisecond)
+	<-tk.C
+	tk.Stop()
+	for range tk.C {
+	}
+}
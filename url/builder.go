@@ -0,0 +1,153 @@
+package astiurl
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Builder represents an URL builder
+type Builder struct {
+	q url.Values
+	u *url.URL
+}
+
+// NewBuilder creates a new builder based on an URL, which can be empty
+func NewBuilder(base string) (b *Builder, err error) {
+	// Parse
+	var u *url.URL
+	if u, err = url.Parse(base); err != nil {
+		err = errors.Wrapf(err, "astiurl: parsing %s failed", base)
+		return
+	}
+
+	// Create builder
+	b = &Builder{
+		q: u.Query(),
+		u: u,
+	}
+	return
+}
+
+// AddQuery adds a value to a query param
+func (b *Builder) AddQuery(key, value string) *Builder {
+	b.q.Add(key, value)
+	return b
+}
+
+// DelQuery deletes a query param
+func (b *Builder) DelQuery(key string) *Builder {
+	b.q.Del(key)
+	return b
+}
+
+// Fragment sets the fragment
+func (b *Builder) Fragment(f string) *Builder {
+	b.u.Fragment = f
+	return b
+}
+
+// Host sets the host
+func (b *Builder) Host(h string) *Builder {
+	b.u.Host = h
+	return b
+}
+
+// Path appends path segments, escaping them so that they can contain "/", "?" or "#"
+func (b *Builder) Path(segments ...string) *Builder {
+	var p = strings.TrimSuffix(b.u.EscapedPath(), "/")
+	for _, s := range segments {
+		p += "/" + url.PathEscape(s)
+	}
+	b.setEscapedPath(p)
+	return b
+}
+
+// setEscapedPath sets the path based on its escaped version
+func (b *Builder) setEscapedPath(p string) {
+	if v, err := url.PathUnescape(p); err == nil {
+		b.u.Path = v
+		b.u.RawPath = p
+		if b.u.EscapedPath() == v {
+			b.u.RawPath = ""
+		}
+	}
+}
+
+// Scheme sets the scheme
+func (b *Builder) Scheme(s string) *Builder {
+	b.u.Scheme = s
+	return b
+}
+
+// SetQuery sets a query param, replacing its values if any
+func (b *Builder) SetQuery(key, value string) *Builder {
+	b.q.Set(key, value)
+	return b
+}
+
+// String returns the URL as a string
+func (b *Builder) String() string {
+	return b.URL().String()
+}
+
+// URL returns a copy of the URL
+func (b *Builder) URL() *url.URL {
+	var u = *b.u
+	u.RawQuery = b.q.Encode()
+	return &u
+}
+
+// User sets the credentials
+func (b *Builder) User(username, password string) *Builder {
+	b.u.User = url.UserPassword(username, password)
+	return b
+}
+
+// ResolveReference resolves a reference, absolute or relative, against a base URL
+func ResolveReference(base, ref string) (o string, err error) {
+	// Parse base
+	var b *url.URL
+	if b, err = url.Parse(base); err != nil {
+		err = errors.Wrapf(err, "astiurl: parsing base %s failed", base)
+		return
+	}
+
+	// Parse reference
+	var r *url.URL
+	if r, err = url.Parse(ref); err != nil {
+		err = errors.Wrapf(err, "astiurl: parsing reference %s failed", ref)
+		return
+	}
+
+	// Resolve
+	o = b.ResolveReference(r).String()
+	return
+}
+
+// Redacted returns the URL with its password and the values of the provided query params replaced by "xxxxx", so
+// that it can be logged safely
+func Redacted(u *url.URL, queryKeys ...string) string {
+	// Copy
+	var c = *u
+
+	// Password
+	if _, ok := c.User.Password(); ok {
+		c.User = url.UserPassword(c.User.Username(), "xxxxx")
+	}
+
+	// Query
+	if len(queryKeys) > 0 {
+		var q = c.Query()
+		for _, k := range queryKeys {
+			if vs, ok := q[k]; ok {
+				for i := range vs {
+					vs[i] = "xxxxx"
+				}
+			}
+		}
+		c.RawQuery = q.Encode()
+	}
+	return c.String()
+}
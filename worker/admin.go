@@ -0,0 +1,209 @@
+package astiworker
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/asticode/go-astitools/logger"
+	"github.com/pkg/errors"
+)
+
+// ReadinessCheck represents a func returning an error if a component is not ready to serve yet
+type ReadinessCheck func(ctx context.Context) error
+
+// DefaultReadinessTimeout represents the default amount of time all readiness checks are given to return
+var DefaultReadinessTimeout = 5 * time.Second
+
+// HandleReadiness registers a named readiness check executed every time /readyz is requested
+func (w *Worker) HandleReadiness(name string, fn ReadinessCheck) {
+	w.mc.Lock()
+	defer w.mc.Unlock()
+	w.readinessChecks[name] = fn
+}
+
+// HandleReadiness registers a readiness check named after the task
+// The check is removed once the task is done.
+func (t *Task) HandleReadiness(fn ReadinessCheck) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.readinessCheck = fn
+}
+
+// TaskInfo represents information about a running task
+type TaskInfo struct {
+	ID        uint64        `json:"id"`
+	Name      string        `json:"name"`
+	Restarts  int           `json:"restarts"`
+	StartedAt time.Time     `json:"started_at"`
+	Uptime    time.Duration `json:"uptime"`
+}
+
+// Tasks returns information about running tasks ordered by start
+func (w *Worker) Tasks() (is []TaskInfo) {
+	// Lock
+	w.mt.Lock()
+	defer w.mt.Unlock()
+
+	// Loop through tasks
+	var now = w.c.Now()
+	for _, t := range w.tasks {
+		t.m.Lock()
+		var restarts = t.restarts
+		t.m.Unlock()
+		is = append(is, TaskInfo{
+			ID:        t.id,
+			Name:      t.c.Name,
+			Restarts:  restarts,
+			StartedAt: t.startedAt,
+			Uptime:    now.Sub(t.startedAt),
+		})
+	}
+	sort.Slice(is, func(i, j int) bool { return is[i].ID < is[j].ID })
+	return
+}
+
+// readinessChecksByName returns both worker and tasks readiness checks indexed by name
+func (w *Worker) readinessChecksByName() (cs map[string]ReadinessCheck) {
+	// Worker checks
+	cs = make(map[string]ReadinessCheck)
+	w.mc.Lock()
+	for n, c := range w.readinessChecks {
+		cs[n] = c
+	}
+	w.mc.Unlock()
+
+	// Tasks checks
+	w.mt.Lock()
+	defer w.mt.Unlock()
+	for _, t := range w.tasks {
+		t.m.Lock()
+		if t.readinessCheck != nil {
+			cs[t.c.Name] = t.readinessCheck
+		}
+		t.m.Unlock()
+	}
+	return
+}
+
+// isStopping returns whether the worker is stopping or stopped
+func (w *Worker) isStopping() bool {
+	w.mq.Lock()
+	defer w.mq.Unlock()
+	return w.stopping
+}
+
+// AdminHandler returns an http.Handler serving:
+//   - /healthz which responds with 200 while the worker is alive and with 503 once it's stopping
+//   - /readyz which executes readiness checks and responds with 200 if they all succeed and with 503 otherwise,
+//     the body listing each check's status
+//   - /tasks which lists running tasks and their uptimes
+func (w *Worker) AdminHandler() http.Handler {
+	var m = http.NewServeMux()
+	m.HandleFunc("/healthz", w.handleHealthz)
+	m.HandleFunc("/readyz", w.handleReadyz)
+	m.HandleFunc("/tasks", w.handleTasks)
+	return m
+}
+
+func (w *Worker) handleHealthz(rw http.ResponseWriter, r *http.Request) {
+	if w.isStopping() {
+		http.Error(rw, "stopping", http.StatusServiceUnavailable)
+		return
+	}
+	rw.Write([]byte("ok"))
+}
+
+func (w *Worker) handleReadyz(rw http.ResponseWriter, r *http.Request) {
+	// Create context
+	ctx, cancel := context.WithTimeout(r.Context(), DefaultReadinessTimeout)
+	defer cancel()
+
+	// Execute checks in parallel
+	var cs = w.readinessChecksByName()
+	type result struct {
+		err  error
+		name string
+	}
+	var ch = make(chan result, len(cs))
+	for n, c := range cs {
+		go func(n string, c ReadinessCheck) { ch <- result{err: c(ctx), name: n} }(n, c)
+	}
+
+	// Gather results
+	var ready = !w.isStopping()
+	var body = struct {
+		Checks map[string]string `json:"checks"`
+		Ready  bool              `json:"ready"`
+	}{Checks: make(map[string]string)}
+	for range cs {
+		res := <-ch
+		body.Checks[res.name] = "ok"
+		if res.err != nil {
+			body.Checks[res.name] = res.err.Error()
+			ready = false
+		}
+	}
+	body.Ready = ready
+
+	// Write
+	if !ready {
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	}
+	w.writeJSON(rw, body)
+}
+
+func (w *Worker) handleTasks(rw http.ResponseWriter, r *http.Request) {
+	var is = w.Tasks()
+	if is == nil {
+		is = []TaskInfo{}
+	}
+	w.writeJSON(rw, is)
+}
+
+// writeJSON writes a JSON body
+func (w *Worker) writeJSON(rw http.ResponseWriter, v interface{}) {
+	rw.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(rw).Encode(v); err != nil {
+		w.l.Error("astiworker: writing json failed", astilogger.KeyError, err)
+	}
+}
+
+// ServeAdmin starts an admin HTTP server on the provided address, see AdminHandler
+// The server runs in its own task and is shut down when the worker is stopping.
+func (w *Worker) ServeAdmin(addr string) (err error) {
+	// Listen
+	var l net.Listener
+	if l, err = net.Listen("tcp", addr); err != nil {
+		err = errors.Wrapf(err, "astiworker: listening on %s failed", addr)
+		return
+	}
+
+	// Create task
+	var t = w.NewTask(TaskConfiguration{Name: "admin"})
+	var s = &http.Server{Handler: w.AdminHandler()}
+
+	// Serve
+	t.l.Info("astiworker: serving admin", "addr", l.Addr())
+	go func() {
+		defer t.Done()
+		if err := s.Serve(l); err != nil && err != http.ErrServerClosed {
+			t.l.Error("astiworker: serving admin failed", "addr", l.Addr(), astilogger.KeyError, err)
+		}
+	}()
+
+	// Shutdown
+	go func() {
+		<-t.Context().Done()
+		ctx, cancel := context.WithTimeout(context.Background(), t.c.StopTimeout)
+		defer cancel()
+		if err := s.Shutdown(ctx); err != nil {
+			t.l.Error("astiworker: shutting down admin failed", astilogger.KeyError, err)
+		}
+	}()
+	return
+}
@@ -0,0 +1,83 @@
+package astiworker_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/time"
+	"github.com/asticode/go-astitools/worker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorker_AdminHandler(t *testing.T) {
+	// Setup
+	var c = astitime.NewFakeClock(time.Unix(1700000000, 0))
+	w := astiworker.NewWorkerWithOptions(astiworker.WorkerOptions{Clock: c})
+	var ready bool
+	w.HandleReadiness("db", func(ctx context.Context) error {
+		if !ready {
+			return errors.New("not connected")
+		}
+		return nil
+	})
+	tk := w.NewTask(astiworker.TaskConfiguration{Name: "consumer"})
+	tk.HandleReadiness(func(ctx context.Context) error { return nil })
+	tk.Do(func(ctx context.Context) { <-ctx.Done() })
+	c.Add(time.Minute)
+	s := httptest.NewServer(w.AdminHandler())
+	defer s.Close()
+
+	// Healthz
+	r, err := http.Get(s.URL + "/healthz")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, r.StatusCode)
+	r.Body.Close()
+
+	// Readyz
+	var b struct {
+		Checks map[string]string `json:"checks"`
+		Ready  bool              `json:"ready"`
+	}
+	r, err = http.Get(s.URL + "/readyz")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, r.StatusCode)
+	assert.NoError(t, json.NewDecoder(r.Body).Decode(&b))
+	r.Body.Close()
+	assert.False(t, b.Ready)
+	assert.Equal(t, map[string]string{"consumer": "ok", "db": "not connected"}, b.Checks)
+	ready = true
+	r, err = http.Get(s.URL + "/readyz")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, r.StatusCode)
+	r.Body.Close()
+
+	// Tasks
+	var ts []astiworker.TaskInfo
+	r, err = http.Get(s.URL + "/tasks")
+	assert.NoError(t, err)
+	assert.NoError(t, json.NewDecoder(r.Body).Decode(&ts))
+	r.Body.Close()
+	assert.Len(t, ts, 1)
+	assert.Equal(t, "consumer", ts[0].Name)
+	assert.Equal(t, time.Minute, ts[0].Uptime)
+
+	// Stopping
+	assert.NoError(t, w.Stop())
+	r, err = http.Get(s.URL + "/healthz")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, r.StatusCode)
+	r.Body.Close()
+}
+
+func TestWorker_ServeAdmin(t *testing.T) {
+	w := astiworker.NewWorker()
+	assert.NoError(t, w.ServeAdmin("127.0.0.1:0"))
+	assert.Len(t, w.Tasks(), 1)
+	assert.NoError(t, w.Stop())
+	assert.Empty(t, w.Tasks())
+}
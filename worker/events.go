@@ -0,0 +1,167 @@
+package astiworker
+
+import (
+	"context"
+	"sync"
+
+	"github.com/asticode/go-astitools/logger"
+)
+
+// Buffer policies applied when a subscriber's buffer is full
+const (
+	// Publish blocks until there's room in the buffer or the subscriber is unsubscribed
+	BufferPolicyBlock = "block"
+	// The oldest buffered event is dropped
+	BufferPolicyDropOldest = "drop_oldest"
+	// The published event is dropped
+	BufferPolicyDropNewest = "drop_newest"
+)
+
+// EventHandler represents a func handling events published on a topic
+type EventHandler func(ctx context.Context, payload interface{})
+
+// SubscribeOptions represents subscribe options
+type SubscribeOptions struct {
+	// Number of events buffered while the handler is busy. Defaults to 16.
+	BufferSize int
+	// Defaults to BufferPolicyBlock
+	Policy string
+}
+
+// subscriber represents a topic subscriber
+type subscriber struct {
+	cancel context.CancelFunc
+	ch     chan interface{}
+	ctx    context.Context
+	h      EventHandler
+	l      astilogger.Logger
+	m      *sync.Mutex // Locks ch sends
+	o      SubscribeOptions
+}
+
+// Publish publishes an event on a topic
+// Events are handled by subscribers in their own goroutines, in the order they were published.
+func (w *Worker) Publish(topic string, payload interface{}) {
+	// Get subscribers
+	w.ms.Lock()
+	var ss []*subscriber
+	for _, s := range w.subscribers[topic] {
+		ss = append(ss, s)
+	}
+	w.ms.Unlock()
+
+	// Loop through subscribers
+	for _, s := range ss {
+		s.push(topic, payload)
+	}
+}
+
+// Subscribe subscribes to a topic until the worker stops or the returned func is called
+func (w *Worker) Subscribe(topic string, h EventHandler, o SubscribeOptions) (unsubscribe func()) {
+	return w.subscribe(w.ctx, topic, h, o)
+}
+
+// Publish publishes an event on a topic, see Worker.Publish
+func (t *Task) Publish(topic string, payload interface{}) {
+	t.w.Publish(topic, payload)
+}
+
+// Subscribe subscribes to a topic until the task stops or the returned func is called
+func (t *Task) Subscribe(topic string, h EventHandler, o SubscribeOptions) (unsubscribe func()) {
+	return t.w.subscribe(t.ctx, topic, h, o)
+}
+
+// subscribe subscribes to a topic until the context is cancelled or the returned func is called
+func (w *Worker) subscribe(ctx context.Context, topic string, h EventHandler, o SubscribeOptions) (unsubscribe func()) {
+	// Default options values
+	if o.BufferSize <= 0 {
+		o.BufferSize = 16
+	}
+	if o.Policy == "" {
+		o.Policy = BufferPolicyBlock
+	}
+
+	// Create subscriber
+	s := &subscriber{
+		ch: make(chan interface{}, o.BufferSize),
+		h:  h,
+		l:  w.l,
+		m:  &sync.Mutex{},
+		o:  o,
+	}
+	s.ctx, s.cancel = context.WithCancel(ctx)
+
+	// Add subscriber
+	w.ms.Lock()
+	w.subscriberID++
+	var id = w.subscriberID
+	if _, ok := w.subscribers[topic]; !ok {
+		w.subscribers[topic] = make(map[uint64]*subscriber)
+	}
+	w.subscribers[topic][id] = s
+	w.ms.Unlock()
+
+	// Handle events
+	go func() {
+		// Remove subscriber
+		defer func() {
+			w.ms.Lock()
+			defer w.ms.Unlock()
+			delete(w.subscribers[topic], id)
+			if len(w.subscribers[topic]) == 0 {
+				delete(w.subscribers, topic)
+			}
+		}()
+
+		// Loop
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case p := <-s.ch:
+				s.h(s.ctx, p)
+			}
+		}
+	}()
+	return s.cancel
+}
+
+// push buffers an event according to the buffer policy
+func (s *subscriber) push(topic string, payload interface{}) {
+	// Lock
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	// Subscriber has been unsubscribed
+	if s.ctx.Err() != nil {
+		return
+	}
+
+	// Buffer
+	select {
+	case s.ch <- payload:
+		return
+	default:
+	}
+
+	// Buffer is full
+	switch s.o.Policy {
+	case BufferPolicyDropNewest:
+		s.l.Debug("astiworker: buffer of subscriber is full, dropping newest event", "topic", topic)
+	case BufferPolicyDropOldest:
+		s.l.Debug("astiworker: buffer of subscriber is full, dropping oldest event", "topic", topic)
+		select {
+		case <-s.ch:
+		default:
+		}
+		select {
+		case s.ch <- payload:
+		default:
+		}
+	default:
+		select {
+		case s.ch <- payload:
+		case <-s.ctx.Done():
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package astiworker_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/asticode/go-astitools/worker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorker_Events(t *testing.T) {
+	// Setup
+	w := astiworker.NewWorker()
+	var m sync.Mutex
+	var got []interface{}
+	var wg sync.WaitGroup
+	tk := w.NewTask(astiworker.TaskConfiguration{Name: "subscriber"})
+	tk.Subscribe("topic", func(ctx context.Context, p interface{}) {
+		m.Lock()
+		got = append(got, p)
+		m.Unlock()
+		wg.Done()
+	}, astiworker.SubscribeOptions{})
+
+	// Publish
+	wg.Add(3)
+	for i := 0; i < 3; i++ {
+		w.Publish("topic", i)
+	}
+	w.Publish("other", "ignored")
+	wg.Wait()
+	assert.Equal(t, []interface{}{0, 1, 2}, got)
+
+	// Drop oldest
+	var block = make(chan struct{})
+	var started = make(chan struct{}, 10)
+	var done = make(chan interface{}, 10)
+	w.Subscribe("drop", func(ctx context.Context, p interface{}) {
+		started <- struct{}{}
+		<-block
+		done <- p
+	}, astiworker.SubscribeOptions{BufferSize: 2, Policy: astiworker.BufferPolicyDropOldest})
+	w.Publish("drop", 0)
+	<-started
+	for i := 1; i < 5; i++ {
+		w.Publish("drop", i)
+	}
+	close(block)
+	assert.Equal(t, 0, <-done)
+	assert.Equal(t, 3, <-done)
+	assert.Equal(t, 4, <-done)
+
+	// Unsubscribe when task stops
+	tk.Done()
+	assert.NoError(t, w.Stop())
+	w.Publish("topic", 3)
+}
@@ -0,0 +1,114 @@
+package astiworker
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/asticode/go-astitools/context"
+)
+
+// Panic policies
+const (
+	// The task is marked as failed and done, other tasks keep running
+	PanicPolicyFail = "fail"
+	// The task's func is executed again, see TaskConfiguration.MaxRestarts and TaskConfiguration.RestartDelay
+	PanicPolicyRestart = "restart"
+	// The task is marked as failed and done, and the worker is stopped
+	PanicPolicyStopWorker = "stop_worker"
+)
+
+// TaskPanic represents a panic recovered in a task
+type TaskPanic struct {
+	// Number of times the task has been restarted before this panic
+	Restarts int
+	Stack    []byte
+	Task     string
+	Value    interface{}
+}
+
+// PanicError represents the error a task panicking is marked as failed with
+type PanicError struct {
+	Stack []byte
+	Task  string
+	Value interface{}
+}
+
+// Error implements the error interface
+func (e PanicError) Error() string {
+	return fmt.Sprintf("astiworker: task %s panicked: %v", e.Task, e.Value)
+}
+
+// Err returns the error the task has been marked as failed with, or nil if it hasn't
+func (t *Task) Err() error {
+	t.m.Lock()
+	defer t.m.Unlock()
+	return t.err
+}
+
+// run executes the task's func, recovering and handling panics according to the task's panic policy
+func (t *Task) run(fn func(ctx context.Context)) {
+	for {
+		// Execute
+		p, ok := t.recover(fn)
+		if !ok {
+			return
+		}
+
+		// Log
+		t.l.Error("astiworker: task panicked", "panic", p.Value, "stack", string(p.Stack))
+
+		// Invoke hook
+		if t.w.onTaskPanic != nil {
+			t.w.onTaskPanic(p)
+		}
+
+		// Restart
+		if t.c.PanicPolicy == PanicPolicyRestart && (t.c.MaxRestarts <= 0 || p.Restarts < t.c.MaxRestarts) &&
+			t.ctx.Err() == nil {
+			if t.c.RestartDelay > 0 && t.w.c.Sleep(t.ctx, t.c.RestartDelay) != nil {
+				return
+			}
+			t.m.Lock()
+			t.restarts++
+			t.m.Unlock()
+			t.l.Info("astiworker: restarting task", "restarts", p.Restarts+1)
+			continue
+		}
+
+		// Mark task as failed
+		var err = PanicError{
+			Stack: p.Stack,
+			Task:  p.Task,
+			Value: p.Value,
+		}
+		t.m.Lock()
+		t.err = err
+		t.m.Unlock()
+
+		// Stop worker
+		if t.c.PanicPolicy == PanicPolicyStopWorker {
+			t.w.stop(t, asticontext.ReasonDependencyFailure, err)
+		}
+		return
+	}
+}
+
+// recover executes the task's func and returns the recovered panic, if any
+func (t *Task) recover(fn func(ctx context.Context)) (p TaskPanic, ok bool) {
+	defer func() {
+		if v := recover(); v != nil {
+			t.m.Lock()
+			p = TaskPanic{
+				Restarts: t.restarts,
+				Stack:    debug.Stack(),
+				Task:     t.c.Name,
+				Value:    v,
+			}
+			t.m.Unlock()
+			ok = true
+		}
+	}()
+	fn(t.ctx)
+	return
+}
@@ -0,0 +1,75 @@
+package astiworker_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/worker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTask_Panic(t *testing.T) {
+	// Fail
+	var m sync.Mutex
+	var ps []astiworker.TaskPanic
+	w := astiworker.NewWorkerWithOptions(astiworker.WorkerOptions{OnTaskPanic: func(p astiworker.TaskPanic) {
+		m.Lock()
+		ps = append(ps, p)
+		m.Unlock()
+	}})
+	other := w.NewTask(astiworker.TaskConfiguration{Name: "other"})
+	other.Do(func(ctx context.Context) { <-ctx.Done() })
+	tk := w.NewTask(astiworker.TaskConfiguration{Name: "fail"})
+	tk.Do(func(ctx context.Context) { panic("boom") })
+	assert.Eventually(t, func() bool { return tk.Err() != nil }, time.Second, time.Millisecond)
+	assert.EqualError(t, tk.Err(), "astiworker: task fail panicked: boom")
+	assert.NoError(t, other.Context().Err())
+	m.Lock()
+	assert.Len(t, ps, 1)
+	assert.Equal(t, "fail", ps[0].Task)
+	assert.Equal(t, "boom", ps[0].Value)
+	assert.NotEmpty(t, ps[0].Stack)
+	m.Unlock()
+	assert.NoError(t, w.Stop())
+
+	// Restart
+	w = astiworker.NewWorker()
+	var count int
+	tk = w.NewTask(astiworker.TaskConfiguration{MaxRestarts: 2, Name: "restart", PanicPolicy: astiworker.PanicPolicyRestart})
+	tk.Do(func(ctx context.Context) {
+		count++
+		panic("boom")
+	})
+	assert.Eventually(t, func() bool { return tk.Err() != nil }, time.Second, time.Millisecond)
+	assert.Equal(t, 3, count)
+	assert.NoError(t, w.Stop())
+
+	// Restart until the func returns
+	w = astiworker.NewWorker()
+	count = 0
+	ch := make(chan bool)
+	tk = w.NewTask(astiworker.TaskConfiguration{Name: "recover", PanicPolicy: astiworker.PanicPolicyRestart})
+	tk.Do(func(ctx context.Context) {
+		if count++; count < 3 {
+			panic("boom")
+		}
+		close(ch)
+		<-ctx.Done()
+	})
+	<-ch
+	assert.Equal(t, 2, w.Tasks()[0].Restarts)
+	assert.NoError(t, w.Stop())
+	assert.NoError(t, tk.Err())
+
+	// Stop worker
+	w = astiworker.NewWorker()
+	other = w.NewTask(astiworker.TaskConfiguration{Name: "other"})
+	other.Do(func(ctx context.Context) { <-ctx.Done() })
+	tk = w.NewTask(astiworker.TaskConfiguration{Name: "stop", PanicPolicy: astiworker.PanicPolicyStopWorker})
+	tk.Do(func(ctx context.Context) { panic("boom") })
+	err := w.Wait()
+	assert.EqualError(t, other.Cause(), "asticontext: cancelled because of dependency failure: astiworker: task stop panicked: boom")
+	assert.NoError(t, err)
+}
@@ -0,0 +1,380 @@
+package astiworker
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/asticode/go-astitools/logger"
+	"github.com/asticode/go-astitools/string"
+	"github.com/asticode/go-astitools/time"
+	"github.com/pkg/errors"
+)
+
+// Job represents a queued job
+type Job struct {
+	Attempts    int       `json:"attempts"`
+	CreatedAt   time.Time `json:"created_at"`
+	ID          string    `json:"id"`
+	LastError   string    `json:"last_error,omitempty"`
+	MaxAttempts int       `json:"max_attempts"`
+	NextAt      time.Time `json:"next_at"`
+	Payload     []byte    `json:"payload"`
+}
+
+// JobHandler represents a func processing a job
+// Returning an error retries the job until its max attempts are reached, it's then moved to the dead letters.
+type JobHandler func(ctx context.Context, j Job) error
+
+// QueueStorage represents an object persisting jobs so that they survive restarts
+// An in-memory storage is provided, Redis or SQL storages can be plugged by implementing this interface.
+type QueueStorage interface {
+	// Delete deletes a pending job
+	Delete(ctx context.Context, id string) error
+	// DeadLetter deletes a pending job and stores it as a dead letter
+	DeadLetter(ctx context.Context, j Job) error
+	// List lists pending jobs
+	List(ctx context.Context) ([]Job, error)
+	// Save creates or updates a pending job
+	Save(ctx context.Context, j Job) error
+}
+
+// QueueOptions represents queue options
+type QueueOptions struct {
+	// Backoff is the amount of time waited before retrying a job. Defaults to a 1s capped exponential backoff.
+	Backoff astitime.Backoff
+	// MaxAttempts is the default max number of times a job is processed. Defaults to 3.
+	MaxAttempts int
+	// Name is the name of the queue's task. Defaults to "queue".
+	Name string
+	// OnDeadLetter is executed once a job has been moved to the dead letters
+	OnDeadLetter func(j Job)
+	// Parallelism is the max number of jobs processed at the same time. Defaults to 1.
+	Parallelism int
+	// Storage defaults to an in-memory storage
+	Storage QueueStorage
+}
+
+// Queue represents a job queue consumed by a bounded pool of goroutines running in a worker task
+type Queue struct {
+	c      astitime.Clock
+	h      JobHandler
+	ids    map[string]bool
+	jobs   []Job
+	m      *sync.Mutex // Locks ids and jobs
+	notify chan struct{}
+	o      QueueOptions
+	w      *Worker
+}
+
+// NewQueue creates a new queue
+// Queue.Start must be called for jobs to be processed.
+func (w *Worker) NewQueue(h JobHandler, o QueueOptions) *Queue {
+	// Default options values
+	if o.Backoff == nil {
+		o.Backoff = astitime.ExponentialBackoff{Base: time.Second, Jitter: 0.1, Max: time.Minute}
+	}
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 3
+	}
+	if o.Name == "" {
+		o.Name = "queue"
+	}
+	if o.Parallelism <= 0 {
+		o.Parallelism = 1
+	}
+	if o.Storage == nil {
+		o.Storage = NewMemoryQueueStorage()
+	}
+
+	// Create queue
+	return &Queue{
+		c:      w.c,
+		h:      h,
+		ids:    make(map[string]bool),
+		m:      &sync.Mutex{},
+		notify: make(chan struct{}, 1),
+		o:      o,
+		w:      w,
+	}
+}
+
+// EnqueueOptions represents enqueue options
+type EnqueueOptions struct {
+	// Delay postpones the first attempt
+	Delay time.Duration
+	// MaxAttempts overrides the queue's max attempts
+	MaxAttempts int
+}
+
+// Enqueue persists and enqueues a new job
+func (q *Queue) Enqueue(ctx context.Context, payload []byte, o EnqueueOptions) (j Job, err error) {
+	// Create job
+	var now = q.c.Now()
+	j = Job{
+		CreatedAt:   now,
+		ID:          astistring.UUIDv7().String(),
+		MaxAttempts: o.MaxAttempts,
+		NextAt:      now.Add(o.Delay),
+		Payload:     payload,
+	}
+	if j.MaxAttempts <= 0 {
+		j.MaxAttempts = q.o.MaxAttempts
+	}
+
+	// Save
+	if err = q.o.Storage.Save(ctx, j); err != nil {
+		err = errors.Wrapf(err, "astiworker: saving job %s failed", j.ID)
+		return
+	}
+
+	// Push
+	q.push(j)
+	return
+}
+
+// Len returns the number of pending jobs
+func (q *Queue) Len() int {
+	q.m.Lock()
+	defer q.m.Unlock()
+	return len(q.jobs)
+}
+
+// Start loads pending jobs from the storage and starts consuming them until the worker stops
+// Jobs being processed when the worker stops are kept in the storage and are processed again on the next start.
+func (q *Queue) Start(ctx context.Context) (err error) {
+	// Load jobs
+	var js []Job
+	if js, err = q.o.Storage.List(ctx); err != nil {
+		err = errors.Wrap(err, "astiworker: listing jobs failed")
+		return
+	}
+	for _, j := range js {
+		q.push(j)
+	}
+
+	// Create task
+	var t = q.w.NewTask(TaskConfiguration{Name: q.o.Name})
+
+	// Consume
+	var wg sync.WaitGroup
+	for i := 0; i < q.o.Parallelism; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.consume(t.Context())
+		}()
+	}
+	go func() {
+		wg.Wait()
+		t.Done()
+	}()
+	return
+}
+
+// push inserts a job according to its next attempt and wakes a consumer up
+// Jobs already pending are ignored, which happens when a job enqueued before Start is listed by Start.
+func (q *Queue) push(j Job) {
+	// Lock
+	q.m.Lock()
+	defer q.m.Unlock()
+
+	// Job is already pending
+	if q.ids[j.ID] {
+		return
+	}
+	q.ids[j.ID] = true
+
+	// Insert
+	var i = sort.Search(len(q.jobs), func(i int) bool { return q.jobs[i].NextAt.After(j.NextAt) })
+	q.jobs = append(q.jobs, Job{})
+	copy(q.jobs[i+1:], q.jobs[i:])
+	q.jobs[i] = j
+
+	// Notify
+	q.wakeUp()
+}
+
+// wakeUp wakes a single consumer up without blocking
+// A wake-up is kept until a consumer is waiting, and consumers taking a job wake another one up if jobs are left, so
+// that all due jobs end up being processed without waking all consumers up on every push.
+func (q *Queue) wakeUp() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// next blocks until a job is due or the context is cancelled
+// The consumer's timer is created on first use, reset to wait for the first job and stopped when next returns.
+func (q *Queue) next(ctx context.Context, tm *astitime.ClockTimer) (j Job, err error) {
+	for {
+		// Lock
+		q.m.Lock()
+
+		// Get first job
+		var wait <-chan time.Time
+		if len(q.jobs) > 0 {
+			d := q.jobs[0].NextAt.Sub(q.c.Now())
+			if d <= 0 {
+				j = q.jobs[0]
+				q.jobs = q.jobs[1:]
+				delete(q.ids, j.ID)
+				if len(q.jobs) > 0 {
+					q.wakeUp()
+				}
+				q.m.Unlock()
+				return
+			}
+			if *tm == nil {
+				*tm = q.c.NewTimer(d)
+			} else {
+				(*tm).Reset(d)
+			}
+			wait = (*tm).C()
+		}
+		q.m.Unlock()
+
+		// Wait
+		select {
+		case <-ctx.Done():
+			stopTimer(*tm, wait)
+			err = ctx.Err()
+			return
+		case <-q.notify:
+			stopTimer(*tm, wait)
+		case <-wait:
+		}
+	}
+}
+
+// stopTimer stops a timer that has been reset, if wait is not nil, and drains its channel so that it can be reset
+func stopTimer(tm astitime.ClockTimer, wait <-chan time.Time) {
+	if wait != nil && !tm.Stop() {
+		select {
+		case <-wait:
+		default:
+		}
+	}
+}
+
+// consume processes jobs until the context is cancelled
+func (q *Queue) consume(ctx context.Context) {
+	var tm astitime.ClockTimer
+	for {
+		// Get next job
+		j, err := q.next(ctx, &tm)
+		if err != nil {
+			return
+		}
+
+		// Process
+		q.process(ctx, j)
+	}
+}
+
+// process processes a job and either deletes it, retries it or moves it to the dead letters
+// The handler's context holds the job's ID as an astilogger field.
+func (q *Queue) process(ctx context.Context, j Job) {
+	// Handle
+	ctx = astilogger.ContextWithFields(ctx, astilogger.KeyJobID, j.ID)
+	var l = astilogger.WithContext(q.w.l, ctx)
+	j.Attempts++
+	var err = q.h(ctx, j)
+
+	// Success
+	if err == nil {
+		if err = q.o.Storage.Delete(context.Background(), j.ID); err != nil {
+			l.Error("astiworker: deleting job failed", astilogger.KeyError, err)
+		}
+		return
+	}
+
+	// Context has been cancelled, the job will be processed again on the next start
+	if ctx.Err() != nil {
+		return
+	}
+	j.LastError = err.Error()
+
+	// Max attempts have been reached
+	if j.Attempts >= j.MaxAttempts {
+		l.Error("astiworker: job failed too many times, moving it to dead letters", "attempts", j.Attempts,
+			astilogger.KeyError, err)
+		if err = q.o.Storage.DeadLetter(context.Background(), j); err != nil {
+			l.Error("astiworker: moving job to dead letters failed", astilogger.KeyError, err)
+			return
+		}
+		if q.o.OnDeadLetter != nil {
+			q.o.OnDeadLetter(j)
+		}
+		return
+	}
+
+	// Retry
+	var d = q.o.Backoff.Duration(j.Attempts)
+	l.Debug("astiworker: job failed, retrying", "delay", d, astilogger.KeyError, err)
+	j.NextAt = q.c.Now().Add(d)
+	if err = q.o.Storage.Save(context.Background(), j); err != nil {
+		l.Error("astiworker: saving job failed", astilogger.KeyError, err)
+	}
+	q.push(j)
+}
+
+// MemoryQueueStorage represents an in-memory queue storage
+type MemoryQueueStorage struct {
+	deadLetters []Job
+	jobs        map[string]Job
+	m           *sync.Mutex // Locks deadLetters and jobs
+}
+
+// NewMemoryQueueStorage creates a new in-memory queue storage
+func NewMemoryQueueStorage() *MemoryQueueStorage {
+	return &MemoryQueueStorage{
+		jobs: make(map[string]Job),
+		m:    &sync.Mutex{},
+	}
+}
+
+// DeadLetters returns dead letters in the order they were added
+func (s *MemoryQueueStorage) DeadLetters() []Job {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return append([]Job{}, s.deadLetters...)
+}
+
+// Delete implements the QueueStorage interface
+func (s *MemoryQueueStorage) Delete(ctx context.Context, id string) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	delete(s.jobs, id)
+	return nil
+}
+
+// DeadLetter implements the QueueStorage interface
+func (s *MemoryQueueStorage) DeadLetter(ctx context.Context, j Job) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	delete(s.jobs, j.ID)
+	s.deadLetters = append(s.deadLetters, j)
+	return nil
+}
+
+// List implements the QueueStorage interface
+func (s *MemoryQueueStorage) List(ctx context.Context) (js []Job, err error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	for _, j := range s.jobs {
+		js = append(js, j)
+	}
+	sort.Slice(js, func(i, j int) bool { return js[i].ID < js[j].ID })
+	return
+}
+
+// Save implements the QueueStorage interface
+func (s *MemoryQueueStorage) Save(ctx context.Context, j Job) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.jobs[j.ID] = j
+	return nil
+}
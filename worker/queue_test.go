@@ -0,0 +1,108 @@
+package astiworker_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/time"
+	"github.com/asticode/go-astitools/worker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueue(t *testing.T) {
+	// Setup
+	var s = astiworker.NewMemoryQueueStorage()
+	var m sync.Mutex
+	var attempts = make(map[string]int)
+	var done = make(chan string, 10)
+	var h = func(ctx context.Context, j astiworker.Job) error {
+		m.Lock()
+		attempts[string(j.Payload)]++
+		m.Unlock()
+		switch string(j.Payload) {
+		case "retry":
+			if j.Attempts < 2 {
+				return errors.New("retry")
+			}
+		case "fail":
+			return errors.New("fail")
+		case "block":
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		done <- string(j.Payload)
+		return nil
+	}
+
+	// First run
+	w := astiworker.NewWorker()
+	q := w.NewQueue(h, astiworker.QueueOptions{
+		Backoff:      astitime.ConstantBackoff(time.Millisecond),
+		OnDeadLetter: func(j astiworker.Job) { done <- "dead " + string(j.Payload) },
+		Parallelism:  2,
+		Storage:      s,
+	})
+	assert.NoError(t, q.Start(context.Background()))
+	for _, p := range []string{"success", "retry", "fail"} {
+		_, err := q.Enqueue(context.Background(), []byte(p), astiworker.EnqueueOptions{})
+		assert.NoError(t, err)
+	}
+	var got []string
+	for i := 0; i < 3; i++ {
+		got = append(got, <-done)
+	}
+	assert.ElementsMatch(t, []string{"success", "retry", "dead fail"}, got)
+	assert.Equal(t, map[string]int{"fail": 3, "retry": 2, "success": 1}, attempts)
+	assert.Len(t, s.DeadLetters(), 1)
+	assert.Equal(t, "fail", s.DeadLetters()[0].LastError)
+
+	// Jobs survive restarts
+	_, err := q.Enqueue(context.Background(), []byte("block"), astiworker.EnqueueOptions{})
+	assert.NoError(t, err)
+	_, err = q.Enqueue(context.Background(), []byte("delayed"), astiworker.EnqueueOptions{Delay: time.Hour})
+	assert.NoError(t, err)
+	for q.Len() > 1 {
+		time.Sleep(time.Millisecond)
+	}
+	assert.NoError(t, w.Stop())
+	js, err := s.List(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, js, 2)
+
+	// Second run
+	w = astiworker.NewWorker()
+	q = w.NewQueue(h, astiworker.QueueOptions{Storage: s})
+	assert.NoError(t, q.Start(context.Background()))
+	for q.Len() > 1 {
+		time.Sleep(time.Millisecond)
+	}
+	assert.NoError(t, w.Stop())
+	m.Lock()
+	assert.Equal(t, 2, attempts["block"])
+	m.Unlock()
+}
+
+func TestQueue_EnqueueBeforeStart(t *testing.T) {
+	var n int
+	var done = make(chan bool, 2)
+	w := astiworker.NewWorker()
+	defer w.Stop()
+	q := w.NewQueue(func(ctx context.Context, j astiworker.Job) error {
+		n++
+		done <- true
+		return nil
+	}, astiworker.QueueOptions{})
+	_, err := q.Enqueue(context.Background(), []byte("p"), astiworker.EnqueueOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, q.Len())
+	assert.NoError(t, q.Start(context.Background()))
+	<-done
+	select {
+	case <-done:
+	case <-time.After(50 * time.Millisecond):
+	}
+	assert.Equal(t, 1, n)
+}
@@ -0,0 +1,62 @@
+package astiworker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/asticode/go-astitools/logger"
+)
+
+// Reloader represents a func capable of re-initializing a component (configuration, TLS certificates, log level,
+// etc.) without restarting tasks
+type Reloader func() error
+
+// HandleReload registers a reloader that will be executed every time the worker is reloaded
+func (w *Worker) HandleReload(fn Reloader) {
+	w.mr.Lock()
+	defer w.mr.Unlock()
+	w.reloaders = append(w.reloaders, fn)
+}
+
+// Reload executes all registered reloaders in their registration order
+// A failing reloader doesn't prevent the next ones from being executed: errors are aggregated in the returned
+// ReloadError
+func (w *Worker) Reload() (err error) {
+	// Get reloaders
+	w.mr.Lock()
+	var rs = make([]Reloader, len(w.reloaders))
+	copy(rs, w.reloaders)
+	w.mr.Unlock()
+
+	// Loop through reloaders
+	w.l.Info("Reloading Worker...")
+	var errs []error
+	for _, r := range rs {
+		if e := r(); e != nil {
+			errs = append(errs, e)
+		}
+	}
+
+	// Process errors
+	if len(errs) > 0 {
+		err = ReloadError{Errors: errs}
+		w.l.Error("astiworker: reloading worker failed", astilogger.KeyError, err)
+		return
+	}
+	w.l.Info("Worker has been reloaded")
+	return
+}
+
+// ReloadError represents an error aggregating errors returned by reloaders
+type ReloadError struct {
+	Errors []error
+}
+
+// Error implements the error interface
+func (e ReloadError) Error() string {
+	var ss []string
+	for _, err := range e.Errors {
+		ss = append(ss, err.Error())
+	}
+	return fmt.Sprintf("astiworker: reloading failed: %s", strings.Join(ss, ", "))
+}
@@ -0,0 +1,29 @@
+package astiworker_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/asticode/go-astitools/worker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorker_Reload(t *testing.T) {
+	w := astiworker.NewWorker()
+	var count int
+	w.HandleReload(func() error {
+		count++
+		return nil
+	})
+	assert.NoError(t, w.Reload())
+	assert.Equal(t, 1, count)
+	w.HandleReload(func() error { return errors.New("error1") })
+	w.HandleReload(func() error {
+		count++
+		return nil
+	})
+	w.HandleReload(func() error { return errors.New("error2") })
+	err := w.Reload()
+	assert.EqualError(t, err, "astiworker: reloading failed: error1, error2")
+	assert.Equal(t, 3, count)
+}
@@ -0,0 +1,192 @@
+package astiworker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/asticode/go-astitools/context"
+	"github.com/asticode/go-astitools/logger"
+	"github.com/pkg/errors"
+)
+
+// DefaultTaskStopTimeout represents the default amount of time a task is given to finish once the worker is stopping
+var DefaultTaskStopTimeout = 5 * time.Second
+
+// Task represents a task handled by the worker
+type Task struct {
+	c              TaskConfiguration
+	cancel         context.CancelFunc
+	ctx            context.Context
+	done           chan bool
+	err            error
+	id             uint64
+	l              astilogger.Logger
+	m              sync.Mutex // Locks err, readinessCheck and restarts
+	o              sync.Once
+	readinessCheck ReadinessCheck
+	restarts       int
+	startedAt      time.Time
+	w              *Worker
+}
+
+// TaskConfiguration represents a task configuration
+type TaskConfiguration struct {
+	// Maximum number of restarts when the panic policy is PanicPolicyRestart. 0 means no maximum.
+	MaxRestarts int    `toml:"max_restarts"`
+	Name        string `toml:"name"`
+	// Defaults to PanicPolicyFail
+	PanicPolicy  string        `toml:"panic_policy"`
+	RestartDelay time.Duration `toml:"restart_delay"`
+	StopTimeout  time.Duration `toml:"stop_timeout"`
+}
+
+// NewTask creates a new task
+// Task.Done must be called once the task is over
+func (w *Worker) NewTask(c TaskConfiguration) (t *Task) {
+	// Default configuration values
+	if c.PanicPolicy == "" {
+		c.PanicPolicy = PanicPolicyFail
+	}
+	if c.StopTimeout == 0 {
+		c.StopTimeout = DefaultTaskStopTimeout
+	}
+
+	// Create task
+	t = &Task{
+		c:         c,
+		done:      make(chan bool),
+		startedAt: w.c.Now(),
+		w:         w,
+	}
+
+	// Add task
+	w.mt.Lock()
+	w.id++
+	t.id = w.id
+	t.l = astilogger.With(w.l, astilogger.KeyTask, c.Name, astilogger.KeyTaskID, t.id)
+	t.ctx, t.cancel = context.WithCancel(astilogger.ContextWithFields(w.ctx, astilogger.KeyTask, c.Name,
+		astilogger.KeyTaskID, t.id))
+	w.tasks[t.id] = t
+	w.mt.Unlock()
+	return
+}
+
+// Context returns the task's context
+// It is cancelled when the worker is stopping. It holds the task's name and ID as astilogger fields so that
+// messages logged with astilogger.WithContext by packages the task is using are correlated with it.
+func (t *Task) Context() context.Context {
+	return t.ctx
+}
+
+// Logger returns the worker's logger with the task's name and ID as fields
+func (t *Task) Logger() astilogger.Logger {
+	return t.l
+}
+
+// Do executes a func in a goroutine and marks the task as done once it returns
+// Panics are recovered and handled according to the task's panic policy, see TaskConfiguration.PanicPolicy.
+func (t *Task) Do(fn func(ctx context.Context)) {
+	go func() {
+		defer t.Done()
+		t.run(fn)
+	}()
+}
+
+// Done marks the task as done
+func (t *Task) Done() {
+	t.o.Do(func() {
+		// Remove task
+		t.w.mt.Lock()
+		delete(t.w.tasks, t.id)
+		t.w.mt.Unlock()
+
+		// Release resources
+		t.cancel()
+		close(t.done)
+	})
+}
+
+// Name returns the task's name
+func (t *Task) Name() string {
+	return t.c.Name
+}
+
+// Cause returns why the task's context has been cancelled, or nil if it hasn't
+func (t *Task) Cause() error {
+	return asticontext.Cause(t.ctx)
+}
+
+// StopWorker stops the worker from within the task, typically after a fatal error
+// Contrary to Worker.Stop, it doesn't wait for the task itself to be done. The task's context is cancelled as well.
+// Other tasks' contexts are cancelled with the dependency failure reason.
+func (t *Task) StopWorker() error {
+	defer t.cancel()
+	return t.w.stop(t, asticontext.ReasonDependencyFailure, errors.Errorf("astiworker: task %s stopped the worker", t.c.Name))
+}
+
+// stop cancels the task's context and waits for the task to be done or for its stop timeout to be reached
+func (t *Task) stop() (ok bool) {
+	// Cancel context
+	t.cancel()
+	t.l.Debug("astiworker: stopping task", "cause", t.Cause())
+
+	// Wait for the task to be done
+	select {
+	case <-t.done:
+		return true
+	case <-t.w.c.After(t.c.StopTimeout):
+		t.l.Warn("astiworker: task didn't stop in time, abandoning it", "timeout", t.c.StopTimeout)
+		return false
+	}
+}
+
+// StopError represents an error listing tasks that failed to stop in time
+type StopError struct {
+	Tasks []string
+}
+
+// Error implements the error interface
+func (e StopError) Error() string {
+	return fmt.Sprintf("astiworker: tasks %s failed to stop", strings.Join(e.Tasks, ", "))
+}
+
+// stopTasks stops all tasks but the excluded one in parallel and reports those that failed to stop in time
+func (w *Worker) stopTasks(exclude *Task) error {
+	// Get tasks
+	w.mt.Lock()
+	var ts []*Task
+	for _, t := range w.tasks {
+		if t != exclude {
+			ts = append(ts, t)
+		}
+	}
+	w.mt.Unlock()
+
+	// Stop tasks
+	var failed []string
+	var m sync.Mutex
+	var wg sync.WaitGroup
+	for _, t := range ts {
+		wg.Add(1)
+		go func(t *Task) {
+			defer wg.Done()
+			if !t.stop() {
+				m.Lock()
+				failed = append(failed, t.c.Name)
+				m.Unlock()
+			}
+		}(t)
+	}
+	wg.Wait()
+
+	// No failed tasks
+	if len(failed) == 0 {
+		return nil
+	}
+	sort.Strings(failed)
+	return StopError{Tasks: failed}
+}
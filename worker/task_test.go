@@ -0,0 +1,98 @@
+package astiworker_test
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/asticode/go-astitools/context"
+	"github.com/asticode/go-astitools/logger"
+	"github.com/asticode/go-astitools/time"
+	"github.com/asticode/go-astitools/worker"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorker_Stop(t *testing.T) {
+	// Success
+	w := astiworker.NewWorker()
+	w.NewTask(astiworker.TaskConfiguration{Name: "success"}).Do(func(ctx context.Context) { <-ctx.Done() })
+	assert.NoError(t, w.Stop())
+	assert.NoError(t, w.Wait())
+
+	// Failure
+	w = astiworker.NewWorker()
+	w.NewTask(astiworker.TaskConfiguration{Name: "stopped"}).Do(func(ctx context.Context) { <-ctx.Done() })
+	ch := make(chan bool)
+	defer close(ch)
+	w.NewTask(astiworker.TaskConfiguration{Name: "hanging2", StopTimeout: time.Millisecond}).Do(func(ctx context.Context) { <-ch })
+	w.NewTask(astiworker.TaskConfiguration{Name: "hanging1", StopTimeout: time.Millisecond}).Do(func(ctx context.Context) { <-ch })
+	err := w.Stop()
+	assert.Equal(t, astiworker.StopError{Tasks: []string{"hanging1", "hanging2"}}, err)
+	assert.EqualError(t, err, "astiworker: tasks hanging1, hanging2 failed to stop")
+	assert.Equal(t, err, w.Wait())
+
+	// Task stopping the worker
+	w = astiworker.NewWorker()
+	w.NewTask(astiworker.TaskConfiguration{Name: "self", StopTimeout: 100 * time.Millisecond}).Do(func(ctx context.Context) {
+		<-ctx.Done()
+		w.Stop()
+	})
+	assert.NoError(t, w.Stop())
+	assert.NoError(t, w.Wait())
+
+	// Task stopping the worker after a fatal error
+	w = astiworker.NewWorker()
+	w.NewTask(astiworker.TaskConfiguration{Name: "other"}).Do(func(ctx context.Context) { <-ctx.Done() })
+	tk := w.NewTask(astiworker.TaskConfiguration{Name: "fatal", StopTimeout: 100 * time.Millisecond})
+	chanErr := make(chan error, 1)
+	tk.Do(func(ctx context.Context) { chanErr <- tk.StopWorker() })
+	assert.NoError(t, w.Wait())
+	assert.NoError(t, <-chanErr)
+}
+
+func TestTask_Cause(t *testing.T) {
+	// User stop
+	w := astiworker.NewWorker()
+	tk := w.NewTask(astiworker.TaskConfiguration{Name: "task"})
+	tk.Do(func(ctx context.Context) { <-ctx.Done() })
+	assert.NoError(t, tk.Cause())
+	assert.NoError(t, w.Stop())
+	assert.Equal(t, asticontext.ReasonUserStop, asticontext.CauseReason(tk.Context()))
+
+	// Dependency failure
+	w = astiworker.NewWorker()
+	other := w.NewTask(astiworker.TaskConfiguration{Name: "other"})
+	other.Do(func(ctx context.Context) { <-ctx.Done() })
+	tk = w.NewTask(astiworker.TaskConfiguration{Name: "fatal"})
+	tk.Do(func(ctx context.Context) { tk.StopWorker() })
+	assert.NoError(t, w.Wait())
+	assert.Equal(t, asticontext.ReasonDependencyFailure, asticontext.CauseReason(other.Context()))
+	assert.EqualError(t, other.Cause(), "asticontext: cancelled because of dependency failure: astiworker: task fatal stopped the worker")
+}
+
+func TestTask_StopTimeout(t *testing.T) {
+	c := astitime.NewFakeClock(time.Now())
+	w := astiworker.NewWorkerWithOptions(astiworker.WorkerOptions{Clock: c})
+	ch := make(chan bool)
+	defer close(ch)
+	w.NewTask(astiworker.TaskConfiguration{Name: "hanging", StopTimeout: time.Hour}).Do(func(ctx context.Context) { <-ch })
+	chanErr := make(chan error, 1)
+	go func() { chanErr <- w.Stop() }()
+	c.BlockUntil(1)
+	c.Add(time.Hour)
+	assert.Equal(t, astiworker.StopError{Tasks: []string{"hanging"}}, <-chanErr)
+}
+
+func TestTask_Logger(t *testing.T) {
+	var buf = &bytes.Buffer{}
+	w := astiworker.NewWorkerWithOptions(astiworker.WorkerOptions{Logger: astilogger.NewStdLogger(log.New(buf, "", 0), astilogger.LevelWarn)})
+	tk := w.NewTask(astiworker.TaskConfiguration{Name: "t"})
+	tk.Logger().Warn("msg", "k", "v")
+	assert.Equal(t, "WARN msg task=t task_id=1 k=v\n", buf.String())
+	id, ok := astilogger.Field(tk.Context(), astilogger.KeyTaskID)
+	assert.True(t, ok)
+	assert.Equal(t, uint64(1), id)
+	tk.Done()
+}
@@ -1,56 +1,180 @@
 package astiworker
 
 import (
+	"context"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 
-	"github.com/asticode/go-astilog"
+	"github.com/asticode/go-astitools/context"
+	"github.com/asticode/go-astitools/logger"
+	"github.com/asticode/go-astitools/time"
+	"github.com/pkg/errors"
 )
 
 // Worker represents an object capable of blocking, handling signals and stopping
 type Worker struct {
-	channelQuit chan bool
+	c               astitime.Clock
+	cancel          asticontext.CancelFunc
+	channelQuit     chan bool
+	ctx             context.Context
+	err             error
+	id              uint64
+	l               astilogger.Logger
+	mc              sync.Mutex // Locks readinessChecks
+	mq              sync.Mutex // Locks channelQuit, err and stopping
+	mr              sync.Mutex // Locks reloaders
+	ms              sync.Mutex // Locks subscriberID and subscribers
+	mt              sync.Mutex // Locks tasks
+	onTaskPanic     func(p TaskPanic)
+	readinessChecks map[string]ReadinessCheck
+	reloaders       []Reloader
+	stopping        bool
+	subscriberID    uint64
+	subscribers     map[string]map[uint64]*subscriber
+	tasks           map[uint64]*Task
+}
+
+// WorkerOptions represents worker options
+type WorkerOptions struct {
+	// Clock used by the worker, e.g. for tasks' stop timeouts. Defaults to the real clock.
+	Clock astitime.Clock
+	// Logger used by the worker and its tasks, see Task.Logger. Defaults to astilogger.Default()
+	Logger astilogger.Logger
+	// OnTaskPanic is called every time a panic is recovered in a task, e.g. to report it to a crash reporting service
+	OnTaskPanic func(p TaskPanic)
 }
 
 // NewWorker builds a new worker
 func NewWorker() *Worker {
-	astilog.Info("Starting Worker...")
-	return &Worker{channelQuit: make(chan bool)}
+	return NewWorkerWithOptions(WorkerOptions{})
+}
+
+// NewWorkerWithOptions builds a new worker with options
+func NewWorkerWithOptions(o WorkerOptions) (w *Worker) {
+	// Default options values
+	if o.Clock == nil {
+		o.Clock = astitime.RealClock{}
+	}
+	if o.Logger == nil {
+		o.Logger = astilogger.Default()
+	}
+
+	// Create worker
+	o.Logger.Info("Starting Worker...")
+	w = &Worker{
+		c:               o.Clock,
+		channelQuit:     make(chan bool),
+		l:               o.Logger,
+		onTaskPanic:     o.OnTaskPanic,
+		readinessChecks: make(map[string]ReadinessCheck),
+		subscribers:     make(map[string]map[uint64]*subscriber),
+		tasks:           make(map[uint64]*Task),
+	}
+	w.ctx, w.cancel = asticontext.WithCancel(context.Background())
+	return
 }
 
 // Close closes the worker
-func (w Worker) Close() {
-	astilog.Info("Closing Worker...")
+func (w *Worker) Close() {
+	w.l.Info("Closing Worker...")
+}
+
+// Clock returns the worker's clock
+func (w *Worker) Clock() astitime.Clock {
+	return w.c
+}
+
+// Logger returns the worker's logger
+func (w *Worker) Logger() astilogger.Logger {
+	return w.l
+}
+
+// Context returns the worker's context
+func (w *Worker) Context() context.Context {
+	return w.ctx
 }
 
 // HandleSignals handles signals
-func (w Worker) HandleSignals() {
+// SIGHUP reloads the worker, other signals stop it
+func (w *Worker) HandleSignals() {
 	ch := make(chan os.Signal, 1)
-	signal.Notify(ch, syscall.SIGABRT, syscall.SIGKILL, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM)
+	signal.Notify(ch, syscall.SIGABRT, syscall.SIGHUP, syscall.SIGKILL, syscall.SIGINT, syscall.SIGQUIT, syscall.SIGTERM)
 	go func() {
 		for s := range ch {
-			astilog.Infof("Received signal %s", s)
-			w.Stop()
+			w.l.Info("Received signal", "signal", s)
+			if s == syscall.SIGHUP {
+				w.Reload()
+				continue
+			}
+			signal.Stop(ch)
+			w.stop(nil, asticontext.ReasonSignal, errors.Errorf("astiworker: received signal %s", s))
 			return
 		}
 	}()
 }
 
 // Stop stops the Worker
-func (w *Worker) Stop() {
-	astilog.Info("Stopping Worker...")
-	if w.channelQuit != nil {
-		close(w.channelQuit)
-		w.channelQuit = nil
-	}
+// Tasks' contexts are cancelled and each task is given its stop timeout to finish. Tasks that don't finish in time
+// are abandoned and reported in the returned StopError.
+// Calls made while the worker is already stopping, e.g. by a task shutting down the worker once its context is
+// cancelled, return right away so that they don't delay the stop. Use Wait to block until the worker has stopped.
+// A task stopping the worker while it's running should use Task.StopWorker instead, otherwise Stop waits for the task
+// itself.
+// Tasks' contexts are cancelled with the user stop reason, see asticontext.Cause.
+func (w *Worker) Stop() error {
+	return w.stop(nil, asticontext.ReasonUserStop, nil)
 }
 
-// Wait is a blocking pattern
-func (w *Worker) Wait() {
-	astilog.Info("Worker is now waiting...")
+// stop stops the worker without waiting for the excluded task, if any, cancelling tasks' contexts with the provided
+// reason
+func (w *Worker) stop(exclude *Task, r asticontext.Reason, cause error) (err error) {
+	// Lock
+	w.mq.Lock()
+
+	// Already stopped
 	if w.channelQuit == nil {
+		err = w.err
+		w.mq.Unlock()
 		return
 	}
-	<-w.channelQuit
+
+	// Already stopping
+	if w.stopping {
+		w.mq.Unlock()
+		return
+	}
+	w.stopping = true
+	w.mq.Unlock()
+
+	// Stop tasks
+	w.l.Info("Stopping Worker...", "reason", r)
+	w.cancel(r, cause)
+	if err = w.stopTasks(exclude); err != nil {
+		w.l.Error("astiworker: stopping tasks failed", astilogger.KeyError, err)
+	}
+
+	// Quit
+	w.mq.Lock()
+	defer w.mq.Unlock()
+	w.err = err
+	close(w.channelQuit)
+	w.channelQuit = nil
+	return
+}
+
+// Wait is a blocking pattern
+// It returns the error returned by Stop, if any
+func (w *Worker) Wait() error {
+	w.l.Info("Worker is now waiting...")
+	w.mq.Lock()
+	ch := w.channelQuit
+	w.mq.Unlock()
+	if ch != nil {
+		<-ch
+	}
+	w.mq.Lock()
+	defer w.mq.Unlock()
+	return w.err
 }
@@ -3,29 +3,51 @@ package astizip
 import (
 	"archive/zip"
 	"context"
+	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 
 	"io/ioutil"
 
 	"github.com/asticode/go-astitools/io"
+	"github.com/asticode/go-astitools/os"
 	"github.com/pkg/errors"
 )
 
 // DefaultFileMode represents the default file mode
 var DefaultFileMode os.FileMode = 0775
 
+// UnzipOptions represents unzip options
+type UnzipOptions struct {
+	// Glob patterns (see path.Match) of entries that must not be unzipped. A pattern matching a directory matches
+	// its content as well
+	Exclude []string
+	// Glob patterns (see path.Match) of entries that must be unzipped. If empty, all entries are unzipped. A pattern
+	// matching a directory matches its content as well
+	Include []string
+	// Number of leading path elements removed from entries names. Entries with fewer path elements are skipped
+	StripComponents int
+}
+
 // Unzip unzips a src into a dst
 // Possible src formats are /path/to/zip.zip or /path/to/zip.zip/internal/path if you only want to unzip files in
 // /internal/path in the .zip archive
-func Unzip(ctx context.Context, src, dst string) (err error) {
+func Unzip(ctx context.Context, src, dst string) error {
+	return UnzipWithOptions(ctx, src, dst, UnzipOptions{})
+}
+
+// UnzipWithOptions unzips a src into a dst using specific options
+// Entries escaping dst (e.g. ../../etc/passwd), symlinks targeting a path outside dst and entries whose parent is a
+// symlink make it fail.
+func UnzipWithOptions(ctx context.Context, src, dst string, o UnzipOptions) (err error) {
 	// Parse src path
 	var split = strings.Split(src, ".zip")
-	src = split[0] + ".zip"
 	var internalPath string
 	if len(split) >= 2 {
+		src = split[0] + ".zip"
 		internalPath = split[1]
 	}
 
@@ -37,34 +59,62 @@ func Unzip(ctx context.Context, src, dst string) (err error) {
 	defer r.Close()
 
 	// Loop through files to determine their type
-	var dirs, files, symlinks = make(map[string]*zip.File), make(map[string]*zip.File), make(map[string]*zip.File)
+	var dirs, files, symlinks []unzipEntry
 	for _, f := range r.File {
 		// Validate internal path
 		var n = string(os.PathSeparator) + f.Name
 		if internalPath != "" && !strings.HasPrefix(n, internalPath) {
 			continue
 		}
-		var p = filepath.Join(dst, strings.TrimPrefix(n, internalPath))
+
+		// Filter
+		var name = strings.TrimPrefix(strings.TrimPrefix(n, internalPath), "/")
+		if !o.match(name) {
+			continue
+		}
+
+		// Strip components
+		var ok bool
+		if name, ok = stripComponents(name, o.StripComponents); !ok {
+			continue
+		}
+
+		// Make sure path doesn't escape dst
+		var p = filepath.Join(dst, name)
+		if p != filepath.Clean(dst) && !strings.HasPrefix(p, filepath.Clean(dst)+string(os.PathSeparator)) {
+			return fmt.Errorf("astizip: entry %s escapes destination %s", f.Name, dst)
+		}
 
 		// Check file type
+		var e = unzipEntry{f: f, p: p}
 		if f.FileInfo().Mode()&os.ModeSymlink != 0 {
-			symlinks[p] = f
+			symlinks = append(symlinks, e)
 		} else if f.FileInfo().IsDir() {
-			dirs[p] = f
+			dirs = append(dirs, e)
 		} else {
-			files[p] = f
+			files = append(files, e)
 		}
 	}
 
 	// Create dirs
-	for p, f := range dirs {
-		if err = os.MkdirAll(p, f.FileInfo().Mode().Perm()); err != nil {
-			return errors.Wrapf(err, "mkdirall %s failed", p)
+	// Modes are applied once the content has been extracted so that read-only dirs can be populated
+	for _, e := range dirs {
+		if err = astios.CheckParents(dst, e.p); err != nil {
+			return
+		}
+		if err = os.MkdirAll(e.p, DefaultFileMode); err != nil {
+			return errors.Wrapf(err, "mkdirall %s failed", e.p)
 		}
 	}
 
 	// Create files
-	for p, f := range files {
+	for _, e := range files {
+		// Check parents
+		var f, p = e.f, e.p
+		if err = astios.CheckParents(dst, p); err != nil {
+			return
+		}
+
 		// Open file reader
 		var fr io.ReadCloser
 		if fr, err = f.Open(); err != nil {
@@ -91,8 +141,14 @@ func Unzip(ctx context.Context, src, dst string) (err error) {
 		}
 	}
 
-	// Create symlinks
-	for p, f := range symlinks {
+	// Create symlinks in archive order, refusing those whose parent is a symlink created before
+	for _, e := range symlinks {
+		// Check parents
+		var f, p = e.f, e.p
+		if err = astios.CheckParents(dst, p); err != nil {
+			return
+		}
+
 		// Open file reader
 		var fr io.ReadCloser
 		if fr, err = f.Open(); err != nil {
@@ -106,10 +162,54 @@ func Unzip(ctx context.Context, src, dst string) (err error) {
 			return errors.Wrapf(err, "ioutil.Readall on %s failed", f.Name)
 		}
 
+		// Check target
+		if err = astios.CheckSymlink(dst, p, string(b)); err != nil {
+			return
+		}
+
+		// Make sure the directory of the symlink exists
+		if err = os.MkdirAll(filepath.Dir(p), DefaultFileMode); err != nil {
+			return errors.Wrapf(err, "mkdirall %s failed", filepath.Dir(p))
+		}
+
 		// Create the symlink
 		if err = os.Symlink(string(b), p); err != nil {
 			return errors.Wrapf(err, "creating symlink from %s to %s failed", string(b), p)
 		}
 	}
+
+	// Apply dirs modes, deepest dirs first
+	sort.Slice(dirs, func(i, j int) bool { return dirs[i].p > dirs[j].p })
+	for _, e := range dirs {
+		if err = os.Chmod(e.p, e.f.FileInfo().Mode().Perm()); err != nil {
+			return errors.Wrapf(err, "chmoding %s failed", e.p)
+		}
+	}
 	return
 }
+
+// unzipEntry represents a zip entry and the path it's unzipped to
+type unzipEntry struct {
+	f *zip.File
+	p string
+}
+
+// match checks whether an entry name matches the include and exclude patterns
+func (o UnzipOptions) match(name string) bool {
+	if len(o.Include) > 0 && !astios.MatchPatterns(o.Include, name) {
+		return false
+	}
+	return !astios.MatchPatterns(o.Exclude, name)
+}
+
+// stripComponents removes the n leading path elements of an entry name
+func stripComponents(name string, n int) (string, bool) {
+	if n <= 0 {
+		return name, true
+	}
+	var items = strings.Split(strings.TrimSuffix(name, "/"), "/")
+	if len(items) <= n {
+		return "", false
+	}
+	return strings.Join(items[n:], "/"), true
+}
@@ -0,0 +1,152 @@
+package astizip_test
+
+import (
+	"archive/zip"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/asticode/go-astitools/zip"
+	"github.com/stretchr/testify/assert"
+)
+
+func createZip(t *testing.T, path string, names ...string) {
+	f, err := os.Create(path)
+	assert.NoError(t, err)
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	defer zw.Close()
+	for _, n := range names {
+		w, err := zw.Create(n)
+		assert.NoError(t, err)
+		w.Write([]byte(n))
+	}
+}
+
+func listFiles(dir string) (o []string) {
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			o = append(o, filepath.ToSlash(strings.TrimPrefix(path, dir+string(os.PathSeparator))))
+		}
+		return nil
+	})
+	sort.Strings(o)
+	return
+}
+
+func TestUnzipWithOptions(t *testing.T) {
+	// Init
+	dir, err := ioutil.TempDir("", "astizip")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	src := filepath.Join(dir, "archive.zip")
+	createZip(t, src, "root/sub/a.txt", "root/sub/b.log", "root/sub/deep/c.txt", "root/other/d.txt")
+
+	// Include, exclude and strip components
+	dst := filepath.Join(dir, "dst1")
+	err = astizip.UnzipWithOptions(context.Background(), src, dst, astizip.UnzipOptions{
+		Exclude:         []string{"*/*/*.log"},
+		Include:         []string{"root/sub"},
+		StripComponents: 2,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a.txt", "deep/c.txt"}, listFiles(dst))
+	b, err := ioutil.ReadFile(filepath.Join(dst, "deep", "c.txt"))
+	assert.NoError(t, err)
+	assert.Equal(t, "root/sub/deep/c.txt", string(b))
+
+	// Zip slip
+	src = filepath.Join(dir, "evil.zip")
+	createZip(t, src, "../evil.txt")
+	err = astizip.Unzip(context.Background(), src, filepath.Join(dir, "dst2"))
+	assert.Error(t, err)
+	_, err = os.Stat(filepath.Join(dir, "evil.txt"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func createZipSymlinks(t *testing.T, path string, links ...[2]string) {
+	f, err := os.Create(path)
+	assert.NoError(t, err)
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	defer zw.Close()
+	for _, l := range links {
+		h := &zip.FileHeader{Name: l[0]}
+		h.SetMode(os.ModeSymlink | 0777)
+		w, err := zw.CreateHeader(h)
+		assert.NoError(t, err)
+		w.Write([]byte(l[1]))
+	}
+}
+
+func TestUnzip_Symlinks(t *testing.T) {
+	// Init
+	dir, err := ioutil.TempDir("", "astizip")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// Valid
+	src := filepath.Join(dir, "valid.zip")
+	createZipSymlinks(t, src, [2]string{"d/l", "../f"})
+	dst := filepath.Join(dir, "dst-valid")
+	assert.NoError(t, astizip.Unzip(context.Background(), src, dst))
+	l, err := os.Readlink(filepath.Join(dst, "d", "l"))
+	assert.NoError(t, err)
+	assert.Equal(t, "../f", l)
+
+	// Escaping targets
+	for i, target := range []string{dir, "../.."} {
+		src = filepath.Join(dir, "escaping.zip")
+		createZipSymlinks(t, src, [2]string{"l", target})
+		dst = filepath.Join(dir, "dst-escaping", strconv.Itoa(i))
+		assert.EqualError(t, astizip.Unzip(context.Background(), src, dst), "astios: symlink "+filepath.Join(dst, "l")+" targets "+target+" which escapes destination "+dst)
+		_, err = os.Lstat(filepath.Join(dst, "l"))
+		assert.True(t, os.IsNotExist(err))
+	}
+
+	// Chained
+	src = filepath.Join(dir, "chained.zip")
+	createZipSymlinks(t, src, [2]string{"s1", "."}, [2]string{"s1/s2", ".."})
+	dst = filepath.Join(dir, "dst-chained")
+	assert.EqualError(t, astizip.Unzip(context.Background(), src, dst), "astios: parent "+filepath.Join(dst, "s1")+" of "+filepath.Join(dst, "s1", "s2")+" is a symlink")
+	_, err = os.Lstat(filepath.Join(dir, "s2"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestUnzip_ReadOnlyDir(t *testing.T) {
+	// Init
+	dir, err := ioutil.TempDir("", "astizip")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	// Create zip
+	src := filepath.Join(dir, "readonly.zip")
+	f, err := os.Create(src)
+	assert.NoError(t, err)
+	zw := zip.NewWriter(f)
+	h := &zip.FileHeader{Name: "d/"}
+	h.SetMode(os.ModeDir | 0555)
+	_, err = zw.CreateHeader(h)
+	assert.NoError(t, err)
+	w, err := zw.Create("d/f")
+	assert.NoError(t, err)
+	w.Write([]byte("content"))
+	zw.Close()
+	f.Close()
+
+	// Unzip
+	dst := filepath.Join(dir, "dst")
+	assert.NoError(t, astizip.Unzip(context.Background(), src, dst))
+	defer os.Chmod(filepath.Join(dst, "d"), 0755)
+	fi, err := os.Stat(filepath.Join(dst, "d"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0555), fi.Mode().Perm())
+	b, err := ioutil.ReadFile(filepath.Join(dst, "d", "f"))
+	assert.NoError(t, err)
+	assert.Equal(t, "content", string(b))
+}